@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bufio"
 	"crypto/tls"
 	"crypto/x509"
 	"errors"
@@ -8,9 +9,18 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"log"
+	"net"
 	"net/http"
 	"os"
+	"os/exec"
 	"os/signal"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
 	"time"
 
 	vegeta "github.com/FractalBlockchain/vegeta/lib"
@@ -20,15 +30,59 @@ func attackCmd() command {
 	fs := flag.NewFlagSet("vegeta attack", flag.ExitOnError)
 	opts := &attackOpts{
 		headers: headers{http.Header{}},
-		laddr:   localAddr{&vegeta.DefaultLocalAddr},
+		tags:    tags{},
+		extract: tags{},
+		laddr:   localAddr{IPAddr: &vegeta.DefaultLocalAddr},
 	}
 
-	fs.StringVar(&opts.name, "name", "", "Attack name")
+	fs.StringVar(&opts.name, "name", "", "Attack name, or template of the attack name of each target, e.g. {{.Method}} {{.URL.Path}}")
 	fs.StringVar(&opts.targetsf, "targets", "stdin", "Targets file")
+	fs.Var(&opts.weighted, "weighted-targets", "Targets file picked from in proportion to a weight, as weight:file (repeatable)")
+	fs.StringVar(&opts.order, "targets-order", "sequential", "Order in which targets are attacked [sequential, random, shuffle]")
+	fs.Int64Var(&opts.seed, "seed", 0, "Random seed of the random and shuffle targets orders [0 = time based]")
+	fs.DurationVar(&opts.backoff, "backoff", 0, "Max time to pause hits to hosts which ask to back off with Retry-After or X-RateLimit headers [0 = never pause]")
+	fs.Var(&opts.backoffHints, "backoff-hints", "Additional response headers with Retry-After like backoff hints (comma separated list)")
+	fs.StringVar(&opts.split, "split", "", "Percentage of targets to send to another base URL, as percent:url")
+	fs.DurationVar(&opts.splitRamp, "split-ramp", 0, "Time over which to linearly shift the -split percentage to 100%")
+	fs.StringVar(&opts.switchf, "switch-targets", "", "Targets file to switch to mid-attack, e.g. for blue/green cutovers")
+	fs.DurationVar(&opts.switchAfter, "switch-after", 0, "Time after which to switch to the -switch-targets")
+	fs.StringVar(&opts.switchName, "switch-name", "switched", "Attack name of the results after switching targets")
+	fs.StringVar(&opts.execCmd, "exec", "", "Command whose output targets are streamed from, run by the system shell")
+	fs.StringVar(&opts.format, "format", "http", "Targets format [http, json, curl, accesslog, results]")
+	fs.BoolVar(&opts.onlyErrors, "only-errors", false, "Only attack the requests of errored results with the results targets format")
+	fs.StringVar(&opts.base, "base", "", "Base URL prepended to access log request paths")
+	fs.Float64Var(&opts.replay, "replay", 0, "Replay access log or results targets with their original timing scaled by this speed factor [0 = use -rate]")
 	fs.StringVar(&opts.outputf, "output", "stdout", "Output file")
+	fs.StringVar(&opts.compress, "compress", "", "Compress results [gzip, zstd]")
+	fs.StringVar(&opts.ntp, "ntp", "", "NTP server to measure the local clock offset against, recorded in results")
 	fs.StringVar(&opts.bodyf, "body", "", "Requests body file")
+	fs.BoolVar(&opts.templates, "templates", false, "Execute targets as templates with built-in functions, evaluated per hit")
+	fs.StringVar(&opts.feedf, "feed", "", "CSV or NDJSON data feed file with variables to substitute in targets templates")
+	fs.StringVar(&opts.feedMode, "feed-mode", "cyclic", "Data feed consumption mode [cyclic, unique]")
+	fs.StringVar(&opts.scenariof, "scenario", "", "JSON steps file of a scenario every virtual user goes through, at -rate users per second, instead of -targets")
+	fs.StringVar(&opts.setupf, "setup", "", "Scenario steps file run once before the attack, whose extracted values are available to targets templates")
+	fs.StringVar(&opts.teardownf, "teardown", "", "Scenario steps file run once after the attack, with the values extracted by -setup")
+	fs.BoolVar(&opts.cookies, "cookies", false, "Keep a cookie jar per -scenario virtual user")
+	fs.BoolVar(&opts.sticky, "sticky", false, "Use dedicated connections per -scenario virtual user")
+	fs.Var(&opts.think, "think", "Pause between the steps of -scenario virtual users, as a duration or a min-max range picked from uniformly")
+	fs.StringVar(&opts.scriptf, "script", "", "JavaScript file defining before(target) and after(result, response) functions, or WebAssembly module (.wasm) exporting generate and validate functions, run around each hit")
+	fs.StringVar(&opts.checksumsf, "checksums", "", "Expected response body SHA-256 digests file in sha256sum format, keyed by target URL")
+	fs.StringVar(&opts.oauth2.TokenURL, "oauth2-token-url", "", "OAuth2 token endpoint the Bearer token of requests is obtained from with the client credentials grant, and refreshed before it expires")
+	fs.StringVar(&opts.oauth2.ClientID, "oauth2-client-id", "", "OAuth2 client ID")
+	fs.StringVar(&opts.oauth2.ClientSecret, "oauth2-client-secret", "", "OAuth2 client secret")
+	fs.Var(&opts.oauth2Scopes, "oauth2-scopes", "OAuth2 requested scopes (comma separated list)")
+	fs.StringVar(&opts.jwtClaimsf, "jwt-claims", "", "JSON claims template file of a JWT minted per hit and sent as a Bearer token")
+	fs.StringVar(&opts.jwtAlg, "jwt-alg", "HS256", "JWT signature algorithm [HS256, RS256]")
+	fs.StringVar(&opts.jwtKeyf, "jwt-key", "", "JWT HS256 secret or PEM encoded RS256 private key file")
+	fs.BoolVar(&opts.traceContext, "trace-context", false, "Send a W3C traceparent header with a new trace per request, whose ID is recorded in results")
+	fs.BoolVar(&opts.b3, "b3", false, "Send Zipkin B3 headers with a new trace per request, whose ID is recorded in results")
+	fs.Var(&opts.requestIDs, "request-ids", "Request headers of a new UUID per request, recorded in results, e.g. X-Request-ID or Idempotency-Key (comma separated list)")
+	fs.BoolVar(&opts.reqBodies, "request-bodies", false, "Record request bodies in results, e.g. for the results targets format to replay them")
+	fs.BoolVar(&opts.reqHeaders, "request-headers", false, "Record request headers in results, credentials included, e.g. for the results targets format to replay them")
+	fs.Var(&opts.respHeaders, "response-headers", "Response headers recorded in results, or * for all (comma separated list)")
 	fs.StringVar(&opts.certf, "cert", "", "TLS client PEM encoded certificate file")
 	fs.StringVar(&opts.keyf, "key", "", "TLS client PEM encoded private key file")
+	fs.Var(&opts.clientCerts, "client-certs", "Comma separated list of TLS client PEM encoded certificate and private key files, presented in turn per connection")
 	fs.Var(&opts.rootCerts, "root-certs", "TLS root certificate files (comma separated list)")
 	fs.BoolVar(&opts.http2, "http2", true, "Send HTTP/2 requests when supported by the server")
 	fs.BoolVar(&opts.h2c, "h2c", false, "Send HTTP/2 requests without TLS encryption")
@@ -36,13 +90,55 @@ func attackCmd() command {
 	fs.BoolVar(&opts.lazy, "lazy", false, "Read targets lazily")
 	fs.DurationVar(&opts.duration, "duration", 0, "Duration of the test [0 = forever]")
 	fs.DurationVar(&opts.timeout, "timeout", vegeta.DefaultTimeout, "Requests timeout")
+	fs.DurationVar(&opts.deadline, "deadline", 0, "Time after which requests are given up on and reported as SLA misses [0 = never]")
+	fs.DurationVar(&opts.dialTimeout, "dial-timeout", 0, "Connection establishment timeout [0 = -timeout]")
+	fs.Var(&opts.resolvers, "resolvers", "DNS servers, as ip[:port], or DNS over HTTPS endpoint URL, target hosts are resolved with instead of the system's (comma separated list)")
+	fs.StringVar(&opts.balancing, "ip-balancing", vegeta.BalanceOrdered, "Distribution of connections across the resolved addresses of target hosts [ordered, round-robin, random, least-conns]")
+	fs.StringVar(&opts.family, "ip-family", vegeta.AnyFamily, "IP address families target hosts are dialed with [any, ipv4, ipv6, happy-eyeballs]")
+	fs.StringVar(&opts.hostsf, "hosts", "", "Hosts file in /etc/hosts format whose addresses override DNS lookups of target hosts")
+	fs.DurationVar(&opts.dnsTTL, "dns-ttl", 0, "Time after which cached DNS lookups are refreshed [0 = never, negative = disable caching]")
+	fs.DurationVar(&opts.tlsTimeout, "tls-timeout", 0, "TLS handshake timeout [0 = 10s]")
+	fs.DurationVar(&opts.headerTimeout, "header-timeout", 0, "Response headers timeout [0 = -timeout]")
+	fs.DurationVar(&opts.totalTimeout, "total-timeout", 0, "Whole request timeout, including reading the response body [0 = unlimited]")
+	fs.DurationVar(&opts.bodyTimeout, "body-timeout", 0, "Response body read timeout [0 = unlimited]")
 	fs.Uint64Var(&opts.rate, "rate", 50, "Requests per second")
+	fs.DurationVar(&opts.soak, "soak", 0, "Interval at which a metrics report of its results is written to a new -soak-output file [0 = disabled]")
+	fs.StringVar(&opts.soakOutput, "soak-output", "soak-%04d.txt", "Soak reports file name pattern, formatted with the report number, as JSON if ending in .json")
+	fs.Float64Var(&opts.watchdog, "watchdog", 0, "Fraction of -rate below which the achieved throughput is flagged [0 = disabled]")
+	fs.DurationVar(&opts.watchdogWindow, "watchdog-window", time.Second, "Window over which the watchdog measures the achieved throughput")
+	fs.IntVar(&opts.watchdogWindows, "watchdog-windows", 5, "Number of consecutive windows below -watchdog after which the throughput is flagged")
+	fs.BoolVar(&opts.watchdogAbort, "watchdog-abort", false, "Stop the attack when the watchdog flags the throughput")
+	fs.IntVar(&opts.warmup, "warmup", 0, "Number of connections established to the host of the first target with HEAD requests before the attack [0 = disabled]")
 	fs.Uint64Var(&opts.workers, "workers", vegeta.DefaultWorkers, "Initial number of workers")
+	fs.IntVar(&opts.maxConns, "max-connections", 0, "Max open connections, past which hits fail [0 = unlimited]")
+	fs.IntVar(&opts.maxHostConns, "max-host-connections", 0, "Max open connections per target host, past which hits fail [0 = unlimited]")
+	fs.BoolVar(&opts.connPerWorker, "conn-per-worker", false, "Send the requests of each worker over a persistent connection of its own, tagged with its number in results")
 	fs.IntVar(&opts.connections, "connections", vegeta.DefaultConnections, "Max open idle connections per target host")
 	fs.IntVar(&opts.redirects, "redirects", vegeta.DefaultRedirects, "Number of redirects to follow. -1 will not follow but marks as success")
 	fs.Var(&opts.headers, "header", "Request header")
-	fs.Var(&opts.laddr, "laddr", "Local IP address")
+	fs.Var(&opts.tags, "tag", "Tag of all targets, as key=value, copied into their results (repeatable)")
+	fs.Var(&opts.extract, "extract", "Value extracted from responses into results, as name=source:expr with a header, json or regex source (repeatable)")
+	fs.BoolVar(&opts.hashBodies, "hash-bodies", false, "Only record SHA-256 digests of response bodies in results")
+	fs.BoolVar(&opts.discardBodies, "discard-bodies", false, "Only record the size of response bodies in results")
+	fs.StringVar(&opts.bodyFiles, "body-files", "", "Directory response bodies are written to, one file per hit, instead of results")
+	fs.Int64Var(&opts.maxBody, "max-body", 0, "Max bytes of response bodies read, past which they're truncated [0 = unlimited]")
+	fs.StringVar(&opts.keyLog, "tls-keylog", os.Getenv("SSLKEYLOGFILE"), "File TLS secrets are appended to for decrypting captured traffic, defaulting to $SSLKEYLOGFILE")
+	fs.IntVar(&opts.tlsSessions, "tls-session-cache", 0, "Number of hosts whose TLS sessions are cached and resumed by new connections [0 = full handshakes only]")
+	fs.IntVar(&opts.tlsFullEvery, "tls-full-every", 0, "Number of TLS handshakes every one of which is a full one despite -tls-session-cache [0 = never]")
+	fs.StringVar(&opts.serverName, "server-name", "", "TLS server name (SNI) sent instead of the host of target URLs")
+	fs.StringVar(&opts.iface, "interface", "", "Name of the network interface connections are bound to, e.g. eth1")
+	fs.Var(&opts.laddr, "laddr", "Local IP address, or comma separated list of addresses and CIDR blocks new connections are rotated across")
 	fs.BoolVar(&opts.keepalive, "keepalive", true, "Use persistent connections")
+	fs.BoolVar(&opts.nodelay, "nodelay", true, "Disable Nagle's algorithm on connections (TCP_NODELAY)")
+	fs.IntVar(&opts.sndbuf, "sndbuf", 0, "Socket send buffer size in bytes (SO_SNDBUF) [0 = system default]")
+	fs.IntVar(&opts.rcvbuf, "rcvbuf", 0, "Socket receive buffer size in bytes (SO_RCVBUF) [0 = system default]")
+	fs.DurationVar(&opts.linger, "linger", -time.Second, "Time closing connections waits for unsent data (SO_LINGER), 0 resets them [negative = close in the background]")
+	fs.IntVar(&opts.slowClient, "slow-client", 0, "Bytes per second at which requests are written on each connection, like slowloris attacks [0 = unlimited]")
+	fs.DurationVar(&opts.idleTimeout, "idle-timeout", 0, "Time after which idle connections are closed [0 = never]")
+	fs.DurationVar(&opts.connMaxAge, "conn-max-age", 0, "Age after which connections are re-established [0 = unlimited]")
+	fs.IntVar(&opts.connMaxRequests, "conn-max-requests", 0, "Number of requests after which connections are re-established [0 = unlimited]")
+	fs.Float64Var(&opts.churn, "churn", 0, "Fraction of open connections re-established every minute [0 = disabled]")
+	fs.IntVar(&opts.verifyRanges, "verify-ranges", 0, "Number of sampled objects whose byte range responses are verified against the full object [0 = disabled]")
 
 	return command{fs, func(args []string) error {
 		fs.Parse(args)
@@ -57,26 +153,108 @@ var (
 
 // attackOpts aggregates the attack function command options
 type attackOpts struct {
-	name        string
-	targetsf    string
-	outputf     string
-	bodyf       string
-	certf       string
-	keyf        string
-	rootCerts   csl
-	http2       bool
-	h2c         bool
-	insecure    bool
-	lazy        bool
-	duration    time.Duration
-	timeout     time.Duration
-	rate        uint64
-	workers     uint64
-	connections int
-	redirects   int
-	headers     headers
-	laddr       localAddr
-	keepalive   bool
+	name            string
+	targetsf        string
+	execCmd         string
+	weighted        weightedFiles
+	order           string
+	seed            int64
+	backoff         time.Duration
+	backoffHints    csl
+	split           string
+	splitRamp       time.Duration
+	switchf         string
+	switchAfter     time.Duration
+	switchName      string
+	format          string
+	base            string
+	replay          float64
+	onlyErrors      bool
+	ntp             string
+	outputf         string
+	compress        string
+	bodyf           string
+	checksumsf      string
+	hashBodies      bool
+	discardBodies   bool
+	bodyFiles       string
+	maxBody         int64
+	scenariof       string
+	setupf          string
+	teardownf       string
+	cookies         bool
+	sticky          bool
+	think           durationRange
+	scriptf         string
+	feedf           string
+	templates       bool
+	feedMode        string
+	oauth2          vegeta.OAuth2Config
+	oauth2Scopes    csl
+	jwtClaimsf      string
+	jwtAlg          string
+	jwtKeyf         string
+	traceContext    bool
+	b3              bool
+	requestIDs      csl
+	respHeaders     csl
+	reqBodies       bool
+	reqHeaders      bool
+	certf           string
+	keyf            string
+	clientCerts     csl
+	rootCerts       csl
+	http2           bool
+	h2c             bool
+	insecure        bool
+	lazy            bool
+	duration        time.Duration
+	timeout         time.Duration
+	dialTimeout     time.Duration
+	resolvers       csl
+	balancing       string
+	family          string
+	hostsf          string
+	dnsTTL          time.Duration
+	tlsTimeout      time.Duration
+	headerTimeout   time.Duration
+	bodyTimeout     time.Duration
+	totalTimeout    time.Duration
+	deadline        time.Duration
+	rate            uint64
+	soak            time.Duration
+	soakOutput      string
+	watchdog        float64
+	watchdogWindow  time.Duration
+	watchdogWindows int
+	watchdogAbort   bool
+	warmup          int
+	workers         uint64
+	connections     int
+	connPerWorker   bool
+	maxConns        int
+	maxHostConns    int
+	redirects       int
+	headers         headers
+	tags            tags
+	extract         tags
+	laddr           localAddr
+	iface           string
+	serverName      string
+	tlsSessions     int
+	keyLog          string
+	tlsFullEvery    int
+	keepalive       bool
+	nodelay         bool
+	sndbuf          int
+	rcvbuf          int
+	linger          time.Duration
+	churn           float64
+	idleTimeout     time.Duration
+	connMaxAge      time.Duration
+	connMaxRequests int
+	slowClient      int
+	verifyRanges    int
 }
 
 // attack validates the attack arguments, sets up the
@@ -86,8 +264,13 @@ func attack(opts *attackOpts) (err error) {
 		return errZeroRate
 	}
 
+	filenames := []string{opts.bodyf}
+	if len(opts.weighted.files) == 0 && opts.execCmd == "" && opts.scenariof == "" {
+		filenames = append(filenames, opts.targetsf)
+	}
+
 	files := map[string]io.Reader{}
-	for _, filename := range []string{opts.targetsf, opts.bodyf} {
+	for _, filename := range filenames {
 		if filename == "" {
 			continue
 		}
@@ -108,13 +291,157 @@ func attack(opts *attackOpts) (err error) {
 
 	var (
 		tr  vegeta.Targeter
-		src = files[opts.targetsf]
-		hdr = opts.headers.Header
+		sc  *vegeta.Scenario
+		p   vegeta.Pacer = vegeta.ConstantPacer{Rate: opts.rate, Duration: opts.duration}
+		hdr              = opts.headers.Header
 	)
-	if opts.lazy {
-		tr = vegeta.NewLazyTargeter(src, body, hdr)
-	} else if tr, err = vegeta.NewEagerTargeter(src, body, hdr); err != nil {
-		return err
+
+	if opts.replay != 0 && opts.format != "accesslog" && opts.format != "results" {
+		return errors.New("replay is only supported with the accesslog and results targets formats")
+	}
+
+	if opts.order != "sequential" {
+		if opts.format != "http" && opts.format != "json" {
+			return errors.New("targets order is only supported with the http and json targets formats")
+		} else if opts.lazy {
+			return errors.New("targets order isn't supported with lazy targets")
+		}
+	}
+
+	if opts.watchdog != 0 {
+		if opts.replay != 0 {
+			return errors.New("watchdog isn't supported when replaying targets")
+		} else if opts.watchdogWindow <= 0 {
+			return errors.New("watchdog window must be bigger than zero")
+		}
+	}
+
+	if opts.scenariof != "" && (len(opts.weighted.files) > 0 || opts.execCmd != "" || opts.replay != 0 ||
+		opts.switchf != "" || opts.split != "" || opts.feedf != "" || opts.checksumsf != "") {
+		return errors.New("scenarios aren't supported with weighted targets, a targets command, replay, switch, split, feed or checksums")
+	}
+
+	if opts.seed == 0 {
+		opts.seed = time.Now().UnixNano()
+	}
+
+	if len(opts.weighted.files) > 0 {
+		if opts.replay != 0 {
+			return errors.New("replay isn't supported with weighted targets")
+		}
+
+		trs := make([]vegeta.Targeter, len(opts.weighted.files))
+		for i, filename := range opts.weighted.files {
+			f, err := file(filename, false)
+			if err != nil {
+				return fmt.Errorf("error opening %s: %s", filename, err)
+			}
+			defer f.Close()
+
+			if trs[i], _, err = targeter(opts, f, body, hdr); err != nil {
+				return fmt.Errorf("error reading %s: %s", filename, err)
+			}
+		}
+
+		if tr, err = vegeta.NewWeightedTargeter(trs, opts.weighted.weights); err != nil {
+			return err
+		}
+	} else if opts.execCmd != "" {
+		if opts.replay != 0 || opts.order != "sequential" {
+			return errors.New("replay and targets order aren't supported with a targets command")
+		}
+
+		var cmd *exec.Cmd
+		if tr, cmd, err = execTargeter(opts, body, hdr); err != nil {
+			return err
+		}
+		defer cmd.Process.Kill()
+	} else if opts.scenariof != "" {
+		if sc, err = scenario(opts.scenariof, body, hdr, opts.tags); err != nil {
+			return err
+		}
+	} else {
+		var replay vegeta.Pacer
+		if tr, replay, err = targeter(opts, files[opts.targetsf], body, hdr); err != nil {
+			return err
+		} else if replay != nil {
+			p = replay
+		}
+	}
+
+	var switched vegeta.Targeter
+	if opts.switchf != "" {
+		f, err := file(opts.switchf, false)
+		if err != nil {
+			return fmt.Errorf("error opening %s: %s", opts.switchf, err)
+		}
+		defer f.Close()
+
+		if switched, _, err = targeter(opts, f, body, hdr); err != nil {
+			return fmt.Errorf("error reading %s: %s", opts.switchf, err)
+		}
+	}
+
+	// Values extracted by the setup phase, once it ran.
+	var setup map[string]interface{}
+
+	if opts.feedf != "" || opts.setupf != "" {
+		feed := vegeta.Feed(func() (map[string]interface{}, error) { return nil, nil })
+		if opts.feedf != "" {
+			if feed, err = dataFeed(opts.feedf, opts.feedMode); err != nil {
+				return err
+			}
+		}
+		feed = withVars(feed, &setup)
+
+		tr = vegeta.NewFeedTargeter(tr, feed)
+		if switched != nil {
+			switched = vegeta.NewFeedTargeter(switched, feed)
+		}
+	} else if opts.templates {
+		tr = vegeta.NewTemplateTargeter(tr)
+		if switched != nil {
+			switched = vegeta.NewTemplateTargeter(switched)
+		}
+	}
+
+	var split *vegeta.TrafficSplit
+	if opts.split != "" {
+		parts := strings.SplitN(opts.split, ":", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("bad split: %s", opts.split)
+		}
+
+		pct, err := strconv.ParseFloat(parts[0], 64)
+		if err != nil {
+			return fmt.Errorf("bad split percentage: %s", parts[0])
+		}
+
+		if split, err = vegeta.NewTrafficSplit(tr, parts[1], pct); err != nil {
+			return err
+		}
+		tr = split.Target
+	}
+
+	if len(opts.tags) > 0 {
+		tr = vegeta.Map(tr, func(tgt *vegeta.Target) error {
+			// Targets may share their tags, so they're copied.
+			tags := make(map[string]string, len(opts.tags)+len(tgt.Tags))
+			for k, v := range opts.tags {
+				tags[k] = v
+			}
+			for k, v := range tgt.Tags {
+				tags[k] = v
+			}
+			tgt.Tags = tags
+			return nil
+		})
+	}
+
+	if opts.checksumsf != "" {
+		if tr, err = checksums(tr, opts.checksumsf); err != nil {
+			return err
+		}
 	}
 
 	out, err := file(opts.outputf, true)
@@ -123,25 +450,115 @@ func attack(opts *attackOpts) (err error) {
 	}
 	defer out.Close()
 
+	var w io.Writer = out
+	if opts.compress != "" {
+		c, cerr := vegeta.NewCompressor(out, opts.compress)
+		if cerr != nil {
+			return cerr
+		}
+		// Closing flushes the end of the compressed stream, whose errors
+		// would otherwise leave a truncated results file behind silently.
+		defer func() {
+			if cerr := c.Close(); cerr != nil && err == nil {
+				err = fmt.Errorf("error writing %s: %s", opts.outputf, cerr)
+			}
+		}()
+		w = c
+	}
+
 	tlsc, err := tlsConfig(opts.insecure, opts.certf, opts.keyf, opts.rootCerts)
 	if err != nil {
 		return err
 	}
 
-	atk := vegeta.NewAttacker(
-		vegeta.Redirects(opts.redirects),
-		vegeta.Timeout(opts.timeout),
-		vegeta.LocalAddr(*opts.laddr.IPAddr),
-		vegeta.TLSConfig(tlsc),
-		vegeta.Workers(opts.workers),
-		vegeta.KeepAlive(opts.keepalive),
-		vegeta.Connections(opts.connections),
-		vegeta.HTTP2(opts.http2),
-		vegeta.H2C(opts.h2c),
+	if opts.keyLog != "" {
+		keyLog, err := os.OpenFile(opts.keyLog, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+		if err != nil {
+			return fmt.Errorf("error opening %s: %s", opts.keyLog, err)
+		}
+		defer keyLog.Close()
+		tlsc.KeyLogWriter = keyLog
+	}
+
+	atk, err := attacker(opts, tlsc)
+	if err != nil {
+		return err
+	}
+
+	if opts.setupf != "" {
+		if setup, err = phase(atk, opts.setupf, body, hdr, nil); err != nil {
+			return fmt.Errorf("setup failed: %s", err)
+		}
+		if sc != nil {
+			sc.Vars = setup
+		}
+	}
+
+	if opts.teardownf != "" {
+		defer func() {
+			// The attack's Attacker is stopped by then.
+			atk, terr := attacker(opts, tlsc)
+			if terr == nil {
+				_, terr = phase(atk, opts.teardownf, body, hdr, setup)
+			}
+			if terr != nil && err == nil {
+				err = fmt.Errorf("teardown failed: %s", terr)
+			}
+		}()
+	}
+
+	name := opts.name
+	if strings.Contains(name, "{{") {
+		names, err := template.New("name").Option("missingkey=zero").Parse(name)
+		if err != nil {
+			return fmt.Errorf("bad attack name template: %s", err)
+		}
+		vegeta.NameTemplate(names)(atk)
+		name = ""
+	}
+
+	var res <-chan *vegeta.Result
+	if sc != nil {
+		res = atk.AttackScenario(sc, p, name)
+	} else {
+		res = atk.AttackWithPacer(tr, p, name)
+	}
+	if split != nil && opts.splitRamp > 0 {
+		done := make(chan struct{})
+		defer close(done)
+		go ramp(split, opts.splitRamp, done)
+	}
+
+	if switched != nil {
+		timer := time.AfterFunc(opts.switchAfter, func() { atk.Switch(switched, opts.switchName) })
+		defer timer.Stop()
+	}
+
+	var (
+		wd   *vegeta.Watchdog
+		tick <-chan time.Time
 	)
 
-	res := atk.Attack(tr, opts.rate, opts.duration, opts.name)
-	enc := vegeta.NewEncoder(out)
+	if opts.watchdog != 0 {
+		wd = vegeta.NewWatchdog(float64(opts.rate), opts.watchdog, opts.watchdogWindows)
+		ticker := time.NewTicker(opts.watchdogWindow)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	var (
+		soak     vegeta.Metrics
+		soakTick <-chan time.Time
+		soakN    int
+	)
+
+	if opts.soak > 0 {
+		ticker := time.NewTicker(opts.soak)
+		defer ticker.Stop()
+		soakTick = ticker.C
+	}
+
+	enc := vegeta.NewEncoder(w)
 	sig := make(chan os.Signal, 1)
 	signal.Notify(sig, os.Interrupt)
 
@@ -149,11 +566,37 @@ func attack(opts *attackOpts) (err error) {
 		select {
 		case <-sig:
 			atk.Stop()
+			if opts.soak > 0 {
+				return checkpoint(opts.soakOutput, soakN, &soak)
+			}
 			return nil
+		case <-soakTick:
+			if err = checkpoint(opts.soakOutput, soakN, &soak); err != nil {
+				return err
+			}
+			soakN++
+		case <-tick:
+			if rate, tripped := wd.Check(opts.watchdogWindow); tripped {
+				log.Printf("Throughput of %.2f/s below %.0f%% of the %d/s rate for %d windows",
+					rate, opts.watchdog*100, opts.rate, opts.watchdogWindows)
+				if opts.watchdogAbort {
+					atk.Stop()
+					tick = nil
+				}
+			}
 		case r, ok := <-res:
 			if !ok {
+				if opts.soak > 0 {
+					return checkpoint(opts.soakOutput, soakN, &soak)
+				}
 				return nil
 			}
+			if opts.soak > 0 {
+				soak.Add(r)
+			}
+			if wd != nil {
+				wd.Add(r)
+			}
 			if err = enc.Encode(r); err != nil {
 				return err
 			}
@@ -161,6 +604,556 @@ func attack(opts *attackOpts) (err error) {
 	}
 }
 
+// checkpoint writes a report of the given Metrics to the file named by the
+// soak reports pattern formatted with the given number, as JSON if it ends
+// in .json and as text otherwise, and resets them. Metrics without results
+// aren't reported.
+func checkpoint(pattern string, n int, m *vegeta.Metrics) error {
+	defer func() { *m = vegeta.Metrics{} }()
+
+	if m.Requests == 0 {
+		return nil
+	}
+	m.Close()
+
+	name := fmt.Sprintf(pattern, n)
+	f, err := os.Create(name)
+	if err != nil {
+		return fmt.Errorf("error creating soak report: %s", err)
+	}
+	defer f.Close()
+
+	rep := vegeta.NewTextReporter(m)
+	if strings.HasSuffix(name, ".json") {
+		rep = vegeta.NewJSONReporter(m)
+	}
+
+	if err = rep.Report(f); err != nil {
+		return fmt.Errorf("error writing soak report %s: %s", name, err)
+	}
+
+	return f.Close()
+}
+
+// targeter returns a Targeter which reads targets from src in the format
+// given in the options, along with a Pacer when replaying them.
+func targeter(opts *attackOpts, src io.Reader, body []byte, hdr http.Header) (tr vegeta.Targeter, p vegeta.Pacer, err error) {
+	switch opts.format {
+	case "http":
+		if opts.lazy {
+			tr = lazily(src, func(r io.Reader) vegeta.Targeter { return vegeta.NewLazyTargeter(r, body, hdr) })
+		} else if opts.order != "sequential" {
+			tr = vegeta.NewLazyTargeter(src, body, hdr)
+		} else if tr, err = vegeta.NewEagerTargeter(src, body, hdr); err != nil {
+			return nil, nil, err
+		}
+	case "json":
+		if opts.lazy {
+			tr = lazily(src, func(r io.Reader) vegeta.Targeter { return vegeta.NewLazyJSONTargeter(r, body, hdr) })
+		} else if opts.order != "sequential" {
+			tr = vegeta.NewLazyJSONTargeter(src, body, hdr)
+		} else if tr, err = vegeta.NewEagerJSONTargeter(src, body, hdr); err != nil {
+			return nil, nil, err
+		}
+	case "curl":
+		if tr, err = vegeta.NewCurlTargeter(src, body, hdr); err != nil {
+			return nil, nil, err
+		}
+	case "accesslog":
+		if opts.replay != 0 {
+			if tr, p, err = vegeta.NewAccessLogReplay(src, opts.base, opts.replay, body, hdr); err != nil {
+				return nil, nil, err
+			}
+		} else if tr, err = vegeta.NewAccessLogTargeter(src, opts.base, body, hdr); err != nil {
+			return nil, nil, err
+		}
+	case "results":
+		rd, err := vegeta.NewDecompressor(src)
+		if err != nil {
+			return nil, nil, err
+		}
+		dec := vegeta.NewDecoder(rd)
+		if opts.replay != 0 {
+			if tr, p, err = vegeta.NewResultsReplay(dec, opts.onlyErrors, opts.replay, body, hdr); err != nil {
+				return nil, nil, err
+			}
+		} else if tr, err = vegeta.NewResultsTargeter(dec, opts.onlyErrors, body, hdr); err != nil {
+			return nil, nil, err
+		}
+	default:
+		return nil, nil, fmt.Errorf("unsupported targets format: %s", opts.format)
+	}
+
+	switch opts.order {
+	case "sequential":
+	case "random":
+		tr, err = vegeta.NewRandomTargeter(tr, opts.seed)
+	case "shuffle":
+		tr, err = vegeta.NewShuffledTargeter(tr, opts.seed)
+	default:
+		err = fmt.Errorf("unsupported targets order: %s", opts.order)
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return tr, p, nil
+}
+
+// execTargeter returns a Targeter which streams targets from the output of
+// the -exec command, run by the system shell, along with the command.
+func execTargeter(opts *attackOpts, body []byte, hdr http.Header) (vegeta.Targeter, *exec.Cmd, error) {
+	var lazy func(io.Reader) vegeta.Targeter
+	switch opts.format {
+	case "http":
+		lazy = func(r io.Reader) vegeta.Targeter { return vegeta.NewLazyTargeter(r, body, hdr) }
+	case "json":
+		lazy = func(r io.Reader) vegeta.Targeter { return vegeta.NewLazyJSONTargeter(r, body, hdr) }
+	default:
+		return nil, nil, errors.New("targets command is only supported with the http and json targets formats")
+	}
+
+	shell, flag := "sh", "-c"
+	if runtime.GOOS == "windows" {
+		shell, flag = "cmd", "/C"
+	}
+
+	cmd := exec.Command(shell, flag, opts.execCmd)
+	cmd.Stderr = os.Stderr
+
+	tr, err := vegeta.NewExecTargeter(cmd, lazy)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error running %q: %s", opts.execCmd, err)
+	}
+
+	return tr, cmd, nil
+}
+
+// lazily returns the Targeter returned by lazy for src, looping over src
+// when it's a regular file so that lazy attacks can last as long as eager
+// ones. Other sources, like pipes, are streamed once.
+func lazily(src io.Reader, lazy func(io.Reader) vegeta.Targeter) vegeta.Targeter {
+	if f, ok := src.(*os.File); ok {
+		if fi, err := f.Stat(); err == nil && fi.Mode().IsRegular() {
+			return vegeta.NewLoopingTargeter(f, lazy)
+		}
+	}
+	return lazy(src)
+}
+
+// dataFeed returns a Feed of the records in the given CSV or NDJSON file,
+// as told by its extension.
+func dataFeed(filename, mode string) (vegeta.Feed, error) {
+	var cyclic bool
+	switch mode {
+	case "cyclic":
+		cyclic = true
+	case "unique":
+	default:
+		return nil, fmt.Errorf("unsupported feed mode: %s", mode)
+	}
+
+	f, err := file(filename, false)
+	if err != nil {
+		return nil, fmt.Errorf("error opening %s: %s", filename, err)
+	}
+	defer f.Close()
+
+	switch ext := strings.ToLower(filepath.Ext(filename)); ext {
+	case ".csv":
+		return vegeta.NewCSVFeed(f, cyclic)
+	case ".json", ".jsonl", ".ndjson":
+		return vegeta.NewJSONFeed(f, cyclic)
+	default:
+		return nil, fmt.Errorf("unsupported feed file extension: %q", ext)
+	}
+}
+
+// ramp linearly shifts the percentage of the given TrafficSplit to 100% over
+// the given duration or until done is closed.
+func ramp(split *vegeta.TrafficSplit, du time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	began, from := time.Now(), split.Percent()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			elapsed := time.Since(began)
+			if elapsed >= du {
+				split.SetPercent(100)
+				return
+			}
+			split.SetPercent(from + (100-from)*float64(elapsed)/float64(du))
+		}
+	}
+}
+
+// attacker returns a new Attacker configured with the given options.
+func attacker(opts *attackOpts, tlsc *tls.Config) (*vegeta.Attacker, error) {
+	atk := vegeta.NewAttacker(
+		vegeta.Redirects(opts.redirects),
+		vegeta.Timeout(opts.timeout),
+		vegeta.LocalAddr(*opts.laddr.IPAddr),
+		vegeta.TLSConfig(tlsc),
+		vegeta.Workers(opts.workers),
+		vegeta.KeepAlive(opts.keepalive),
+		vegeta.Connections(opts.connections),
+		vegeta.HTTP2(opts.http2),
+		vegeta.H2C(opts.h2c),
+		vegeta.HashBodies(opts.hashBodies),
+		vegeta.DiscardBodies(opts.discardBodies),
+		vegeta.MaxBody(opts.maxBody),
+		vegeta.Cookies(opts.cookies),
+		vegeta.StickyConnections(opts.sticky),
+		vegeta.ThinkTime(opts.think[0], opts.think[1]),
+		vegeta.TraceContext(opts.traceContext),
+		vegeta.B3Propagation(opts.b3),
+		vegeta.RequestIDs(opts.requestIDs...),
+		vegeta.ResponseHeaders(opts.respHeaders...),
+		vegeta.RequestBodies(opts.reqBodies),
+		vegeta.RequestHeaders(opts.reqHeaders),
+		vegeta.DNSCache(opts.dnsTTL),
+		vegeta.RangeVerification(opts.verifyRanges),
+	)
+
+	for _, t := range []struct {
+		d   time.Duration
+		opt func(time.Duration) func(*vegeta.Attacker)
+	}{
+		{opts.dialTimeout, vegeta.DialTimeout},
+		{opts.tlsTimeout, vegeta.TLSHandshakeTimeout},
+		{opts.headerTimeout, vegeta.ResponseHeaderTimeout},
+		{opts.bodyTimeout, vegeta.BodyTimeout},
+		{opts.totalTimeout, vegeta.TotalTimeout},
+		{opts.deadline, vegeta.Deadline},
+	} {
+		if t.d > 0 {
+			t.opt(t.d)(atk)
+		}
+	}
+
+	if len(opts.resolvers) == 1 && strings.HasPrefix(opts.resolvers[0], "https://") {
+		client := &http.Client{
+			Timeout:   opts.timeout,
+			Transport: &http.Transport{Proxy: http.ProxyFromEnvironment, TLSClientConfig: tlsc},
+		}
+		vegeta.DNSResolver(vegeta.NewDoHResolver(opts.resolvers[0], client))(atk)
+	} else if len(opts.resolvers) > 0 {
+		vegeta.DNSResolver(vegeta.NewDNSServersResolver(opts.resolvers...))(atk)
+	}
+
+	switch opts.balancing {
+	case vegeta.BalanceOrdered:
+	case vegeta.BalanceRoundRobin, vegeta.BalanceRandom, vegeta.BalanceLeastConns:
+		vegeta.IPBalancing(opts.balancing)(atk)
+	default:
+		return nil, fmt.Errorf("unsupported ip balancing: %s", opts.balancing)
+	}
+
+	switch opts.family {
+	case vegeta.AnyFamily:
+	case vegeta.IPv4Only, vegeta.IPv6Only, vegeta.HappyEyeballs:
+		vegeta.IPFamily(opts.family)(atk)
+	default:
+		return nil, fmt.Errorf("unsupported ip family: %s", opts.family)
+	}
+
+	if opts.hostsf != "" {
+		hosts, err := hostsFile(opts.hostsf)
+		if err != nil {
+			return nil, err
+		}
+		vegeta.HostMap(hosts)(atk)
+	}
+
+	if len(opts.laddr.all) > 1 {
+		vegeta.LocalAddrs(opts.laddr.all...)(atk)
+	}
+
+	if !opts.nodelay {
+		vegeta.NoDelay(false)(atk)
+	}
+
+	if opts.sndbuf > 0 || opts.rcvbuf > 0 {
+		vegeta.SocketBuffers(opts.sndbuf, opts.rcvbuf)(atk)
+	}
+
+	if opts.linger >= 0 {
+		vegeta.Linger(opts.linger)(atk)
+	}
+
+	if opts.maxConns > 0 || opts.maxHostConns > 0 {
+		vegeta.MaxConnections(opts.maxConns, opts.maxHostConns)(atk)
+	}
+
+	if opts.warmup > 0 {
+		vegeta.Warmup(opts.warmup, "HEAD")(atk)
+	}
+
+	if opts.connPerWorker {
+		vegeta.ConnectionPerWorker(true)(atk)
+	}
+
+	if opts.serverName != "" {
+		vegeta.ServerName(opts.serverName)(atk)
+	}
+
+	if opts.tlsSessions > 0 {
+		vegeta.TLSSessionCache(opts.tlsSessions, opts.tlsFullEvery)(atk)
+	}
+
+	if len(opts.clientCerts) > 0 {
+		certs := make([]tls.Certificate, 0, len(opts.clientCerts))
+		for _, f := range opts.clientCerts {
+			cert, err := tls.LoadX509KeyPair(f, f)
+			if err != nil {
+				return nil, fmt.Errorf("bad client certificate %s: %s", f, err)
+			}
+			certs = append(certs, cert)
+		}
+		vegeta.ClientCertificates(certs...)(atk)
+	}
+
+	if opts.bodyFiles != "" {
+		if err := os.MkdirAll(opts.bodyFiles, 0755); err != nil {
+			return nil, err
+		}
+		vegeta.ResponseBodies(vegeta.BodyFiles(opts.bodyFiles))(atk)
+	}
+
+	if opts.iface != "" {
+		vegeta.Interface(opts.iface)(atk)
+	}
+
+	if opts.idleTimeout > 0 {
+		vegeta.IdleTimeout(opts.idleTimeout)(atk)
+	}
+
+	if opts.connMaxAge > 0 || opts.connMaxRequests > 0 {
+		vegeta.ConnLifetime(opts.connMaxAge, opts.connMaxRequests)(atk)
+	}
+
+	if opts.churn > 0 {
+		vegeta.Churn(opts.churn)(atk)
+	}
+
+	if opts.slowClient > 0 {
+		vegeta.SlowClient(opts.slowClient)(atk)
+	}
+
+	if opts.backoff > 0 {
+		vegeta.Backoff(opts.backoff, opts.backoffHints...)(atk)
+	}
+
+	if opts.ntp != "" {
+		offset, err := vegeta.ClockOffset(opts.ntp, opts.timeout)
+		if err != nil {
+			return nil, fmt.Errorf("error measuring clock offset: %s", err)
+		}
+		vegeta.ClockSync(offset)(atk)
+	}
+
+	if len(opts.extract) > 0 {
+		names := make([]string, 0, len(opts.extract))
+		for name := range opts.extract {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		es := make([]*vegeta.Extractor, len(names))
+		for i, name := range names {
+			e, err := vegeta.NewExtractor(name, opts.extract[name])
+			if err != nil {
+				return nil, err
+			}
+			es[i] = e
+		}
+		vegeta.Extractors(es...)(atk)
+	}
+
+	if opts.oauth2.TokenURL != "" {
+		cfg := opts.oauth2
+		cfg.Scopes = opts.oauth2Scopes
+		cfg.Client = &http.Client{
+			Timeout:   opts.timeout,
+			Transport: &http.Transport{Proxy: http.ProxyFromEnvironment, TLSClientConfig: tlsc},
+		}
+
+		o, err := vegeta.NewOAuth2(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching oauth2 token: %s", err)
+		}
+		vegeta.OAuth2Credentials(o)(atk)
+	}
+
+	if opts.jwtClaimsf != "" {
+		claims, err := ioutil.ReadFile(opts.jwtClaimsf)
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %s", opts.jwtClaimsf, err)
+		}
+
+		key, err := ioutil.ReadFile(opts.jwtKeyf)
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %s", opts.jwtKeyf, err)
+		}
+
+		s, err := vegeta.NewJWTSigner(opts.jwtAlg, key, string(claims))
+		if err != nil {
+			return nil, err
+		}
+		vegeta.JWTs(s)(atk)
+	}
+
+	if opts.scriptf != "" {
+		script, err := newScript(opts.scriptf)
+		if err != nil {
+			return nil, fmt.Errorf("error loading script %s: %s", opts.scriptf, err)
+		}
+		vegeta.Scripted(script)(atk)
+	}
+
+	return atk, nil
+}
+
+// phase runs the scenario steps file with the given name once with the given
+// values, returning them along with the values it extracted.
+func phase(atk *vegeta.Attacker, filename string, body []byte, hdr http.Header, vars map[string]interface{}) (map[string]interface{}, error) {
+	sc, err := scenario(filename, body, hdr, nil)
+	if err != nil {
+		return nil, err
+	}
+	sc.Vars = vars
+	return atk.RunScenario(sc)
+}
+
+// withVars returns a Feed which adds the given values to the records of the
+// given Feed, without overriding theirs.
+func withVars(feed vegeta.Feed, vars *map[string]interface{}) vegeta.Feed {
+	return func() (map[string]interface{}, error) {
+		rec, err := feed()
+		if err != nil {
+			return nil, err
+		}
+
+		// Records may be shared, so they're copied.
+		merged := make(map[string]interface{}, len(rec)+len(*vars))
+		for k, v := range *vars {
+			merged[k] = v
+		}
+		for k, v := range rec {
+			merged[k] = v
+		}
+
+		return merged, nil
+	}
+}
+
+// scenario reads the scenario steps file with the given name, adding the
+// given tags to all its steps.
+func scenario(filename string, body []byte, hdr http.Header, tags map[string]string) (*vegeta.Scenario, error) {
+	f, err := file(filename, false)
+	if err != nil {
+		return nil, fmt.Errorf("error opening %s: %s", filename, err)
+	}
+	defer f.Close()
+
+	sc, err := vegeta.NewScenario(f, body, hdr)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %s", filename, err)
+	}
+
+	for i := range sc.Steps {
+		tgt := &sc.Steps[i].Target
+		for k, v := range tags {
+			if _, ok := tgt.Tags[k]; !ok {
+				if tgt.Tags == nil {
+					tgt.Tags = map[string]string{}
+				}
+				tgt.Tags[k] = v
+			}
+		}
+	}
+
+	return sc, nil
+}
+
+// checksums returns a Targeter which sets the expected SHA-256 digest of
+// the Targets returned by the given Targeter from a file in the format
+// written by sha256sum, with target URLs in place of file names.
+func checksums(tr vegeta.Targeter, filename string) (vegeta.Targeter, error) {
+	f, err := file(filename, false)
+	if err != nil {
+		return nil, fmt.Errorf("error opening %s: %s", filename, err)
+	}
+	defer f.Close()
+
+	sums := map[string]string{}
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 0 {
+			continue
+		} else if len(fields) != 2 || len(fields[0]) != 64 {
+			return nil, fmt.Errorf("bad checksum: %s", sc.Text())
+		}
+		sums[strings.TrimPrefix(fields[1], "*")] = fields[0]
+	}
+
+	if err = sc.Err(); err != nil {
+		return nil, fmt.Errorf("error reading %s: %s", filename, err)
+	}
+
+	return func(tgt *vegeta.Target) error {
+		if err := tr(tgt); err != nil {
+			return err
+		}
+		if sum, ok := sums[tgt.URL]; ok {
+			tgt.SHA256 = sum
+		}
+		return nil
+	}, nil
+}
+
+// hostsFile reads the addresses of host names from the given file in
+// /etc/hosts format.
+func hostsFile(filename string) (map[string][]string, error) {
+	f, err := file(filename, false)
+	if err != nil {
+		return nil, fmt.Errorf("error opening %s: %s", filename, err)
+	}
+	defer f.Close()
+
+	hosts := map[string][]string{}
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := sc.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		} else if len(fields) < 2 || net.ParseIP(fields[0]) == nil {
+			return nil, fmt.Errorf("bad hosts entry: %s", sc.Text())
+		}
+
+		for _, host := range fields[1:] {
+			hosts[host] = append(hosts[host], fields[0])
+		}
+	}
+
+	if err = sc.Err(); err != nil {
+		return nil, fmt.Errorf("error reading %s: %s", filename, err)
+	}
+
+	return hosts, nil
+}
+
 // tlsConfig builds a *tls.Config from the given options.
 func tlsConfig(insecure bool, certf, keyf string, rootCerts []string) (*tls.Config, error) {
 	var err error
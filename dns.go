@@ -0,0 +1,110 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	vegeta "github.com/FractalBlockchain/vegeta/lib"
+)
+
+func dnsCmd() command {
+	fs := flag.NewFlagSet("vegeta dns", flag.ExitOnError)
+	opts := &dnsOpts{}
+
+	fs.StringVar(&opts.name, "name", "", "Attack name")
+	fs.StringVar(&opts.targetsf, "targets", "stdin", "Queries file with one name and optional type per line")
+	fs.StringVar(&opts.outputf, "output", "stdout", "Output file")
+	fs.StringVar(&opts.server, "server", vegeta.DefaultDNSServer, "Resolver address or DoH endpoint URL")
+	fs.StringVar(&opts.proto, "proto", vegeta.DNSOverUDP, "Resolver protocol [udp, tcp, tls, https]")
+	fs.BoolVar(&opts.insecure, "insecure", false, "Ignore invalid server TLS certificates")
+	fs.DurationVar(&opts.duration, "duration", 0, "Duration of the test [0 = forever]")
+	fs.DurationVar(&opts.timeout, "timeout", vegeta.DefaultTimeout, "Queries timeout")
+	fs.Uint64Var(&opts.rate, "rate", 50, "Queries per second")
+	fs.Uint64Var(&opts.workers, "workers", vegeta.DefaultWorkers, "Initial number of workers")
+
+	return command{fs, func(args []string) error {
+		fs.Parse(args)
+		return dns(opts)
+	}}
+}
+
+// dnsOpts aggregates the dns function command options
+type dnsOpts struct {
+	name     string
+	targetsf string
+	outputf  string
+	server   string
+	proto    string
+	insecure bool
+	duration time.Duration
+	timeout  time.Duration
+	rate     uint64
+	workers  uint64
+}
+
+// dns validates the dns arguments, sets up the required resources,
+// launches the DNS attack and writes the results
+func dns(opts *dnsOpts) error {
+	if opts.rate == 0 {
+		return errZeroRate
+	}
+
+	switch opts.proto {
+	case vegeta.DNSOverUDP, vegeta.DNSOverTCP, vegeta.DNSOverTLS, vegeta.DNSOverHTTPS:
+	default:
+		return fmt.Errorf("unsupported protocol: %s", opts.proto)
+	}
+
+	src, err := file(opts.targetsf, false)
+	if err != nil {
+		return fmt.Errorf("error opening %s: %s", opts.targetsf, err)
+	}
+	defer src.Close()
+
+	tr, err := vegeta.NewDNSTargeter(src)
+	if err != nil {
+		return err
+	}
+
+	out, err := file(opts.outputf, true)
+	if err != nil {
+		return fmt.Errorf("error opening %s: %s", opts.outputf, err)
+	}
+	defer out.Close()
+
+	tlsc, err := tlsConfig(opts.insecure, "", "", nil)
+	if err != nil {
+		return err
+	}
+
+	atk := vegeta.NewDNSAttacker(
+		vegeta.DNSServer(opts.server),
+		vegeta.DNSProtocol(opts.proto),
+		vegeta.DNSTimeout(opts.timeout),
+		vegeta.DNSWorkers(opts.workers),
+		vegeta.DNSTLSConfig(tlsc),
+	)
+
+	res := atk.Attack(tr, opts.rate, opts.duration, opts.name)
+	enc := vegeta.NewEncoder(out)
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt)
+
+	for {
+		select {
+		case <-sig:
+			atk.Stop()
+			return nil
+		case r, ok := <-res:
+			if !ok {
+				return nil
+			}
+			if err = enc.Encode(r); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -11,16 +11,17 @@ import (
 
 func dumpCmd() command {
 	fs := flag.NewFlagSet("vegeta dump", flag.ExitOnError)
-	dumper := fs.String("dumper", "json", "Dumper [json, csv]")
+	dumper := fs.String("dumper", "json", "Dumper [json, ndjson, csv, protobuf]")
 	inputs := fs.String("inputs", "stdin", "Input files (comma separated)")
 	output := fs.String("output", "stdout", "Output file")
+	header := fs.Bool("header", false, "Write a header of column names in CSV dumps")
 	return command{fs, func(args []string) error {
 		fs.Parse(args)
-		return dump(*dumper, *inputs, *output)
+		return dump(*dumper, *inputs, *output, *header)
 	}}
 }
 
-func dump(dumper, inputs, output string) error {
+func dump(dumper, inputs, output string, header bool) error {
 	files := strings.Split(inputs, ",")
 	srcs := make([]vegeta.Decoder, len(files))
 	for i, f := range files {
@@ -29,7 +30,13 @@ func dump(dumper, inputs, output string) error {
 			return err
 		}
 		defer in.Close()
-		srcs[i] = vegeta.NewDecoder(in)
+
+		rd, err := vegeta.NewDecompressor(in)
+		if err != nil {
+			return err
+		}
+		defer rd.Close()
+		srcs[i] = vegeta.NewDecoder(rd)
 	}
 	dec := vegeta.NewRoundRobinDecoder(srcs...)
 
@@ -43,8 +50,15 @@ func dump(dumper, inputs, output string) error {
 	switch dumper {
 	case "csv":
 		enc = vegeta.NewCSVEncoder(out)
+		if header {
+			enc = vegeta.NewCSVHeaderEncoder(out)
+		}
 	case "json":
 		enc = vegeta.NewJSONEncoder(out)
+	case "ndjson":
+		enc = vegeta.NewNDJSONEncoder(out)
+	case "protobuf":
+		enc = vegeta.NewProtobufEncoder(out)
 	default:
 		return fmt.Errorf("unsupported dumper: %s", dumper)
 	}
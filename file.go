@@ -1,7 +1,13 @@
 package main
 
 import (
+	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
+	"sort"
+
+	vegeta "github.com/FractalBlockchain/vegeta/lib"
 )
 
 func file(name string, create bool) (*os.File, error) {
@@ -17,3 +23,31 @@ func file(name string, create bool) (*os.File, error) {
 		return os.Open(name)
 	}
 }
+
+// writeTarget writes the given Target in the http targets format, with its
+// body written to the bodyf file, if any.
+func writeTarget(w io.Writer, tgt *vegeta.Target, bodyf string) error {
+	fmt.Fprintf(w, "%s %s\n", tgt.Method, tgt.URL)
+
+	keys := make([]string, 0, len(tgt.Header))
+	for k := range tgt.Header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		for _, v := range tgt.Header[k] {
+			fmt.Fprintf(w, "%s: %s\n", k, v)
+		}
+	}
+
+	if len(tgt.Body) > 0 {
+		if err := ioutil.WriteFile(bodyf, tgt.Body, 0644); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "@%s\n", bodyf)
+	}
+
+	_, err := fmt.Fprintln(w)
+	return err
+}
@@ -5,7 +5,10 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // headers is the http.Header used in each target request
@@ -40,11 +43,122 @@ func (h headers) Set(value string) error {
 }
 
 // localAddr implements the Flag interface for parsing net.IPAddr
-type localAddr struct{ *net.IPAddr }
+// and comma separated lists of them and of CIDR blocks, whose addresses are
+// rotated across.
+type localAddr struct {
+	*net.IPAddr
+	all []net.IPAddr
+}
+
+func (ip *localAddr) Set(value string) error {
+	ip.all = ip.all[:0]
+	for _, v := range strings.Split(value, ",") {
+		if !strings.Contains(v, "/") {
+			addr, err := net.ResolveIPAddr("ip", v)
+			if err != nil {
+				return err
+			}
+			ip.all = append(ip.all, *addr)
+			continue
+		}
+
+		addrs, err := cidrAddrs(v)
+		if err != nil {
+			return err
+		}
+		ip.all = append(ip.all, addrs...)
+	}
+	ip.IPAddr = &ip.all[0]
+	return nil
+}
+
+// maxCIDRAddrs is the maximum number of addresses of a CIDR block.
+const maxCIDRAddrs = 1 << 16
+
+// cidrAddrs returns the host addresses of the given CIDR block.
+func cidrAddrs(cidr string) ([]net.IPAddr, error) {
+	_, ipnet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, err
+	}
+
+	ones, bits := ipnet.Mask.Size()
+	if bits-ones > 16 {
+		return nil, fmt.Errorf("CIDR block %s has more than %d addresses", cidr, maxCIDRAddrs)
+	}
+
+	var addrs []net.IPAddr
+	for ip := ipnet.IP; ipnet.Contains(ip); ip = nextIP(ip) {
+		addrs = append(addrs, net.IPAddr{IP: ip})
+	}
+
+	// The network and broadcast addresses of IPv4 subnets aren't hosts.
+	if bits == 32 && bits-ones > 1 {
+		addrs = addrs[1 : len(addrs)-1]
+	}
+
+	return addrs, nil
+}
+
+// nextIP returns the IP address following the given one.
+func nextIP(ip net.IP) net.IP {
+	next := append(net.IP(nil), ip...)
+	for i := len(next) - 1; i >= 0; i-- {
+		if next[i]++; next[i] != 0 {
+			break
+		}
+	}
+	return next
+}
 
-func (ip *localAddr) Set(value string) (err error) {
-	ip.IPAddr, err = net.ResolveIPAddr("ip", value)
-	return
+// weightedFiles implements the flag.Value interface for repeated weight:file
+// flags.
+type weightedFiles struct {
+	weights []uint64
+	files   []string
+}
+
+func (w *weightedFiles) Set(value string) error {
+	parts := strings.SplitN(value, ":", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return fmt.Errorf("weighted targets '%s' has a wrong format", value)
+	}
+	weight, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("weighted targets '%s' has a bad weight", value)
+	}
+	w.weights = append(w.weights, weight)
+	w.files = append(w.files, parts[1])
+	return nil
+}
+
+func (w weightedFiles) String() string {
+	pairs := make([]string, len(w.files))
+	for i := range w.files {
+		pairs[i] = fmt.Sprintf("%d:%s", w.weights[i], w.files[i])
+	}
+	return strings.Join(pairs, ",")
+}
+
+// tags implements the flag.Value interface for repeated key=value flags.
+type tags map[string]string
+
+func (t tags) Set(value string) error {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return fmt.Errorf("tag '%s' has a wrong format", value)
+	}
+	t[parts[0]] = parts[1]
+	return nil
+}
+
+func (t tags) String() string {
+	pairs := make([]string, 0, len(t))
+	for k, v := range t {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
 }
 
 // csl implements the flag.Value interface for comma separated lists
@@ -56,3 +170,31 @@ func (l *csl) Set(v string) error {
 }
 
 func (l csl) String() string { return strings.Join(l, ",") }
+
+// durationRange implements the flag.Value interface for a duration or a
+// min-max range of durations.
+type durationRange [2]time.Duration
+
+func (r *durationRange) Set(value string) (err error) {
+	bounds := strings.SplitN(value, "-", 2)
+	if r[0], err = time.ParseDuration(bounds[0]); err != nil {
+		return err
+	}
+	r[1] = r[0]
+	if len(bounds) == 2 {
+		if r[1], err = time.ParseDuration(bounds[1]); err != nil {
+			return err
+		}
+	}
+	if r[0] < 0 || r[1] < r[0] {
+		return fmt.Errorf("duration range '%s' is invalid", value)
+	}
+	return nil
+}
+
+func (r durationRange) String() string {
+	if r[0] == r[1] {
+		return r[0].String()
+	}
+	return r[0].String() + "-" + r[1].String()
+}
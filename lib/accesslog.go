@@ -0,0 +1,183 @@
+package vegeta
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NewAccessLogTargeter eagerly reads all requests out of the provided access
+// log and returns a NewStaticTargeter with them. See NewAccessLogReplay for
+// the supported log formats.
+//
+// base is prepended to logged request paths which aren't absolute URLs.
+// body will be set as the Target's body.
+// hdr will be merged with the each Target's headers.
+func NewAccessLogTargeter(src io.Reader, base string, body []byte, hdr http.Header) (Targeter, error) {
+	tgts, _, err := readAccessLog(src, base, body, hdr)
+	if err != nil {
+		return nil, err
+	}
+	return NewStaticTargeter(tgts...), nil
+}
+
+// NewAccessLogReplay eagerly reads all requests out of the provided access
+// log and returns a Targeter which yields them once, in logged order, along
+// with a Pacer which hits them with their original inter-arrival times
+// divided by the given speed factor: 2 replays twice as fast, 0.5 twice as
+// slow.
+//
+// Access logs can be in the Common or Combined Log Formats, as written by
+// Apache and nginx, or have one JSON object per line with a request line
+// ("request") or method ("method") and URL ("url", "uri" or "path") fields
+// and a timestamp ("time", "timestamp" or "@timestamp") field which is
+// either RFC 3339, Common Log Format or a number of seconds since the epoch.
+//
+// base is prepended to logged request paths which aren't absolute URLs.
+// body will be set as the Target's body.
+// hdr will be merged with the each Target's headers.
+func NewAccessLogReplay(src io.Reader, base string, speed float64, body []byte, hdr http.Header) (Targeter, Pacer, error) {
+	tgts, times, err := readAccessLog(src, base, body, hdr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return newReplay(tgts, times, speed)
+}
+
+// clf matches the Common Log Format with the optional Combined Log Format
+// referer and user agent fields.
+var clf = regexp.MustCompile(`^\S+ \S+ \S+ \[([^\]]+)\] "([^"]*)" \S+ \S+(?: "([^"]*)" "([^"]*)")?`)
+
+const clfTime = "02/Jan/2006:15:04:05 -0700"
+
+func readAccessLog(src io.Reader, base string, body []byte, hdr http.Header) ([]Target, []time.Time, error) {
+	var (
+		tgts  []Target
+		times []time.Time
+		sc    = bufio.NewScanner(src)
+	)
+
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	base = strings.TrimSuffix(base, "/")
+
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+
+		var (
+			ts      time.Time
+			reqline string
+			method  string
+			uri     string
+			ua, ref string
+			err     error
+		)
+
+		if strings.HasPrefix(line, "{") {
+			var entry map[string]interface{}
+			if err = json.Unmarshal([]byte(line), &entry); err != nil {
+				return nil, nil, fmt.Errorf("bad log entry on line %d: %s", n, err)
+			}
+			reqline = logField(entry, "request")
+			method = logField(entry, "method", "request_method")
+			uri = logField(entry, "url", "uri", "request_uri", "path")
+			ua = logField(entry, "user_agent", "http_user_agent")
+			ref = logField(entry, "referer", "http_referer")
+			if ts, err = logTime(logField(entry, "time", "timestamp", "@timestamp", "time_local", "ts")); err != nil {
+				return nil, nil, fmt.Errorf("bad log entry on line %d: %s", n, err)
+			}
+		} else {
+			m := clf.FindStringSubmatch(line)
+			if m == nil {
+				return nil, nil, fmt.Errorf("bad log entry on line %d: %s", n, line)
+			}
+			if ts, err = time.Parse(clfTime, m[1]); err != nil {
+				return nil, nil, fmt.Errorf("bad log entry on line %d: %s", n, err)
+			}
+			reqline, ref, ua = m[2], m[3], m[4]
+		}
+
+		if reqline != "" {
+			fields := strings.Fields(reqline)
+			if len(fields) < 2 {
+				return nil, nil, fmt.Errorf("bad request on line %d: %s", n, reqline)
+			}
+			method, uri = fields[0], fields[1]
+		}
+
+		if method == "" || uri == "" {
+			return nil, nil, fmt.Errorf("bad log entry on line %d: missing method or URL", n)
+		}
+
+		tgt := Target{Method: method, URL: uri, Body: body, Header: http.Header{}}
+		if !strings.Contains(uri, "://") {
+			tgt.URL = base + uri
+		}
+
+		for k, vs := range hdr {
+			tgt.Header[k] = vs
+		}
+
+		if ua != "" && ua != "-" {
+			tgt.Header.Set("User-Agent", ua)
+		}
+
+		if ref != "" && ref != "-" {
+			tgt.Header.Set("Referer", ref)
+		}
+
+		tgts = append(tgts, tgt)
+		times = append(times, ts)
+	}
+
+	if err := sc.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if len(tgts) == 0 {
+		return nil, nil, ErrNoTargets
+	}
+
+	return tgts, times, nil
+}
+
+// logField returns the first of the given keys found in the entry as a
+// string.
+func logField(entry map[string]interface{}, keys ...string) string {
+	for _, k := range keys {
+		switch v := entry[k].(type) {
+		case string:
+			return v
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64)
+		}
+	}
+	return ""
+}
+
+func logTime(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, fmt.Errorf("missing timestamp")
+	}
+
+	if secs, err := strconv.ParseFloat(v, 64); err == nil {
+		return time.Unix(0, int64(secs*1e9)), nil
+	}
+
+	for _, layout := range []string{time.RFC3339Nano, clfTime} {
+		if ts, err := time.Parse(layout, v); err == nil {
+			return ts, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("bad timestamp: %s", v)
+}
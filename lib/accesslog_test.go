@@ -0,0 +1,108 @@
+package vegeta
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const accessLog = `127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326
+127.0.0.1 - - [10/Oct/2000:13:55:37 -0700] "POST /login HTTP/1.1" 302 0 "http://goku/" "Mozilla/5.0"
+
+{"time": "2000-10-10T13:55:39-07:00", "method": "DELETE", "uri": "/things/1"}
+{"@timestamp": 971211340.5, "request": "GET http://other/ HTTP/1.1"}
+`
+
+func TestNewAccessLogReplay(t *testing.T) {
+	t.Parallel()
+
+	tr, p, err := NewAccessLogReplay(strings.NewReader(accessLog), "http://staging/", 2, nil, http.Header{"X-Replay": {"1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Target{
+		{Method: "GET", URL: "http://staging/apache_pb.gif", Header: http.Header{"X-Replay": {"1"}}},
+		{Method: "POST", URL: "http://staging/login", Header: http.Header{
+			"X-Replay":   {"1"},
+			"Referer":    {"http://goku/"},
+			"User-Agent": {"Mozilla/5.0"},
+		}},
+		{Method: "DELETE", URL: "http://staging/things/1", Header: http.Header{"X-Replay": {"1"}}},
+		{Method: "GET", URL: "http://other/", Header: http.Header{"X-Replay": {"1"}}},
+	}
+
+	for i := range want {
+		var got Target
+		if err := tr(&got); err != nil {
+			t.Fatal(err)
+		} else if !reflect.DeepEqual(got, want[i]) {
+			t.Errorf("\ngot:  %+v\nwant: %+v", got, want[i])
+		}
+	}
+
+	if err := tr(&Target{}); err != ErrNoTargets {
+		t.Errorf("got: %v, want: %v", err, ErrNoTargets)
+	}
+
+	for hit, want := range []time.Duration{0, 500 * time.Millisecond, 1500 * time.Millisecond, 2250 * time.Millisecond} {
+		if got, stop := p.Pace(uint64(hit)); got != want || stop {
+			t.Errorf("hit %d: got: (%s, %t), want: (%s, false)", hit, got, stop, want)
+		}
+	}
+
+	if _, stop := p.Pace(4); !stop {
+		t.Error("want stop after the last entry")
+	}
+}
+
+func TestNewAccessLogTargeter_BadEntries(t *testing.T) {
+	t.Parallel()
+
+	for _, log := range []string{
+		"",
+		"garbage",
+		`127.0.0.1 - - [bad time] "GET / HTTP/1.1" 200 0`,
+		`{"time": "2000-10-10T13:55:39Z"}`,
+		`{"method": "GET", "uri": "/"}`,
+	} {
+		if _, err := NewAccessLogTargeter(strings.NewReader(log), "", nil, nil); err == nil {
+			t.Errorf("%q: want error, got none", log)
+		}
+	}
+}
+
+func TestAttackAccessLogReplay(t *testing.T) {
+	t.Parallel()
+
+	var hits int64
+	server := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt64(&hits, 1)
+		}),
+	)
+	defer server.Close()
+
+	// Leave out the last entry which has an absolute URL.
+	log := accessLog[:strings.LastIndex(accessLog, "{")]
+	tr, p, err := NewAccessLogReplay(strings.NewReader(log), server.URL, 10, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	began := time.Now()
+	for range NewAttacker().AttackWithPacer(tr, p, "") {
+	}
+
+	if elapsed := time.Since(began); elapsed < 150*time.Millisecond {
+		t.Errorf("replay took %s, want at least 150ms", elapsed)
+	}
+
+	if got, want := atomic.LoadInt64(&hits), int64(3); got != want {
+		t.Errorf("got %d hits, want %d", got, want)
+	}
+}
@@ -0,0 +1,92 @@
+package vegeta
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// ErrAssertion is the error reported, as a prefix of the Error field of
+// Results, when a response fails the Assertions of its Target. It's counted
+// apart from transport and status code errors in Metrics.
+var ErrAssertion = errors.New("assertion failed")
+
+// Assertions are checks of the responses to a Target. Empty fields aren't
+// checked.
+type Assertions struct {
+	// Status is the set of expected status codes. When set, it replaces the
+	// default check of successful or redirect status codes.
+	Status []int `json:"status"`
+	// Body is a regular expression the response body must match.
+	Body string `json:"body"`
+	// JSON maps dot separated paths of values in the JSON response body,
+	// as extracted by NewExtractor, to their expected values.
+	JSON map[string]string `json:"json"`
+	// MaxBytes is the maximum size of the response body.
+	MaxBytes uint64 `json:"max_bytes"`
+	// Headers are the names of the headers the response must have.
+	Headers []string `json:"headers"`
+
+	body *regexp.Regexp
+}
+
+// Compile compiles the Assertions, returning an error if they're invalid.
+// It must be called before they're checked.
+func (as *Assertions) Compile() (err error) {
+	if as.Body != "" {
+		if as.body, err = regexp.Compile(as.Body); err != nil {
+			return fmt.Errorf("bad body assertion: %s", err)
+		}
+	}
+	return nil
+}
+
+// Check checks the given response, its body and the size of its body, which
+// is given apart since bodies may be dropped after being hashed.
+func (as *Assertions) Check(r *http.Response, body []byte, size uint64) error {
+	if len(as.Status) > 0 && !as.expects(r.StatusCode) {
+		return fmt.Errorf("%s: status %d not in %v", ErrAssertion, r.StatusCode, as.Status)
+	}
+
+	if as.MaxBytes > 0 && size > as.MaxBytes {
+		return fmt.Errorf("%s: body of %d bytes exceeds %d", ErrAssertion, size, as.MaxBytes)
+	}
+
+	for _, h := range as.Headers {
+		if _, ok := r.Header[http.CanonicalHeaderKey(h)]; !ok {
+			return fmt.Errorf("%s: missing header %s", ErrAssertion, h)
+		}
+	}
+
+	if as.body != nil && !as.body.Match(body) {
+		return fmt.Errorf("%s: body doesn't match %q", ErrAssertion, as.Body)
+	}
+
+	for path, want := range as.JSON {
+		if got, err := extractJSON(body, path); err != nil {
+			return fmt.Errorf("%s: json %s: %s", ErrAssertion, path, err)
+		} else if got != want {
+			return fmt.Errorf("%s: json %s is %q, want %q", ErrAssertion, path, got, want)
+		}
+	}
+
+	return nil
+}
+
+// expects returns true if the Assertions expect the given status code.
+func (as *Assertions) expects(code int) bool {
+	for _, c := range as.Status {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// isAssertion returns true if the given Result error is an assertion
+// failure.
+func isAssertion(err string) bool {
+	return strings.HasPrefix(err, ErrAssertion.Error())
+}
@@ -0,0 +1,82 @@
+package vegeta
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAssertions(t *testing.T) {
+	t.Parallel()
+
+	r := &http.Response{StatusCode: 201, Header: http.Header{"Etag": {"v1"}}}
+	body := []byte(`{"data": {"name": "kakarot", "level": 9001}}`)
+
+	for _, tc := range []struct {
+		as  Assertions
+		err string
+	}{
+		{Assertions{}, ""},
+		{Assertions{Status: []int{200, 201}}, ""},
+		{Assertions{Status: []int{200}}, "assertion failed: status 201 not in [200]"},
+		{Assertions{MaxBytes: uint64(len(body))}, ""},
+		{Assertions{MaxBytes: 10}, "assertion failed: body of 44 bytes exceeds 10"},
+		{Assertions{Headers: []string{"etag"}}, ""},
+		{Assertions{Headers: []string{"Location"}}, "assertion failed: missing header Location"},
+		{Assertions{Body: `"name": "\w+"`}, ""},
+		{Assertions{Body: `vegeta`}, `assertion failed: body doesn't match "vegeta"`},
+		{Assertions{JSON: map[string]string{"data.name": "kakarot", "$.data.level": "9001"}}, ""},
+		{Assertions{JSON: map[string]string{"data.level": "8000"}}, `assertion failed: json data.level is "9001", want "8000"`},
+		{Assertions{JSON: map[string]string{"data.id": "1"}}, "assertion failed: json data.id: no match"},
+	} {
+		if err := tc.as.Compile(); err != nil {
+			t.Fatal(err)
+		}
+
+		var got string
+		if err := tc.as.Check(r, body, uint64(len(body))); err != nil {
+			got = err.Error()
+		}
+
+		if got != tc.err {
+			t.Errorf("%+v: got error %q, want %q", tc.as, got, tc.err)
+		}
+	}
+
+	if err := (&Assertions{Body: "("}).Compile(); err == nil {
+		t.Error("got no error compiling a bad body assertion")
+	}
+}
+
+func TestAttackAssertions(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/gone" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"name": "kakarot"}`))
+	}))
+	defer server.Close()
+
+	targets := strings.Join([]string{
+		`{"method": "DELETE", "url": "` + server.URL + `/gone", "assert": {"status": [204, 404]}}`,
+		`{"method": "GET", "url": "` + server.URL + `/things", "assert": {"json": {"name": "vegeta"}}}`,
+		`{"method": "GET", "url": "` + server.URL + `/gone"}`,
+	}, "\n")
+
+	tr := NewLazyJSONTargeter(strings.NewReader(targets), nil, nil)
+	atk := NewAttacker()
+
+	for _, want := range []string{
+		"",
+		`assertion failed: json name is "kakarot", want "vegeta"`,
+		"404 Not Found",
+	} {
+		if got := atk.hit(tr, "", 0).Error; got != want {
+			t.Errorf("got error %q, want %q", got, want)
+		}
+	}
+}
@@ -3,26 +3,70 @@ package vegeta
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"net"
 	"net/http"
+	"net/http/httptrace"
 	"net/url"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"text/template"
 	"time"
 
-	"github.com/rs/dnscache"
+	"github.com/FractalBlockchain/vegeta/lib/pacing"
 	"golang.org/x/net/http2"
 )
 
 // Attacker is an attack executor which wraps an http.Client
 type Attacker struct {
-	dialer    *net.Dialer
-	client    http.Client
-	stopch    chan struct{}
-	workers   uint64
-	redirects int
+	dialer     *net.Dialer
+	client     http.Client
+	stopch     chan struct{}
+	workers    uint64
+	redirects  int
+	ranges     *rangeVerifier
+	offset     time.Duration
+	active     atomic.Value
+	backoff    *backoff
+	hash       bool
+	discard    bool
+	bodies     BodyHandler
+	maxBody    int64
+	reqBodies  bool
+	reqHeaders bool
+	script     Script
+	churn      *churn
+	lifetime   *connLifetime
+	names      *template.Template
+	body       time.Duration
+	total      time.Duration
+	deadline   time.Duration
+	cookies    bool
+	sticky     bool
+	think      [2]time.Duration
+	extractors []*Extractor
+	success    func(*Result, *http.Response) bool
+	oauth2     *OAuth2
+	jwt        *JWTSigner
+	trace      tracing
+	ids        []string
+	resHeaders []string
+	onRequest  []func(*http.Request)
+	onResponse []func(*http.Request, *http.Response, *Result)
+	dns        dnsConfig
+	resolver   Resolver
+	balancer   *balancer
+	family     string
+	laddrs     *localAddrs
+	sockopts   []func(*net.TCPConn) error
+	limit      *connLimit
+	warmup     warmup
+	lanes      *lanes
+
+	tlsTransports sync.Map
 }
 
 const (
@@ -44,23 +88,51 @@ const (
 var (
 	// DefaultLocalAddr is the default local IP address an Attacker uses.
 	DefaultLocalAddr = net.IPAddr{IP: net.IPv4zero}
-	// DefaultTLSConfig is the default tls.Config an Attacker uses.
+	// DefaultTLSConfig is the default tls.Config an Attacker uses, which
+	// skips the verification of certificates. InsecureSkipVerify(false)
+	// enables it against the system's root CAs, and RootCAs against others.
+	// The vegeta command verifies certificates unless run with -insecure.
 	DefaultTLSConfig = &tls.Config{InsecureSkipVerify: true}
 )
 
-// Cached DNS resolver
-var resolver = &dnscache.Resolver{}
+// ErrCorruptedBody is the error reported when a response body doesn't match
+// the SHA256 digest expected by its Target.
+var ErrCorruptedBody = errors.New("corrupted body: sha256 mismatch")
 
-func dialContext(ctx context.Context, network string, addr string) (conn net.Conn, err error) {
-	separator := strings.LastIndex(addr, ":")
-	ips, err := resolver.LookupHost(ctx, addr[:separator])
+// ErrUnsuccessful is the error reported when a response is rejected by the
+// predicate given to SuccessPredicate.
+var ErrUnsuccessful = errors.New("unsuccessful response")
+
+// dialContext dials addr with the Attacker's dialer, resolving its host with
+// the Attacker's Resolver, within the Attacker's connection caps.
+func (a *Attacker) dialContext(ctx context.Context, network string, addr string) (conn net.Conn, err error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	if err = a.limit.acquire(host); err != nil {
+		return nil, err
+	}
+	defer func() { conn, err = a.limit.track(host, conn, err) }()
+	ips, err := a.lookup(ctx, host)
 	if err != nil {
 		return nil, err
 	}
+	if ips, err = filterFamily(a.family, ips); err != nil {
+		return nil, &net.DNSError{Err: err.Error(), Name: host}
+	}
+	ips = a.balancer.order(host, ips)
+	if a.family == HappyEyeballs {
+		var ip string
+		if conn, ip, err = a.race(ctx, network, port, interleave(ips)); err == nil {
+			conn = a.balancer.track(ip, conn)
+		}
+		return conn, err
+	}
 	for _, ip := range ips {
-		conn, err = net.Dial(network, ip+addr[separator:])
+		conn, err = a.dial(ctx, network, ip, port)
 		if err == nil {
-			break
+			return a.balancer.track(ip, conn), nil
 		}
 	}
 	return
@@ -69,17 +141,19 @@ func dialContext(ctx context.Context, network string, addr string) (conn net.Con
 // NewAttacker returns a new Attacker with default options which are overridden
 // by the optionally provided opts.
 func NewAttacker(opts ...func(*Attacker)) *Attacker {
-	a := &Attacker{stopch: make(chan struct{}), workers: DefaultWorkers}
+	a := &Attacker{stopch: make(chan struct{}), workers: DefaultWorkers, redirects: DefaultRedirects}
+	a.resolver = a.dns.resolver()
 	a.dialer = &net.Dialer{
 		LocalAddr: &net.TCPAddr{IP: DefaultLocalAddr.IP, Zone: DefaultLocalAddr.Zone},
 		KeepAlive: 30 * time.Second,
 		Timeout:   DefaultTimeout,
 	}
 	a.client = http.Client{
+		CheckRedirect: a.checkRedirect,
 		Transport: &http.Transport{
 			Proxy:                 http.ProxyFromEnvironment,
 			Dial:                  a.dialer.Dial,
-			DialContext:           dialContext,
+			DialContext:           a.dialContext,
 			ResponseHeaderTimeout: DefaultTimeout,
 			TLSClientConfig:       DefaultTLSConfig,
 			TLSHandshakeTimeout:   10 * time.Second,
@@ -94,6 +168,62 @@ func NewAttacker(opts ...func(*Attacker)) *Attacker {
 	return a
 }
 
+// Client returns a functional option which makes an Attacker send its
+// requests with a copy of the given http.Client, e.g. one with instrumented
+// or custom transports. Later options configure its Transport if it's an
+// *http.Transport or wraps one, as found with Unwrap methods, and are
+// ignored otherwise.
+func Client(c *http.Client) func(*Attacker) {
+	return func(a *Attacker) { a.client = *c }
+}
+
+// RoundTripper returns a functional option which makes an Attacker send its
+// requests with the given http.RoundTripper, e.g. one wrapping an
+// *http.Transport for instrumentation. Wrappers which have an
+// Unwrap() http.RoundTripper method keep the wrapped *http.Transport
+// configurable by later options.
+func RoundTripper(rt http.RoundTripper) func(*Attacker) {
+	return func(a *Attacker) { a.client.Transport = rt }
+}
+
+// transport returns the *http.Transport of the Attacker's client, following
+// the Unwrap methods of the RoundTrippers wrapping it, or nil if it has none.
+func (a *Attacker) transport() *http.Transport {
+	return unwrapTransport(a.client.Transport)
+}
+
+// unwrapTransport returns the given *http.Transport, or the one wrapped by the
+// given RoundTripper as per the Unwrap methods of the RoundTrippers wrapping
+// it, or nil if there's none.
+func unwrapTransport(rt http.RoundTripper) *http.Transport {
+	for {
+		switch t := rt.(type) {
+		case *http.Transport:
+			return t
+		case interface{ Unwrap() http.RoundTripper }:
+			rt = t.Unwrap()
+		default:
+			return nil
+		}
+	}
+}
+
+// errNoTransport is returned by options which need connections of their own
+// when the Attacker's client has no *http.Transport to clone, e.g. one set
+// with RoundTripper which doesn't unwrap to one.
+var errNoTransport = errors.New("no *http.Transport to open connections of its own with")
+
+// ownTransport returns a clone of the Attacker's *http.Transport, as found by
+// transport, which opens connections of its own. RoundTrippers wrapping the
+// Attacker's transport are bypassed, since they can't be rewired to the clone.
+func (a *Attacker) ownTransport() (*http.Transport, error) {
+	tr := a.transport()
+	if tr == nil {
+		return nil, errNoTransport
+	}
+	return tr.Clone(), nil
+}
+
 // Workers returns a functional option which sets the initial number of workers
 // an Attacker uses to hit its targets. More workers may be spawned dynamically
 // to sustain the requested rate in the face of slow responses and errors.
@@ -105,8 +235,9 @@ func Workers(n uint64) func(*Attacker) {
 // open connections per target host.
 func Connections(n int) func(*Attacker) {
 	return func(a *Attacker) {
-		tr := a.client.Transport.(*http.Transport)
-		tr.MaxIdleConnsPerHost = n
+		if tr := a.transport(); tr != nil {
+			tr.MaxIdleConnsPerHost = n
+		}
 	}
 }
 
@@ -115,16 +246,7 @@ func Connections(n int) func(*Attacker) {
 func Redirects(n int) func(*Attacker) {
 	return func(a *Attacker) {
 		a.redirects = n
-		a.client.CheckRedirect = func(_ *http.Request, via []*http.Request) error {
-			switch {
-			case n == NoFollow:
-				return http.ErrUseLastResponse
-			case n < len(via):
-				return fmt.Errorf("stopped after %d redirects", n)
-			default:
-				return nil
-			}
-		}
+		a.client.CheckRedirect = a.checkRedirect
 	}
 }
 
@@ -132,19 +254,24 @@ func Redirects(n int) func(*Attacker) {
 // the http.Client's Transport
 func Proxy(proxy func(*http.Request) (*url.URL, error)) func(*Attacker) {
 	return func(a *Attacker) {
-		tr := a.client.Transport.(*http.Transport)
-		tr.Proxy = proxy
+		if tr := a.transport(); tr != nil {
+			tr.Proxy = proxy
+		}
 	}
 }
 
 // Timeout returns a functional option which sets the maximum amount of time
-// an Attacker will wait for a request to be responded to.
+// an Attacker will wait for a request to be responded to. It bounds both
+// dialing and waiting for response headers, which DialTimeout and
+// ResponseHeaderTimeout set separately, while TotalTimeout bounds whole
+// requests.
 func Timeout(d time.Duration) func(*Attacker) {
 	return func(a *Attacker) {
-		tr := a.client.Transport.(*http.Transport)
-		tr.ResponseHeaderTimeout = d
 		a.dialer.Timeout = d
-		tr.Dial = a.dialer.Dial
+		if tr := a.transport(); tr != nil {
+			tr.ResponseHeaderTimeout = d
+			tr.Dial = a.dialer.Dial
+		}
 	}
 }
 
@@ -152,9 +279,10 @@ func Timeout(d time.Duration) func(*Attacker) {
 // an Attacker will use with its requests.
 func LocalAddr(addr net.IPAddr) func(*Attacker) {
 	return func(a *Attacker) {
-		tr := a.client.Transport.(*http.Transport)
 		a.dialer.LocalAddr = &net.TCPAddr{IP: addr.IP, Zone: addr.Zone}
-		tr.Dial = a.dialer.Dial
+		if tr := a.transport(); tr != nil {
+			tr.Dial = a.dialer.Dial
+		}
 	}
 }
 
@@ -162,10 +290,11 @@ func LocalAddr(addr net.IPAddr) func(*Attacker) {
 // connections on the dialer and transport.
 func KeepAlive(keepalive bool) func(*Attacker) {
 	return func(a *Attacker) {
-		tr := a.client.Transport.(*http.Transport)
-		tr.DisableKeepAlives = !keepalive
 		if !keepalive {
 			a.dialer.KeepAlive = 0
+		}
+		if tr := a.transport(); tr != nil {
+			tr.DisableKeepAlives = !keepalive
 			tr.Dial = a.dialer.Dial
 		}
 	}
@@ -175,8 +304,9 @@ func KeepAlive(keepalive bool) func(*Attacker) {
 // Attacker to use with its requests.
 func TLSConfig(c *tls.Config) func(*Attacker) {
 	return func(a *Attacker) {
-		tr := a.client.Transport.(*http.Transport)
-		tr.TLSClientConfig = c
+		if tr := a.transport(); tr != nil {
+			tr.TLSClientConfig = c
+		}
 	}
 }
 
@@ -184,7 +314,9 @@ func TLSConfig(c *tls.Config) func(*Attacker) {
 // on requests performed by an Attacker.
 func HTTP2(enabled bool) func(*Attacker) {
 	return func(a *Attacker) {
-		if tr := a.client.Transport.(*http.Transport); enabled {
+		if tr := a.transport(); tr == nil {
+			return
+		} else if enabled {
 			http2.ConfigureTransport(tr)
 		} else {
 			tr.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
@@ -196,7 +328,7 @@ func HTTP2(enabled bool) func(*Attacker) {
 // performed by an Attacker
 func H2C(enabled bool) func(*Attacker) {
 	return func(a *Attacker) {
-		if tr := a.client.Transport.(*http.Transport); enabled {
+		if tr := a.transport(); tr != nil && enabled {
 			a.client.Transport = &http2.Transport{
 				AllowHTTP: true,
 				DialTLS: func(network, addr string, cfg *tls.Config) (net.Conn, error) {
@@ -207,39 +339,156 @@ func H2C(enabled bool) func(*Attacker) {
 	}
 }
 
+// HashBodies returns a functional option which makes an Attacker keep only
+// the SHA-256 digest of response bodies in the BodyHash field of Results,
+// instead of the full bodies, to save memory and disk space in long attacks.
+func HashBodies(enabled bool) func(*Attacker) {
+	return func(a *Attacker) { a.hash = enabled }
+}
+
+// DiscardBodies returns a functional option which makes an Attacker read
+// response bodies into a counting discard writer, recording their size in
+// the BytesIn field of Results but not the bodies, which saves the memory
+// and throughput lost to large responses. Options which inspect bodies, e.g.
+// body assertions, checksums, extractors and scripts, see empty bodies, unless
+// RangeVerification reads them whole. HashBodies takes precedence.
+func DiscardBodies(enabled bool) func(*Attacker) {
+	return func(a *Attacker) { a.discard = enabled }
+}
+
+// SuccessPredicate returns a functional option which makes an Attacker judge
+// responses with the given predicate instead of their status code, e.g. to
+// treat 404s as successful or slow responses as failures. It's called with
+// the Result, whose Code, Latency and Body are set, and the response, whose
+// body was read. Rejected responses are reported with ErrUnsuccessful.
+func SuccessPredicate(ok func(*Result, *http.Response) bool) func(*Attacker) {
+	return func(a *Attacker) { a.success = ok }
+}
+
+// OnRequest returns a functional option which makes an Attacker call the
+// given hook with each of its requests right before sending it, e.g. to
+// mutate it or instrument it. Hooks are called in the order they were added
+// in and must be safe for concurrent use.
+func OnRequest(hook func(*http.Request)) func(*Attacker) {
+	return func(a *Attacker) { a.onRequest = append(a.onRequest, hook) }
+}
+
+// OnResponse returns a functional option which makes an Attacker call the
+// given hook with each of its requests which got a response, the response,
+// whose body was read, and its Result, e.g. to enrich it. Hooks are called in
+// the order they were added in, after Scripts and before bodies are dropped
+// by HashBodies, and must be safe for concurrent use.
+func OnResponse(hook func(*http.Request, *http.Response, *Result)) func(*Attacker) {
+	return func(a *Attacker) { a.onResponse = append(a.onResponse, hook) }
+}
+
+// NameTemplate returns a functional option which makes an Attacker name the
+// Results of each hit by executing the given template, e.g.
+// `{{.Method}} {{.URL.Path}}`, so that a single attack against many
+// endpoints produces per endpoint series in reports and plots. Templates are
+// executed with the hit's Name, as given to Attack or Switch, Method, URL as
+// a *url.URL, Header and Tags.
+func NameTemplate(t *template.Template) func(*Attacker) {
+	return func(a *Attacker) { a.names = t }
+}
+
+// attackName holds the fields attack name templates are executed with.
+type attackName struct {
+	Name   string
+	Method string
+	URL    *url.URL
+	Header http.Header
+	Tags   map[string]string
+}
+
 // Attack reads its Targets from the passed Targeter and attacks them at
 // the rate specified for the given duration. When the duration is zero the attack
 // runs until Stop is called. Results are sent to the returned channel as soon
 // as they arrive and will have their Attack field set to the given name.
 func (a *Attacker) Attack(tr Targeter, rate uint64, du time.Duration, name string) <-chan *Result {
+	return a.AttackWithPacer(tr, ConstantPacer{Rate: rate, Duration: du}, name)
+}
+
+// AttackWithPacer is like Attack but hits the Targets at the times defined by
+// the given Pacer.
+func (a *Attacker) AttackWithPacer(tr Targeter, p Pacer, name string) <-chan *Result {
+	a.active.Store(&activeTargeter{tr: a.warm(tr), name: name})
+	hits := Pace(p, a.workers, a.stopch, func(seq uint64) *Result {
+		at := a.active.Load().(*activeTargeter)
+		return a.hit(at.tr, at.name, seq)
+	})
+
+	results := make(chan *Result)
+	go func() {
+		defer close(results)
+		defer a.closeTLSTransports(nil)
+		for res := range hits {
+			results <- res
+		}
+	}()
+
+	return results
+}
+
+// Switch atomically swaps the Targeter of the current attack with the given
+// one, without interrupting it. Results of hits made after the switch have
+// their Attack field set to the given name, which marks the cutover in the
+// results, e.g. to measure the behaviour of blue/green deployments under
+// sustained load.
+func (a *Attacker) Switch(tr Targeter, name string) {
+	a.active.Store(&activeTargeter{tr: tr, name: name})
+}
+
+// activeTargeter is the Targeter an Attacker currently attacks along with
+// the attack name of its Results.
+type activeTargeter struct {
+	tr   Targeter
+	name string
+}
+
+// A Pacer defines the times at which an attack hits its targets. It's an
+// alias of pacing.Pacer.
+type Pacer = pacing.Pacer
+
+// ConstantPacer is a Pacer which hits at a constant rate, in hits per second,
+// for the given duration. It's an alias of pacing.ConstantPacer.
+type ConstantPacer = pacing.ConstantPacer
+
+// Pace calls hit with the sequence number of every hit at the times defined
+// by the given Pacer, from a dynamically growing pool of workers which starts
+// with the given number of workers, until the Pacer or stopch stops it. It
+// returns the channel of the Results of hit, closed once all hits returned,
+// with their Intended times set unless hit set them. It's shared by all
+// attack engines and drives custom ones too, e.g. of other protocols.
+func Pace(p Pacer, n uint64, stopch <-chan struct{}, hit func(uint64) *Result) <-chan *Result {
 	var workers sync.WaitGroup
 	results := make(chan *Result)
-	ticks := make(chan uint64)
-	for i := uint64(0); i < a.workers; i++ {
+	ticks := make(chan tick)
+	for i := uint64(0); i < n; i++ {
 		workers.Add(1)
-		go a.attack(tr, name, &workers, ticks, results)
+		go work(hit, &workers, ticks, results)
 	}
 
 	go func() {
 		defer close(results)
 		defer workers.Wait()
 		defer close(ticks)
-		interval := 1e9 / rate
-		hits := rate * uint64(du.Seconds())
 		began, seq := time.Now(), uint64(0)
 		for {
-			now, next := time.Now(), began.Add(time.Duration(seq*interval))
+			due, stop := p.Pace(seq)
+			if stop {
+				return
+			}
+			now, next := time.Now(), began.Add(due)
 			time.Sleep(next.Sub(now))
 			select {
-			case ticks <- seq:
-				if seq++; seq == hits {
-					return
-				}
-			case <-a.stopch:
+			case ticks <- tick{seq, next}:
+				seq++
+			case <-stopch:
 				return
 			default: // all workers are blocked. start one more and try again
 				workers.Add(1)
-				go a.attack(tr, name, &workers, ticks, results)
+				go work(hit, &workers, ticks, results)
 			}
 		}
 	}()
@@ -247,6 +496,23 @@ func (a *Attacker) Attack(tr Targeter, rate uint64, du time.Duration, name strin
 	return results
 }
 
+// A tick is a hit due at a given time.
+type tick struct {
+	seq uint64
+	due time.Time
+}
+
+func work(hit func(uint64) *Result, workers *sync.WaitGroup, ticks <-chan tick, results chan<- *Result) {
+	defer workers.Done()
+	for t := range ticks {
+		r := hit(t.seq)
+		if r.Intended.IsZero() {
+			r.Intended = t.due
+		}
+		results <- r
+	}
+}
+
 // Stop stops the current attack.
 func (a *Attacker) Stop() {
 	select {
@@ -257,29 +523,75 @@ func (a *Attacker) Stop() {
 	}
 }
 
-func (a *Attacker) attack(tr Targeter, name string, workers *sync.WaitGroup, ticks <-chan uint64, results chan<- *Result) {
-	defer workers.Done()
-	for seq := range ticks {
-		results <- a.hit(tr, name, seq)
+func (a *Attacker) hit(tr Targeter, name string, seq uint64) *Result {
+	if a.lanes != nil {
+		return a.lanes.hit(a, tr, name, seq)
 	}
+	return a.do(tr, name, seq, &a.client, a.script)
 }
 
-func (a *Attacker) hit(tr Targeter, name string, seq uint64) *Result {
+// do hits the next Target of tr with the given client, running the given
+// Script around it.
+func (a *Attacker) do(tr Targeter, name string, seq uint64, client *http.Client, script Script) *Result {
+	var tgt Target
+	if err := tr(&tgt); err != nil {
+		a.Stop()
+		return &Result{Attack: name, Seq: seq, ClockOffset: a.offset, Error: err.Error()}
+	}
+
+	if a.churn != nil {
+		a.churn.retire(time.Now())
+	}
+
+	if script != nil {
+		if err := script.Before(&tgt); err != nil {
+			return &Result{Attack: name, Seq: seq, ClockOffset: a.offset, Error: err.Error()}
+		}
+	}
+
+	res := a.send(&tgt, name, seq, client, script)
+	for first := res; tgt.Retry.retries(res) && tgt.Retry.wait(int(res.Retries), a.stopch); {
+		retries := res.Retries + 1
+		res = a.send(&tgt, name, seq, client, script)
+		res.Retries = retries
+		if !first.Timestamp.IsZero() && !res.Timestamp.IsZero() {
+			res.Latency += res.Timestamp.Sub(first.Timestamp)
+			res.Timestamp = first.Timestamp
+		}
+	}
+
+	return res
+}
+
+// send sends a request of the given Target and returns its Result.
+func (a *Attacker) send(tgt *Target, name string, seq uint64, client *http.Client, script Script) *Result {
 	var (
-		res = Result{Attack: name, Seq: seq}
-		tgt Target
-		err error
+		res         = Result{Attack: name, Seq: seq, ClockOffset: a.offset}
+		err         error
+		readingBody bool
 	)
 
 	defer func() {
 		if err != nil {
 			res.Error = err.Error()
 		}
+		res.ErrorClass = classifyError(res.Error, res.Code, readingBody)
 	}()
 
-	if err = tr(&tgt); err != nil {
-		a.Stop()
-		return &res
+	res.Method, res.URL, res.Tags = tgt.Method, tgt.URL, tgt.Tags
+	if a.reqHeaders {
+		res.Header = tgt.Header
+	}
+
+	if a.reqBodies {
+		res.RequestBody = tgt.Body
+	}
+
+	if tgt.ServerName != "" || tgt.Certificate != nil {
+		if client, err = a.tlsClient(client, tgt); err != nil {
+			err = fmt.Errorf("target TLS settings: %s", err)
+			return &res
+		}
 	}
 
 	req, err := tgt.Request()
@@ -287,26 +599,200 @@ func (a *Attacker) hit(tr Targeter, name string, seq uint64) *Result {
 		return &res
 	}
 
+	if a.names != nil {
+		var name strings.Builder
+		data := attackName{Name: res.Attack, Method: req.Method, URL: req.URL, Header: req.Header, Tags: tgt.Tags}
+		if err = a.names.Execute(&name, data); err != nil {
+			err = fmt.Errorf("bad attack name template: %s", err)
+			return &res
+		}
+		res.Attack = name.String()
+	}
+
+	if a.oauth2 != nil {
+		var token string
+		if token, err = a.oauth2.Token(); err != nil {
+			return &res
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	if a.jwt != nil {
+		var token string
+		data := attackName{Name: res.Attack, Method: req.Method, URL: req.URL, Header: req.Header, Tags: tgt.Tags}
+		if token, err = a.jwt.Sign(data); err != nil {
+			return &res
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	if res.TraceID, err = a.trace.inject(req.Header); err != nil {
+		return &res
+	}
+
+	if res.RequestID, err = injectID(req.Header, a.ids); err != nil {
+		return &res
+	}
+
+	if a.backoff != nil {
+		res.Backoff = a.backoff.wait(req.URL.Host, a.stopch)
+	}
+
+	total := a.total
+	if tgt.Timeout > 0 {
+		total = tgt.Timeout
+	}
+
+	if total > 0 {
+		ctx, cancel := context.WithTimeout(req.Context(), total)
+		defer cancel()
+		req = req.WithContext(ctx)
+		defer func() {
+			if err != nil && ctx.Err() == context.DeadlineExceeded {
+				err = fmt.Errorf("%s: %s", ErrRequestTimeout, err)
+			}
+		}()
+	}
+
+	if a.deadline > 0 {
+		ctx, cancel := context.WithTimeout(req.Context(), a.deadline)
+		defer cancel()
+		req = req.WithContext(ctx)
+		defer func() {
+			if err != nil && ctx.Err() == context.DeadlineExceeded {
+				err = fmt.Errorf("%s: %s", ErrSLAMiss, err)
+			}
+		}()
+	}
+
+	var (
+		cancel  context.CancelFunc
+		expired int32
+	)
+	if a.body > 0 {
+		var ctx context.Context
+		ctx, cancel = context.WithCancel(req.Context())
+		defer cancel()
+		req = req.WithContext(ctx)
+		defer func() {
+			if err != nil && atomic.LoadInt32(&expired) == 1 {
+				err = fmt.Errorf("%s: %s", ErrBodyTimeout, err)
+			}
+		}()
+	}
+
+	phases := newPhaseTrace()
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), phases.clientTrace()))
+	if a.lifetime != nil {
+		req = req.WithContext(httptrace.WithClientTrace(req.Context(), a.lifetime.clientTrace()))
+	}
+
+	for _, hook := range a.onRequest {
+		hook(req)
+	}
+
+	req = withResult(req, &res)
 	res.Timestamp = time.Now()
-	r, err := a.client.Do(req)
+	r, err := client.Do(req)
+	res.Phases, res.TLSHandshake = phases.phases()
+	res.RemoteAddr, res.ConnReused = phases.conn()
 	if err != nil {
+		err = limitError(timeoutError(err))
 		return &res
 	}
 	defer r.Body.Close()
 
-	if res.Body, err = ioutil.ReadAll(r.Body); err != nil {
+	if a.body > 0 {
+		timer := time.AfterFunc(a.body, func() {
+			atomic.StoreInt32(&expired, 1)
+			cancel()
+		})
+		defer timer.Stop()
+	}
+
+	res.ResponseHeader = captureHeaders(r.Header, a.resHeaders)
+
+	if a.backoff != nil {
+		a.backoff.observe(req.URL.Host, r.Header)
+	}
+
+	var (
+		body      io.Reader = r.Body
+		truncated *truncatedBody
+	)
+	if a.maxBody > 0 {
+		truncated = &truncatedBody{Reader: r.Body, n: a.maxBody}
+		body = truncated
+	}
+
+	reading, readingBody := time.Now(), true
+	counted := &countingReader{Reader: body}
+	if err = a.bodyHandler().HandleBody(&res, counted); err != nil {
 		return &res
 	}
+	res.BytesIn = uint64(counted.n)
+	res.Phases.Body, readingBody = time.Since(reading), false
+	res.Truncated = truncated != nil && truncated.truncated
 	res.Latency = time.Since(res.Timestamp)
-	res.BytesIn = uint64(len(res.Body))
 
 	if req.ContentLength != -1 {
 		res.BytesOut = uint64(req.ContentLength)
 	}
 
-	if res.Code = uint16(r.StatusCode); res.Code < 200 || res.Code >= 400 {
+	// A success predicate or expected status codes replace the default
+	// check.
+	switch res.Code = uint16(r.StatusCode); {
+	case a.success != nil:
+		if res.Accepted = a.success(&res, r); !res.Accepted {
+			res.Error = fmt.Sprintf("%s: %s", ErrUnsuccessful, r.Status)
+		}
+	case (res.Code < 200 || res.Code >= 400) && (tgt.Assert == nil || len(tgt.Assert.Status) == 0):
 		res.Error = r.Status
 	}
 
+	if tgt.SHA256 != "" && res.Error == "" {
+		if sum := res.BodySum(); !strings.EqualFold(sum, tgt.SHA256) {
+			res.Error = fmt.Sprintf("%s: got %s, want %s", ErrCorruptedBody, sum, tgt.SHA256)
+		}
+	}
+
+	if tgt.Assert != nil && res.Error == "" {
+		if aerr := tgt.Assert.Check(r, res.Body, res.BytesIn); aerr != nil {
+			res.Error = aerr.Error()
+		} else if len(tgt.Assert.Status) > 0 {
+			res.Accepted = true
+		}
+	}
+
+	if a.ranges != nil && a.bodies == nil && !res.Truncated && res.Error == "" {
+		err = a.ranges.verify(client, req, r, res.Body)
+	}
+
+	if len(a.extractors) > 0 {
+		res.Extract = make(map[string]string, len(a.extractors))
+		for _, e := range a.extractors {
+			if v, err := e.Extract(r, res.Body); err == nil {
+				res.Extract[e.Name] = v
+			}
+		}
+	}
+
+	if script != nil && err == nil {
+		err = script.After(&res, r)
+	}
+
+	for _, hook := range a.onResponse {
+		hook(req, r, &res)
+	}
+
+	if a.bodies == nil && res.Body != nil {
+		switch {
+		case a.hash:
+			res.BodyHash, res.Body = res.BodySum(), nil
+		case a.discard:
+			res.Body = nil
+		}
+	}
+
 	return &res
 }
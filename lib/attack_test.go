@@ -11,7 +11,9 @@ import (
 	"net/url"
 	"reflect"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"text/template"
 	"time"
 )
 
@@ -41,7 +43,8 @@ func TestAttackDuration(t *testing.T) {
 	defer server.Close()
 	tr := NewStaticTargeter(Target{Method: "GET", URL: server.URL})
 	atk := NewAttacker()
-	time.AfterFunc(2*time.Second, func() { t.Fatal("Timed out") })
+	timer := time.AfterFunc(2*time.Second, func() { t.Fatal("Timed out") })
+	defer timer.Stop()
 
 	rate, hits := uint64(100), uint64(0)
 	for range atk.Attack(tr, rate, 0, "") {
@@ -235,3 +238,302 @@ func TestProxyOption(t *testing.T) {
 		t.Errorf("got body: %q, want: %q", got, want)
 	}
 }
+
+func TestBodyChecksum(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("VEGETA"))
+		}),
+	)
+	defer server.Close()
+
+	const sum = "514b4d4d323a8722a5be46808f9f81f73ead4b646b25e298c5ec58255ee00e9b"
+	atk := NewAttacker()
+	for _, tc := range []struct {
+		sha256 string
+		want   string
+	}{
+		{"", ""},
+		{strings.ToUpper(sum), ""},
+		{"deadbeef", ErrCorruptedBody.Error() + ": got " + sum + ", want deadbeef"},
+	} {
+		tr := NewStaticTargeter(Target{Method: "GET", URL: server.URL, SHA256: tc.sha256})
+		if got := atk.hit(tr, "", 0).Error; got != tc.want {
+			t.Errorf("sha256 %q: got error %q, want %q", tc.sha256, got, tc.want)
+		}
+	}
+}
+
+func TestHashBodies(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("VEGETA"))
+		}),
+	)
+	defer server.Close()
+
+	const sum = "514b4d4d323a8722a5be46808f9f81f73ead4b646b25e298c5ec58255ee00e9b"
+	for _, opts := range [][]func(*Attacker){
+		{HashBodies(true)},
+		{HashBodies(true), RangeVerification(1)},
+	} {
+		atk := NewAttacker(opts...)
+		tr := NewStaticTargeter(Target{Method: "GET", URL: server.URL, SHA256: sum})
+		res := atk.hit(tr, "", 0)
+
+		if res.Error != "" {
+			t.Errorf("got error %q", res.Error)
+		}
+
+		if res.Body != nil || res.BodyHash != sum || res.BytesIn != 6 {
+			t.Errorf("got body %q, hash %q, bytes in %d, want only hash %q of 6 bytes",
+				res.Body, res.BodyHash, res.BytesIn, sum)
+		}
+	}
+
+	plain := Result{Body: []byte("VEGETA")}
+	if got := plain.BodySum(); got != sum {
+		t.Errorf("got body sum %s, want %s", got, sum)
+	}
+}
+
+func TestDiscardBodies(t *testing.T) {
+	t.Parallel()
+
+	body := bytes.Repeat([]byte("VEGETA"), 1<<16)
+	server := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write(body)
+		}),
+	)
+	defer server.Close()
+
+	atk := NewAttacker(DiscardBodies(true))
+	tr := NewStaticTargeter(Target{Method: "GET", URL: server.URL})
+	res := atk.hit(tr, "", 0)
+
+	if res.Error != "" {
+		t.Errorf("got error %q", res.Error)
+	}
+
+	if res.Body != nil || res.BytesIn != uint64(len(body)) {
+		t.Errorf("got body of %d bytes, bytes in %d, want no body of %d bytes",
+			len(res.Body), res.BytesIn, len(body))
+	}
+}
+
+func TestSuccessPredicate(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/gone":
+			w.WriteHeader(http.StatusNotFound)
+		case "/error":
+			w.Write([]byte("oops"))
+		default:
+			w.Write([]byte("ok"))
+		}
+	}))
+	defer server.Close()
+
+	atk := NewAttacker(SuccessPredicate(func(res *Result, r *http.Response) bool {
+		return r.StatusCode == http.StatusNotFound || bytes.Equal(res.Body, []byte("ok"))
+	}))
+
+	var m Metrics
+	for path, want := range map[string]string{
+		"/ok":    "",
+		"/gone":  "",
+		"/error": "unsuccessful response: 200 OK",
+	} {
+		tr := NewStaticTargeter(Target{Method: "GET", URL: server.URL + path})
+		res := atk.hit(tr, "", 0)
+		if res.Error != want {
+			t.Errorf("%s: got error %q, want %q", path, res.Error, want)
+		}
+		if res.Accepted != (want == "") {
+			t.Errorf("%s: got accepted %t", path, res.Accepted)
+		}
+		m.Add(res)
+	}
+	m.Close()
+
+	if got, want := m.Success, 2/3.0; got != want {
+		t.Errorf("got success %f, want %f", got, want)
+	}
+}
+
+func TestHooks(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Shard", "s"+r.Header.Get("X-Tenant"))
+	}))
+	defer server.Close()
+
+	var calls []string
+	atk := NewAttacker(
+		OnRequest(func(r *http.Request) {
+			calls = append(calls, "request 1")
+			r.Header.Set("X-Tenant", "1")
+		}),
+		OnRequest(func(*http.Request) { calls = append(calls, "request 2") }),
+		OnResponse(func(req *http.Request, r *http.Response, res *Result) {
+			calls = append(calls, "response "+req.Header.Get("X-Tenant"))
+			res.Tags = map[string]string{"shard": r.Header.Get("X-Shard")}
+		}),
+	)
+
+	tr := NewStaticTargeter(Target{Method: "GET", URL: server.URL})
+	res := atk.hit(tr, "", 0)
+
+	if want := []string{"request 1", "request 2", "response 1"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("got calls %v, want %v", calls, want)
+	}
+
+	if got := res.Tags["shard"]; got != "s1" {
+		t.Errorf("got shard tag %q, want %q", got, "s1")
+	}
+}
+
+// countingTransport is a RoundTripper which counts the requests it wraps.
+type countingTransport struct {
+	rt http.RoundTripper
+	n  int32
+}
+
+func (t *countingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	atomic.AddInt32(&t.n, 1)
+	return t.rt.RoundTrip(r)
+}
+
+func (t *countingTransport) Unwrap() http.RoundTripper { return t.rt }
+
+func TestRoundTripper(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer server.Close()
+
+	base := &http.Transport{}
+	counting := &countingTransport{rt: base}
+	opaque := &countingTransport{rt: http.DefaultTransport}
+	tr := NewStaticTargeter(Target{Method: "GET", URL: server.URL})
+
+	for _, opts := range [][]func(*Attacker){
+		{RoundTripper(counting), Connections(7), KeepAlive(false), Timeout(time.Second), HTTP2(false)},
+		{Client(&http.Client{Transport: counting})},
+		// Options are ignored by RoundTrippers which can't be unwrapped
+		// instead of panicking.
+		{RoundTripper(struct{ http.RoundTripper }{opaque}), Connections(7), Churn(0.5), SlowClient(1000)},
+	} {
+		if res := NewAttacker(opts...).hit(tr, "", 0); res.Error != "" {
+			t.Fatal(res.Error)
+		}
+	}
+
+	if got := atomic.LoadInt32(&counting.n); got != 2 {
+		t.Errorf("got %d requests through the wrapper, want 2", got)
+	}
+
+	if got := atomic.LoadInt32(&opaque.n); got != 1 {
+		t.Errorf("got %d requests through the opaque wrapper, want 1", got)
+	}
+
+	if base.MaxIdleConnsPerHost != 7 || !base.DisableKeepAlives || base.ResponseHeaderTimeout != time.Second {
+		t.Errorf("got unconfigured wrapped transport: %+v", base)
+	}
+}
+
+func TestTags(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer server.Close()
+
+	tags := map[string]string{"endpoint": "root", "tenant": "a"}
+	tr := NewStaticTargeter(Target{Method: "GET", URL: server.URL, Tags: tags})
+	if got := NewAttacker().hit(tr, "", 0).Tags; !reflect.DeepEqual(got, tags) {
+		t.Errorf("got tags %v, want %v", got, tags)
+	}
+}
+
+func TestNameTemplate(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer server.Close()
+
+	tmpl := template.Must(template.New("name").Parse(`{{.Name}}: {{.Method}} {{.URL.Path}} {{.Tags.tenant}}`))
+	atk := NewAttacker(NameTemplate(tmpl))
+
+	tr := NewStaticTargeter(Target{Method: "GET", URL: server.URL + "/things?id=1", Tags: map[string]string{"tenant": "a"}})
+	if got, want := atk.hit(tr, "green", 0).Attack, "green: GET /things a"; got != want {
+		t.Errorf("got attack name %q, want %q", got, want)
+	}
+
+	atk = NewAttacker(NameTemplate(template.Must(template.New("name").Parse(`{{.Nope}}`))))
+	if res := atk.hit(tr, "", 0); !strings.HasPrefix(res.Error, "bad attack name template") {
+		t.Errorf("got error %q, want bad attack name template error", res.Error)
+	}
+}
+
+func TestSwitch(t *testing.T) {
+	t.Parallel()
+
+	blue := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer blue.Close()
+	green := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer green.Close()
+
+	atk := NewAttacker()
+	res := atk.Attack(NewStaticTargeter(Target{Method: "GET", URL: blue.URL}), 100, time.Second, "blue")
+
+	hits := map[string]int{}
+	for r := range res {
+		if hits[r.Attack]++; r.Attack == "blue" && hits["blue"] == 20 {
+			atk.Switch(NewStaticTargeter(Target{Method: "GET", URL: green.URL}), "green")
+		}
+
+		if want := map[string]string{"blue": blue.URL, "green": green.URL}[r.Attack]; r.URL != want {
+			t.Errorf("%s result: got URL %s, want %s", r.Attack, r.URL, want)
+		}
+	}
+
+	if hits["blue"] < 20 || hits["green"] == 0 || hits["blue"]+hits["green"] != 100 {
+		t.Errorf("got hits %v, want 100 split between blue and green", hits)
+	}
+}
+
+func TestPace(t *testing.T) {
+	t.Parallel()
+
+	var (
+		seqs     []uint64
+		intended []time.Time
+	)
+	for res := range Pace(ConstantPacer{Rate: 10, Duration: time.Second}, 1, nil, func(seq uint64) *Result {
+		return &Result{Seq: seq}
+	}) {
+		seqs = append(seqs, res.Seq)
+		intended = append(intended, res.Intended)
+	}
+
+	if len(seqs) != 10 {
+		t.Fatalf("got %d hits, want 10", len(seqs))
+	}
+
+	for i, seq := range seqs {
+		if seq != uint64(i) {
+			t.Errorf("hit %d: got seq %d", i, seq)
+		}
+		if got, want := intended[i].Sub(intended[0]), time.Duration(seq)*100*time.Millisecond; got != want {
+			t.Errorf("hit %d: got intended offset %s, want %s", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package vegeta
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Backoff returns a functional option which makes an Attacker pause its hits
+// to a host when its responses ask clients to back off, either with:
+//
+//   - a Retry-After header, in seconds or as an HTTP date, or
+//   - an X-RateLimit-Remaining header of 0, until the time given by the
+//     X-RateLimit-Reset header, in seconds from now or since the UNIX epoch.
+//
+// Additional hint headers, whose values are interpreted like Retry-After's,
+// can be given for custom backoff protocols. Hits are paused for at most
+// max and the time each one was paused for is recorded in its Result's
+// Backoff field.
+func Backoff(max time.Duration, hints ...string) func(*Attacker) {
+	return func(a *Attacker) {
+		a.backoff = &backoff{
+			max:   max,
+			hints: append([]string{"Retry-After"}, hints...),
+			until: map[string]time.Time{},
+		}
+	}
+}
+
+// backoff tracks until when hits to each host are paused.
+type backoff struct {
+	mu    sync.Mutex
+	max   time.Duration
+	hints []string
+	until map[string]time.Time
+}
+
+// wait blocks until hits to the given host are no longer paused or stop is
+// closed, returning the time it waited for.
+func (b *backoff) wait(host string, stop <-chan struct{}) time.Duration {
+	b.mu.Lock()
+	until := b.until[host]
+	b.mu.Unlock()
+
+	d := time.Until(until)
+	if d <= 0 {
+		return 0
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	began := time.Now()
+	select {
+	case <-timer.C:
+	case <-stop:
+	}
+
+	return time.Since(began)
+}
+
+// observe pauses hits to the given host as asked by the given response
+// headers.
+func (b *backoff) observe(host string, hdr http.Header) {
+	now := time.Now()
+
+	var until time.Time
+	for _, h := range b.hints {
+		if t, ok := retryAfter(hdr.Get(h), now); ok && t.After(until) {
+			until = t
+		}
+	}
+
+	if hdr.Get("X-RateLimit-Remaining") == "0" {
+		if t, ok := rateLimitReset(hdr.Get("X-RateLimit-Reset"), now); ok && t.After(until) {
+			until = t
+		}
+	}
+
+	if !until.After(now) {
+		return
+	}
+
+	if max := now.Add(b.max); until.After(max) {
+		until = max
+	}
+
+	b.mu.Lock()
+	if until.After(b.until[host]) {
+		b.until[host] = until
+	}
+	b.mu.Unlock()
+}
+
+// retryAfter parses a Retry-After header value, in seconds or as an HTTP
+// date.
+func retryAfter(v string, now time.Time) (time.Time, bool) {
+	if v = strings.TrimSpace(v); v == "" {
+		return time.Time{}, false
+	}
+
+	if secs, err := strconv.ParseFloat(v, 64); err == nil && secs >= 0 {
+		return now.Add(time.Duration(secs * float64(time.Second))), true
+	}
+
+	if t, err := http.ParseTime(v); err == nil {
+		return t, true
+	}
+
+	return time.Time{}, false
+}
+
+// rateLimitReset parses an X-RateLimit-Reset header value, which is either a
+// number of seconds from now or, if larger than a year, a UNIX timestamp.
+func rateLimitReset(v string, now time.Time) (time.Time, bool) {
+	secs, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+	if err != nil || secs < 0 {
+		return time.Time{}, false
+	}
+
+	if secs > 365*24*60*60 {
+		return time.Unix(secs, 0), true
+	}
+
+	return now.Add(time.Duration(secs) * time.Second), true
+}
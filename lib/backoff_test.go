@@ -0,0 +1,67 @@
+package vegeta
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	t.Parallel()
+
+	var hits int64
+	server := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch atomic.AddInt64(&hits, 1) {
+			case 1:
+				w.Header().Set("Retry-After", "0.2")
+				w.WriteHeader(http.StatusTooManyRequests)
+			case 2:
+				w.Header().Set("X-RateLimit-Remaining", "0")
+				w.Header().Set("X-RateLimit-Reset", "60")
+			case 3:
+				w.Header().Set("X-Slow-Down", "0.1")
+			}
+		}),
+	)
+	defer server.Close()
+
+	atk := NewAttacker(Backoff(300*time.Millisecond, "X-Slow-Down"))
+	tr := NewStaticTargeter(Target{Method: "GET", URL: server.URL})
+
+	for i, want := range []time.Duration{0, 200 * time.Millisecond, 300 * time.Millisecond, 100 * time.Millisecond, 0} {
+		res := atk.hit(tr, "", uint64(i))
+		if d := res.Backoff - want; d < -20*time.Millisecond || d > 50*time.Millisecond {
+			t.Errorf("hit %d: got backoff %s, want %s", i, res.Backoff, want)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now().Truncate(time.Second)
+	for _, tc := range []struct {
+		in   string
+		want time.Time
+		ok   bool
+	}{
+		{"", time.Time{}, false},
+		{"120", now.Add(2 * time.Minute), true},
+		{now.Add(time.Hour).UTC().Format(http.TimeFormat), now.Add(time.Hour), true},
+		{"soon", time.Time{}, false},
+		{"-1", time.Time{}, false},
+	} {
+		if got, ok := retryAfter(tc.in, now); ok != tc.ok || !got.Equal(tc.want) {
+			t.Errorf("%q: got (%s, %t), want (%s, %t)", tc.in, got, ok, tc.want, tc.ok)
+		}
+	}
+
+	epoch := now.Add(time.Hour).Unix()
+	if got, ok := rateLimitReset(strconv.FormatInt(epoch, 10), now); !ok || got.Unix() != epoch {
+		t.Errorf("got (%s, %t), want (%s, true)", got, ok, time.Unix(epoch, 0))
+	}
+}
@@ -0,0 +1,106 @@
+package vegeta
+
+import (
+	"math/rand"
+	"net"
+	"sync"
+)
+
+// Policies of distribution of an Attacker's connections across the
+// addresses of target hosts.
+const (
+	// BalanceOrdered dials the addresses in the order they were resolved
+	// in, falling back to the next ones on errors, which loads the first
+	// healthy address only.
+	BalanceOrdered = "ordered"
+	// BalanceRoundRobin dials the addresses of each host in turn.
+	BalanceRoundRobin = "round-robin"
+	// BalanceRandom dials a random address.
+	BalanceRandom = "random"
+	// BalanceLeastConns dials the address with the fewest open connections
+	// of the Attacker, which spreads them evenly across all addresses.
+	BalanceLeastConns = "least-conns"
+)
+
+// IPBalancing returns a functional option which sets the policy by which an
+// Attacker distributes its connections across the resolved addresses of
+// target hosts, e.g. to load test DNS balanced clusters evenly. It's one of
+// BalanceOrdered, the default, BalanceRoundRobin, BalanceRandom or
+// BalanceLeastConns. Whatever the policy, the other addresses are dialed in
+// turn when dialing the first one fails.
+func IPBalancing(policy string) func(*Attacker) {
+	return func(a *Attacker) {
+		a.balancer = &balancer{policy: policy, next: map[string]int{}, open: map[string]int{}}
+	}
+}
+
+// balancer orders the addresses dialed by an Attacker.
+type balancer struct {
+	policy string
+	mu     sync.Mutex
+	next   map[string]int
+	open   map[string]int
+}
+
+// order returns the given addresses of host in the order they're to be
+// dialed in.
+func (b *balancer) order(host string, ips []string) []string {
+	if b == nil || len(ips) < 2 {
+		return ips
+	}
+
+	var first int
+	switch b.policy {
+	case BalanceRoundRobin:
+		b.mu.Lock()
+		first = b.next[host] % len(ips)
+		b.next[host] = first + 1
+		b.mu.Unlock()
+	case BalanceRandom:
+		first = rand.Intn(len(ips))
+	case BalanceLeastConns:
+		b.mu.Lock()
+		for i, ip := range ips {
+			if b.open[ip] < b.open[ips[first]] {
+				first = i
+			}
+		}
+		b.mu.Unlock()
+	default:
+		return ips
+	}
+
+	return append(ips[first:len(ips):len(ips)], ips[:first]...)
+}
+
+// track returns the given connection to the given address, counted as open
+// until it's closed if its connections are balanced by count.
+func (b *balancer) track(ip string, conn net.Conn) net.Conn {
+	if b == nil || b.policy != BalanceLeastConns {
+		return conn
+	}
+
+	b.mu.Lock()
+	b.open[ip]++
+	b.mu.Unlock()
+
+	return &balancedConn{Conn: conn, b: b, ip: ip}
+}
+
+// balancedConn is a net.Conn counted as open by a balancer.
+type balancedConn struct {
+	net.Conn
+	b    *balancer
+	ip   string
+	once sync.Once
+}
+
+// Close implements the net.Conn interface.
+func (c *balancedConn) Close() error {
+	c.once.Do(func() {
+		c.b.mu.Lock()
+		c.b.open[c.ip]--
+		c.b.mu.Unlock()
+	})
+	return c.Conn.Close()
+}
@@ -0,0 +1,61 @@
+package vegeta
+
+import (
+	"net"
+	"reflect"
+	"testing"
+)
+
+func TestBalancer(t *testing.T) {
+	t.Parallel()
+
+	ips := []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}
+	first := func(policy string, n int) (got []string) {
+		a := NewAttacker(IPBalancing(policy))
+		for i := 0; i < n; i++ {
+			order := a.balancer.order("goku", ips)
+			if len(order) != len(ips) {
+				t.Fatalf("%s: got order %v of %v", policy, order, ips)
+			}
+			got = append(got, order[0])
+			a.balancer.track(order[0], nil)
+		}
+		return got
+	}
+
+	for policy, want := range map[string][]string{
+		BalanceOrdered:    {"10.0.0.1", "10.0.0.1", "10.0.0.1", "10.0.0.1"},
+		BalanceRoundRobin: {"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.1"},
+		BalanceLeastConns: {"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.1"},
+	} {
+		if got := first(policy, len(want)); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got first addresses %v, want %v", policy, got, want)
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, ip := range first(BalanceRandom, 100) {
+		seen[ip] = true
+	}
+	if len(seen) != len(ips) {
+		t.Errorf("random: got first addresses %v, want all of %v", seen, ips)
+	}
+
+	if !reflect.DeepEqual(ips, []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}) {
+		t.Errorf("got resolved addresses modified to %v", ips)
+	}
+
+	// Closed connections aren't counted anymore.
+	a := NewAttacker(IPBalancing(BalanceLeastConns))
+	c1, c2 := net.Pipe()
+	defer c2.Close()
+	conn := a.balancer.track("10.0.0.1", c1)
+	if got := a.balancer.order("goku", ips)[0]; got != "10.0.0.2" {
+		t.Errorf("got first address %s with an open connection to 10.0.0.1", got)
+	}
+	conn.Close()
+	conn.Close()
+	if got := a.balancer.order("goku", ips)[0]; got != "10.0.0.1" {
+		t.Errorf("got first address %s with no open connections", got)
+	}
+}
@@ -0,0 +1,104 @@
+package vegeta
+
+import (
+	"sort"
+	"strconv"
+)
+
+// UniqueBodies counts the distinct response bodies of each target, by their
+// SHA-256 digest, which exposes error pages, empty responses or cached
+// responses served to the wrong clients under load.
+type UniqueBodies struct {
+	Targets []*TargetBodies `json:"targets"`
+
+	index map[string]*TargetBodies
+}
+
+// TargetBodies holds the distinct response bodies of a target.
+type TargetBodies struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	// Total is the number of responses to the target.
+	Total uint64 `json:"total"`
+	// Bodies holds the distinct response bodies, most frequent first.
+	Bodies []*BodyCount `json:"bodies"`
+
+	index map[string]*BodyCount
+}
+
+// BodyCount holds the number of responses with the same body.
+type BodyCount struct {
+	// Sum is the hex encoded SHA-256 digest of the body.
+	Sum string `json:"sum"`
+	// Bytes is the size of the body.
+	Bytes uint64 `json:"bytes"`
+	// Count is the number of responses with the body.
+	Count uint64 `json:"count"`
+	// StatusCodes is a histogram of the status codes of the responses.
+	StatusCodes map[string]int `json:"status_codes"`
+	// Sample is the beginning of the body, unless only its digest was
+	// recorded.
+	Sample []byte `json:"sample"`
+}
+
+// sampleSize is the number of bytes of a body kept as its sample.
+const sampleSize = 64
+
+// Add implements the Add method of the Report interface by counting the
+// body of the given Result. Results without a response are ignored.
+func (u *UniqueBodies) Add(r *Result) {
+	if r.Code == 0 {
+		return
+	}
+
+	if u.index == nil {
+		u.index = map[string]*TargetBodies{}
+	}
+
+	key := r.Method + " " + r.URL
+	tb, ok := u.index[key]
+	if !ok {
+		tb = &TargetBodies{Method: r.Method, URL: r.URL, index: map[string]*BodyCount{}}
+		u.index[key] = tb
+		u.Targets = append(u.Targets, tb)
+	}
+
+	sum := r.BodySum()
+	bc, ok := tb.index[sum]
+	if !ok {
+		bc = &BodyCount{Sum: sum, Bytes: r.BytesIn, StatusCodes: map[string]int{}}
+		if len(r.Body) > sampleSize {
+			bc.Sample = append([]byte(nil), r.Body[:sampleSize]...)
+		} else {
+			bc.Sample = append([]byte(nil), r.Body...)
+		}
+		tb.index[sum] = bc
+		tb.Bodies = append(tb.Bodies, bc)
+	}
+
+	tb.Total++
+	bc.Count++
+	bc.StatusCodes[strconv.Itoa(int(r.Code))]++
+}
+
+// Close implements the Close method of the Report interface by sorting the
+// targets by URL and method and their bodies by decreasing frequency.
+func (u *UniqueBodies) Close() {
+	sort.Slice(u.Targets, func(i, j int) bool {
+		a, b := u.Targets[i], u.Targets[j]
+		if a.URL != b.URL {
+			return a.URL < b.URL
+		}
+		return a.Method < b.Method
+	})
+
+	for _, tb := range u.Targets {
+		sort.Slice(tb.Bodies, func(i, j int) bool {
+			a, b := tb.Bodies[i], tb.Bodies[j]
+			if a.Count != b.Count {
+				return a.Count > b.Count
+			}
+			return a.Sum < b.Sum
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package vegeta
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUniqueBodies(t *testing.T) {
+	t.Parallel()
+
+	var u UniqueBodies
+	for _, r := range []Result{
+		{Method: "GET", URL: "http://goku/b", Code: 200, Body: []byte("ok"), BytesIn: 2},
+		{Method: "GET", URL: "http://goku/a", Code: 200, Body: []byte("ok"), BytesIn: 2},
+		{Method: "GET", URL: "http://goku/a", Code: 500, Body: []byte("oops"), BytesIn: 4},
+		{Method: "GET", URL: "http://goku/a", Code: 200, BodyHash: (&Result{Body: []byte("ok")}).BodySum(), BytesIn: 2},
+		{Method: "GET", URL: "http://goku/a", Code: 0, Error: "connection refused"},
+	} {
+		r := r
+		u.Add(&r)
+	}
+	u.Close()
+
+	if got, want := len(u.Targets), 2; got != want {
+		t.Fatalf("got %d targets, want %d", got, want)
+	}
+
+	a := u.Targets[0]
+	if a.URL != "http://goku/a" || a.Total != 3 || len(a.Bodies) != 2 {
+		t.Fatalf("got target %s with %d responses and %d bodies, want http://goku/a with 3 and 2",
+			a.URL, a.Total, len(a.Bodies))
+	}
+
+	ok := a.Bodies[0]
+	if ok.Count != 2 || string(ok.Sample) != "ok" || !reflect.DeepEqual(ok.StatusCodes, map[string]int{"200": 2}) {
+		t.Errorf("got most frequent body %+v, want ok body counted twice", ok)
+	}
+
+	var buf bytes.Buffer
+	if err := NewUniqueBodiesReporter(&u).Report(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"GET http://goku/a [total, distinct] 3, 2",
+		"2      66.67%  2689367b205c  2      200:2         \"ok\"",
+		"GET http://goku/b [total, distinct] 1, 1",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("report doesn't contain %q:\n%s", want, buf.String())
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package vegeta
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+)
+
+// A BodyHandler reads the response bodies of an Attacker's hits, recording
+// what it keeps of them in their Results. The Attacker counts the bytes read
+// in BytesIn.
+type BodyHandler interface {
+	HandleBody(res *Result, body io.Reader) error
+}
+
+// BodyHandlerFunc is an adapter to use ordinary functions as BodyHandlers.
+type BodyHandlerFunc func(res *Result, body io.Reader) error
+
+// HandleBody implements the BodyHandler interface.
+func (f BodyHandlerFunc) HandleBody(res *Result, body io.Reader) error { return f(res, body) }
+
+// BodyHandlers of response bodies.
+var (
+	// KeptBodies keeps the bodies in the Body field of Results, the
+	// default.
+	KeptBodies BodyHandler = BodyHandlerFunc(func(res *Result, body io.Reader) (err error) {
+		res.Body, err = ioutil.ReadAll(body)
+		return err
+	})
+	// HashedBodies keeps the hex encoded SHA-256 digest of the bodies in the
+	// BodyHash field of Results, which still tells wrong responses apart.
+	HashedBodies BodyHandler = BodyHandlerFunc(func(res *Result, body io.Reader) error {
+		h := sha256.New()
+		if _, err := io.Copy(h, body); err != nil {
+			return err
+		}
+		res.BodyHash = hex.EncodeToString(h.Sum(nil))
+		return nil
+	})
+	// DiscardedBodies only keeps the size of the bodies.
+	DiscardedBodies BodyHandler = BodyHandlerFunc(func(_ *Result, body io.Reader) error {
+		_, err := io.Copy(ioutil.Discard, body)
+		return err
+	})
+)
+
+// BodyFiles returns a BodyHandler which writes each body to a file of its own
+// in the given existing directory, named after the attack and sequence number
+// of its hit followed by the number of the file, since scenario steps and
+// retries share sequence numbers, and keeps its path in the BodyFile field of
+// Results.
+func BodyFiles(dir string) BodyHandler {
+	var files uint64
+	return BodyHandlerFunc(func(res *Result, body io.Reader) error {
+		name := strconv.FormatUint(res.Seq, 10) + "-" +
+			strconv.FormatUint(atomic.AddUint64(&files, 1), 10) + ".body"
+		if res.Attack != "" {
+			name = url.PathEscape(res.Attack) + "-" + name
+		}
+
+		path := filepath.Join(dir, name)
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+
+		if _, err = io.Copy(f, body); err != nil {
+			f.Close()
+			return err
+		} else if err = f.Close(); err != nil {
+			return err
+		}
+
+		res.BodyFile = path
+		return nil
+	})
+}
+
+// ResponseBodies returns a functional option which sets the BodyHandler of
+// the response bodies of an Attacker, overriding HashBodies and
+// DiscardBodies. Options which inspect bodies, e.g. body assertions,
+// checksums, extractors, scripts and range verification, only see those kept
+// by KeptBodies, the default.
+func ResponseBodies(h BodyHandler) func(*Attacker) {
+	return func(a *Attacker) { a.bodies = h }
+}
+
+// bodyHandler returns the BodyHandler of the Attacker's response bodies.
+func (a *Attacker) bodyHandler() BodyHandler {
+	switch {
+	case a.bodies != nil:
+		return a.bodies
+	case a.ranges != nil:
+		// Range verification needs the whole body, which is hashed or
+		// discarded afterwards.
+		return KeptBodies
+	case a.hash:
+		return HashedBodies
+	case a.discard:
+		return DiscardedBodies
+	default:
+		return KeptBodies
+	}
+}
+
+// countingReader is an io.Reader which counts the bytes read.
+type countingReader struct {
+	io.Reader
+	n int64
+}
+
+// Read implements the io.Reader interface.
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// ResponseHeaders returns a functional option which makes an Attacker record
+// the given headers of responses in the ResponseHeader field of Results, or
+// all of them if one is "*", e.g. X-Cache or Server to analyze cache hit
+// ratios or the backends which served the hits.
+func ResponseHeaders(names ...string) func(*Attacker) {
+	return func(a *Attacker) {
+		a.resHeaders = make([]string, 0, len(names))
+		for _, name := range names {
+			if name == "*" {
+				a.resHeaders = []string{"*"}
+				return
+			}
+			a.resHeaders = append(a.resHeaders, http.CanonicalHeaderKey(name))
+		}
+	}
+}
+
+// RequestBodies returns a functional option which makes an Attacker record
+// the bodies of its requests in the RequestBody field of Results, along with
+// the method and URL always recorded, e.g. so that results targets replay
+// them.
+func RequestBodies(enabled bool) func(*Attacker) {
+	return func(a *Attacker) { a.reqBodies = enabled }
+}
+
+// RequestHeaders returns a functional option which makes an Attacker record
+// the headers of its Targets in the Header field of Results, e.g. so that
+// results targets replay them. They're not recorded by default, since they
+// often hold credentials, e.g. in Authorization or Cookie headers, which
+// would be written to every Result. Headers set by options, e.g. OAuth2 and
+// JWT tokens, are never recorded.
+func RequestHeaders(enabled bool) func(*Attacker) {
+	return func(a *Attacker) { a.reqHeaders = enabled }
+}
+
+// captureHeaders returns a copy of the given canonical header names of hdr,
+// all of them if the only name is "*", or nil if there are none.
+func captureHeaders(hdr http.Header, names []string) http.Header {
+	if len(names) == 0 {
+		return nil
+	} else if names[0] == "*" {
+		return hdr.Clone()
+	}
+
+	var captured http.Header
+	for _, name := range names {
+		if vs, ok := hdr[name]; ok {
+			if captured == nil {
+				captured = make(http.Header, len(names))
+			}
+			captured[name] = append([]string(nil), vs...)
+		}
+	}
+
+	return captured
+}
+
+// MaxBody returns a functional option which makes an Attacker read at most n
+// bytes of each response body, setting the Truncated field of Results of
+// longer ones, which protects it from targets returning huge or unbounded
+// streams. Zero, the default, reads bodies whole.
+func MaxBody(n int64) func(*Attacker) {
+	return func(a *Attacker) { a.maxBody = n }
+}
+
+// truncatedBody is an io.Reader of at most n bytes of a response body,
+// which notes whether the body was longer.
+type truncatedBody struct {
+	io.Reader
+	n         int64
+	truncated bool
+}
+
+// Read implements the io.Reader interface.
+func (b *truncatedBody) Read(p []byte) (int, error) {
+	if b.n <= 0 {
+		// Peek at the next byte to tell bodies of exactly n bytes apart,
+		// giving up on readers which make no progress, as bufio does.
+		peek := make([]byte, 1)
+		for i := 0; i < 100; i++ {
+			n, err := b.Reader.Read(peek)
+			if n > 0 {
+				b.truncated = true
+				return 0, io.EOF
+			} else if err != nil {
+				return 0, err
+			}
+		}
+		return 0, io.ErrNoProgress
+	}
+
+	if int64(len(p)) > b.n {
+		p = p[:b.n]
+	}
+
+	n, err := b.Reader.Read(p)
+	b.n -= int64(n)
+
+	return n, err
+}
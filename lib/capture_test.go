@@ -0,0 +1,192 @@
+package vegeta
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResponseHeaders(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Add("X-Cache", "HIT")
+		w.Header().Add("X-Cache", "from cdn")
+		w.Header().Set("Server", "kakarot")
+	}))
+	defer server.Close()
+
+	tr := NewStaticTargeter(Target{Method: "GET", URL: server.URL})
+
+	for _, tc := range []struct {
+		names []string
+		want  http.Header
+	}{
+		{nil, nil},
+		{[]string{"x-cache", "X-Missing"}, http.Header{"X-Cache": {"HIT", "from cdn"}}},
+		{[]string{"*"}, http.Header{
+			"X-Cache":        {"HIT", "from cdn"},
+			"Server":         {"kakarot"},
+			"Content-Length": {"0"},
+		}},
+	} {
+		atk := NewAttacker(ResponseHeaders(tc.names...))
+		res := atk.hit(tr, "", 0)
+		if res.Error != "" {
+			t.Fatal(res.Error)
+		}
+
+		got := res.ResponseHeader
+		delete(got, "Date")
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("names %v: got headers %v, want %v", tc.names, got, tc.want)
+		}
+	}
+}
+
+func TestMaxBody(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.URL.Query().Get("body"))
+	}))
+	defer server.Close()
+
+	for _, tc := range []struct {
+		body      string
+		opts      []func(*Attacker)
+		want      string
+		bytesIn   uint64
+		truncated bool
+	}{
+		{"kamehameha", nil, "kamehameha", 10, false},
+		{"kamehameha", []func(*Attacker){MaxBody(4)}, "kame", 4, true},
+		{"kame", []func(*Attacker){MaxBody(4)}, "kame", 4, false},
+		{"kamehameha", []func(*Attacker){MaxBody(4), DiscardBodies(true)}, "", 4, true},
+		{"", []func(*Attacker){MaxBody(4)}, "", 0, false},
+	} {
+		atk := NewAttacker(tc.opts...)
+		tr := NewStaticTargeter(Target{Method: "GET", URL: server.URL + "?body=" + tc.body})
+		res := atk.hit(tr, "", 0)
+		if res.Error != "" {
+			t.Fatal(res.Error)
+		}
+
+		if string(res.Body) != tc.want || res.BytesIn != tc.bytesIn || res.Truncated != tc.truncated {
+			t.Errorf("body %q: got body %q of %d bytes truncated %t, want %q of %d bytes truncated %t",
+				tc.body, res.Body, res.BytesIn, res.Truncated, tc.want, tc.bytesIn, tc.truncated)
+		}
+	}
+}
+
+func TestResponseBodies(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		io.WriteString(w, "kamehameha")
+	}))
+	defer server.Close()
+
+	tr := NewStaticTargeter(Target{Method: "GET", URL: server.URL})
+	dir := t.TempDir()
+
+	for _, tc := range []struct {
+		name    string
+		handler BodyHandler
+		check   func(*Result) bool
+	}{
+		{"kept", KeptBodies, func(r *Result) bool { return string(r.Body) == "kamehameha" }},
+		{"hashed", HashedBodies, func(r *Result) bool { return r.Body == nil && r.BodyHash == r.BodySum() && len(r.BodyHash) == 64 }},
+		{"discarded", DiscardedBodies, func(r *Result) bool { return r.Body == nil && r.BodyHash == "" }},
+		{"files", BodyFiles(dir), func(r *Result) bool {
+			body, err := ioutil.ReadFile(r.BodyFile)
+			return err == nil && r.Body == nil && string(body) == "kamehameha" &&
+				r.BodyFile == filepath.Join(dir, "files-7-1.body")
+		}},
+		{"func", BodyHandlerFunc(func(r *Result, body io.Reader) error {
+			_, err := io.CopyN(ioutil.Discard, body, 4)
+			return err
+		}), func(r *Result) bool { return r.BytesIn == 4 }},
+	} {
+		atk := NewAttacker(ResponseBodies(tc.handler), HashBodies(true))
+		res := atk.hit(tr, tc.name, 7)
+		if res.Error != "" {
+			t.Fatal(res.Error)
+		}
+
+		if tc.name != "func" && res.BytesIn != 10 {
+			t.Errorf("%s: got %d bytes in, want 10", tc.name, res.BytesIn)
+		}
+
+		if !tc.check(res) {
+			t.Errorf("%s: got result %+v", tc.name, res)
+		}
+	}
+}
+
+func TestBodyFiles_SameSeq(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.URL.Path)
+	}))
+	defer server.Close()
+
+	atk := NewAttacker(ResponseBodies(BodyFiles(t.TempDir())))
+
+	// Scenario steps and retries of a hit share its sequence number.
+	first := atk.hit(NewStaticTargeter(Target{Method: "GET", URL: server.URL + "/first"}), "steps", 1)
+	second := atk.hit(NewStaticTargeter(Target{Method: "GET", URL: server.URL + "/second"}), "steps", 1)
+
+	for _, tc := range []struct {
+		res  *Result
+		body string
+	}{{first, "/first"}, {second, "/second"}} {
+		if body, err := ioutil.ReadFile(tc.res.BodyFile); err != nil {
+			t.Fatal(err)
+		} else if string(body) != tc.body {
+			t.Errorf("%s: got body %q, want %q", tc.res.BodyFile, body, tc.body)
+		}
+	}
+}
+
+// stallingReader returns no bytes and no error on every other Read.
+type stallingReader struct {
+	io.Reader
+	stalled bool
+}
+
+func (r *stallingReader) Read(p []byte) (int, error) {
+	if r.stalled = !r.stalled; r.stalled {
+		return 0, nil
+	}
+	return r.Reader.Read(p)
+}
+
+func TestTruncatedBody(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		body      string
+		truncated bool
+	}{
+		{"kamehameha", true},
+		{"kame", false},
+	} {
+		b := &truncatedBody{Reader: &stallingReader{Reader: strings.NewReader(tc.body)}, n: 4}
+		got, err := ioutil.ReadAll(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(got) != "kame" || b.truncated != tc.truncated {
+			t.Errorf("body %q: got %q truncated %t, want %q truncated %t",
+				tc.body, got, b.truncated, "kame", tc.truncated)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package vegeta
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// errChurned is returned by writes to connections retired by churn.
+var errChurned = errors.New("connection churned")
+
+// Churn returns a functional option which makes an Attacker re-establish the
+// given fraction of its open connections every minute, spread evenly over
+// time, to simulate client churn and measure the handshake capacity of
+// targets under steady state. Retired connections are closed when they're
+// next written to, upon which the HTTP/1.1 request about to be sent on them
+// is transparently retried on a new connection.
+func Churn(fraction float64) func(*Attacker) {
+	return func(a *Attacker) {
+		tr := a.transport()
+		if tr == nil {
+			return
+		}
+
+		c := &churn{fraction: fraction, conns: map[*churnConn]struct{}{}, last: time.Now()}
+		dial := tr.DialContext
+		tr.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			conn, err := dial(ctx, network, addr)
+			if err != nil {
+				return nil, err
+			}
+			return c.track(conn), nil
+		}
+		a.churn = c
+	}
+}
+
+// churn tracks the open connections of an Attacker and retires them over
+// time.
+type churn struct {
+	mu       sync.Mutex
+	fraction float64
+	conns    map[*churnConn]struct{}
+	last     time.Time
+	due      float64
+}
+
+func (c *churn) track(conn net.Conn) net.Conn {
+	cc := &churnConn{Conn: conn, churn: c}
+	c.mu.Lock()
+	c.conns[cc] = struct{}{}
+	c.mu.Unlock()
+	return cc
+}
+
+// retire retires randomly picked connections in proportion to the time
+// elapsed since it was last called.
+func (c *churn) retire(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.due += float64(len(c.conns)) * c.fraction * now.Sub(c.last).Minutes()
+	c.last = now
+
+	// Map iteration order is random.
+	for cc := range c.conns {
+		if c.due < 1 {
+			return
+		}
+		delete(c.conns, cc)
+		atomic.StoreInt32(&cc.retired, 1)
+		c.due--
+	}
+
+	c.due = 0
+}
+
+// churnConn is a net.Conn tracked by churn.
+type churnConn struct {
+	net.Conn
+	churn   *churn
+	retired int32
+	once    sync.Once
+}
+
+func (cc *churnConn) Write(b []byte) (int, error) {
+	if atomic.LoadInt32(&cc.retired) == 1 {
+		cc.Close()
+		return 0, errChurned
+	}
+	return cc.Conn.Write(b)
+}
+
+func (cc *churnConn) Close() error {
+	cc.once.Do(func() {
+		cc.churn.mu.Lock()
+		delete(cc.churn.conns, cc)
+		cc.churn.mu.Unlock()
+	})
+	return cc.Conn.Close()
+}
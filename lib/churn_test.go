@@ -0,0 +1,52 @@
+package vegeta
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestChurn(t *testing.T) {
+	t.Parallel()
+
+	var conns int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	atk := NewAttacker(Churn(1))
+	tr := NewStaticTargeter(Target{Method: "GET", URL: server.URL})
+
+	for i := 0; i < 3; i++ {
+		if res := atk.hit(tr, "", uint64(i)); res.Error != "" {
+			t.Fatalf("hit %d: got error %q", i, res.Error)
+		}
+	}
+
+	if got := atomic.LoadInt32(&conns); got != 1 {
+		t.Fatalf("got %d connections before churn, want 1", got)
+	}
+
+	// A minute later, all connections are due to be re-established.
+	atk.churn.mu.Lock()
+	atk.churn.last = atk.churn.last.Add(-time.Minute)
+	atk.churn.mu.Unlock()
+
+	for i := 3; i < 6; i++ {
+		if res := atk.hit(tr, "", uint64(i)); res.Error != "" {
+			t.Fatalf("hit %d: got error %q", i, res.Error)
+		}
+	}
+
+	if got := atomic.LoadInt32(&conns); got != 2 {
+		t.Errorf("got %d connections after churn, want 2", got)
+	}
+}
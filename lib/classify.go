@@ -0,0 +1,84 @@
+package vegeta
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Classes of the errors of Results, recorded in their ErrorClass field.
+const (
+	// ErrorClassDNS is the class of host name resolution failures.
+	ErrorClassDNS = "dns"
+	// ErrorClassConnectRefused is the class of refused connections.
+	ErrorClassConnectRefused = "connect_refused"
+	// ErrorClassConnectTimeout is the class of connections which timed out
+	// being established.
+	ErrorClassConnectTimeout = "connect_timeout"
+	// ErrorClassConnect is the class of the other connection failures,
+	// including ErrConnLimit.
+	ErrorClassConnect = "connect"
+	// ErrorClassTLS is the class of TLS handshake failures and timeouts.
+	ErrorClassTLS = "tls"
+	// ErrorClassTimeout is the class of requests which timed out after
+	// connecting, including SLA misses.
+	ErrorClassTimeout = "timeout"
+	// ErrorClassBodyRead is the class of failures to read response bodies.
+	ErrorClassBodyRead = "body_read"
+	// ErrorClassClient is the class of 4xx responses.
+	ErrorClassClient = "4xx"
+	// ErrorClassServer is the class of 5xx responses.
+	ErrorClassServer = "5xx"
+	// ErrorClassAssertion is the class of responses which failed assertions,
+	// checksums or success predicates.
+	ErrorClassAssertion = "assertion"
+	// ErrorClassOther is the class of any other error.
+	ErrorClassOther = "other"
+)
+
+// classifyError returns the class of the given Result error of a response of
+// the given status code, if any, or an empty string without an error. Errors
+// without a class of their own which occurred while reading a response body
+// are of ErrorClassBodyRead.
+func classifyError(msg string, code uint16, readingBody bool) string {
+	if msg == "" {
+		return ""
+	}
+
+	hasPrefix := func(err error) bool { return strings.HasPrefix(msg, err.Error()) }
+
+	switch {
+	case hasPrefix(ErrDialTimeout):
+		return ErrorClassConnectTimeout
+	case hasPrefix(ErrTLSHandshakeTimeout):
+		return ErrorClassTLS
+	case hasPrefix(ErrResponseHeaderTimeout), hasPrefix(ErrBodyTimeout),
+		hasPrefix(ErrRequestTimeout), hasPrefix(ErrSLAMiss):
+		return ErrorClassTimeout
+	case hasPrefix(ErrConnLimit):
+		return ErrorClassConnect
+	case isAssertion(msg), hasPrefix(ErrCorruptedBody):
+		return ErrorClassAssertion
+	case code >= 400 && code < 600 && (hasPrefix(ErrUnsuccessful) || strings.HasPrefix(msg, strconv.Itoa(int(code)))):
+		if code < 500 {
+			return ErrorClassClient
+		}
+		return ErrorClassServer
+	case hasPrefix(ErrUnsuccessful):
+		return ErrorClassAssertion
+	case strings.Contains(msg, "lookup "):
+		return ErrorClassDNS
+	case strings.Contains(msg, "connection refused"), strings.Contains(msg, "actively refused"):
+		return ErrorClassConnectRefused
+	case strings.Contains(msg, "dial "):
+		return ErrorClassConnect
+	case strings.Contains(msg, "tls: "), strings.Contains(msg, "x509: "):
+		return ErrorClassTLS
+	case strings.Contains(msg, "Client.Timeout exceeded"), strings.Contains(msg, "context deadline exceeded"),
+		strings.Contains(msg, "i/o timeout"):
+		return ErrorClassTimeout
+	case readingBody:
+		return ErrorClassBodyRead
+	default:
+		return ErrorClassOther
+	}
+}
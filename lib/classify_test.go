@@ -0,0 +1,86 @@
+package vegeta
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestClassifyError(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		msg         string
+		code        uint16
+		readingBody bool
+		want        string
+	}{
+		{"", 200, false, ""},
+		{"Get \"http://goku\": dial tcp: lookup goku: no such host", 0, false, ErrorClassDNS},
+		{"Get \"http://goku\": dial tcp 127.0.0.1:80: connect: connection refused", 0, false, ErrorClassConnectRefused},
+		{"dial timeout: Get \"http://goku\": dial tcp 10.0.0.1:80: i/o timeout", 0, false, ErrorClassConnectTimeout},
+		{"connection limit: Get \"http://goku\": 10 open connections", 0, false, ErrorClassConnect},
+		{"Get \"http://goku\": dial tcp 10.0.0.1:80: connect: network is unreachable", 0, false, ErrorClassConnect},
+		{"Get \"https://goku\": x509: certificate signed by unknown authority", 0, false, ErrorClassTLS},
+		{"tls handshake timeout: Get \"https://goku\": net/http: TLS handshake timeout", 0, false, ErrorClassTLS},
+		{"response header timeout: Get \"http://goku\": net/http: timeout awaiting response headers", 0, false, ErrorClassTimeout},
+		{"sla miss: Get \"http://goku\": context deadline exceeded", 0, false, ErrorClassTimeout},
+		{"body read timeout: context canceled", 200, true, ErrorClassTimeout},
+		{"unexpected EOF", 0, true, ErrorClassBodyRead},
+		{"unexpected EOF", 0, false, ErrorClassOther},
+		{"404 Not Found", 404, false, ErrorClassClient},
+		{"503 Service Unavailable", 503, false, ErrorClassServer},
+		{"unsuccessful response: 502 Bad Gateway", 502, false, ErrorClassServer},
+		{"unsuccessful response: 200 OK", 200, false, ErrorClassAssertion},
+		{"assertion failed: status: got 200, want [201]", 200, false, ErrorClassAssertion},
+		{"corrupted body: sha256 mismatch: got 00, want 01", 200, false, ErrorClassAssertion},
+		{"304 Not Modified", 304, false, ErrorClassOther},
+	} {
+		if got := classifyError(tc.msg, tc.code, tc.readingBody); got != tc.want {
+			t.Errorf("%q: got class %q, want %q", tc.msg, got, tc.want)
+		}
+	}
+}
+
+func TestErrorClasses(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	refused := "http://" + ln.Addr().String()
+	ln.Close()
+
+	var m Metrics
+	atk := NewAttacker()
+	for _, tc := range []struct {
+		url  string
+		want string
+	}{
+		{server.URL, ErrorClassServer},
+		{refused, ErrorClassConnectRefused},
+	} {
+		res := atk.hit(NewStaticTargeter(Target{Method: "GET", URL: tc.url}), "", 0)
+		if res.ErrorClass != tc.want {
+			t.Errorf("%s: got error class %q of %q, want %q", tc.url, res.ErrorClass, res.Error, tc.want)
+		}
+		m.Add(res)
+	}
+
+	// Results of older attacks aren't classified yet.
+	m.Add(&Result{Code: 404, Error: "404 Not Found"})
+	m.Close()
+
+	want := map[string]uint64{ErrorClassServer: 1, ErrorClassConnectRefused: 1, ErrorClassClient: 1}
+	if !reflect.DeepEqual(m.ErrorClasses, want) {
+		t.Errorf("got error classes %v, want %v", m.ErrorClasses, want)
+	}
+}
@@ -0,0 +1,101 @@
+package vegeta
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// ntpEpochOffset is the number of seconds between the NTP epoch (1900) and
+// the UNIX epoch (1970).
+const ntpEpochOffset = 2208988800
+
+// ClockOffset measures the offset of the local clock to the clock of the
+// given NTP server with a single SNTP (RFC 4330) query. The returned offset
+// must be added to local times to align them with the server's clock. The
+// port defaults to 123 when omitted from the server address.
+func ClockOffset(server string, timeout time.Duration) (time.Duration, error) {
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "123")
+	}
+
+	conn, err := net.DialTimeout("udp", server, timeout)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	if err = conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return 0, err
+	}
+
+	req := make([]byte, 48)
+	req[0] = 0x23 // LI = 0, VN = 4, Mode = 3 (client)
+
+	t1 := time.Now()
+	putNTPTime(req[40:48], t1)
+	if _, err = conn.Write(req); err != nil {
+		return 0, err
+	}
+
+	res := make([]byte, 48)
+	for {
+		n, err := conn.Read(res)
+		if err != nil {
+			return 0, err
+		}
+		t4 := time.Now()
+
+		// Ignore stray responses to other queries.
+		if n < 48 || string(res[24:32]) != string(req[40:48]) {
+			continue
+		}
+
+		if res[0]&0x7 != 4 {
+			return 0, fmt.Errorf("ntp: bad response mode %d", res[0]&0x7)
+		} else if res[1] == 0 {
+			return 0, errors.New("ntp: kiss-of-death response " + string(res[12:16]))
+		}
+
+		t2, t3 := ntpTime(res[32:40]), ntpTime(res[40:48])
+		return (t2.Sub(t1) + t3.Sub(t4)) / 2, nil
+	}
+}
+
+// ClockSync returns a functional option which records the given clock offset,
+// as measured by ClockOffset, in the Results of an Attacker. This allows the
+// Results of attackers running on different hosts to be aligned on a common
+// timeline with NewClockAlignedDecoder.
+func ClockSync(offset time.Duration) func(*Attacker) {
+	return func(a *Attacker) { a.offset = offset }
+}
+
+// NewClockAlignedDecoder returns a Decoder which adds the ClockOffset of every
+// Result decoded by dec to its Timestamp and Intended time, if set, and resets
+// it.
+func NewClockAlignedDecoder(dec Decoder) Decoder {
+	return func(r *Result) error {
+		if err := dec(r); err != nil {
+			return err
+		}
+		r.Timestamp = r.Timestamp.Add(r.ClockOffset)
+		if !r.Intended.IsZero() {
+			r.Intended = r.Intended.Add(r.ClockOffset)
+		}
+		r.ClockOffset = 0
+		return nil
+	}
+}
+
+func ntpTime(b []byte) time.Time {
+	secs := int64(binary.BigEndian.Uint32(b[0:4])) - ntpEpochOffset
+	frac := uint64(binary.BigEndian.Uint32(b[4:8]))
+	return time.Unix(secs, int64(frac*1e9>>32))
+}
+
+func putNTPTime(b []byte, t time.Time) {
+	binary.BigEndian.PutUint32(b[0:4], uint32(t.Unix()+ntpEpochOffset))
+	binary.BigEndian.PutUint32(b[4:8], uint32(uint64(t.Nanosecond())<<32/1e9))
+}
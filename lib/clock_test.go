@@ -0,0 +1,82 @@
+package vegeta
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestClockOffset(t *testing.T) {
+	t.Parallel()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	const skew = 3 * time.Second
+	go func() {
+		b := make([]byte, 48)
+		n, addr, err := conn.ReadFrom(b)
+		if err != nil || n != 48 {
+			return
+		}
+
+		res := make([]byte, 48)
+		res[0], res[1] = 0x24, 1 // VN = 4, Mode = 4 (server), stratum 1
+		copy(res[24:32], b[40:48])
+		putNTPTime(res[32:40], time.Now().Add(skew))
+		putNTPTime(res[40:48], time.Now().Add(skew))
+		conn.WriteTo(res, addr)
+	}()
+
+	offset, err := ClockOffset(conn.LocalAddr().String(), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d := offset - skew; d < -50*time.Millisecond || d > 50*time.Millisecond {
+		t.Errorf("got offset %s, want %s", offset, skew)
+	}
+}
+
+func TestNewClockAlignedDecoder(t *testing.T) {
+	t.Parallel()
+
+	ts, intended := time.Unix(10, 0), time.Unix(9, 0)
+	results := []Result{
+		{Timestamp: ts, Intended: intended, ClockOffset: -time.Second},
+		{Timestamp: ts, ClockOffset: -time.Second},
+	}
+
+	dec := NewClockAlignedDecoder(func(r *Result) error {
+		*r, results = results[0], results[1:]
+		return nil
+	})
+
+	var r Result
+	if err := dec(&r); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := ts.Add(-time.Second); !r.Timestamp.Equal(want) || r.ClockOffset != 0 {
+		t.Errorf("got: %s (%s), want: %s (0s)", r.Timestamp, r.ClockOffset, want)
+	}
+
+	if want := intended.Add(-time.Second); !r.Intended.Equal(want) {
+		t.Errorf("got intended: %s, want: %s", r.Intended, want)
+	}
+
+	if got, want := r.CorrectedLatency(), time.Second+r.Latency; got != want {
+		t.Errorf("got corrected latency: %s, want: %s", got, want)
+	}
+
+	if err := dec(&r); err != nil {
+		t.Fatal(err)
+	}
+
+	if !r.Intended.IsZero() {
+		t.Errorf("got intended: %s, want: zero", r.Intended)
+	}
+}
@@ -0,0 +1,193 @@
+package vegeta
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"text/tabwriter"
+	"time"
+)
+
+// Verdicts of the MetricDeltas of a Comparison.
+const (
+	// VerdictRegressed is the verdict of metrics which got worse beyond
+	// the tolerance of a Comparison.
+	VerdictRegressed = "regressed"
+	// VerdictImproved is the verdict of metrics which got better beyond
+	// the tolerance of a Comparison.
+	VerdictImproved = "improved"
+	// VerdictUnchanged is the verdict of metrics within the tolerance of a
+	// Comparison.
+	VerdictUnchanged = "unchanged"
+)
+
+// A Comparison compares the closed Metrics of an attack with those of a
+// baseline one, e.g. of the previous release.
+type Comparison struct {
+	// Baseline holds the Metrics compared against.
+	Baseline *Metrics
+	// Current holds the compared Metrics.
+	Current *Metrics
+	// Tolerance is the relative change of latencies and of the rate within
+	// which they're unchanged. Zero means 5%.
+	Tolerance float64
+	// SuccessTolerance is the change of the success ratio within which
+	// it's unchanged. Zero means 0.1 percentage points.
+	SuccessTolerance float64
+}
+
+// A MetricDelta is the change of a metric between the Metrics of a
+// Comparison.
+type MetricDelta struct {
+	// Metric is the name of the metric, as that of an Objective.
+	Metric string `json:"metric"`
+	// Baseline is the formatted value of the baseline metric.
+	Baseline string `json:"baseline"`
+	// Current is the formatted value of the current metric.
+	Current string `json:"current"`
+	// Change is the relative change of the metric, or the change of the
+	// ratio for success. Changes from zero count as 100% and those of
+	// metrics missing in either Metrics as none.
+	Change float64 `json:"change"`
+	// Verdict is VerdictRegressed, VerdictImproved or VerdictUnchanged.
+	Verdict string `json:"verdict"`
+}
+
+// Deltas returns the changes of the latency mean, the latency percentiles
+// of the Current Metrics, the maximum latency, the rate and the success
+// ratio, in this order.
+func (c *Comparison) Deltas() []MetricDelta {
+	metrics := []string{"mean", "p50", "p95", "p99", "p99.9"}
+	if len(c.Current.Percentiles) > 0 {
+		metrics = metrics[:1]
+		for _, p := range c.Current.Percentiles {
+			metrics = append(metrics, "p"+FormatPercentile(p))
+		}
+	}
+	metrics = append(metrics, "max", "rate", "success")
+
+	deltas := make([]MetricDelta, 0, len(metrics))
+	for _, metric := range metrics {
+		o := Objective{Metric: metric}
+		base, cur := o.value(c.Baseline), o.value(c.Current)
+
+		d := MetricDelta{
+			Metric:   metric,
+			Baseline: o.format(base),
+			Current:  o.format(cur),
+			Verdict:  VerdictUnchanged,
+		}
+
+		// Latencies regress up, the rate and success ratio down.
+		worse, tolerance := 1.0, c.Tolerance
+		if tolerance == 0 {
+			tolerance = 0.05
+		}
+
+		switch metric {
+		case "success":
+			if tolerance = c.SuccessTolerance; tolerance == 0 {
+				tolerance = 0.001
+			}
+			d.Change, worse = cur-base, -1
+		case "rate":
+			d.Change, worse = relativeChange(base, cur), -1
+		default:
+			d.Change = relativeChange(base, cur)
+		}
+
+		switch {
+		case math.IsNaN(d.Change):
+			d.Change = 0
+		case d.Change*worse > tolerance:
+			d.Verdict = VerdictRegressed
+		case d.Change*worse < -tolerance:
+			d.Verdict = VerdictImproved
+		}
+
+		deltas = append(deltas, d)
+	}
+
+	return deltas
+}
+
+// relativeChange returns the change from base to cur relative to base.
+func relativeChange(base, cur float64) float64 {
+	switch {
+	case base != 0:
+		return (cur - base) / base
+	case cur > 0:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Regressed returns the metrics which regressed.
+func (c *Comparison) Regressed() []MetricDelta {
+	var regressed []MetricDelta
+	for _, d := range c.Deltas() {
+		if d.Verdict == VerdictRegressed {
+			regressed = append(regressed, d)
+		}
+	}
+	return regressed
+}
+
+// NewComparisonReporter returns a Reporter that writes out a Comparison as
+// an aligned, formatted table of the changes of each metric followed by an
+// overall verdict, which is regressed if any metric regressed.
+func NewComparisonReporter(c *Comparison) Reporter {
+	return func(w io.Writer) (err error) {
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.StripEscape)
+
+		if _, err = fmt.Fprintf(tw, "Metric\tBaseline\tCurrent\tChange\tVerdict\n"); err != nil {
+			return err
+		}
+
+		var regressed int
+		for _, d := range c.Deltas() {
+			if d.Verdict == VerdictRegressed {
+				regressed++
+			}
+
+			change := fmt.Sprintf("%+.2f%%", d.Change*100)
+			if d.Metric == "success" {
+				change = fmt.Sprintf("%+.2fpp", d.Change*100)
+			}
+
+			if _, err = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
+				d.Metric, d.Baseline, d.Current, change, d.Verdict,
+			); err != nil {
+				return err
+			}
+		}
+
+		verdict := "unchanged or improved"
+		if regressed > 0 {
+			verdict = fmt.Sprintf("%s (%d metrics)", VerdictRegressed, regressed)
+		}
+
+		if _, err = fmt.Fprintf(tw, "\nVerdict: %s\n", verdict); err != nil {
+			return err
+		}
+
+		return tw.Flush()
+	}
+}
+
+// NewComparisonJSONReporter returns a Reporter that writes out the deltas of
+// a Comparison as JSON, along with the durations of both attacks.
+func NewComparisonJSONReporter(c *Comparison) Reporter {
+	return func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(struct {
+			Baseline  time.Duration `json:"baseline_duration"`
+			Current   time.Duration `json:"current_duration"`
+			Deltas    []MetricDelta `json:"deltas"`
+			Regressed bool          `json:"regressed"`
+		}{
+			c.Baseline.Duration, c.Current.Duration, c.Deltas(), len(c.Regressed()) > 0,
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package vegeta
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestComparison(t *testing.T) {
+	t.Parallel()
+
+	metrics := func(latency time.Duration, errors int) *Metrics {
+		var m Metrics
+		for i := 0; i < 1000; i++ {
+			r := Result{Code: 200, Timestamp: time.Unix(0, int64(i)*int64(10*time.Millisecond)), Latency: latency}
+			if i < errors {
+				r.Code, r.Error = 500, "500 Internal Server Error"
+			}
+			m.Add(&r)
+		}
+		m.Close()
+		return &m
+	}
+
+	c := Comparison{
+		Baseline: metrics(100*time.Millisecond, 0),
+		Current:  metrics(120*time.Millisecond, 10),
+	}
+
+	deltas := c.Deltas()
+
+	want := map[string]string{
+		"mean":    VerdictRegressed,
+		"p50":     VerdictRegressed,
+		"p99.9":   VerdictRegressed,
+		"max":     VerdictRegressed,
+		"rate":    VerdictUnchanged,
+		"success": VerdictRegressed,
+	}
+
+	for _, d := range deltas {
+		if v, ok := want[d.Metric]; ok && d.Verdict != v {
+			t.Errorf("%s: got verdict %s, want %s", d.Metric, d.Verdict, v)
+		}
+	}
+
+	if d := deltas[0]; d.Metric != "mean" || d.Baseline != "100ms" || d.Current != "120ms" || d.Change < 0.199 || d.Change > 0.201 {
+		t.Errorf("got mean delta %+v", d)
+	}
+
+	if d := deltas[len(deltas)-1]; d.Metric != "success" || d.Change > -0.0099 || d.Change < -0.0101 {
+		t.Errorf("got success delta %+v", d)
+	}
+
+	// Within the tolerance, nothing changed.
+	c.Tolerance, c.SuccessTolerance = 0.25, 0.02
+	if got := c.Regressed(); len(got) != 0 {
+		t.Errorf("got regressions %+v within the tolerance", got)
+	}
+
+	c.Baseline, c.Current = c.Current, c.Baseline
+	c.Tolerance, c.SuccessTolerance = 0, 0
+
+	var buf bytes.Buffer
+	if err := NewComparisonReporter(&c).Report(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if out := buf.String(); !strings.HasPrefix(out, "Metric ") || !strings.Contains(out, "improved") ||
+		!strings.HasSuffix(out, "Verdict: unchanged or improved\n") {
+		t.Errorf("got report:\n%s", out)
+	}
+
+	buf.Reset()
+	if err := NewComparisonJSONReporter(&c).Report(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	var got struct {
+		Deltas    []MetricDelta
+		Regressed bool
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Regressed || len(got.Deltas) != len(deltas) {
+		t.Errorf("got %+v", got)
+	}
+}
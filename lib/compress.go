@@ -0,0 +1,65 @@
+package vegeta
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compressions of results files, which shrink them manyfold as most of
+// their bytes are repeated bodies, errors and headers.
+const (
+	// Gzip compresses with gzip, which any tool decompresses.
+	Gzip = "gzip"
+	// Zstd compresses with Zstandard, which is faster and smaller.
+	Zstd = "zstd"
+)
+
+// Magic numbers at the start of compressed streams.
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// NewCompressor returns an io.WriteCloser which writes what's written to it
+// to w compressed with the given compression, Gzip or Zstd. It must be
+// closed to flush the compressed stream, which doesn't close w.
+func NewCompressor(w io.Writer, compression string) (io.WriteCloser, error) {
+	switch compression {
+	case Gzip:
+		return gzip.NewWriter(w), nil
+	case Zstd:
+		return zstd.NewWriter(w)
+	default:
+		return nil, fmt.Errorf("unsupported compression: %q", compression)
+	}
+}
+
+// NewDecompressor returns an io.ReadCloser of what's read from r, which it
+// decompresses if it's compressed with Gzip or Zstd, as detected by their
+// magic numbers. Closing it releases its resources but doesn't close r.
+func NewDecompressor(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(len(zstdMagic))
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		return gzip.NewReader(br)
+	case bytes.HasPrefix(magic, zstdMagic):
+		dec, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		return dec.IOReadCloser(), nil
+	default:
+		return ioutil.NopCloser(br), nil
+	}
+}
@@ -0,0 +1,67 @@
+package vegeta
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+)
+
+func TestCompression(t *testing.T) {
+	t.Parallel()
+
+	for _, compression := range []string{"", Gzip, Zstd} {
+		var buf bytes.Buffer
+		var w io.Writer = &buf
+
+		if compression != "" {
+			c, err := NewCompressor(&buf, compression)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer c.Close()
+			w = c
+		}
+
+		enc := NewEncoder(w)
+		for i := 0; i < 100; i++ {
+			if err := enc.Encode(&Result{Seq: uint64(i), Error: "connection refused"}); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if c, ok := w.(io.Closer); ok {
+			if err := c.Close(); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		rd, err := NewDecompressor(&buf)
+		if err != nil {
+			t.Fatalf("%q: %v", compression, err)
+		}
+		defer rd.Close()
+
+		dec := NewDecoder(rd)
+		for i := 0; i < 100; i++ {
+			var r Result
+			if err := dec.Decode(&r); err != nil {
+				t.Fatalf("%q: result %d: %v", compression, i, err)
+			} else if r.Seq != uint64(i) {
+				t.Fatalf("%q: got seq %d, want %d", compression, r.Seq, i)
+			}
+		}
+	}
+
+	if _, err := NewCompressor(ioutil.Discard, "lz4"); err == nil {
+		t.Error("got no error with an unsupported compression")
+	}
+
+	rd, err := NewDecompressor(bytes.NewReader([]byte{1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := ioutil.ReadAll(rd); err != nil || !bytes.Equal(got, []byte{1}) {
+		t.Errorf("got %v, %v from a short uncompressed stream", got, err)
+	}
+}
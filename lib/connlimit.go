@@ -0,0 +1,112 @@
+package vegeta
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+)
+
+// ErrConnLimit is the error reported in Results, as a prefix of their Error
+// field, of hits which needed a new connection while the Attacker had as many
+// open as allowed by MaxConnections.
+var ErrConnLimit = errors.New("connection limit")
+
+// MaxConnections returns a functional option which caps the number of
+// connections an Attacker keeps open, in total and per target host, unlike
+// Connections which only caps idle ones. Hits which would dial past either
+// cap fail right away with an ErrConnLimit error instead of waiting for a
+// connection, so that degraded targets can't make connections pile up. Zero
+// means no cap.
+func MaxConnections(total, perHost int) func(*Attacker) {
+	return func(a *Attacker) {
+		a.limit = &connLimit{total: total, perHost: perHost, hosts: map[string]int{}}
+	}
+}
+
+// connLimit counts the open connections of an Attacker against its caps.
+type connLimit struct {
+	total   int
+	perHost int
+	mu      sync.Mutex
+	open    int
+	hosts   map[string]int
+}
+
+// connLimitError is the error of dials past a cap.
+type connLimitError string
+
+func (e connLimitError) Error() string { return string(e) }
+
+// acquire counts a new connection to the given host, unless that exceeds a
+// cap.
+func (l *connLimit) acquire(host string) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	switch {
+	case l.total > 0 && l.open >= l.total:
+		return connLimitError(fmt.Sprintf("%d open connections", l.open))
+	case l.perHost > 0 && l.hosts[host] >= l.perHost:
+		return connLimitError(fmt.Sprintf("%d open connections to %s", l.hosts[host], host))
+	}
+
+	l.open++
+	l.hosts[host]++
+
+	return nil
+}
+
+// release uncounts a connection to the given host.
+func (l *connLimit) release(host string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.open--
+	if l.hosts[host]--; l.hosts[host] <= 0 {
+		delete(l.hosts, host)
+	}
+}
+
+// track returns the result of dialing the given host, whose connection is
+// counted as open until it's closed. Failed dials are uncounted right away.
+func (l *connLimit) track(host string, conn net.Conn, err error) (net.Conn, error) {
+	if l == nil {
+		return conn, err
+	}
+
+	if err != nil {
+		l.release(host)
+		return nil, err
+	}
+
+	return &limitedConn{Conn: conn, l: l, host: host}, nil
+}
+
+// limitedConn is a net.Conn counted as open by a connLimit.
+type limitedConn struct {
+	net.Conn
+	l    *connLimit
+	host string
+	once sync.Once
+}
+
+// Close implements the net.Conn interface.
+func (c *limitedConn) Close() error {
+	c.once.Do(func() { c.l.release(c.host) })
+	return c.Conn.Close()
+}
+
+// limitError returns err prefixed with ErrConnLimit if it's caused by a dial
+// past a cap.
+func limitError(err error) error {
+	var lerr connLimitError
+	if !errors.As(err, &lerr) {
+		return err
+	}
+	return fmt.Errorf("%s: %s", ErrConnLimit, err)
+}
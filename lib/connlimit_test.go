@@ -0,0 +1,57 @@
+package vegeta
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMaxConnections(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	for _, tc := range []struct {
+		total, perHost int
+	}{
+		{1, 0},
+		{0, 1},
+	} {
+		atk := NewAttacker(KeepAlive(false), MaxConnections(tc.total, tc.perHost))
+		tr := NewStaticTargeter(Target{Method: "GET", URL: server.URL})
+
+		var (
+			wg      sync.WaitGroup
+			results [2]*Result
+		)
+		for i := range results {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				// Staggered so that the first hit gets the connection.
+				time.Sleep(time.Duration(i) * 50 * time.Millisecond)
+				results[i] = atk.hit(tr, "", 0)
+			}(i)
+		}
+		wg.Wait()
+
+		if res := results[0]; res.Error != "" {
+			t.Errorf("%+v: got error %q, want none", tc, res.Error)
+		}
+
+		if res := results[1]; !strings.HasPrefix(res.Error, ErrConnLimit.Error()+": ") {
+			t.Errorf("%+v: got error %q, want prefix %q", tc, res.Error, ErrConnLimit)
+		}
+
+		// Closed connections are uncounted.
+		if res := atk.hit(tr, "", 0); res.Error != "" {
+			t.Errorf("%+v: got error %q after closing, want none", tc, res.Error)
+		}
+	}
+}
@@ -0,0 +1,361 @@
+package vegeta
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// NewCurlTargeter eagerly reads all curl command lines out of the provided
+// io.Reader, as produced by the "Copy as cURL" feature of browser developer
+// tools, and returns a NewStaticTargeter with them. Commands are separated
+// by new lines, which can be escaped with a backslash to continue a command
+// on the next line.
+//
+// body will be set as the Target's body if no body is provided.
+// hdr will be merged with the each Target's headers.
+func NewCurlTargeter(src io.Reader, body []byte, hdr http.Header) (Targeter, error) {
+	var tgts []Target
+	sc := bufio.NewScanner(src)
+	sc.Buffer(make([]byte, 64*1024), 64*1024*1024)
+
+	var cmd string
+	for sc.Scan() {
+		line := sc.Text()
+		if strings.HasSuffix(line, "\\") {
+			cmd += line[:len(line)-1] + " "
+			continue
+		}
+
+		if cmd += line; strings.TrimSpace(cmd) == "" {
+			cmd = ""
+			continue
+		}
+
+		tgt, err := ParseCurlCommand(cmd)
+		if err != nil {
+			return nil, err
+		}
+		cmd = ""
+
+		if len(tgt.Body) == 0 {
+			tgt.Body = body
+		}
+		for k, vs := range hdr {
+			tgt.Header[k] = append(tgt.Header[k], vs...)
+		}
+		tgts = append(tgts, tgt)
+	}
+
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(tgts) == 0 {
+		return nil, ErrNoTargets
+	}
+
+	return NewStaticTargeter(tgts...), nil
+}
+
+// curlOptions are the curl options known to ParseCurlCommand, mapped to
+// whether they take a value. Those it doesn't handle don't affect the
+// generated Target and are ignored.
+var curlOptions = map[string]bool{
+	"-X": true, "--request": true, "-H": true, "--header": true,
+	"-d": true, "--data": true, "--data-ascii": true, "--data-binary": true,
+	"--data-raw": true, "--data-urlencode": true, "--json": true,
+	"-u": true, "--user": true, "-A": true, "--user-agent": true,
+	"-e": true, "--referer": true, "-b": true, "--cookie": true,
+	"--url": true, "-G": false, "--get": false, "-I": false, "--head": false,
+	"-F": true, "--form": true, "--form-string": true, "-T": true, "--upload-file": true,
+
+	"-o": true, "--output": true, "-m": true, "--max-time": true,
+	"--connect-timeout": true, "-x": true, "--proxy": true, "--cacert": true,
+	"-E": true, "--cert": true, "--key": true, "-w": true, "--write-out": true,
+	"--retry": true, "--retry-delay": true, "--retry-max-time": true,
+	"--resolve": true, "--connect-to": true, "-c": true, "--cookie-jar": true,
+	"-D": true, "--dump-header": true, "--max-redirs": true,
+
+	"--compressed": false, "-s": false, "--silent": false, "-S": false,
+	"--show-error": false, "-k": false, "--insecure": false, "-L": false,
+	"--location": false, "-v": false, "--verbose": false, "-i": false,
+	"--include": false, "-f": false, "--fail": false, "-g": false,
+	"--globoff": false, "-N": false, "--no-buffer": false, "--http1.1": false,
+	"--http2": false, "--path-as-is": false,
+}
+
+// ParseCurlCommand parses a curl command line into a Target. It supports the
+// subset of curl options that affect the request: method, headers, data,
+// basic auth, cookies, user agent and referer. Common options which don't,
+// e.g. --compressed, are ignored, and unknown ones are errors, since their
+// values can't be told apart from the URL.
+func ParseCurlCommand(cmd string) (Target, error) {
+	tgt := Target{Method: "GET", Header: http.Header{}}
+
+	args, err := shellWords(cmd)
+	if err != nil {
+		return tgt, fmt.Errorf("bad curl command: %s", err)
+	}
+
+	if len(args) == 0 || args[0] != "curl" {
+		return tgt, fmt.Errorf("bad curl command: %s", cmd)
+	}
+
+	var (
+		method string
+		data   []string
+		get    bool
+		rawURL string
+	)
+
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+
+		// Support --flag=value as well as --flag value, and short options
+		// grouped together, e.g. -sS, or with their value attached, e.g.
+		// -XPOST.
+		if strings.HasPrefix(arg, "--") {
+			if eq := strings.Index(arg, "="); eq != -1 {
+				args = append(args[:i+1], append([]string{arg[eq+1:]}, args[i+1:]...)...)
+				arg = arg[:eq]
+			}
+		} else if len(arg) > 2 && arg[0] == '-' {
+			rest := arg[2:]
+			if arg = arg[:2]; !curlOptions[arg] {
+				rest = "-" + rest
+			}
+			args = append(args[:i+1], append([]string{rest}, args[i+1:]...)...)
+		}
+
+		if takesValue, ok := curlOptions[arg]; !ok && strings.HasPrefix(arg, "-") {
+			return tgt, fmt.Errorf("unsupported curl option: %s", arg)
+		} else if takesValue {
+			if i+1 >= len(args) {
+				return tgt, fmt.Errorf("bad curl command: missing value for %s", arg)
+			}
+			i++
+		}
+		val := args[i]
+
+		switch arg {
+		case "-X", "--request":
+			method = val
+		case "-H", "--header":
+			kv := strings.SplitN(val, ":", 2)
+			if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+				return tgt, fmt.Errorf("bad header: %s", val)
+			}
+			k := strings.TrimSpace(kv[0])
+			tgt.Header[k] = append(tgt.Header[k], strings.TrimSpace(kv[1]))
+		case "-d", "--data", "--data-ascii", "--data-binary":
+			if strings.HasPrefix(val, "@") {
+				bs, err := ioutil.ReadFile(val[1:])
+				if err != nil {
+					return tgt, fmt.Errorf("bad body: %s", err)
+				}
+				val = string(bs)
+				if arg != "--data-binary" {
+					val = strings.NewReplacer("\r", "", "\n", "").Replace(val)
+				}
+			}
+			data = append(data, val)
+		case "--data-raw":
+			data = append(data, val)
+		case "--data-urlencode":
+			if eq := strings.Index(val, "="); eq != -1 {
+				val = val[:eq+1] + url.QueryEscape(val[eq+1:])
+			} else {
+				val = url.QueryEscape(val)
+			}
+			data = append(data, val)
+		case "--json":
+			data = append(data, val)
+			setDefaultHeader(tgt.Header, "Content-Type", "application/json")
+			setDefaultHeader(tgt.Header, "Accept", "application/json")
+		case "-u", "--user":
+			tgt.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(val)))
+		case "-A", "--user-agent":
+			tgt.Header.Set("User-Agent", val)
+		case "-e", "--referer":
+			tgt.Header.Set("Referer", val)
+		case "-b", "--cookie":
+			tgt.Header.Add("Cookie", val)
+		case "-G", "--get":
+			get = true
+		case "-I", "--head":
+			method = "HEAD"
+		case "-F", "--form", "--form-string", "-T", "--upload-file":
+			return tgt, fmt.Errorf("unsupported curl option: %s", arg)
+		case "--url":
+			if rawURL != "" {
+				return tgt, fmt.Errorf("bad curl command: more than one URL")
+			}
+			rawURL = val
+		default:
+			if strings.HasPrefix(arg, "-") {
+				break // ignored option
+			} else if rawURL != "" {
+				return tgt, fmt.Errorf("bad curl command: more than one URL")
+			}
+			rawURL = arg
+		}
+	}
+
+	if rawURL == "" {
+		return tgt, fmt.Errorf("bad curl command: missing URL")
+	}
+
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "http://" + rawURL
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return tgt, fmt.Errorf("bad URL: %s", rawURL)
+	}
+
+	if len(data) > 0 {
+		if get {
+			if u.RawQuery != "" {
+				u.RawQuery += "&"
+			}
+			u.RawQuery += strings.Join(data, "&")
+		} else {
+			tgt.Body = []byte(strings.Join(data, "&"))
+			tgt.Method = "POST"
+			setDefaultHeader(tgt.Header, "Content-Type", "application/x-www-form-urlencoded")
+		}
+	}
+
+	if method != "" {
+		tgt.Method = method
+	}
+	tgt.URL = u.String()
+
+	return tgt, nil
+}
+
+// setDefaultHeader sets the given header unless it's already set,
+// case-insensitively.
+func setDefaultHeader(h http.Header, key, val string) {
+	for k := range h {
+		if strings.EqualFold(k, key) {
+			return
+		}
+	}
+	h.Set(key, val)
+}
+
+// shellWords splits the given POSIX shell command line into words, handling
+// single quotes, double quotes, ANSI-C quotes ($'...') and backslash escapes.
+func shellWords(s string) ([]string, error) {
+	var (
+		words []string
+		word  strings.Builder
+		in    bool // in a word
+	)
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if in {
+				words = append(words, word.String())
+				word.Reset()
+				in = false
+			}
+		case c == '\\':
+			in = true
+			if i+1 < len(s) {
+				i++
+				if s[i] != '\n' {
+					word.WriteByte(s[i])
+				}
+			}
+		case c == '\'':
+			in = true
+			end := strings.IndexByte(s[i+1:], '\'')
+			if end == -1 {
+				return nil, fmt.Errorf("unterminated single quote")
+			}
+			word.WriteString(s[i+1 : i+1+end])
+			i += end + 1
+		case c == '$' && i+1 < len(s) && s[i+1] == '\'':
+			in = true
+			n, err := ansiCQuoted(s[i+2:], &word)
+			if err != nil {
+				return nil, err
+			}
+			i += n + 2
+		case c == '"':
+			in = true
+			for i++; ; i++ {
+				if i >= len(s) {
+					return nil, fmt.Errorf("unterminated double quote")
+				}
+				if s[i] == '"' {
+					break
+				}
+				if s[i] == '\\' && i+1 < len(s) && strings.IndexByte("$`\"\\\n", s[i+1]) != -1 {
+					i++
+					if s[i] == '\n' {
+						continue
+					}
+				}
+				word.WriteByte(s[i])
+			}
+		default:
+			in = true
+			word.WriteByte(c)
+		}
+	}
+
+	if in {
+		words = append(words, word.String())
+	}
+
+	return words, nil
+}
+
+// ansiCQuoted decodes the body of a $'...' string into the given word and
+// returns the number of bytes consumed, including the closing quote.
+func ansiCQuoted(s string, word *strings.Builder) (int, error) {
+	escapes := map[byte]byte{
+		'n': '\n', 't': '\t', 'r': '\r', '\\': '\\', '\'': '\'', '"': '"',
+		'a': '\a', 'b': '\b', 'e': 0x1b, 'f': '\f', 'v': '\v',
+	}
+
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\'':
+			return i, nil
+		case '\\':
+			if i+1 >= len(s) {
+				return 0, fmt.Errorf("unterminated ANSI-C quote")
+			}
+			i++
+			if e, ok := escapes[s[i]]; ok {
+				word.WriteByte(e)
+			} else if s[i] == 'x' && i+2 < len(s) {
+				var b byte
+				if _, err := fmt.Sscanf(s[i+1:i+3], "%02x", &b); err != nil {
+					return 0, fmt.Errorf("bad escape: \\x%s", s[i+1:i+3])
+				}
+				word.WriteByte(b)
+				i += 2
+			} else {
+				word.WriteByte('\\')
+				word.WriteByte(s[i])
+			}
+		default:
+			word.WriteByte(s[i])
+		}
+	}
+
+	return 0, fmt.Errorf("unterminated ANSI-C quote")
+}
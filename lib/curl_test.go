@@ -0,0 +1,140 @@
+package vegeta
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseCurlCommand(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		cmd  string
+		want Target
+		err  string
+	}{
+		{
+			cmd:  "curl http://goku:9090/path",
+			want: Target{Method: "GET", URL: "http://goku:9090/path", Header: http.Header{}},
+		},
+		{
+			cmd: `curl 'https://goku/things' -H 'Accept: application/json' -H "X-Account-ID: 99" --compressed`,
+			want: Target{
+				Method: "GET",
+				URL:    "https://goku/things",
+				Header: http.Header{"Accept": {"application/json"}, "X-Account-ID": {"99"}},
+			},
+		},
+		{
+			cmd: `curl -X PUT --data-raw $'{"name":"vegeta\'s"}' -H 'content-type: application/json' goku/things/1`,
+			want: Target{
+				Method: "PUT",
+				URL:    "http://goku/things/1",
+				Body:   []byte(`{"name":"vegeta's"}`),
+				Header: http.Header{"content-type": {"application/json"}},
+			},
+		},
+		{
+			cmd: `curl -d a=1 --data b=2 -u user:pass http://goku/form`,
+			want: Target{
+				Method: "POST",
+				URL:    "http://goku/form",
+				Body:   []byte("a=1&b=2"),
+				Header: http.Header{
+					"Authorization": {"Basic dXNlcjpwYXNz"},
+					"Content-Type":  {"application/x-www-form-urlencoded"},
+				},
+			},
+		},
+		{
+			cmd:  `curl -G --data-urlencode 'q=dragon balls' http://goku/search?page=1`,
+			want: Target{Method: "GET", URL: "http://goku/search?page=1&q=dragon+balls", Header: http.Header{}},
+		},
+		{
+			cmd:  `curl --request=DELETE -o /dev/null --max-time 3 http://goku/`,
+			want: Target{Method: "DELETE", URL: "http://goku/", Header: http.Header{}},
+		},
+		{
+			cmd: `curl -sSXPOST -d'{"a":1}' -HContent-Type:application/json -ugoku:pass -Avegeta http://goku/things`,
+			want: Target{
+				Method: "POST",
+				URL:    "http://goku/things",
+				Body:   []byte(`{"a":1}`),
+				Header: http.Header{
+					"Authorization": {"Basic Z29rdTpwYXNz"},
+					"Content-Type":  {"application/json"},
+					"User-Agent":    {"vegeta"},
+				},
+			},
+		},
+		{
+			cmd: `curl -ehttp://goku/ -bsession=1 --retry-delay 3 -Lk http://goku/me`,
+			want: Target{
+				Method: "GET",
+				URL:    "http://goku/me",
+				Header: http.Header{"Referer": {"http://goku/"}, "Cookie": {"session=1"}},
+			},
+		},
+		{cmd: "wget http://goku", err: "bad curl command"},
+		{cmd: "curl --retry-connrefused 3 http://goku/", err: "unsupported curl option: --retry-connrefused"},
+		{cmd: "curl -Z http://goku/", err: "unsupported curl option: -Z"},
+		{cmd: "curl -sZ http://goku/", err: "unsupported curl option: -Z"},
+		{cmd: "curl http://goku/a http://goku/b", err: "more than one URL"},
+		{cmd: "curl -H", err: "missing value"},
+		{cmd: "curl -F a=@file http://goku", err: "unsupported curl option"},
+		{cmd: "curl 'http://goku", err: "unterminated single quote"},
+	} {
+		got, err := ParseCurlCommand(tc.cmd)
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("%s: got error %v, want %q", tc.cmd, err, tc.err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: %s", tc.cmd, err)
+		} else if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s:\ngot:  %+v\nwant: %+v", tc.cmd, got, tc.want)
+		}
+	}
+}
+
+func TestNewCurlTargeter(t *testing.T) {
+	t.Parallel()
+
+	src := strings.NewReader(`curl 'http://goku/a' \
+  -H 'X-Foo: bar'
+
+curl http://goku/b
+`)
+
+	tr, err := NewCurlTargeter(src, []byte("body"), http.Header{"X-Default": {"1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []Target{
+		{
+			Method: "GET",
+			URL:    "http://goku/a",
+			Body:   []byte("body"),
+			Header: http.Header{"X-Foo": {"bar"}, "X-Default": {"1"}},
+		},
+		{
+			Method: "GET",
+			URL:    "http://goku/b",
+			Body:   []byte("body"),
+			Header: http.Header{"X-Default": {"1"}},
+		},
+	} {
+		var got Target
+		if err := tr(&got); err != nil {
+			t.Fatal(err)
+		} else if !reflect.DeepEqual(got, want) {
+			t.Errorf("\ngot:  %+v\nwant: %+v", got, want)
+		}
+	}
+}
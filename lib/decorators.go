@@ -0,0 +1,90 @@
+package vegeta
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// Filter returns a Targeter which reads Targets from tr and skips those for
+// which keep returns false. It never returns if tr keeps returning Targets
+// which aren't kept, e.g. a static Targeter.
+func Filter(tr Targeter, keep func(*Target) bool) Targeter {
+	return func(tgt *Target) error {
+		for {
+			if err := tr(tgt); err != nil {
+				return err
+			} else if keep(tgt) {
+				return nil
+			}
+		}
+	}
+}
+
+// Map returns a Targeter which reads Targets from tr and modifies each of
+// them with fn, whose errors are returned.
+func Map(tr Targeter, fn func(*Target) error) Targeter {
+	return func(tgt *Target) error {
+		if err := tr(tgt); err != nil {
+			return err
+		}
+		return fn(tgt)
+	}
+}
+
+// RateSplit returns a Targeter which reads the given fraction of its Targets
+// from other and the rest from tr, evenly interleaved.
+func RateSplit(tr, other Targeter, fraction float64) (Targeter, error) {
+	if fraction < 0 || fraction > 1 {
+		return nil, fmt.Errorf("bad split fraction: %v", fraction)
+	}
+
+	const scale = 1000000
+	weight := uint64(fraction*scale + 0.5)
+
+	return NewWeightedTargeter([]Targeter{tr, other}, []uint64{scale - weight, weight})
+}
+
+// Take returns a Targeter which reads at most n Targets from tr and returns
+// ErrNoTargets afterwards.
+func Take(tr Targeter, n uint64) Targeter {
+	var taken uint64
+	return func(tgt *Target) error {
+		if atomic.AddUint64(&taken, 1) > n {
+			return ErrNoTargets
+		}
+		return tr(tgt)
+	}
+}
+
+// Concat returns a Targeter which reads Targets from each of the given
+// Targeters in turn, moving on to the next one once a Targeter returns
+// ErrNoTargets.
+func Concat(trs ...Targeter) Targeter {
+	var (
+		mu      sync.Mutex
+		current int
+	)
+
+	return func(tgt *Target) error {
+		for {
+			mu.Lock()
+			i := current
+			mu.Unlock()
+
+			if i >= len(trs) {
+				return ErrNoTargets
+			}
+
+			if err := trs[i](tgt); err != ErrNoTargets {
+				return err
+			}
+
+			mu.Lock()
+			if current == i {
+				current++
+			}
+			mu.Unlock()
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package vegeta
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// urls reads all Targets of tr and returns their URLs.
+func urls(t *testing.T, tr Targeter) []string {
+	t.Helper()
+
+	var got []string
+	for {
+		var tgt Target
+		if err := tr(&tgt); err == ErrNoTargets {
+			return got
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, tgt.URL)
+	}
+}
+
+func TestDecorators(t *testing.T) {
+	t.Parallel()
+
+	lazy := func() Targeter {
+		return NewLazyTargeter(strings.NewReader("GET http://goku/a\nGET http://goku/b\nPOST http://goku/c\n"), nil, nil)
+	}
+
+	for _, tc := range []struct {
+		name string
+		tr   Targeter
+		want []string
+	}{
+		{"filter", Filter(lazy(), func(t *Target) bool { return t.Method == "GET" }), []string{"http://goku/a", "http://goku/b"}},
+		{"map", Map(lazy(), func(t *Target) error { t.URL += "?v=1"; return nil }), []string{"http://goku/a?v=1", "http://goku/b?v=1", "http://goku/c?v=1"}},
+		{"take", Take(NewStaticTargeter(Target{Method: "GET", URL: "http://goku/a"}), 2), []string{"http://goku/a", "http://goku/a"}},
+		{"concat", Concat(Take(lazy(), 1), lazy()), []string{"http://goku/a", "http://goku/a", "http://goku/b", "http://goku/c"}},
+		{"composed", Take(Concat(lazy(), Filter(lazy(), func(t *Target) bool { return t.Method == "POST" })), 5), []string{"http://goku/a", "http://goku/b", "http://goku/c", "http://goku/c"}},
+	} {
+		if got := urls(t, tc.tr); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got: %v, want: %v", tc.name, got, tc.want)
+		}
+	}
+
+	boom := errors.New("boom")
+	tr := Map(lazy(), func(*Target) error { return boom })
+	if err := tr(&Target{}); err != boom {
+		t.Errorf("map: got error %v, want %v", err, boom)
+	}
+}
+
+func TestRateSplit(t *testing.T) {
+	t.Parallel()
+
+	a := NewStaticTargeter(Target{Method: "GET", URL: "http://goku/a"})
+	b := NewStaticTargeter(Target{Method: "GET", URL: "http://goku/b"})
+
+	tr, err := RateSplit(a, b, 0.25)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := urls(t, Take(tr, 8))
+	want := []string{"http://goku/a", "http://goku/a", "http://goku/b", "http://goku/a", "http://goku/a", "http://goku/a", "http://goku/b", "http://goku/a"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+
+	for _, fraction := range []float64{-0.1, 1.1} {
+		if _, err := RateSplit(a, b, fraction); err == nil {
+			t.Errorf("%v: want error, got none", fraction)
+		}
+	}
+}
@@ -0,0 +1,406 @@
+package vegeta
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// DNSQuery is a DNS question blueprint.
+type DNSQuery struct {
+	Name string
+	Type string
+}
+
+// A DNSTargeter decodes a DNSQuery or returns an error in case of failure.
+// Implementations must be safe for concurrent use.
+type DNSTargeter func(*DNSQuery) error
+
+// NewDNSTargeter eagerly reads all queries out of the provided io.Reader and
+// returns a DNSTargeter which round-robins over them.
+//
+// Each non empty line holds a domain name optionally followed by a query
+// type, which defaults to A:
+//
+//	example.com
+//	example.com AAAA
+func NewDNSTargeter(src io.Reader) (DNSTargeter, error) {
+	var qs []DNSQuery
+	sc := bufio.NewScanner(src)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		switch len(fields) {
+		case 0:
+			continue
+		case 1:
+			fields = append(fields, "A")
+		case 2:
+		default:
+			return nil, fmt.Errorf("bad query: %s", sc.Text())
+		}
+		q := DNSQuery{Name: fields[0], Type: strings.ToUpper(fields[1])}
+		if _, ok := dnsTypes[q.Type]; !ok {
+			return nil, fmt.Errorf("bad query type: %s", fields[1])
+		}
+		qs = append(qs, q)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(qs) == 0 {
+		return nil, ErrNoTargets
+	}
+
+	i := int64(-1)
+	return func(q *DNSQuery) error {
+		if q == nil {
+			return ErrNilTarget
+		}
+		*q = qs[atomic.AddInt64(&i, 1)%int64(len(qs))]
+		return nil
+	}, nil
+}
+
+// DNS transport protocols supported by a DNSAttacker.
+const (
+	// DNSOverUDP sends plain DNS queries over UDP.
+	DNSOverUDP = "udp"
+	// DNSOverTCP sends plain DNS queries over TCP.
+	DNSOverTCP = "tcp"
+	// DNSOverTLS sends DNS queries over TLS (RFC 7858).
+	DNSOverTLS = "tls"
+	// DNSOverHTTPS sends DNS queries over HTTPS (RFC 8484).
+	DNSOverHTTPS = "https"
+)
+
+// DNSAttacker is an attack executor which sends DNS queries to a single
+// resolver.
+type DNSAttacker struct {
+	server  string
+	proto   string
+	timeout time.Duration
+	workers uint64
+	tlsc    *tls.Config
+	client  http.Client
+	stopch  chan struct{}
+}
+
+// DefaultDNSServer is the default resolver address a DNSAttacker queries.
+const DefaultDNSServer = "127.0.0.1:53"
+
+// NewDNSAttacker returns a new DNSAttacker with default options which are
+// overridden by the optionally provided opts.
+func NewDNSAttacker(opts ...func(*DNSAttacker)) *DNSAttacker {
+	a := &DNSAttacker{
+		server:  DefaultDNSServer,
+		proto:   DNSOverUDP,
+		timeout: DefaultTimeout,
+		workers: DefaultWorkers,
+		tlsc:    DefaultTLSConfig,
+		stopch:  make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	a.client = http.Client{
+		Timeout:   a.timeout,
+		Transport: &http.Transport{TLSClientConfig: a.tlsc},
+	}
+
+	return a
+}
+
+// DNSServer returns a functional option which sets the resolver a DNSAttacker
+// sends its queries to. It's a host:port address for all protocols but
+// DNSOverHTTPS, for which it's the URL of the DoH endpoint.
+func DNSServer(addr string) func(*DNSAttacker) {
+	return func(a *DNSAttacker) { a.server = addr }
+}
+
+// DNSProtocol returns a functional option which sets the transport protocol
+// used by a DNSAttacker: one of DNSOverUDP, DNSOverTCP, DNSOverTLS or
+// DNSOverHTTPS.
+func DNSProtocol(proto string) func(*DNSAttacker) {
+	return func(a *DNSAttacker) { a.proto = proto }
+}
+
+// DNSTimeout returns a functional option which sets the maximum amount of
+// time a DNSAttacker waits for a query to be answered.
+func DNSTimeout(d time.Duration) func(*DNSAttacker) {
+	return func(a *DNSAttacker) { a.timeout = d }
+}
+
+// DNSWorkers returns a functional option which sets the initial number of
+// workers a DNSAttacker uses to send its queries.
+func DNSWorkers(n uint64) func(*DNSAttacker) {
+	return func(a *DNSAttacker) { a.workers = n }
+}
+
+// DNSTLSConfig returns a functional option which sets the *tls.Config used
+// with the DNSOverTLS and DNSOverHTTPS protocols.
+func DNSTLSConfig(c *tls.Config) func(*DNSAttacker) {
+	return func(a *DNSAttacker) { a.tlsc = c }
+}
+
+// Attack reads its queries from the passed DNSTargeter and sends them at the
+// rate specified for the given duration. When the duration is zero the attack
+// runs until Stop is called. Each Result has its Code field set to the
+// response RCODE and its Attack field set to the given name.
+func (a *DNSAttacker) Attack(tr DNSTargeter, rate uint64, du time.Duration, name string) <-chan *Result {
+	return Pace(ConstantPacer{Rate: rate, Duration: du}, a.workers, a.stopch, func(seq uint64) *Result {
+		return a.hit(tr, name, seq)
+	})
+}
+
+// Stop stops the current attack.
+func (a *DNSAttacker) Stop() {
+	select {
+	case <-a.stopch:
+		return
+	default:
+		close(a.stopch)
+	}
+}
+
+func (a *DNSAttacker) hit(tr DNSTargeter, name string, seq uint64) *Result {
+	var (
+		res = Result{Attack: name, Seq: seq}
+		q   DNSQuery
+		err error
+	)
+
+	defer func() {
+		if err != nil {
+			res.Error = err.Error()
+		}
+	}()
+
+	if err = tr(&q); err != nil {
+		a.Stop()
+		return &res
+	}
+
+	id := uint16(rand.Uint32())
+	if a.proto == DNSOverHTTPS {
+		id = 0 // RFC 8484, section 4.1
+	}
+
+	msg, err := dnsQuestion(id, q.Name, dnsTypes[q.Type])
+	if err != nil {
+		return &res
+	}
+
+	res.Timestamp = time.Now()
+	res.BytesOut = uint64(len(msg))
+
+	if res.Body, err = a.exchange(msg); err != nil {
+		return &res
+	}
+	res.Latency = time.Since(res.Timestamp)
+	res.BytesIn = uint64(len(res.Body))
+
+	rcode, err := dnsRcode(id, res.Body)
+	if err != nil {
+		return &res
+	}
+
+	if res.Code = rcode; rcode != 0 {
+		res.Error = dnsRcodeText(rcode)
+	}
+
+	return &res
+}
+
+func (a *DNSAttacker) exchange(msg []byte) ([]byte, error) {
+	switch a.proto {
+	case DNSOverUDP:
+		conn, err := net.DialTimeout("udp", a.server, a.timeout)
+		if err != nil {
+			return nil, err
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(a.timeout))
+		if _, err = conn.Write(msg); err != nil {
+			return nil, err
+		}
+		buf := make([]byte, 65535)
+		n, err := conn.Read(buf)
+		return buf[:n], err
+	case DNSOverTCP, DNSOverTLS:
+		var (
+			conn net.Conn
+			err  error
+		)
+		dialer := &net.Dialer{Timeout: a.timeout}
+		if a.proto == DNSOverTLS {
+			conn, err = tls.DialWithDialer(dialer, "tcp", a.server, a.tlsc)
+		} else {
+			conn, err = dialer.Dial("tcp", a.server)
+		}
+		if err != nil {
+			return nil, err
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(a.timeout))
+		framed := make([]byte, 2+len(msg))
+		binary.BigEndian.PutUint16(framed, uint16(len(msg)))
+		copy(framed[2:], msg)
+		if _, err = conn.Write(framed); err != nil {
+			return nil, err
+		}
+		var size uint16
+		if err = binary.Read(conn, binary.BigEndian, &size); err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size)
+		_, err = io.ReadFull(conn, buf)
+		return buf, err
+	case DNSOverHTTPS:
+		req, err := http.NewRequest("POST", a.server, bytes.NewReader(msg))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/dns-message")
+		req.Header.Set("Accept", "application/dns-message")
+		r, err := a.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer r.Body.Close()
+		body, err := ioutil.ReadAll(r.Body)
+		if err == nil && r.StatusCode != http.StatusOK {
+			err = errors.New(r.Status)
+		}
+		return body, err
+	default:
+		return nil, fmt.Errorf("unsupported DNS protocol: %s", a.proto)
+	}
+}
+
+var dnsTypes = map[string]uint16{
+	"A":     1,
+	"NS":    2,
+	"CNAME": 5,
+	"SOA":   6,
+	"PTR":   12,
+	"MX":    15,
+	"TXT":   16,
+	"AAAA":  28,
+	"SRV":   33,
+	"ANY":   255,
+}
+
+var dnsRcodes = [...]string{
+	"NOERROR", "FORMERR", "SERVFAIL", "NXDOMAIN", "NOTIMP", "REFUSED",
+	"YXDOMAIN", "YXRRSET", "NXRRSET", "NOTAUTH", "NOTZONE",
+}
+
+func dnsRcodeText(rcode uint16) string {
+	if int(rcode) < len(dnsRcodes) {
+		return dnsRcodes[rcode]
+	}
+	return fmt.Sprintf("RCODE%d", rcode)
+}
+
+// dnsQuestion encodes a recursive DNS query message with a single question
+// for the given name and type in the IN class.
+func dnsQuestion(id uint16, name string, qtype uint16) ([]byte, error) {
+	var buf bytes.Buffer
+
+	// ID, flags (RD), QDCOUNT, ANCOUNT, NSCOUNT, ARCOUNT
+	for _, v := range []uint16{id, 0x0100, 1, 0, 0, 0} {
+		binary.Write(&buf, binary.BigEndian, v)
+	}
+
+	for _, label := range strings.Split(strings.TrimSuffix(name, "."), ".") {
+		if len(label) == 0 || len(label) > 63 {
+			return nil, fmt.Errorf("bad domain name: %s", name)
+		}
+		buf.WriteByte(byte(len(label)))
+		buf.WriteString(label)
+	}
+	buf.WriteByte(0)
+
+	binary.Write(&buf, binary.BigEndian, qtype)
+	binary.Write(&buf, binary.BigEndian, uint16(1)) // IN
+
+	return buf.Bytes(), nil
+}
+
+// dnsRcode validates the header of the given DNS response message and
+// returns its RCODE.
+func dnsRcode(id uint16, msg []byte) (uint16, error) {
+	if len(msg) < 12 {
+		return 0, errors.New("short DNS response")
+	}
+	if got := binary.BigEndian.Uint16(msg); got != id {
+		return 0, fmt.Errorf("DNS response ID mismatch: got %d, want %d", got, id)
+	}
+	flags := binary.BigEndian.Uint16(msg[2:])
+	if flags&0x8000 == 0 {
+		return 0, errors.New("DNS message is not a response")
+	}
+	return flags & 0x000f, nil
+}
+
+// dnsAddrs returns the addresses of the A and AAAA records in the answer
+// section of the given DNS response message.
+func dnsAddrs(msg []byte) ([]string, error) {
+	qd, an := binary.BigEndian.Uint16(msg[4:]), binary.BigEndian.Uint16(msg[6:])
+
+	off := 12
+	for i := 0; i < int(qd); i++ {
+		if off = dnsSkipName(msg, off) + 4; off > len(msg) {
+			return nil, errors.New("short DNS response")
+		}
+	}
+
+	var addrs []string
+	for i := 0; i < int(an); i++ {
+		if off = dnsSkipName(msg, off) + 10; off > len(msg) {
+			return nil, errors.New("short DNS response")
+		}
+
+		typ := binary.BigEndian.Uint16(msg[off-10:])
+		size := int(binary.BigEndian.Uint16(msg[off-2:]))
+		if off+size > len(msg) {
+			return nil, errors.New("short DNS response")
+		}
+
+		if rdata := msg[off : off+size]; typ == dnsTypes["A"] && size == 4 || typ == dnsTypes["AAAA"] && size == 16 {
+			addrs = append(addrs, net.IP(rdata).String())
+		}
+		off += size
+	}
+
+	return addrs, nil
+}
+
+// dnsSkipName returns the offset following the possibly compressed domain
+// name at the given offset of msg, or len(msg)+1 if it's truncated.
+func dnsSkipName(msg []byte, off int) int {
+	for off < len(msg) {
+		switch n := int(msg[off]); {
+		case n == 0:
+			return off + 1
+		case n&0xc0 == 0xc0:
+			return off + 2
+		default:
+			off += n + 1
+		}
+	}
+	return len(msg) + 1
+}
@@ -0,0 +1,137 @@
+package vegeta
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewDNSTargeter(t *testing.T) {
+	t.Parallel()
+
+	src := strings.NewReader("example.com\n\nexample.org aaaa\n")
+	tr, err := NewDNSTargeter(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []DNSQuery{
+		{Name: "example.com", Type: "A"},
+		{Name: "example.org", Type: "AAAA"},
+		{Name: "example.com", Type: "A"},
+	} {
+		var got DNSQuery
+		if err := tr(&got); err != nil {
+			t.Fatal(err)
+		} else if !reflect.DeepEqual(got, want) {
+			t.Errorf("got: %+v, want: %+v", got, want)
+		}
+	}
+
+	for _, bad := range []string{"", "example.com BOGUS", "a b c"} {
+		if _, err := NewDNSTargeter(strings.NewReader(bad)); err == nil {
+			t.Errorf("%q: want error, got none", bad)
+		}
+	}
+}
+
+// dnsReply answers the given query with the given rcode and no records.
+func dnsReply(query []byte, rcode uint16) []byte {
+	reply := append([]byte(nil), query...)
+	binary.BigEndian.PutUint16(reply[2:], 0x8180|rcode)
+	return reply
+}
+
+func TestDNSAttackerUDP(t *testing.T) {
+	t.Parallel()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			rcode := uint16(0)
+			if strings.Contains(string(buf[:n]), "missing") {
+				rcode = 3
+			}
+			conn.WriteTo(dnsReply(buf[:n], rcode), addr)
+		}
+	}()
+
+	tr, err := NewDNSTargeter(strings.NewReader("example.com\nmissing.example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	atk := NewDNSAttacker(DNSServer(conn.LocalAddr().String()), DNSTimeout(time.Second))
+	for i, want := range []struct {
+		code uint16
+		err  string
+	}{
+		{0, ""},
+		{3, "NXDOMAIN"},
+	} {
+		res := atk.hit(tr, "dns", uint64(i))
+		if res.Code != want.code || res.Error != want.err {
+			t.Errorf("got: (%d, %q), want: (%d, %q)", res.Code, res.Error, want.code, want.err)
+		}
+		if res.BytesOut == 0 || res.BytesIn == 0 {
+			t.Errorf("want non zero bytes, got out=%d in=%d", res.BytesOut, res.BytesIn)
+		}
+	}
+}
+
+func TestDNSAttackerTCP(t *testing.T) {
+	t.Parallel()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			var size uint16
+			binary.Read(conn, binary.BigEndian, &size)
+			query := make([]byte, size)
+			io.ReadFull(conn, query)
+			reply := dnsReply(query, 2)
+			binary.Write(conn, binary.BigEndian, uint16(len(reply)))
+			conn.Write(reply)
+			conn.Close()
+		}
+	}()
+
+	tr, err := NewDNSTargeter(strings.NewReader("example.com MX"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	atk := NewDNSAttacker(
+		DNSServer(ln.Addr().String()),
+		DNSProtocol(DNSOverTCP),
+		DNSTimeout(time.Second),
+	)
+
+	res := atk.hit(tr, "", 0)
+	if got, want := res.Error, "SERVFAIL"; got != want {
+		t.Fatalf("got: %v, want: %v", got, want)
+	}
+}
@@ -0,0 +1,34 @@
+// Package vegeta is the library behind the vegeta command, made of parts
+// which custom load testing binaries are assembled from:
+//
+//	Targeters  produce the Targets of an attack, e.g. NewLazyTargeter,
+//	           NewLazyJSONTargeter or NewStaticTargeter, and are composed
+//	           with Filter, Map, Take, Concat or RateSplit.
+//	Pacers     define the times at which an attack hits, e.g.
+//	           ConstantPacer, and live in the pacing subpackage, which
+//	           only depends on the standard library. Pace drives any hit
+//	           function at those times.
+//	Attackers  hit Targets or Scenarios, configured with functional
+//	           options such as Timeout or Scripted. DNSAttacker and
+//	           ProbeAttacker hit DNS servers and TCP ports.
+//	Encoders   write Results, e.g. NewEncoder, NewCSVEncoder and
+//	           NewJSONEncoder, and Decoders read them back.
+//	Reports    accumulate Results, e.g. Metrics, Histogram or Results, and
+//	           Reporters write them out, e.g. NewTextReporter.
+//
+// Parts which moved to subpackages keep aliases in this package, so that
+// code importing them from here keeps working.
+//
+// A minimal binary attacking a single target and reporting its metrics is:
+//
+//	tr := vegeta.NewStaticTargeter(vegeta.Target{Method: "GET", URL: "http://localhost/"})
+//	atk := vegeta.NewAttacker(vegeta.Timeout(5 * time.Second))
+//
+//	var m vegeta.Metrics
+//	for res := range atk.Attack(tr, 100, 10*time.Second, "") {
+//		m.Add(res)
+//	}
+//	m.Close()
+//
+//	vegeta.NewTextReporter(&m).Report(os.Stdout)
+package vegeta
@@ -0,0 +1,50 @@
+package vegeta
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+	"sync"
+)
+
+// NewExecTargeter starts the given command and returns a Targeter which
+// streams Targets from its standard output with the Targeter returned by
+// lazy, such as NewLazyTargeter. This allows generating Targets dynamically
+// with programs written in any language.
+//
+// Once the command's output is exhausted, the Targeter waits for it to exit
+// and returns ErrNoTargets, or the error the command exited with. Callers are
+// responsible for killing the command if the attack ends before it exits.
+func NewExecTargeter(cmd *exec.Cmd, lazy func(io.Reader) Targeter) (Targeter, error) {
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	var (
+		tr   = lazy(stdout)
+		once sync.Once
+		werr error
+	)
+
+	return func(tgt *Target) error {
+		err := tr(tgt)
+		if err != ErrNoTargets {
+			return err
+		}
+
+		once.Do(func() {
+			if werr = cmd.Wait(); werr != nil {
+				werr = fmt.Errorf("targets command: %s", werr)
+			} else {
+				werr = ErrNoTargets
+			}
+		})
+
+		return werr
+	}, nil
+}
@@ -0,0 +1,49 @@
+package vegeta
+
+import (
+	"io"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestNewExecTargeter(t *testing.T) {
+	t.Parallel()
+
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	lazy := func(r io.Reader) Targeter { return NewLazyTargeter(r, nil, nil) }
+
+	tr, err := NewExecTargeter(exec.Command("sh", "-c", `for i in 1 2 3; do echo "GET http://goku/$i"; done`), lazy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"http://goku/1", "http://goku/2", "http://goku/3"} {
+		var tgt Target
+		if err := tr(&tgt); err != nil {
+			t.Fatal(err)
+		} else if tgt.URL != want {
+			t.Errorf("got: %s, want: %s", tgt.URL, want)
+		}
+	}
+
+	if err := tr(&Target{}); err != ErrNoTargets {
+		t.Errorf("got: %v, want: %v", err, ErrNoTargets)
+	}
+
+	tr, err = NewExecTargeter(exec.Command("sh", "-c", "exit 3"), lazy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tr(&Target{}); err == nil || !strings.Contains(err.Error(), "exit status 3") {
+		t.Errorf("got: %v, want exit status error", err)
+	}
+
+	if _, err := NewExecTargeter(exec.Command("/does/not/exist"), lazy); err == nil {
+		t.Error("want error for missing command, got none")
+	}
+}
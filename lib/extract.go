@@ -0,0 +1,124 @@
+package vegeta
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrNoMatch is returned by Extractors which found nothing to extract.
+var ErrNoMatch = errors.New("no match")
+
+// An Extractor extracts a named value out of responses.
+type Extractor struct {
+	// Name is the name of the extracted value.
+	Name string
+	// Source is where the value is extracted from: "header", "json" or
+	// "regex".
+	Source string
+	// Expr is the name of the header, the dot separated path of the value
+	// in the JSON body, e.g. data.items.0.id, or the regular expression
+	// matched against the body whose first subexpression, or whole match
+	// when it has none, is extracted.
+	Expr string
+
+	re *regexp.Regexp
+}
+
+// NewExtractor returns an Extractor of the value with the given name defined
+// by spec, which is formatted as source:expr, e.g. json:auth.token,
+// header:Location or regex:id=(\d+).
+func NewExtractor(name, spec string) (*Extractor, error) {
+	parts := strings.SplitN(spec, ":", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return nil, fmt.Errorf("bad extractor %s: %q", name, spec)
+	}
+
+	e := &Extractor{Name: name, Source: parts[0], Expr: parts[1]}
+	switch e.Source {
+	case "header", "json":
+	case "regex":
+		re, err := regexp.Compile(e.Expr)
+		if err != nil {
+			return nil, fmt.Errorf("bad extractor %s: %s", name, err)
+		}
+		e.re = re
+	default:
+		return nil, fmt.Errorf("bad extractor %s: unknown source %q", name, e.Source)
+	}
+
+	return e, nil
+}
+
+// Extractors returns a functional option which makes an Attacker record the
+// values extracted from responses by the given Extractors in the Extract
+// field of Results, e.g. to break down latencies by the shard which served
+// them. Values which aren't found aren't recorded.
+func Extractors(es ...*Extractor) func(*Attacker) {
+	return func(a *Attacker) { a.extractors = es }
+}
+
+// Extract extracts the Extractor's value out of the given response and its
+// body.
+func (e *Extractor) Extract(r *http.Response, body []byte) (string, error) {
+	switch e.Source {
+	case "header":
+		if v := r.Header.Get(e.Expr); v != "" {
+			return v, nil
+		}
+	case "json":
+		return extractJSON(body, e.Expr)
+	case "regex":
+		if m := e.re.FindSubmatch(body); len(m) > 1 {
+			return string(m[1]), nil
+		} else if len(m) == 1 {
+			return string(m[0]), nil
+		}
+	}
+	return "", ErrNoMatch
+}
+
+// extractJSON returns the value at the given dot separated path of the JSON
+// document in body. Strings are returned as is, other values JSON encoded.
+func extractJSON(body []byte, path string) (string, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return "", fmt.Errorf("bad JSON body: %s", err)
+	}
+
+	for _, key := range strings.Split(strings.TrimPrefix(path, "$."), ".") {
+		switch node := v.(type) {
+		case map[string]interface{}:
+			var ok bool
+			if v, ok = node[key]; !ok {
+				return "", ErrNoMatch
+			}
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return "", ErrNoMatch
+			}
+			v = node[i]
+		default:
+			return "", ErrNoMatch
+		}
+	}
+
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	default:
+		bs, err := json.Marshal(v)
+		return string(bs), err
+	}
+}
@@ -0,0 +1,73 @@
+package vegeta
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestExtractor(t *testing.T) {
+	t.Parallel()
+
+	r := &http.Response{Header: http.Header{"Location": []string{"/things/1"}}}
+	body := []byte(`{"data": {"items": [{"id": 7, "tags": ["a"]}]}, "name": "goku"}`)
+
+	for _, tc := range []struct {
+		spec string
+		want string
+		err  string
+	}{
+		{"header:Location", "/things/1", ""},
+		{"header:Etag", "", "no match"},
+		{"json:name", "goku", ""},
+		{"json:$.data.items.0.id", "7", ""},
+		{"json:data.items.0.tags", `["a"]`, ""},
+		{"json:data.items.1.id", "", "no match"},
+		{"regex:\"id\": (\\d+)", "7", ""},
+		{"regex:goku", "goku", ""},
+		{"regex:vegeta", "", "no match"},
+	} {
+		e, err := NewExtractor("v", tc.spec)
+		if err != nil {
+			t.Fatalf("%s: %s", tc.spec, err)
+		}
+
+		got, err := e.Extract(r, body)
+		if got != tc.want || (err == nil) != (tc.err == "") || err != nil && err.Error() != tc.err {
+			t.Errorf("%s: got (%q, %v), want (%q, %q)", tc.spec, got, err, tc.want, tc.err)
+		}
+	}
+
+	for _, spec := range []string{"json", "json:", "xpath:/a", "regex:("} {
+		if _, err := NewExtractor("v", spec); err == nil {
+			t.Errorf("%s: want error, got none", spec)
+		}
+	}
+}
+
+func TestExtractors(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Shard", "eu-1")
+		w.Write([]byte(`{"version": 3}`))
+	}))
+	defer server.Close()
+
+	var es []*Extractor
+	for name, spec := range map[string]string{"shard": "header:X-Shard", "version": "json:version", "missing": "json:nope"} {
+		e, err := NewExtractor(name, spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		es = append(es, e)
+	}
+
+	atk := NewAttacker(Extractors(es...))
+	res := atk.hit(NewStaticTargeter(Target{Method: "GET", URL: server.URL}), "", 0)
+
+	if want := map[string]string{"shard": "eu-1", "version": "3"}; !reflect.DeepEqual(res.Extract, want) {
+		t.Errorf("got %v, want %v", res.Extract, want)
+	}
+}
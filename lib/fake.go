@@ -0,0 +1,108 @@
+package vegeta
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// Word lists fake data is generated from.
+var (
+	fakeFirstNames = []string{
+		"Ada", "Alan", "Alice", "Amara", "Ana", "Bruno", "Carlos", "Chen", "Chloe",
+		"Daniel", "Elena", "Emma", "Fatima", "Grace", "Hana", "Hugo", "Ivan",
+		"Jamal", "Jin", "Julia", "Kenji", "Lara", "Leo", "Lina", "Lucas", "Maria",
+		"Mateo", "Maya", "Mohammed", "Nadia", "Noah", "Olga", "Omar", "Priya",
+		"Rafael", "Sakura", "Sara", "Sofia", "Tariq", "Yusuf", "Zoe",
+	}
+	fakeLastNames = []string{
+		"Adams", "Almeida", "Andersen", "Brown", "Chen", "Costa", "Dubois",
+		"Fischer", "Garcia", "Gonzalez", "Hansen", "Ivanov", "Jensen", "Johnson",
+		"Khan", "Kim", "Kowalski", "Lee", "Lopez", "Martin", "Meyer", "Nakamura",
+		"Nguyen", "Novak", "Okafor", "Patel", "Rossi", "Santos", "Schmidt", "Silva",
+		"Smith", "Tanaka", "Williams", "Wright", "Yilmaz", "Zhang",
+	}
+	fakeStreets = []string{
+		"Main St", "Oak Ave", "Pine Rd", "Maple Dr", "Cedar Ln", "Elm St",
+		"Lake View Rd", "Hill St", "Park Ave", "River Rd", "Sunset Blvd",
+		"Church St", "High St", "Station Rd", "Mill Ln",
+	}
+	fakeCities = []string{
+		"Amsterdam", "Austin", "Berlin", "Buenos Aires", "Cape Town", "Chicago",
+		"Dublin", "Lagos", "Lisbon", "London", "Madrid", "Melbourne", "Mumbai",
+		"Nairobi", "Osaka", "Paris", "Prague", "Seoul", "Singapore", "Stockholm",
+		"São Paulo", "Toronto", "Vancouver", "Warsaw",
+	}
+	fakeDomains         = []string{"example.com", "example.net", "example.org"}
+	fakeCompanySuffixes = []string{"Inc", "LLC", "Ltd", "GmbH", "Group", "Labs"}
+	fakeLoremWords      = strings.Fields(`lorem ipsum dolor sit amet consectetur
+		adipiscing elit sed do eiusmod tempor incididunt ut labore et dolore magna
+		aliqua enim ad minim veniam quis nostrud exercitation ullamco laboris nisi
+		aliquip ex ea commodo consequat duis aute irure in reprehenderit voluptate
+		velit esse cillum fugiat nulla pariatur excepteur sint occaecat cupidatat
+		non proident sunt culpa qui officia deserunt mollit anim id est laborum`)
+)
+
+func fakePick(words []string) string { return words[rand.Intn(len(words))] }
+
+func fakeFirstName() string { return fakePick(fakeFirstNames) }
+
+func fakeLastName() string { return fakePick(fakeLastNames) }
+
+func fakeName() string { return fakeFirstName() + " " + fakeLastName() }
+
+func fakeEmail() string {
+	return fmt.Sprintf("%s.%s%d@%s",
+		strings.ToLower(fakeFirstName()),
+		strings.ToLower(fakeLastName()),
+		rand.Intn(1000),
+		fakePick(fakeDomains),
+	)
+}
+
+func fakePhone() string {
+	return fmt.Sprintf("+1-%03d-555-%04d", 200+rand.Intn(800), rand.Intn(10000))
+}
+
+func fakeStreet() string {
+	return fmt.Sprintf("%d %s", 1+rand.Intn(9999), fakePick(fakeStreets))
+}
+
+func fakeCity() string { return fakePick(fakeCities) }
+
+func fakeZip() string { return fmt.Sprintf("%05d", rand.Intn(100000)) }
+
+func fakeAddress() string {
+	return fmt.Sprintf("%s, %s %s", fakeStreet(), fakeZip(), fakeCity())
+}
+
+func fakeCompany() string {
+	return fakeLastName() + " " + fakePick(fakeCompanySuffixes)
+}
+
+// lorem returns n random lorem ipsum words.
+func lorem(n int) (string, error) {
+	if n < 0 {
+		return "", fmt.Errorf("lorem: negative length %d", n)
+	}
+	words := make([]string, n)
+	for i := range words {
+		words[i] = fakePick(fakeLoremWords)
+	}
+	return strings.Join(words, " "), nil
+}
+
+// loremSentence returns a capitalized sentence of 4 to 12 lorem ipsum words.
+func loremSentence() string {
+	s, _ := lorem(4 + rand.Intn(9))
+	return strings.ToUpper(s[:1]) + s[1:] + "."
+}
+
+// loremParagraph returns a paragraph of 3 to 6 lorem ipsum sentences.
+func loremParagraph() string {
+	sentences := make([]string, 3+rand.Intn(4))
+	for i := range sentences {
+		sentences[i] = loremSentence()
+	}
+	return strings.Join(sentences, " ")
+}
@@ -0,0 +1,53 @@
+package vegeta
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestFakeTemplateFuncs(t *testing.T) {
+	t.Parallel()
+
+	body := `{"name": "{{fakeName}}", "email": "{{fakeEmail}}", "phone": "{{fakePhone}}",` +
+		` "company": "{{fakeCompany}}", "address": "{{fakeAddress}}", "bio": "{{loremParagraph}}",` +
+		` "tags": "{{lorem 3}}"}`
+
+	tr := NewTemplateTargeter(NewStaticTargeter(Target{Method: "POST", URL: "http://goku", Body: []byte(body)}))
+
+	email := regexp.MustCompile(`^[a-z]+\.[a-z]+\d{1,3}@example\.(com|net|org)$`)
+	emails := map[string]bool{}
+	for i := 0; i < 20; i++ {
+		var tgt Target
+		if err := tr(&tgt); err != nil {
+			t.Fatal(err)
+		}
+
+		var got map[string]string
+		if err := json.Unmarshal(tgt.Body, &got); err != nil {
+			t.Fatalf("bad body %s: %s", tgt.Body, err)
+		}
+
+		if !email.MatchString(got["email"]) {
+			t.Errorf("bad email: %s", got["email"])
+		}
+		emails[got["email"]] = true
+
+		if len(strings.Fields(got["name"])) != 2 {
+			t.Errorf("bad name: %s", got["name"])
+		}
+
+		if n := len(strings.Fields(got["tags"])); n != 3 {
+			t.Errorf("got %d lorem words, want 3", n)
+		}
+
+		if !strings.HasSuffix(got["bio"], ".") {
+			t.Errorf("bad paragraph: %s", got["bio"])
+		}
+	}
+
+	if len(emails) < 2 {
+		t.Errorf("fake data isn't random: %v", emails)
+	}
+}
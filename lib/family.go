@@ -0,0 +1,136 @@
+package vegeta
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+)
+
+// IP address families an Attacker dials target hosts with.
+const (
+	// AnyFamily dials the resolved addresses of any family one after the
+	// other, in the order set by IPBalancing.
+	AnyFamily = "any"
+	// IPv4Only only dials IPv4 addresses.
+	IPv4Only = "ipv4"
+	// IPv6Only only dials IPv6 addresses.
+	IPv6Only = "ipv6"
+	// HappyEyeballs races the addresses of both families, alternating
+	// between them starting with IPv6, as per RFC 8305.
+	HappyEyeballs = "happy-eyeballs"
+)
+
+// HappyEyeballsDelay is the time after which HappyEyeballs dialing starts
+// dialing the next address while the previous ones are still connecting, as
+// recommended by RFC 8305.
+const HappyEyeballsDelay = 250 * time.Millisecond
+
+// IPFamily returns a functional option which sets the IP address families an
+// Attacker dials target hosts with: AnyFamily, the default, IPv4Only,
+// IPv6Only or HappyEyeballs.
+func IPFamily(family string) func(*Attacker) {
+	return func(a *Attacker) { a.family = family }
+}
+
+// errNoFamilyAddrs is returned when a host has no addresses of the family
+// dialed.
+var errNoFamilyAddrs = errors.New("no addresses of the dialed family")
+
+// filterFamily returns the given addresses of the given family.
+func filterFamily(family string, ips []string) ([]string, error) {
+	if family != IPv4Only && family != IPv6Only {
+		return ips, nil
+	}
+
+	filtered := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		if v4 := net.ParseIP(ip).To4() != nil; v4 == (family == IPv4Only) {
+			filtered = append(filtered, ip)
+		}
+	}
+
+	if len(filtered) == 0 {
+		return nil, errNoFamilyAddrs
+	}
+
+	return filtered, nil
+}
+
+// interleave returns the given addresses alternating between IPv6 and IPv4
+// ones, starting with IPv6, and otherwise in the same order.
+func interleave(ips []string) []string {
+	var v4, v6 []string
+	for _, ip := range ips {
+		if net.ParseIP(ip).To4() != nil {
+			v4 = append(v4, ip)
+		} else {
+			v6 = append(v6, ip)
+		}
+	}
+
+	out := make([]string, 0, len(ips))
+	for i := 0; i < len(v4) || i < len(v6); i++ {
+		if i < len(v6) {
+			out = append(out, v6[i])
+		}
+		if i < len(v4) {
+			out = append(out, v4[i])
+		}
+	}
+
+	return out
+}
+
+// race dials the given addresses with the given port, starting a new attempt
+// every HappyEyeballsDelay or as soon as the previous one fails, and returns
+// the first established connection along with its address. The others are
+// closed.
+func (a *Attacker) race(ctx context.Context, network, port string, ips []string) (net.Conn, string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type attempt struct {
+		conn net.Conn
+		ip   string
+		err  error
+	}
+
+	var (
+		attempts = make(chan attempt, len(ips))
+		next     <-chan time.Time
+		pending  int
+		err      error
+	)
+
+	for i := 0; i < len(ips) || pending > 0; {
+		if i < len(ips) && (pending == 0 || next == nil) {
+			ip := ips[i]
+			go func() {
+				conn, err := a.dial(ctx, network, ip, port)
+				attempts <- attempt{conn, ip, err}
+			}()
+			i, pending, next = i+1, pending+1, time.After(HappyEyeballsDelay)
+		}
+
+		select {
+		case at := <-attempts:
+			if pending--; at.err == nil {
+				go func(pending int) {
+					for ; pending > 0; pending-- {
+						if at := <-attempts; at.conn != nil {
+							at.conn.Close()
+						}
+					}
+				}(pending)
+				return at.conn, at.ip, nil
+			}
+			// Failures start the next attempt right away.
+			err, next = at.err, nil
+		case <-next:
+			next = nil
+		}
+	}
+
+	return nil, "", err
+}
@@ -0,0 +1,70 @@
+package vegeta
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestIPFamily(t *testing.T) {
+	t.Parallel()
+
+	ips := []string{"10.0.0.1", "::1", "10.0.0.2", "fe80::1", "10.0.0.3"}
+	for _, tc := range []struct {
+		family string
+		want   []string
+	}{
+		{AnyFamily, ips},
+		{IPv4Only, []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}},
+		{IPv6Only, []string{"::1", "fe80::1"}},
+	} {
+		if got, err := filterFamily(tc.family, ips); err != nil || !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got %v, %v, want %v", tc.family, got, err, tc.want)
+		}
+	}
+
+	if _, err := filterFamily(IPv6Only, []string{"10.0.0.1"}); err != errNoFamilyAddrs {
+		t.Errorf("got error %v, want %v", err, errNoFamilyAddrs)
+	}
+
+	want := []string{"::1", "10.0.0.1", "fe80::1", "10.0.0.2", "10.0.0.3"}
+	if got := interleave(ips); !reflect.DeepEqual(got, want) {
+		t.Errorf("got interleaved %v, want %v", got, want)
+	}
+}
+
+func TestHappyEyeballs(t *testing.T) {
+	t.Parallel()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	_, port, _ := net.SplitHostPort(ln.Addr().String())
+	atk := NewAttacker(IPFamily(HappyEyeballs))
+
+	// Nothing listens on the first address, whose failure starts dialing the
+	// second one without waiting for HappyEyeballsDelay.
+	began := time.Now()
+	conn, ip, err := atk.race(context.Background(), "tcp", port, []string{"127.0.0.2", "127.0.0.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if ip != "127.0.0.1" {
+		t.Errorf("got connection to %s, want 127.0.0.1", ip)
+	}
+
+	if took := time.Since(began); took >= HappyEyeballsDelay {
+		t.Errorf("got connection after %s", took)
+	}
+
+	if _, _, err = atk.race(context.Background(), "tcp", "1", []string{"127.0.0.1"}); err == nil {
+		t.Error("got no error dialing a closed port")
+	}
+}
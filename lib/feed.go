@@ -0,0 +1,110 @@
+package vegeta
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// A Feed returns the variables of the next record of a data feed, or io.EOF
+// when it's exhausted. Implementations must be safe for concurrent use.
+type Feed func() (map[string]interface{}, error)
+
+// NewCSVFeed eagerly reads all records out of the provided CSV io.Reader,
+// whose first row holds the variable names of each column, and returns a
+// Feed which yields them in order. When cyclic is true, the Feed starts over
+// once it yields the last record. Otherwise each record is yielded once.
+func NewCSVFeed(src io.Reader, cyclic bool) (Feed, error) {
+	rows, err := csv.NewReader(src).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("bad feed: %s", err)
+	} else if len(rows) < 2 {
+		return nil, fmt.Errorf("bad feed: no records")
+	}
+
+	names, records := rows[0], make([]map[string]interface{}, 0, len(rows)-1)
+	for _, row := range rows[1:] {
+		rec := make(map[string]interface{}, len(names))
+		for i, name := range names {
+			rec[name] = row[i]
+		}
+		records = append(records, rec)
+	}
+
+	return newFeed(records, cyclic), nil
+}
+
+// NewJSONFeed eagerly reads all records out of the provided io.Reader, as a
+// stream of JSON objects, usually one per line, and returns a Feed which
+// yields them in order. When cyclic is true, the Feed starts over once it
+// yields the last record. Otherwise each record is yielded once.
+func NewJSONFeed(src io.Reader, cyclic bool) (Feed, error) {
+	dec := json.NewDecoder(src)
+	dec.UseNumber()
+
+	var records []map[string]interface{}
+	for {
+		var rec map[string]interface{}
+		if err := dec.Decode(&rec); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("bad feed: %s", err)
+		}
+		records = append(records, rec)
+	}
+
+	if len(records) == 0 {
+		return nil, fmt.Errorf("bad feed: no records")
+	}
+
+	return newFeed(records, cyclic), nil
+}
+
+func newFeed(records []map[string]interface{}, cyclic bool) Feed {
+	var (
+		mu sync.Mutex
+		i  int
+	)
+
+	return func() (map[string]interface{}, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if i == len(records) {
+			if !cyclic {
+				return nil, io.EOF
+			}
+			i = 0
+		}
+
+		rec := records[i]
+		i++
+
+		return rec, nil
+	}
+}
+
+// NewFeedTargeter returns a Targeter which executes the URL, header values
+// and body of every Target read from tr as text/template templates with the
+// variables of the next record of the given Feed, e.g.
+// http://goku/users/{{.user_id}}. It returns ErrNoTargets once the Feed is
+// exhausted. See NewTemplateTargeter for the built-in template functions.
+func NewFeedTargeter(tr Targeter, feed Feed) Targeter {
+	tmpls := templateCache{funcs: templateFuncs}
+	return func(tgt *Target) error {
+		if err := tr(tgt); err != nil {
+			return err
+		}
+
+		vars, err := feed()
+		if err == io.EOF {
+			return ErrNoTargets
+		} else if err != nil {
+			return err
+		}
+
+		return tmpls.executeTarget(tgt, vars)
+	}
+}
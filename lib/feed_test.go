@@ -0,0 +1,102 @@
+package vegeta
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNewFeedTargeter(t *testing.T) {
+	t.Parallel()
+
+	tgt := Target{
+		Method: "POST",
+		URL:    "http://goku/users/{{.id}}",
+		Header: http.Header{"X-Tenant": {"{{.tenant}}"}, "Accept": {"application/json"}},
+		Body:   []byte(`{"name": "{{.name}}"}`),
+	}
+
+	for _, tc := range []struct {
+		name string
+		feed func(cyclic bool) (Feed, error)
+	}{
+		{"csv", func(cyclic bool) (Feed, error) {
+			return NewCSVFeed(strings.NewReader("id,tenant,name\n1,a,goku\n2,b,vegeta\n"), cyclic)
+		}},
+		{"json", func(cyclic bool) (Feed, error) {
+			return NewJSONFeed(strings.NewReader(`{"id": 1, "tenant": "a", "name": "goku"}
+{"id": 2, "tenant": "b", "name": "vegeta"}
+`), cyclic)
+		}},
+	} {
+		for _, cyclic := range []bool{true, false} {
+			feed, err := tc.feed(cyclic)
+			if err != nil {
+				t.Fatalf("%s: %s", tc.name, err)
+			}
+
+			tr := NewFeedTargeter(NewStaticTargeter(tgt), feed)
+			for i, want := range []Target{
+				{
+					Method: "POST",
+					URL:    "http://goku/users/1",
+					Header: http.Header{"X-Tenant": {"a"}, "Accept": {"application/json"}},
+					Body:   []byte(`{"name": "goku"}`),
+				},
+				{
+					Method: "POST",
+					URL:    "http://goku/users/2",
+					Header: http.Header{"X-Tenant": {"b"}, "Accept": {"application/json"}},
+					Body:   []byte(`{"name": "vegeta"}`),
+				},
+			} {
+				var got Target
+				if err := tr(&got); err != nil {
+					t.Fatalf("%s: hit %d: %s", tc.name, i, err)
+				} else if !reflect.DeepEqual(got, want) {
+					t.Errorf("%s: hit %d:\ngot:  %+v\nwant: %+v", tc.name, i, got, want)
+				}
+			}
+
+			var got Target
+			if err := tr(&got); cyclic && (err != nil || got.URL != "http://goku/users/1") {
+				t.Errorf("%s: cyclic feed didn't start over: %v, %s", tc.name, err, got.URL)
+			} else if !cyclic && err != ErrNoTargets {
+				t.Errorf("%s: got: %v, want: %v", tc.name, err, ErrNoTargets)
+			}
+		}
+	}
+
+	if tgt.URL != "http://goku/users/{{.id}}" || tgt.Header.Get("X-Tenant") != "{{.tenant}}" {
+		t.Errorf("original target was modified: %+v", tgt)
+	}
+}
+
+func TestNewFeedTargeter_Errors(t *testing.T) {
+	t.Parallel()
+
+	feed, err := NewCSVFeed(strings.NewReader("id\n1\n"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, url := range []string{"http://goku/{{.missing}}", "http://goku/{{.id"} {
+		tr := NewFeedTargeter(NewStaticTargeter(Target{Method: "GET", URL: url}), feed)
+		if err := tr(&Target{}); err == nil || !strings.Contains(err.Error(), "bad template") {
+			t.Errorf("%s: got: %v, want bad template error", url, err)
+		}
+	}
+
+	for _, src := range []string{"", "id\n", "id,name\n1\n"} {
+		if _, err := NewCSVFeed(strings.NewReader(src), true); err == nil {
+			t.Errorf("%q: want error, got none", src)
+		}
+	}
+
+	for _, src := range []string{"", "[1]", "{"} {
+		if _, err := NewJSONFeed(strings.NewReader(src), true); err == nil {
+			t.Errorf("%q: want error, got none", src)
+		}
+	}
+}
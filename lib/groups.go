@@ -0,0 +1,172 @@
+package vegeta
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Keys Results are grouped by in GroupedMetrics.
+const (
+	// GroupByName groups Results by the name of their attack.
+	GroupByName GroupBy = "name"
+	// GroupByMethod groups Results by the method of their request.
+	GroupByMethod GroupBy = "method"
+	// GroupByPath groups Results by the URL path of their request.
+	GroupByPath GroupBy = "path"
+	// GroupByTag is the prefix of the GroupBy of a tag of the Targets of
+	// Results, e.g. "tag:endpoint".
+	GroupByTag GroupBy = "tag:"
+)
+
+// A GroupBy is the key Results are grouped by: GroupByName, GroupByMethod,
+// GroupByPath or GroupByTag followed by the name of a tag.
+type GroupBy string
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+func (g *GroupBy) UnmarshalText(value []byte) error {
+	switch by := GroupBy(value); {
+	case by == GroupByName, by == GroupByMethod, by == GroupByPath,
+		strings.HasPrefix(string(by), string(GroupByTag)) && len(by) > len(GroupByTag):
+		*g = by
+		return nil
+	default:
+		return fmt.Errorf("bad group by: %q", value)
+	}
+}
+
+// key returns the key of the group of the given Result.
+func (g GroupBy) key(r *Result) string {
+	switch g {
+	case GroupByName:
+		return r.Attack
+	case GroupByMethod:
+		return r.Method
+	case GroupByPath:
+		if u, err := url.Parse(r.URL); err == nil {
+			return u.Path
+		}
+		return r.URL
+	default:
+		return r.Tags[strings.TrimPrefix(string(g), string(GroupByTag))]
+	}
+}
+
+// GroupedMetrics is a Report of the Metrics of groups of Results, e.g. of
+// each endpoint of an attack of mixed targets.
+type GroupedMetrics struct {
+	// By is what Results are grouped by.
+	By GroupBy `json:"by"`
+	// Percentiles are the extra latency percentiles computed for each
+	// group, as those of Metrics.
+	Percentiles []float64 `json:"-"`
+	// Location is the time zone of the times of the Metrics of each group,
+	// as that of Metrics.
+	Location *time.Location `json:"-"`
+	// ApdexT is the target latency of the Apdex score of each group, as
+	// that of Metrics.
+	ApdexT time.Duration `json:"-"`
+	// Objectives are the service level objectives evaluated for each group.
+	Objectives []Objective `json:"-"`
+	// Groups are the Metrics of each group, keyed by the value of their
+	// key, which is empty for Results without one, e.g. without the tag.
+	Groups map[string]*Metrics `json:"groups"`
+}
+
+// Add implements the Add method of the Report interface by adding the given
+// Result to the Metrics of its group.
+func (g *GroupedMetrics) Add(r *Result) {
+	if g.Groups == nil {
+		g.Groups = map[string]*Metrics{}
+	}
+
+	key := g.By.key(r)
+	m, ok := g.Groups[key]
+	if !ok {
+		m = &Metrics{
+			Percentiles: g.Percentiles,
+			Location:    g.Location,
+			ApdexT:      g.ApdexT,
+			Objectives:  g.Objectives,
+		}
+		g.Groups[key] = m
+	}
+
+	m.Add(r)
+}
+
+// Close implements the Close method of the Report interface by closing the
+// Metrics of each group.
+func (g *GroupedMetrics) Close() {
+	for _, m := range g.Groups {
+		m.Close()
+	}
+}
+
+// NewGroupedTextReporter returns a Reporter that writes out GroupedMetrics as
+// an aligned, formatted table with a row per group, sorted by key.
+func NewGroupedTextReporter(g *GroupedMetrics) Reporter {
+	return func(w io.Writer) (err error) {
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.StripEscape)
+
+		if _, err = fmt.Fprintf(tw, "Group\t%s\n", metricsHeader(g.Percentiles)); err != nil {
+			return err
+		}
+
+		keys := make([]string, 0, len(g.Groups))
+		for key := range g.Groups {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			name := key
+			if name == "" {
+				name = "-"
+			}
+
+			if _, err = fmt.Fprintf(tw, "%s\t%s\n", name, metricsRow(g.Groups[key], g.Percentiles)); err != nil {
+				return err
+			}
+		}
+
+		return tw.Flush()
+	}
+}
+
+// NewGroupedCSVReporter returns a Reporter that writes out GroupedMetrics as
+// CSV, as NewCSVReporter does for Metrics, with a record per group, sorted
+// by key, led by the key.
+func NewGroupedCSVReporter(g *GroupedMetrics) Reporter {
+	return func(w io.Writer) error {
+		enc := csv.NewWriter(w)
+		enc.Write(append([]string{string(g.By)}, csvMetricsHeader(g.Percentiles)...))
+
+		keys := make([]string, 0, len(g.Groups))
+		for key := range g.Groups {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			enc.Write(append([]string{key}, csvMetricsRow(g.Groups[key], g.Percentiles)...))
+		}
+
+		enc.Flush()
+		return enc.Error()
+	}
+}
+
+// NewGroupedJSONReporter returns a Reporter that writes out GroupedMetrics
+// as JSON.
+func NewGroupedJSONReporter(g *GroupedMetrics) Reporter {
+	return func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(g)
+	}
+}
@@ -0,0 +1,125 @@
+package vegeta
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestGroupedMetrics(t *testing.T) {
+	t.Parallel()
+
+	results := []Result{
+		{Attack: "a", Method: "GET", URL: "http://localhost/users?id=1", Code: 200, Tags: map[string]string{"endpoint": "users"}},
+		{Attack: "a", Method: "GET", URL: "http://localhost/users?id=2", Code: 500, Error: "500 Internal Server Error"},
+		{Attack: "b", Method: "POST", URL: "http://localhost/orders", Code: 201, Tags: map[string]string{"endpoint": "orders"}},
+	}
+
+	for _, tc := range []struct {
+		by   string
+		want map[string]uint64
+	}{
+		{"name", map[string]uint64{"a": 2, "b": 1}},
+		{"method", map[string]uint64{"GET": 2, "POST": 1}},
+		{"path", map[string]uint64{"/users": 2, "/orders": 1}},
+		{"tag:endpoint", map[string]uint64{"users": 1, "orders": 1, "": 1}},
+	} {
+		g := GroupedMetrics{}
+		if err := g.By.UnmarshalText([]byte(tc.by)); err != nil {
+			t.Fatalf("%s: %v", tc.by, err)
+		}
+
+		for i := range results {
+			g.Add(&results[i])
+		}
+		g.Close()
+
+		got := make(map[string]uint64, len(g.Groups))
+		for key, m := range g.Groups {
+			got[key] = m.Requests
+		}
+
+		if len(got) != len(tc.want) {
+			t.Errorf("%s: got groups %v, want %v", tc.by, got, tc.want)
+			continue
+		}
+		for key, n := range tc.want {
+			if got[key] != n {
+				t.Errorf("%s: got groups %v, want %v", tc.by, got, tc.want)
+				break
+			}
+		}
+	}
+}
+
+func TestGroupedMetrics_Reporters(t *testing.T) {
+	t.Parallel()
+
+	g := GroupedMetrics{By: GroupByMethod}
+	g.Add(&Result{Method: "GET", Code: 200})
+	g.Add(&Result{Method: "POST", Code: 500, Error: "500 Internal Server Error"})
+	g.Close()
+
+	var buf bytes.Buffer
+	if err := NewGroupedTextReporter(&g).Report(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	for i, prefix := range []string{"Group ", "GET ", "POST "} {
+		if i >= len(lines) || !strings.HasPrefix(lines[i], prefix) {
+			t.Fatalf("got report:\n%s\nwant line %d prefixed with %q", buf.String(), i, prefix)
+		}
+	}
+
+	buf.Reset()
+	if err := NewGroupedJSONReporter(&g).Report(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	var got struct {
+		By     GroupBy
+		Groups map[string]struct{ Requests uint64 }
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.By != GroupByMethod || got.Groups["GET"].Requests != 1 || got.Groups["POST"].Requests != 1 {
+		t.Errorf("got %+v", got)
+	}
+
+	buf.Reset()
+	if err := NewGroupedCSVReporter(&g).Report(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	lines = strings.Split(strings.TrimSpace(buf.String()), "\n")
+	for i, prefix := range []string{"method,requests,", "GET,1,", "POST,1,"} {
+		if i >= len(lines) || !strings.HasPrefix(lines[i], prefix) {
+			t.Fatalf("got report:\n%s\nwant line %d prefixed with %q", buf.String(), i, prefix)
+		}
+	}
+}
+
+func TestGroupBy_UnmarshalText(t *testing.T) {
+	t.Parallel()
+
+	for in, ok := range map[string]bool{
+		"name":     true,
+		"method":   true,
+		"path":     true,
+		"tag:env":  true,
+		"tag:":     false,
+		"host":     false,
+		"":         false,
+		"Name":     false,
+		"tags:env": false,
+	} {
+		var by GroupBy
+		if err := by.UnmarshalText([]byte(in)); (err == nil) != ok {
+			t.Errorf("%q: got error %v, want ok %t", in, err, ok)
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package vegeta
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/bits"
+	"sort"
+	"time"
+)
+
+// HDR histogram layout: latencies of 1ns up to an hour with two significant
+// decimal digits, i.e. counted in 128 linear sub-buckets per power of two,
+// which bounds the relative error of estimates below 1%.
+const (
+	hdrHighest                = int64(time.Hour)
+	hdrSubBucketHalfMagnitude = 7
+	hdrSubBucketHalfCount     = 1 << hdrSubBucketHalfMagnitude
+	hdrSubBucketCount         = 2 * hdrSubBucketHalfCount
+	hdrSubBucketMask          = hdrSubBucketCount - 1
+)
+
+// hdrBuckets is the number of powers of two buckets up to hdrHighest.
+var hdrBuckets = func() int {
+	n := 1
+	for smallestUntrackable := int64(hdrSubBucketCount); smallestUntrackable <= hdrHighest; smallestUntrackable <<= 1 {
+		n++
+	}
+	return n
+}()
+
+// An HDRHistogram is a High Dynamic Range histogram of latencies, as
+// described at http://hdrhistogram.org, which estimates their quantiles in
+// bounded memory however many it records, e.g. over week long soak tests.
+// Estimates are within 1% of the recorded latencies, which are capped to an
+// hour. It's not safe for concurrent use.
+type HDRHistogram struct {
+	counts []uint64
+	total  uint64
+}
+
+// NewHDRHistogram returns an empty HDRHistogram.
+func NewHDRHistogram() *HDRHistogram {
+	return &HDRHistogram{counts: make([]uint64, (hdrBuckets+1)*hdrSubBucketHalfCount)}
+}
+
+// Record records the given latency.
+func (h *HDRHistogram) Record(d time.Duration) {
+	h.counts[hdrIndex(int64(d))]++
+	h.total++
+}
+
+// Count returns the number of recorded latencies.
+func (h *HDRHistogram) Count() uint64 { return h.total }
+
+// Merge records the latencies recorded by the given HDRHistogram.
+func (h *HDRHistogram) Merge(other *HDRHistogram) {
+	for i, n := range other.counts {
+		h.counts[i] += n
+	}
+	h.total += other.total
+}
+
+// Quantile returns an estimate of the given quantile, between 0 and 1, of
+// the recorded latencies, or zero if there are none.
+func (h *HDRHistogram) Quantile(q float64) time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+
+	rank := uint64(math.Ceil(math.Min(math.Max(q, 0), 1) * float64(h.total)))
+	if rank == 0 {
+		rank = 1
+	}
+
+	var seen uint64
+	for i, n := range h.counts {
+		if seen += n; seen >= rank {
+			return time.Duration(hdrHighestEquivalent(i))
+		}
+	}
+
+	return time.Duration(hdrHighest)
+}
+
+// A sparseHistogram is an HDRHistogram which only holds its non-zero
+// counts, which is far smaller for latencies of a narrow range, e.g. those of
+// the hits of a second, at the cost of slower quantile estimates.
+type sparseHistogram struct {
+	counts map[int]uint64
+	total  uint64
+}
+
+// Record records the given latency.
+func (h *sparseHistogram) Record(d time.Duration) {
+	if h.counts == nil {
+		h.counts = map[int]uint64{}
+	}
+	h.counts[hdrIndex(int64(d))]++
+	h.total++
+}
+
+// Quantile returns an estimate of the given quantile, between 0 and 1, of
+// the recorded latencies, or zero if there are none, as HDRHistogram does.
+func (h *sparseHistogram) Quantile(q float64) time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+
+	indices := make([]int, 0, len(h.counts))
+	for i := range h.counts {
+		indices = append(indices, i)
+	}
+	sort.Ints(indices)
+
+	rank := uint64(math.Ceil(math.Min(math.Max(q, 0), 1) * float64(h.total)))
+	if rank == 0 {
+		rank = 1
+	}
+
+	var seen uint64
+	for _, i := range indices {
+		if seen += h.counts[i]; seen >= rank {
+			return time.Duration(hdrHighestEquivalent(i))
+		}
+	}
+
+	return time.Duration(hdrHighest)
+}
+
+// WritePercentiles writes out the percentile distribution of the recorded
+// latencies in the .hgrm format of HdrHistogram, with values in the given
+// unit, e.g. time.Millisecond, reported at percentiles which halve their
+// distance to 100% every 5 ticks. Distributions of several attacks can be
+// plotted together with the HdrHistogram plotter, at
+// http://hdrhistogram.github.io/HdrHistogram/plotFiles.html.
+func (h *HDRHistogram) WritePercentiles(w io.Writer, unit time.Duration) (err error) {
+	scaled := func(v int64) float64 { return float64(v) / float64(unit) }
+
+	if _, err = fmt.Fprintf(w, "%12s %14s %10s %14s\n\n", "Value", "Percentile", "TotalCount", "1/(1-Percentile)"); err != nil {
+		return err
+	}
+
+	var max int64
+	for _, p := range h.distribution() {
+		max = p.value
+		if p.quantile == 1 {
+			_, err = fmt.Fprintf(w, "%12.3f %2.12f %10d\n", scaled(p.value), p.quantile, p.count)
+		} else {
+			_, err = fmt.Fprintf(w, "%12.3f %2.12f %10d %14.2f\n", scaled(p.value), p.quantile, p.count, 1/(1-p.quantile))
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	var mean, sqdiffs float64
+	if h.total > 0 {
+		for i, n := range h.counts {
+			lowest, size := hdrRange(i)
+			mean += float64(n) * float64(lowest+size/2)
+		}
+		mean /= float64(h.total)
+
+		for i, n := range h.counts {
+			if n > 0 {
+				lowest, size := hdrRange(i)
+				sqdiffs += float64(n) * math.Pow(float64(lowest+size/2)-mean, 2)
+			}
+		}
+		sqdiffs /= float64(h.total)
+	}
+
+	_, err = fmt.Fprintf(w,
+		"#[Mean    = %12.3f, StdDeviation   = %12.3f]\n"+
+			"#[Max     = %12.3f, Total count    = %12d]\n"+
+			"#[Buckets = %12d, SubBuckets     = %12d]\n",
+		mean/float64(unit), math.Sqrt(sqdiffs)/float64(unit),
+		scaled(max), h.total,
+		hdrBuckets, hdrSubBucketCount,
+	)
+
+	return err
+}
+
+// An hdrQuantile is a quantile of the distribution of an HDRHistogram with
+// its value and the number of latencies up to it.
+type hdrQuantile struct {
+	value    int64
+	quantile float64
+	count    uint64
+}
+
+// distribution returns the quantiles of the recorded latencies at which
+// the percentile distribution is reported, which halve their distance to 1
+// every 5 ticks, up to 1.
+func (h *HDRHistogram) distribution() (qs []hdrQuantile) {
+	var (
+		seen  uint64
+		level float64 // in percents, as HdrHistogram
+	)
+
+	for i, n := range h.counts {
+		if n == 0 {
+			continue
+		}
+
+		value := hdrHighestEquivalent(i)
+		seen += n
+
+		for 100*float64(seen)/float64(h.total) >= level {
+			qs = append(qs, hdrQuantile{value, level / 100, seen})
+			if seen == h.total {
+				return append(qs, hdrQuantile{value, 1, seen})
+			}
+
+			ticks := 5 * math.Pow(2, math.Floor(math.Log2(100/(100-level)))+1)
+			level += 100 / ticks
+		}
+	}
+
+	return qs
+}
+
+// hdrIndex returns the index of the count of the given value.
+func hdrIndex(v int64) int {
+	if v < 0 {
+		v = 0
+	} else if v > hdrHighest {
+		v = hdrHighest
+	}
+
+	bucket := 64 - hdrSubBucketHalfMagnitude - 1 - bits.LeadingZeros64(uint64(v|hdrSubBucketMask))
+	sub := int(v >> uint(bucket))
+
+	return (bucket+1)<<hdrSubBucketHalfMagnitude + sub - hdrSubBucketHalfCount
+}
+
+// hdrHighestEquivalent returns the highest value counted at the given index.
+func hdrHighestEquivalent(i int) int64 {
+	lowest, size := hdrRange(i)
+	return lowest + size - 1
+}
+
+// hdrRange returns the lowest value counted at the given index and the
+// number of values counted there.
+func hdrRange(i int) (lowest, size int64) {
+	bucket := i>>hdrSubBucketHalfMagnitude - 1
+	sub := int64(i&(hdrSubBucketHalfCount-1) + hdrSubBucketHalfCount)
+	if bucket < 0 {
+		sub -= hdrSubBucketHalfCount
+		bucket = 0
+	}
+
+	return sub << uint(bucket), 1 << uint(bucket)
+}
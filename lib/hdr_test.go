@@ -0,0 +1,135 @@
+package vegeta
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHDRHistogram(t *testing.T) {
+	t.Parallel()
+
+	h := NewHDRHistogram()
+	if got := h.Quantile(0.5); got != 0 {
+		t.Errorf("got quantile %s of an empty histogram, want 0", got)
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	latencies := make([]time.Duration, 100000)
+	for i := range latencies {
+		// Log-normally distributed latencies of about 10ms to several seconds.
+		latencies[i] = time.Duration(float64(10*time.Millisecond) * (1 + rng.ExpFloat64()*rng.ExpFloat64()*10))
+		h.Record(latencies[i])
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+	for _, q := range []float64{0, 0.5, 0.9, 0.95, 0.99, 0.999, 0.9999, 1} {
+		want := latencies[int(q*float64(len(latencies)-1))]
+		if got := h.Quantile(q); got < want || float64(got-want) > 0.01*float64(want) {
+			t.Errorf("quantile %g: got %s, want %s within 1%%", q, got, want)
+		}
+	}
+
+	if got, want := h.Count(), uint64(len(latencies)); got != want {
+		t.Errorf("got count %d, want %d", got, want)
+	}
+
+	// Latencies out of range are capped.
+	h = NewHDRHistogram()
+	h.Record(-time.Second)
+	h.Record(0)
+	h.Record(time.Nanosecond)
+	h.Record(24 * time.Hour)
+
+	for q, want := range map[float64]time.Duration{0.25: 0, 0.5: 0, 0.75: time.Nanosecond} {
+		if got := h.Quantile(q); got != want {
+			t.Errorf("quantile %g: got %s, want %s", q, got, want)
+		}
+	}
+
+	if got := h.Quantile(1); got < time.Hour || got > time.Hour+time.Hour/100 {
+		t.Errorf("got max quantile %s, want about an hour", got)
+	}
+
+	other := NewHDRHistogram()
+	other.Record(2 * time.Hour)
+	other.Record(2 * time.Hour)
+	h.Merge(other)
+	if got := h.Count(); got != 6 {
+		t.Errorf("got count %d after merging, want 6", got)
+	}
+}
+
+func TestSparseHistogram(t *testing.T) {
+	t.Parallel()
+
+	var sparse sparseHistogram
+	if got := sparse.Quantile(0.5); got != 0 {
+		t.Errorf("got quantile %s of an empty histogram, want 0", got)
+	}
+
+	h := NewHDRHistogram()
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 10000; i++ {
+		d := time.Duration(float64(10*time.Millisecond) * (1 + rng.ExpFloat64()))
+		h.Record(d)
+		sparse.Record(d)
+	}
+
+	for _, q := range []float64{0, 0.5, 0.9, 0.99, 0.999, 1} {
+		if got, want := sparse.Quantile(q), h.Quantile(q); got != want {
+			t.Errorf("quantile %g: got %s, want %s", q, got, want)
+		}
+	}
+
+	if got, max := len(sparse.counts), len(h.counts); got >= max/10 {
+		t.Errorf("got %d counts, want far fewer than %d", got, max)
+	}
+}
+
+func TestHDRHistogram_WritePercentiles(t *testing.T) {
+	t.Parallel()
+
+	h := NewHDRHistogram()
+	for i := 1; i <= 100; i++ {
+		h.Record(time.Duration(i) * time.Millisecond)
+	}
+
+	var buf bytes.Buffer
+	if err := h.WritePercentiles(&buf, time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if got, want := lines[0], "       Value     Percentile TotalCount 1/(1-Percentile)"; got != want {
+		t.Errorf("got header %q, want %q", got, want)
+	}
+
+	for _, want := range []string{
+		"       1.004 0.000000000000          1           1.00",
+		"      50.070 0.500000000000         50           2.00",
+		"      90.178 0.900000000000         90          10.00",
+		"     100.139 1.000000000000        100",
+		"#[Max     =      100.139, Total count    =          100]",
+	} {
+		if !strings.Contains(buf.String(), want+"\n") {
+			t.Errorf("missing line %q in:\n%s", want, buf.String())
+		}
+	}
+
+	last := -1.0
+	for _, line := range lines[2 : len(lines)-3] {
+		var value, percentile float64
+		if _, err := fmt.Sscan(line, &value, &percentile); err != nil {
+			t.Fatalf("bad line %q: %v", line, err)
+		}
+		if percentile < last {
+			t.Errorf("percentile %g after %g", percentile, last)
+		}
+		last = percentile
+	}
+}
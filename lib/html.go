@@ -0,0 +1,214 @@
+package vegeta
+
+import (
+	"bytes"
+	"html/template"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// An HTMLReport is a Report of the Metrics of an attack along with their
+// Timeline, as written out by NewHTMLReporter.
+type HTMLReport struct {
+	// Metrics are the Metrics of the whole attack.
+	Metrics Metrics
+	// Timeline holds the metrics of each interval of the attack.
+	Timeline Timeline
+}
+
+// Add implements the Add method of the Report interface by adding the given
+// Result to the Metrics and the Timeline.
+func (r *HTMLReport) Add(res *Result) {
+	r.Metrics.Add(res)
+	r.Timeline.Add(res)
+}
+
+// Close implements the Close method of the Report interface by closing the
+// Metrics and the Timeline.
+func (r *HTMLReport) Close() {
+	r.Metrics.Close()
+	r.Timeline.Close()
+}
+
+// NewHTMLReporter returns a Reporter that writes out a closed HTMLReport as
+// a self-contained HTML page, e.g. to attach to tickets, with the text
+// report, interactive charts of the latencies and throughput over the given
+// TimeAxis and of the latency percentile distribution, built with
+// http://dygraphs.com/, and bar charts of the status codes and error classes.
+func NewHTMLReporter(title string, r *HTMLReport, ax TimeAxis) Reporter {
+	return func(w io.Writer) error {
+		var text bytes.Buffer
+		if err := NewTextReporter(&r.Metrics).Report(&text); err != nil {
+			return err
+		}
+
+		m := &r.Metrics
+		ps := r.Timeline.Percentiles
+		if len(ps) == 0 {
+			ps = []float64{50, 95, 99}
+		}
+
+		latencies := []string{`"Time"`, `"Mean"`}
+		for _, p := range ps {
+			latencies = append(latencies, strconv.Quote("P"+FormatPercentile(p)))
+		}
+		latencies = append(latencies, `"Max"`)
+
+		var latencyRows, throughputRows []string
+		for _, b := range r.Timeline.Buckets {
+			at := strconv.FormatFloat(b.Offset.Seconds(), 'f', -1, 64)
+			if ax.WallClock {
+				// Dygraphs shows dates in UTC, so they're shifted to show
+				// them in the axis' time zone, as in plots.
+				_, offset := b.Start.In(ax.location()).Zone()
+				ms := b.Start.Add(time.Duration(offset)*time.Second).UnixNano() / 1e6
+				at = "new Date(" + strconv.FormatInt(ms, 10) + ")"
+			}
+
+			row := []string{at, htmlMillis(b.Latencies.Mean)}
+			for _, p := range ps {
+				row = append(row, htmlMillis(b.quantile(p/100)))
+			}
+			row = append(row, htmlMillis(b.Latencies.Max))
+			latencyRows = append(latencyRows, "["+strings.Join(row, ",")+"]")
+
+			errors := float64(b.Requests) * (1 - b.Success) / r.Timeline.interval().Seconds()
+			throughputRows = append(throughputRows, "["+at+","+
+				strconv.FormatFloat(b.Rate, 'f', 2, 64)+","+
+				strconv.FormatFloat(errors, 'f', 2, 64)+"]")
+		}
+
+		// Percentiles are plotted by their number of nines, as the usual
+		// logarithmic percentile distribution plots of HdrHistogram.
+		var distributionRows []string
+		if m.latencies != nil {
+			for _, q := range m.latencies.distribution() {
+				if q.quantile < 1 {
+					nines := strconv.FormatFloat(math.Log10(1/(1-q.quantile)), 'f', 6, 64)
+					distributionRows = append(distributionRows, "["+nines+","+htmlMillis(time.Duration(q.value))+"]")
+				}
+			}
+		}
+
+		codes := make(map[string]uint64, len(m.StatusCodes))
+		for code, n := range m.StatusCodes {
+			codes[code] = uint64(n)
+		}
+
+		xlabel := "Seconds elapsed"
+		if ax.WallClock {
+			xlabel = "Time (" + ax.location().String() + ")"
+		}
+
+		return htmlTemplate.Execute(w, map[string]interface{}{
+			"Title":        title,
+			"Text":         text.String(),
+			"Dygraphs":     template.JS(asset(dygraphs)),
+			"XLabel":       xlabel,
+			"UTC":          ax.WallClock,
+			"Labels":       template.JS("[" + strings.Join(latencies, ",") + "]"),
+			"Latencies":    template.JS("[" + strings.Join(latencyRows, ",\n") + "]"),
+			"Throughput":   template.JS("[" + strings.Join(throughputRows, ",\n") + "]"),
+			"Distribution": template.JS("[" + strings.Join(distributionRows, ",\n") + "]"),
+			"Codes":        htmlBars(codes, m.Requests),
+			"Errors":       htmlBars(m.ErrorClasses, m.Requests),
+		})
+	}
+}
+
+// htmlMillis formats the given duration in milliseconds.
+func htmlMillis(d time.Duration) string {
+	return strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', -1, 64)
+}
+
+// An htmlBar is a bar of a bar chart of an HTML report.
+type htmlBar struct {
+	Label   string
+	Count   uint64
+	Percent float64
+}
+
+// htmlBars returns the bars of the given counts out of total, sorted by
+// label.
+func htmlBars(counts map[string]uint64, total uint64) []htmlBar {
+	bars := make([]htmlBar, 0, len(counts))
+	for label, n := range counts {
+		bars = append(bars, htmlBar{Label: label, Count: n, Percent: 100 * float64(n) / float64(total)})
+	}
+
+	sort.Slice(bars, func(i, j int) bool { return bars[i].Label < bars[j].Label })
+	return bars
+}
+
+var htmlTemplate = template.Must(template.New("html").Parse(`<!doctype html>
+<html>
+<head>
+  <title>{{.Title}}</title>
+  <meta charset="utf-8">
+  <style>
+    body { font-family: sans-serif; margin: 2em; }
+    pre { background: #f6f6f6; padding: 1em; overflow-x: auto; }
+    .chart { width: 100%; height: 400px; margin-bottom: 2em; }
+    table.bars { width: 100%; }
+    table.bars td { padding: 0.2em 0.5em; font-family: monospace; white-space: nowrap; }
+    table.bars td.bar { width: 70%; }
+    table.bars td.bar div { background: #4e79a7; height: 1em; }
+  </style>
+  <script>{{.Dygraphs}}</script>
+</head>
+<body>
+  <h1>{{.Title}}</h1>
+  <pre>{{.Text}}</pre>
+
+  <h2>Latencies over time</h2>
+  <div id="latencies" class="chart"></div>
+
+  <h2>Throughput over time</h2>
+  <div id="throughput" class="chart"></div>
+
+  <h2>Latency percentile distribution</h2>
+  <div id="distribution" class="chart"></div>
+
+  <h2>Status codes</h2>
+  <table class="bars">
+  {{- range .Codes}}
+    <tr><td>{{.Label}}</td><td>{{.Count}}</td><td>{{printf "%.2f%%" .Percent}}</td><td class="bar"><div style="width: {{printf "%.2f" .Percent}}%"></div></td></tr>
+  {{- end}}
+  </table>
+
+  <h2>Errors</h2>
+  {{- if .Errors}}
+  <table class="bars">
+  {{- range .Errors}}
+    <tr><td>{{.Label}}</td><td>{{.Count}}</td><td>{{printf "%.2f%%" .Percent}}</td><td class="bar"><div style="width: {{printf "%.2f" .Percent}}%"></div></td></tr>
+  {{- end}}
+  </table>
+  {{- else}}
+  <p>None</p>
+  {{- end}}
+
+  <script>
+  var overTime = {xlabel: {{.XLabel}}, labelsUTC: {{.UTC}}, legend: 'always', strokeWidth: 1.3};
+  new Dygraph(document.getElementById("latencies"), {{.Latencies}},
+    Object.assign({labels: {{.Labels}}, ylabel: 'Latency (ms)', logscale: true}, overTime));
+  new Dygraph(document.getElementById("throughput"), {{.Throughput}},
+    Object.assign({labels: ["Time", "Requests/s", "Errors/s"], ylabel: 'Hits per second'}, overTime));
+  new Dygraph(document.getElementById("distribution"), {{.Distribution}}, {
+    labels: ["Percentile", "Latency"],
+    xlabel: 'Percentile',
+    ylabel: 'Latency (ms)',
+    legend: 'always',
+    strokeWidth: 1.3,
+    axes: {x: {
+      valueFormatter: function(n) { return (100 - 100 * Math.pow(10, -n)).toPrecision(6) + '%'; },
+      axisLabelFormatter: function(n) { return (100 - 100 * Math.pow(10, -n)).toPrecision(6) + '%'; }
+    }}
+  });
+  </script>
+</body>
+</html>
+`))
@@ -0,0 +1,50 @@
+package vegeta
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTMLReporter(t *testing.T) {
+	t.Parallel()
+
+	r := HTMLReport{Timeline: Timeline{Interval: 10 * time.Second}}
+	begin := time.Unix(1000, 0)
+	for i := 0; i < 30; i++ {
+		res := Result{
+			Code:      200,
+			Timestamp: begin.Add(time.Duration(i) * time.Second),
+			Latency:   time.Duration(i+1) * time.Millisecond,
+		}
+		if i >= 20 {
+			res.Code, res.Error, res.ErrorClass = 500, "500 Internal Server Error", "http_5xx"
+		}
+		r.Add(&res)
+	}
+	r.Close()
+
+	var buf bytes.Buffer
+	if err := NewHTMLReporter("Vegeta <Report>", &r, TimeAxis{}).Report(&buf); err != nil {
+		t.Fatal(err)
+	}
+	page := buf.String()
+
+	for _, want := range []string{
+		"<title>Vegeta &lt;Report&gt;</title>",
+		"<pre>Requests ",
+		`labels: ["Time","Mean","P50","P95","P99","Max"]`,
+		"[0,5.5,5.013503,10,10,10]",
+		"[20,25.5,25.034751,30,30,30]",
+		"[10,1.00,0.00]",
+		"[20,1.00,1.00]",
+		"<td>200</td><td>20</td><td>66.67%</td>",
+		"<td>500</td><td>10</td><td>33.33%</td>",
+		"<td>http_5xx</td><td>10</td><td>33.33%</td>",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("missing %q", want)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package vegeta
+
+import "syscall"
+
+// Interface returns a functional option which makes an Attacker send its
+// requests through the network interface with the given name, e.g. eth1.
+// On Linux, its sockets are bound to the device (SO_BINDTODEVICE), which may
+// require the CAP_NET_RAW capability on older kernels. Elsewhere, connections
+// are rotated across the interface's addresses as set by LocalAddrs.
+func Interface(name string) func(*Attacker) {
+	return func(a *Attacker) { a.bindInterface(name) }
+}
+
+// control adds the given function to those the Attacker's dialer calls with
+// the raw connections of its sockets before dialing.
+func (a *Attacker) control(f func(network, address string, c syscall.RawConn) error) {
+	prev := a.dialer.Control
+	a.dialer.Control = func(network, address string, c syscall.RawConn) error {
+		if prev != nil {
+			if err := prev(network, address, c); err != nil {
+				return err
+			}
+		}
+		return f(network, address, c)
+	}
+}
@@ -0,0 +1,25 @@
+package vegeta
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// bindInterface binds the sockets of the Attacker to the network interface
+// with the given name.
+func (a *Attacker) bindInterface(name string) {
+	a.control(func(_, _ string, c syscall.RawConn) error {
+		var err error
+		if cerr := c.Control(func(fd uintptr) {
+			err = syscall.SetsockoptString(int(fd), syscall.SOL_SOCKET, syscall.SO_BINDTODEVICE, name)
+		}); cerr != nil {
+			return cerr
+		}
+
+		if err != nil {
+			return fmt.Errorf("binding to interface %s: %s", name, err)
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,40 @@
+//go:build !linux
+// +build !linux
+
+package vegeta
+
+import (
+	"fmt"
+	"net"
+	"syscall"
+)
+
+// bindInterface makes the Attacker rotate its connections across the
+// addresses of the network interface with the given name.
+func (a *Attacker) bindInterface(name string) {
+	var laddrs []net.IPAddr
+	iface, err := net.InterfaceByName(name)
+	if err == nil {
+		var addrs []net.Addr
+		if addrs, err = iface.Addrs(); err == nil {
+			for _, addr := range addrs {
+				if ipnet, ok := addr.(*net.IPNet); ok {
+					laddrs = append(laddrs, net.IPAddr{IP: ipnet.IP})
+				}
+			}
+		}
+	}
+
+	if err == nil && len(laddrs) == 0 {
+		err = fmt.Errorf("no addresses")
+	}
+
+	if err != nil {
+		a.control(func(string, string, syscall.RawConn) error {
+			return fmt.Errorf("binding to interface %s: %s", name, err)
+		})
+		return
+	}
+
+	LocalAddrs(laddrs...)(a)
+}
@@ -0,0 +1,48 @@
+package vegeta
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestInterface(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer server.Close()
+
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var lo string
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagLoopback != 0 && iface.Flags&net.FlagUp != 0 {
+			lo = iface.Name
+			break
+		}
+	}
+
+	tr := NewStaticTargeter(Target{Method: "GET", URL: server.URL})
+
+	if lo == "" {
+		t.Log("no loopback interface")
+	} else {
+		atk := NewAttacker(KeepAlive(false), Interface(lo))
+		res := atk.hit(tr, "", 0)
+		if strings.Contains(res.Error, "operation not permitted") {
+			t.Logf("not permitted to bind to %s: %s", lo, res.Error)
+		} else if res.Error != "" || res.Code != 200 {
+			t.Errorf("bound to %s: got code %d and error %q", lo, res.Code, res.Error)
+		}
+	}
+
+	atk := NewAttacker(KeepAlive(false), Interface("vegeta-nonexistent"))
+	if res := atk.hit(tr, "", 0); !strings.Contains(res.Error, "binding to interface vegeta-nonexistent") {
+		t.Errorf("got error %q, want a binding error", res.Error)
+	}
+}
@@ -0,0 +1,172 @@
+package vegeta
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// JUnit XML test report elements, as rendered by CI servers like Jenkins
+// and GitLab.
+type (
+	junitSuites struct {
+		XMLName  xml.Name     `xml:"testsuites"`
+		Name     string       `xml:"name,attr"`
+		Tests    int          `xml:"tests,attr"`
+		Failures int          `xml:"failures,attr"`
+		Time     float64      `xml:"time,attr"`
+		Suites   []junitSuite `xml:"testsuite"`
+	}
+
+	junitSuite struct {
+		Name      string      `xml:"name,attr"`
+		Tests     int         `xml:"tests,attr"`
+		Failures  int         `xml:"failures,attr"`
+		Time      float64     `xml:"time,attr"`
+		Timestamp string      `xml:"timestamp,attr,omitempty"`
+		Cases     []junitCase `xml:"testcase"`
+		SystemOut junitOutput `xml:"system-out"`
+	}
+
+	junitOutput struct {
+		Text string `xml:",cdata"`
+	}
+
+	junitCase struct {
+		Name      string        `xml:"name,attr"`
+		Classname string        `xml:"classname,attr"`
+		Time      float64       `xml:"time,attr"`
+		Failure   *junitFailure `xml:"failure,omitempty"`
+	}
+
+	junitFailure struct {
+		Message string `xml:"message,attr"`
+		Type    string `xml:"type,attr"`
+	}
+)
+
+// NewJUnitReporter returns a Reporter that writes out the closed Metrics as
+// a JUnit XML test report with a test case per SLO of the Metrics and per
+// given failure condition, as checked by Check, so that CI servers render
+// load tests as passed or failed. Without either, the only test case fails
+// unless all hits succeeded. The text report of the Metrics is attached as
+// the output of the test suite.
+func NewJUnitReporter(m *Metrics, conditions []Objective) Reporter {
+	return func(w io.Writer) error {
+		suite, err := newJUnitSuite("vegeta", m, conditions)
+		if err != nil {
+			return err
+		}
+		return writeJUnit(w, suite)
+	}
+}
+
+// NewGroupedJUnitReporter returns a Reporter that writes out closed
+// GroupedMetrics as a JUnit XML test report with a test suite per group, as
+// NewJUnitReporter does for Metrics.
+func NewGroupedJUnitReporter(g *GroupedMetrics, conditions []Objective) Reporter {
+	return func(w io.Writer) error {
+		keys := make([]string, 0, len(g.Groups))
+		for key := range g.Groups {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		suites := make([]junitSuite, 0, len(keys))
+		for _, key := range keys {
+			name := key
+			if name == "" {
+				name = "-"
+			}
+
+			suite, err := newJUnitSuite(string(g.By)+"="+name, g.Groups[key], conditions)
+			if err != nil {
+				return err
+			}
+			suites = append(suites, suite)
+		}
+
+		return writeJUnit(w, suites...)
+	}
+}
+
+// newJUnitSuite returns the test suite of the given Metrics.
+func newJUnitSuite(name string, m *Metrics, conditions []Objective) (junitSuite, error) {
+	var out bytes.Buffer
+	if err := NewTextReporter(m).Report(&out); err != nil {
+		return junitSuite{}, err
+	}
+
+	suite := junitSuite{
+		Name:      name,
+		Time:      (m.Duration + m.Wait).Seconds(),
+		SystemOut: junitOutput{Text: out.String()},
+	}
+
+	if !m.Earliest.IsZero() {
+		suite.Timestamp = m.Earliest.Format("2006-01-02T15:04:05")
+	}
+
+	add := func(test, failure string) {
+		c := junitCase{Name: test, Classname: suite.Name}
+		if failure != "" {
+			c.Failure = &junitFailure{Message: failure, Type: "threshold"}
+			suite.Failures++
+		}
+		suite.Cases = append(suite.Cases, c)
+		suite.Tests++
+	}
+
+	for _, slo := range m.SLOs {
+		var failure string
+		if !slo.Met {
+			failure = fmt.Sprintf("objective %s missed: %s", slo.Objective, slo.Value)
+		}
+		add("slo "+slo.Objective, failure)
+	}
+
+	for _, c := range conditions {
+		var failure string
+		if res, violated := c.violated(m); violated {
+			failure = fmt.Sprintf("failure condition %s holds: %s", res.Objective, res.Value)
+		}
+		add("fail-if "+c.String(), failure)
+	}
+
+	if suite.Tests == 0 {
+		var failure string
+		if m.Success < 1 {
+			failure = fmt.Sprintf("%.2f%% of %d requests succeeded", m.Success*100, m.Requests)
+		}
+		add("success", failure)
+	}
+
+	return suite, nil
+}
+
+// writeJUnit writes out the given test suites as a JUnit XML test report.
+func writeJUnit(w io.Writer, suites ...junitSuite) error {
+	report := junitSuites{Name: "vegeta", Suites: suites}
+	for _, s := range suites {
+		report.Tests += s.Tests
+		report.Failures += s.Failures
+		if s.Time > report.Time {
+			report.Time = s.Time
+		}
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,89 @@
+package vegeta
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJUnitReporter(t *testing.T) {
+	t.Parallel()
+
+	slos, err := ParseObjectives("p99<500ms,success>99%")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conditions, err := ParseObjectives("errors>10")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := Metrics{Objectives: slos}
+	for i := 0; i < 10; i++ {
+		r := Result{Code: 200, Method: "GET", Timestamp: time.Unix(int64(i), 0), Latency: 100 * time.Millisecond}
+		if i == 0 {
+			r.Code, r.Error = 500, "500 Internal Server Error"
+		}
+		m.Add(&r)
+	}
+	m.Close()
+
+	var buf bytes.Buffer
+	if err := NewJUnitReporter(&m, conditions).Report(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	var got junitSuites
+	if err := xml.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("%v:\n%s", err, buf.String())
+	}
+
+	if got.Tests != 3 || got.Failures != 1 || len(got.Suites) != 1 {
+		t.Fatalf("got %d tests, %d failures in %d suites, want 3, 1 in 1", got.Tests, got.Failures, len(got.Suites))
+	}
+
+	suite := got.Suites[0]
+	for i, want := range []struct {
+		name   string
+		failed bool
+	}{
+		{"slo p99<500ms", false},
+		{"slo success>99%", true},
+		{"fail-if errors>10", false},
+	} {
+		if c := suite.Cases[i]; c.Name != want.name || (c.Failure != nil) != want.failed {
+			t.Errorf("got test case %+v, want %+v", c, want)
+		}
+	}
+
+	if !strings.HasPrefix(suite.SystemOut.Text, "Requests ") {
+		t.Errorf("got output %q, want the text report", suite.SystemOut.Text)
+	}
+
+	// Without thresholds, groups fail unless all their hits succeeded.
+	g := GroupedMetrics{By: GroupByMethod}
+	g.Add(&Result{Method: "GET", Code: 200})
+	g.Add(&Result{Method: "POST", Code: 500, Error: "500 Internal Server Error"})
+	g.Close()
+
+	buf.Reset()
+	if err := NewGroupedJUnitReporter(&g, nil).Report(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	got = junitSuites{}
+	if err := xml.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("%v:\n%s", err, buf.String())
+	}
+
+	if len(got.Suites) != 2 || got.Suites[0].Name != "method=GET" || got.Suites[1].Name != "method=POST" {
+		t.Fatalf("got suites %+v", got.Suites)
+	}
+
+	if got.Suites[0].Failures != 0 || got.Suites[1].Failures != 1 || got.Failures != 1 {
+		t.Errorf("got failures %d, %d of %d, want 0, 1 of 1", got.Suites[0].Failures, got.Suites[1].Failures, got.Failures)
+	}
+}
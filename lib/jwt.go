@@ -0,0 +1,114 @@
+package vegeta
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// A JWTSigner mints a signed JSON Web Token per hit, for APIs which reject
+// reused tokens or bind them to the attributes of requests. It's safe for
+// concurrent use.
+type JWTSigner struct {
+	alg    string
+	hmac   []byte
+	rsa    *rsa.PrivateKey
+	claims *template.Template
+	header string
+}
+
+// NewJWTSigner returns a JWTSigner of tokens signed with the given algorithm,
+// HS256 with key as the shared secret or RS256 with key as a PEM encoded
+// PKCS#1 or PKCS#8 RSA private key. Their claims are the JSON object output
+// by the given text/template template, executed on every hit with the same
+// data as NameTemplate and the built-in functions of NewTemplateTargeter,
+// e.g. {"sub": "goku", "jti": "{{uuid}}", "iat": {{now "unix"}}, "htu": "{{.URL}}"}.
+func NewJWTSigner(alg string, key []byte, claims string) (*JWTSigner, error) {
+	s := &JWTSigner{alg: alg}
+	switch alg {
+	case "HS256":
+		if len(key) == 0 {
+			return nil, errors.New("bad jwt key: empty HS256 secret")
+		}
+		s.hmac = key
+	case "RS256":
+		block, _ := pem.Decode(key)
+		if block == nil {
+			return nil, errors.New("bad jwt key: no PEM encoded RS256 key")
+		}
+
+		if k, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+			s.rsa = k
+		} else if k, err := x509.ParsePKCS8PrivateKey(block.Bytes); err != nil {
+			return nil, fmt.Errorf("bad jwt key: %s", err)
+		} else if s.rsa, _ = k.(*rsa.PrivateKey); s.rsa == nil {
+			return nil, errors.New("bad jwt key: not an RSA private key")
+		}
+	default:
+		return nil, fmt.Errorf("bad jwt algorithm: %q", alg)
+	}
+
+	var err error
+	if s.claims, err = template.New("claims").Funcs(templateFuncs).Option("missingkey=error").Parse(claims); err != nil {
+		return nil, fmt.Errorf("bad jwt claims: %s", err)
+	}
+
+	s.header = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"` + alg + `","typ":"JWT"}`))
+
+	return s, nil
+}
+
+// JWTs returns a functional option which makes an Attacker send a fresh token
+// minted by the given JWTSigner as a Bearer token in the Authorization header
+// of each of its requests.
+func JWTs(s *JWTSigner) func(*Attacker) {
+	return func(a *Attacker) { a.jwt = s }
+}
+
+// Sign mints a token whose claims are executed with the given data.
+func (s *JWTSigner) Sign(data interface{}) (string, error) {
+	var claims bytes.Buffer
+	if err := s.claims.Execute(&claims, data); err != nil {
+		return "", fmt.Errorf("bad jwt claims: %s", err)
+	}
+
+	var payload bytes.Buffer
+	if err := json.Compact(&payload, claims.Bytes()); err != nil {
+		return "", fmt.Errorf("bad jwt claims: %s", err)
+	}
+
+	var b strings.Builder
+	b.WriteString(s.header)
+	b.WriteByte('.')
+	b.WriteString(base64.RawURLEncoding.EncodeToString(payload.Bytes()))
+
+	var sig []byte
+	switch s.alg {
+	case "HS256":
+		mac := hmac.New(sha256.New, s.hmac)
+		mac.Write([]byte(b.String()))
+		sig = mac.Sum(nil)
+	case "RS256":
+		sum := sha256.Sum256([]byte(b.String()))
+		var err error
+		if sig, err = rsa.SignPKCS1v15(rand.Reader, s.rsa, crypto.SHA256, sum[:]); err != nil {
+			return "", fmt.Errorf("jwt signature: %s", err)
+		}
+	}
+
+	b.WriteByte('.')
+	b.WriteString(base64.RawURLEncoding.EncodeToString(sig))
+
+	return b.String(), nil
+}
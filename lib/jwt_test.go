@@ -0,0 +1,113 @@
+package vegeta
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestJWTSigner(t *testing.T) {
+	t.Parallel()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)})
+
+	var tokens []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokens = append(tokens, strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	}))
+	defer server.Close()
+
+	const claims = `{"sub": "goku", "jti": "{{uuid}}", "htm": "{{.Method}}", "htu": "{{.URL.Path}}"}`
+	for _, tc := range []struct {
+		alg    string
+		key    []byte
+		verify func(signed string, sig []byte) error
+	}{
+		{"HS256", []byte("kamehameha"), func(signed string, sig []byte) error {
+			mac := hmac.New(sha256.New, []byte("kamehameha"))
+			mac.Write([]byte(signed))
+			if !hmac.Equal(sig, mac.Sum(nil)) {
+				return rsa.ErrVerification
+			}
+			return nil
+		}},
+		{"RS256", rsaPEM, func(signed string, sig []byte) error {
+			sum := sha256.Sum256([]byte(signed))
+			return rsa.VerifyPKCS1v15(&rsaKey.PublicKey, crypto.SHA256, sum[:], sig)
+		}},
+	} {
+		s, err := NewJWTSigner(tc.alg, tc.key, claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tokens = tokens[:0]
+		atk := NewAttacker(JWTs(s), Workers(1))
+		tr := NewStaticTargeter(Target{Method: "POST", URL: server.URL + "/things"})
+		for i := 0; i < 2; i++ {
+			if res := atk.hit(tr, "", 0); res.Error != "" {
+				t.Fatalf("%s: got error %q", tc.alg, res.Error)
+			}
+		}
+
+		if len(tokens) != 2 || tokens[0] == tokens[1] {
+			t.Fatalf("%s: got tokens %q, want two distinct ones", tc.alg, tokens)
+		}
+
+		parts := strings.Split(tokens[0], ".")
+		if len(parts) != 3 {
+			t.Fatalf("%s: got malformed token %q", tc.alg, tokens[0])
+		}
+
+		sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err = tc.verify(parts[0]+"."+parts[1], sig); err != nil {
+			t.Errorf("%s: bad signature: %s", tc.alg, err)
+		}
+
+		var header, payload map[string]string
+		for i, v := range []*map[string]string{&header, &payload} {
+			bs, err := base64.RawURLEncoding.DecodeString(parts[i])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = json.Unmarshal(bs, v); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if header["alg"] != tc.alg || payload["htm"] != "POST" || payload["htu"] != "/things" || len(payload["jti"]) != 36 {
+			t.Errorf("%s: got header %v, claims %v", tc.alg, header, payload)
+		}
+	}
+
+	for _, tc := range []struct {
+		alg, key, claims string
+	}{
+		{"none", "kamehameha", claims},
+		{"HS256", "", claims},
+		{"RS256", "kamehameha", claims},
+		{"HS256", "kamehameha", `{"sub": "{{.Goku"}`},
+	} {
+		if _, err := NewJWTSigner(tc.alg, []byte(tc.key), tc.claims); err == nil {
+			t.Errorf("%+v: got no error", tc)
+		}
+	}
+}
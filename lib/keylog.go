@@ -0,0 +1,17 @@
+package vegeta
+
+import (
+	"crypto/tls"
+	"io"
+)
+
+// KeyLogWriter returns a functional option which makes an Attacker write the
+// secrets of its TLS connections to the given writer in the NSS key log
+// format, which Wireshark decrypts captured traffic with, e.g. to debug
+// protocol level issues during a load test. It compromises the security of
+// the connections and should only be used for debugging.
+func KeyLogWriter(w io.Writer) func(*Attacker) {
+	return func(a *Attacker) {
+		a.tlsConfig(func(c *tls.Config) { c.KeyLogWriter = w })
+	}
+}
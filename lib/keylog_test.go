@@ -0,0 +1,46 @@
+package vegeta
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestKeyLogWriter(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer server.Close()
+
+	var w lockedBuffer
+	atk := NewAttacker(KeyLogWriter(&w))
+	if res := atk.hit(NewStaticTargeter(Target{Method: "GET", URL: server.URL}), "", 0); res.Error != "" {
+		t.Fatal(res.Error)
+	}
+
+	// TLS 1.3 connections log their traffic secrets.
+	if log := w.String(); !strings.Contains(log, "CLIENT_TRAFFIC_SECRET_0 ") {
+		t.Errorf("got key log %q, want traffic secrets", log)
+	}
+}
+
+// lockedBuffer is a bytes.Buffer safe for concurrent use.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
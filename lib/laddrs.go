@@ -0,0 +1,75 @@
+package vegeta
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+)
+
+// LocalAddrs returns a functional option which makes an Attacker rotate its
+// new connections across the given local IP addresses, e.g. to exceed the
+// ephemeral port limits of a single source address or simulate many clients
+// from a multi-homed machine. Connections are made from the addresses of the
+// same family as the dialed one, or as set by LocalAddr if there are none.
+func LocalAddrs(addrs ...net.IPAddr) func(*Attacker) {
+	return func(a *Attacker) {
+		a.laddrs = &localAddrs{}
+		for _, addr := range addrs {
+			tcp := &net.TCPAddr{IP: addr.IP, Zone: addr.Zone}
+			if addr.IP.To4() != nil {
+				a.laddrs.v4 = append(a.laddrs.v4, tcp)
+			} else {
+				a.laddrs.v6 = append(a.laddrs.v6, tcp)
+			}
+		}
+	}
+}
+
+// localAddrs are the local addresses an Attacker rotates its connections
+// across.
+type localAddrs struct {
+	v4, v6 []*net.TCPAddr
+	n      uint64
+}
+
+// next returns the next local address to dial the given IP from, or nil
+// if there are none of its family.
+func (l *localAddrs) next(ip string) *net.TCPAddr {
+	if l == nil {
+		return nil
+	}
+
+	addrs := l.v6
+	if net.ParseIP(ip).To4() != nil {
+		addrs = l.v4
+	}
+
+	if len(addrs) == 0 {
+		return nil
+	}
+
+	return addrs[(atomic.AddUint64(&l.n, 1)-1)%uint64(len(addrs))]
+}
+
+// dial dials the given IP and port with the Attacker's dialer, from the next
+// of its rotated local addresses if any, and sets its socket options.
+func (a *Attacker) dial(ctx context.Context, network, ip, port string) (net.Conn, error) {
+	d := a.dialer
+	if laddr := a.laddrs.next(ip); laddr != nil {
+		rotated := *a.dialer
+		rotated.LocalAddr = laddr
+		d = &rotated
+	}
+
+	conn, err := d.DialContext(ctx, network, net.JoinHostPort(ip, port))
+	if err != nil || len(a.sockopts) == 0 {
+		return conn, err
+	}
+
+	if err = a.tune(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return conn, nil
+}
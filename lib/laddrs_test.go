@@ -0,0 +1,38 @@
+package vegeta
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestLocalAddrs(t *testing.T) {
+	t.Parallel()
+
+	var sources []string
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		host, _, _ := net.SplitHostPort(r.RemoteAddr)
+		sources = append(sources, host)
+	}))
+	defer server.Close()
+
+	atk := NewAttacker(
+		KeepAlive(false),
+		Workers(1),
+		LocalAddrs(net.IPAddr{IP: net.ParseIP("127.0.0.2")}, net.IPAddr{IP: net.ParseIP("::1")}, net.IPAddr{IP: net.ParseIP("127.0.0.3")}),
+	)
+
+	tr := NewStaticTargeter(Target{Method: "GET", URL: server.URL})
+	for i := 0; i < 3; i++ {
+		if res := atk.hit(tr, "", 0); res.Error != "" {
+			t.Fatal(res.Error)
+		}
+	}
+
+	// IPv6 addresses aren't used for IPv4 targets.
+	if want := []string{"127.0.0.2", "127.0.0.3", "127.0.0.2"}; !reflect.DeepEqual(sources, want) {
+		t.Errorf("got source addresses %v, want %v", sources, want)
+	}
+}
@@ -0,0 +1,91 @@
+package vegeta
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// ConnectionPerWorker returns a functional option which makes every worker of
+// an Attacker send its requests over a persistent connection of its own per
+// target host, instead of those of a pool shared by all workers, like fleets
+// of devices do. The Results of each connection are tagged with its number in
+// the "conn" tag, so that its throughput can be measured. The connections are
+// opened by clones of the Attacker's *http.Transport, bypassing the
+// RoundTrippers set with RoundTripper which wrap it, and hits fail if there's
+// none.
+func ConnectionPerWorker(enabled bool) func(*Attacker) {
+	return func(a *Attacker) {
+		if a.lanes = nil; enabled {
+			a.lanes = &lanes{}
+		}
+	}
+}
+
+// lanes are the clients of the workers of an Attacker, each of which keeps a
+// single connection per host.
+type lanes struct {
+	mu   sync.Mutex
+	free []*lane
+	n    int
+}
+
+// lane is the client of a worker.
+type lane struct {
+	id     string
+	client *http.Client
+}
+
+// get returns the most recently used free lane, or a new one with a copy of
+// the Attacker's client and its own transport.
+func (ls *lanes) get(a *Attacker) (*lane, error) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	if n := len(ls.free); n > 0 {
+		l := ls.free[n-1]
+		ls.free = ls.free[:n-1]
+		return l, nil
+	}
+
+	tr, err := a.ownTransport()
+	if err != nil {
+		return nil, err
+	}
+	tr.MaxConnsPerHost, tr.MaxIdleConnsPerHost = 1, 1
+
+	c := a.client
+	c.Transport = tr
+
+	l := &lane{id: strconv.Itoa(ls.n), client: &c}
+	ls.n++
+
+	return l, nil
+}
+
+// put frees the given lane.
+func (ls *lanes) put(l *lane) {
+	ls.mu.Lock()
+	ls.free = append(ls.free, l)
+	ls.mu.Unlock()
+}
+
+// hit hits the next Target of tr over a lane.
+func (ls *lanes) hit(a *Attacker, tr Targeter, name string, seq uint64) *Result {
+	l, err := ls.get(a)
+	if err != nil {
+		return &Result{Attack: name, Seq: seq, ClockOffset: a.offset, Error: "connection per worker: " + err.Error()}
+	}
+	defer ls.put(l)
+
+	res := a.do(tr, name, seq, l.client, a.script)
+
+	tags := make(map[string]string, len(res.Tags)+1)
+	for k, v := range res.Tags {
+		tags[k] = v
+	}
+	tags["conn"] = l.id
+	res.Tags = tags
+
+	return res
+}
@@ -0,0 +1,92 @@
+package vegeta
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestConnectionPerWorker(t *testing.T) {
+	t.Parallel()
+
+	var conns int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	atk := NewAttacker(ConnectionPerWorker(true))
+	tgt := Target{Method: "GET", URL: server.URL, Tags: map[string]string{"endpoint": "root"}}
+	tr := NewStaticTargeter(tgt)
+
+	var (
+		mu    sync.Mutex
+		lanes = map[string]int{}
+	)
+	for round := 0; round < 3; round++ {
+		var wg sync.WaitGroup
+		for i := 0; i < 3; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				res := atk.hit(tr, "", 0)
+				if res.Error != "" || res.Tags["endpoint"] != "root" {
+					t.Errorf("got error %q and tags %v", res.Error, res.Tags)
+				}
+				mu.Lock()
+				lanes[res.Tags["conn"]]++
+				mu.Unlock()
+			}()
+		}
+		wg.Wait()
+	}
+
+	if n := atomic.LoadInt32(&conns); n != 3 {
+		t.Errorf("got %d connections, want 3", n)
+	}
+
+	for _, conn := range []string{"0", "1", "2"} {
+		if lanes[conn] != 3 {
+			t.Errorf("got %v hits per connection, want 3 each of 0, 1 and 2", lanes)
+			break
+		}
+	}
+
+	if _, ok := tgt.Tags["conn"]; ok {
+		t.Error("target tags were modified")
+	}
+}
+
+func TestConnectionPerWorker_Transports(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer server.Close()
+
+	tr := NewStaticTargeter(Target{Method: "GET", URL: server.URL})
+	for _, tc := range []struct {
+		name string
+		rt   http.RoundTripper
+		err  string
+	}{
+		{"wrapped", &countingTransport{rt: &http.Transport{}}, ""},
+		{"opaque", struct{ http.RoundTripper }{http.DefaultTransport}, "connection per worker: " + errNoTransport.Error()},
+	} {
+		atk := NewAttacker(RoundTripper(tc.rt), ConnectionPerWorker(true))
+		if res := atk.hit(tr, "", 0); res.Error != tc.err {
+			t.Errorf("%s: got error %q, want %q", tc.name, res.Error, tc.err)
+		} else if tc.err == "" && res.Tags["conn"] != "0" {
+			t.Errorf("%s: got tags %v, want a conn tag", tc.name, res.Tags)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package vegeta
+
+import (
+	"context"
+	"net"
+	"net/http/httptrace"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// IdleTimeout returns a functional option which sets the maximum amount of
+// time the idle connections of an Attacker are kept open for. Zero, the
+// default, means no limit.
+func IdleTimeout(d time.Duration) func(*Attacker) {
+	return func(a *Attacker) {
+		if tr := a.transport(); tr != nil {
+			tr.IdleConnTimeout = d
+		}
+	}
+}
+
+// ConnLifetime returns a functional option which makes an Attacker
+// re-establish its connections once they're older than maxAge or served
+// maxRequests requests, as real clients and load balancers do, so that long
+// soak tests exercise connection churn. Zero means no limit. Like with Churn,
+// expired connections are closed when they're next written to, upon which the
+// HTTP/1.1 request about to be sent on them is transparently retried on a new
+// connection.
+func ConnLifetime(maxAge time.Duration, maxRequests int) func(*Attacker) {
+	return func(a *Attacker) {
+		tr := a.transport()
+		if tr == nil || (maxAge <= 0 && maxRequests <= 0) {
+			return
+		}
+
+		a.lifetime = &connLifetime{maxAge: maxAge, maxRequests: int64(maxRequests)}
+		dial := tr.DialContext
+		tr.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			conn, err := dial(ctx, network, addr)
+			if err != nil {
+				return nil, err
+			}
+			return a.lifetime.track(conn), nil
+		}
+	}
+}
+
+// connLifetime tracks the connections of an Attacker which expire with age
+// or use. Requests are counted as they're assigned connections by the
+// transport, which are found by their addresses since other options may
+// wrap them, e.g. in *tls.Conns.
+type connLifetime struct {
+	maxAge      time.Duration
+	maxRequests int64
+	conns       sync.Map
+}
+
+// track returns the given connection as an agedConn.
+func (l *connLifetime) track(conn net.Conn) net.Conn {
+	c := &agedConn{Conn: conn, lifetime: l, key: connKey(conn), born: time.Now()}
+	if c.key != "" {
+		l.conns.Store(c.key, c)
+	}
+	return c
+}
+
+// clientTrace returns an httptrace.ClientTrace which counts the requests of
+// the tracked connections they're sent on.
+func (l *connLifetime) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if c, ok := l.conns.Load(connKey(info.Conn)); ok {
+				c.(*agedConn).use()
+			}
+		},
+	}
+}
+
+// connKey returns the local and remote addresses of the given connection,
+// which identify it, or an empty string if it has none.
+func connKey(conn net.Conn) string {
+	local, remote := conn.LocalAddr(), conn.RemoteAddr()
+	if local == nil || remote == nil {
+		return ""
+	}
+	return local.String() + "|" + remote.String()
+}
+
+// agedConn is a net.Conn which expires with age or use.
+type agedConn struct {
+	net.Conn
+	lifetime *connLifetime
+	key      string
+	born     time.Time
+	requests int64
+	expired  int32
+}
+
+// use counts a request sent on the connection, which expires it once it
+// served its maximum number of requests.
+func (c *agedConn) use() {
+	if n := atomic.AddInt64(&c.requests, 1); c.lifetime.maxRequests > 0 && n > c.lifetime.maxRequests {
+		atomic.StoreInt32(&c.expired, 1)
+	}
+}
+
+func (c *agedConn) Write(b []byte) (int, error) {
+	if atomic.LoadInt32(&c.expired) == 1 || c.lifetime.maxAge > 0 && time.Since(c.born) > c.lifetime.maxAge {
+		c.Close()
+		return 0, errChurned
+	}
+	return c.Conn.Write(b)
+}
+
+func (c *agedConn) Close() error {
+	if c.key != "" {
+		c.lifetime.conns.Delete(c.key)
+	}
+	return c.Conn.Close()
+}
@@ -0,0 +1,97 @@
+package vegeta
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestConnLifetime(t *testing.T) {
+	t.Parallel()
+
+	var conns int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	for _, tc := range []struct {
+		opt   func(*Attacker)
+		sleep time.Duration
+		conns int32
+	}{
+		{ConnLifetime(0, 0), 0, 1},
+		{ConnLifetime(0, 2), 0, 3},
+		{ConnLifetime(100*time.Millisecond, 0), 60 * time.Millisecond, 3},
+		{IdleTimeout(30 * time.Millisecond), 60 * time.Millisecond, 6},
+	} {
+		atomic.StoreInt32(&conns, 0)
+		atk := NewAttacker(tc.opt)
+		tr := NewStaticTargeter(Target{Method: "POST", URL: server.URL, Body: []byte("kamehameha")})
+		for i := 0; i < 6; i++ {
+			if res := atk.hit(tr, "", 0); res.Error != "" {
+				t.Fatal(res.Error)
+			}
+			time.Sleep(tc.sleep)
+		}
+
+		if n := atomic.LoadInt32(&conns); n != tc.conns {
+			t.Errorf("got %d connections, want %d", n, tc.conns)
+		}
+	}
+}
+
+func TestConnLifetime_Concurrent(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu       sync.Mutex
+		requests = map[string]int{}
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.RemoteAddr]++
+		mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	atk := NewAttacker(ConnLifetime(0, 3))
+	tr := NewStaticTargeter(Target{Method: "GET", URL: server.URL})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 15; j++ {
+				if res := atk.hit(tr, "", 0); res.Error != "" {
+					t.Error(res.Error)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	total := 0
+	for addr, n := range requests {
+		if total += n; n > 3 {
+			t.Errorf("connection %s served %d requests, want at most 3", addr, n)
+		}
+	}
+
+	if total != 60 {
+		t.Errorf("got %d requests, want 60", total)
+	}
+}
@@ -0,0 +1,158 @@
+package vegeta
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NewMarkdownReporter returns a Reporter that writes out closed Metrics as
+// Markdown tables, e.g. to paste into pull requests, incident documents or
+// chats: a summary with the columns of the time reporter, the status codes,
+// the error classes and the results of the SLOs. Given a closed Timeline of
+// the same Results, it also writes sparklines of the latencies over time.
+func NewMarkdownReporter(m *Metrics, tl *Timeline) Reporter {
+	return func(w io.Writer) error {
+		header := strings.Split(metricsHeader(m.Percentiles), "\t")
+		aligns := make([]string, len(header))
+		for i := range aligns {
+			aligns[i] = "---:"
+		}
+
+		blocks := []string{markdownTable(header, aligns, strings.Split(metricsRow(m, m.Percentiles), "\t"))}
+
+		codes := make(map[string]uint64, len(m.StatusCodes))
+		for code, n := range m.StatusCodes {
+			codes[code] = uint64(n)
+		}
+
+		if len(codes) > 0 {
+			blocks = append(blocks, markdownCounts("Status Code", codes, m.Requests))
+		}
+
+		if len(m.ErrorClasses) > 0 {
+			blocks = append(blocks, markdownCounts("Error Class", m.ErrorClasses, m.Requests))
+		}
+
+		if len(m.SLOs) > 0 {
+			rows := make([][]string, len(m.SLOs))
+			for i, slo := range m.SLOs {
+				verdict := "met"
+				if !slo.Met {
+					verdict = "**MISSED**"
+				}
+				rows[i] = []string{"`" + slo.Objective + "`", slo.Value, verdict}
+			}
+			blocks = append(blocks, markdownTable([]string{"Objective", "Value", "Verdict"}, []string{"---", "---:", "---"}, rows...))
+		}
+
+		if m.Apdex != nil {
+			blocks = append(blocks, fmt.Sprintf("Apdex score: %.2f (T = %s)\n", m.Apdex.Score, m.Apdex.T))
+		}
+
+		if tl != nil && len(tl.Buckets) > 0 {
+			blocks = append(blocks, markdownSparklines(tl))
+		}
+
+		_, err := io.WriteString(w, strings.Join(blocks, "\n"))
+		return err
+	}
+}
+
+// markdownSparklines returns a Markdown table of sparklines of the latency
+// percentiles of the given Timeline, and of the maximum ones, over time.
+func markdownSparklines(tl *Timeline) string {
+	var names []string
+	var qs []float64
+	for _, p := range tl.Percentiles {
+		names, qs = append(names, FormatPercentile(p)), append(qs, p/100)
+	}
+	if len(qs) == 0 {
+		names, qs = []string{"50", "95", "99"}, []float64{0.5, 0.95, 0.99}
+	}
+	names, qs = append(names, "Max"), append(qs, 1)
+
+	rows := make([][]string, len(names))
+	for i, name := range names {
+		ds := make([]time.Duration, len(tl.Buckets))
+		for j, b := range tl.Buckets {
+			ds[j] = b.quantile(qs[i])
+		}
+
+		min, max := ds[0], ds[0]
+		for _, d := range ds {
+			if d < min {
+				min = d
+			}
+			if d > max {
+				max = d
+			}
+		}
+
+		rows[i] = []string{name, sparkline(ds, min, max), min.String(), max.String()}
+	}
+
+	header := []string{"Latency", "Every " + tl.interval().String(), "Min", "Max"}
+	return markdownTable(header, []string{"---", "---", "---:", "---:"}, rows...)
+}
+
+// sparkBars are the bars of sparklines, from the lowest to the highest.
+var sparkBars = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline returns a sparkline of the given durations between min and max.
+func sparkline(ds []time.Duration, min, max time.Duration) string {
+	bars := make([]rune, len(ds))
+	for i, d := range ds {
+		var bar int
+		if max > min {
+			bar = int(float64(d-min) / float64(max-min) * float64(len(sparkBars)-1))
+		}
+		bars[i] = sparkBars[bar]
+	}
+	return string(bars)
+}
+
+// markdownCounts returns a Markdown table of the given counts out of total,
+// sorted by key.
+func markdownCounts(name string, counts map[string]uint64, total uint64) string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	rows := make([][]string, len(keys))
+	for i, key := range keys {
+		rows[i] = []string{
+			key,
+			strconv.FormatUint(counts[key], 10),
+			fmt.Sprintf("%.2f%%", 100*float64(counts[key])/float64(total)),
+		}
+	}
+
+	return markdownTable([]string{name, "Count", "%"}, []string{"---", "---:", "---:"}, rows...)
+}
+
+// markdownTable returns a Markdown table with the given header, column
+// alignments and rows, whose pipes are escaped.
+func markdownTable(header, aligns []string, rows ...[]string) string {
+	var b strings.Builder
+	line := func(cells []string) {
+		b.WriteString("|")
+		for _, c := range cells {
+			b.WriteString(" " + strings.Replace(c, "|", `\|`, -1) + " |")
+		}
+		b.WriteString("\n")
+	}
+
+	line(header)
+	b.WriteString("|" + strings.Join(aligns, "|") + "|\n")
+	for _, row := range rows {
+		line(row)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,91 @@
+package vegeta
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMarkdownReporter(t *testing.T) {
+	t.Parallel()
+
+	m := Metrics{Objectives: []Objective{{Metric: "success", Op: ">", Threshold: 0.9}}}
+	tl := Timeline{Interval: 10 * time.Second}
+	begin := time.Unix(1000, 0)
+	for i := 0; i < 30; i++ {
+		r := Result{
+			Code:      200,
+			Timestamp: begin.Add(time.Duration(i) * time.Second),
+			Latency:   time.Duration(i+1) * time.Millisecond,
+		}
+		if i >= 20 {
+			r.Code, r.Error, r.ErrorClass = 500, "500 Internal | Server Error", "http_5xx"
+		}
+		m.Add(&r)
+		tl.Add(&r)
+	}
+	m.Close()
+	tl.Close()
+
+	var buf bytes.Buffer
+	if err := NewMarkdownReporter(&m, nil).Report(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	want := strings.Join([]string{
+		"| Requests | Rate | Success | Mean | 50 | 95 | 99 | Max | Errors |",
+		"|---:|---:|---:|---:|---:|---:|---:|---:|---:|",
+		"| 30 | 1.03 | 66.67% | 15.5ms | 15.007743ms | 29.097983ms | 30ms | 30ms | 10 |",
+		"",
+		"| Status Code | Count | % |",
+		"|---|---:|---:|",
+		"| 200 | 20 | 66.67% |",
+		"| 500 | 10 | 33.33% |",
+		"",
+		"| Error Class | Count | % |",
+		"|---|---:|---:|",
+		"| http_5xx | 10 | 33.33% |",
+		"",
+		"| Objective | Value | Verdict |",
+		"|---|---:|---|",
+		"| `success>90%` | 66.66666666666666% | **MISSED** |",
+		"",
+	}, "\n")
+
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	buf.Reset()
+	if err := NewMarkdownReporter(&m, &tl).Report(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	sparklines := strings.Join([]string{
+		"| Latency | Every 10s | Min | Max |",
+		"|---|---|---:|---:|",
+		"| 50 | ▁▄█ | 5.013503ms | 25.034751ms |",
+		"| 95 | ▁▄█ | 10ms | 30ms |",
+		"| 99 | ▁▄█ | 10ms | 30ms |",
+		"| Max | ▁▄█ | 10ms | 30ms |",
+		"",
+	}, "\n")
+
+	if got := buf.String(); !strings.HasSuffix(got, "\n\n"+sparklines) {
+		t.Errorf("got:\n%s\nwant sparklines:\n%s", got, sparklines)
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	t.Parallel()
+
+	ds := []time.Duration{1, 2, 3, 4, 5, 6, 7, 8}
+	if got, want := sparkline(ds, 1, 8), "▁▂▃▄▅▆▇█"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	if got, want := sparkline([]time.Duration{3, 3}, 3, 3), "▁▁"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
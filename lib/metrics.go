@@ -1,10 +1,11 @@
 package vegeta
 
 import (
+	"math"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
-
-	"github.com/streadway/quantile"
 )
 
 type (
@@ -37,13 +38,50 @@ type (
 		StatusCodes map[string]int `json:"status_codes"`
 		// Errors is a set of unique errors returned by the targets during the attack.
 		Errors []string `json:"errors"`
+		// ErrorClasses is a histogram of the classes of the errors.
+		ErrorClasses map[string]uint64 `json:"error_classes"`
+		// CodeLatencies holds latency metrics of the hits of each status
+		// code, e.g. to tell fast failures from slow successes.
+		CodeLatencies map[string]*LatencyMetrics `json:"code_latencies"`
+		// ErrorClassLatencies holds latency metrics of the hits of each
+		// class of errors.
+		ErrorClassLatencies map[string]*LatencyMetrics `json:"error_class_latencies"`
+		// AssertionFailures is the number of responses which failed the
+		// assertions of their targets, which aren't successful.
+		AssertionFailures uint64 `json:"assertion_failures"`
+		// Backoffs holds metrics of the hits paused as asked by the targets.
+		Backoffs BackoffMetrics `json:"backoffs"`
+		// TLSHandshakes holds metrics of the TLS handshakes of the hits.
+		TLSHandshakes TLSHandshakeMetrics `json:"tls_handshakes"`
+		// Phases holds latency metrics of the phases of the hits.
+		Phases PhaseMetrics `json:"phases"`
+		// Apdex holds the Apdex score of the hits when ApdexT is set.
+		Apdex *ApdexMetrics `json:"apdex,omitempty"`
+		// SLOs are the results of the evaluation of the Objectives once
+		// closed.
+		SLOs []ObjectiveResult `json:"slos,omitempty"`
+		// Location is the time zone of the Earliest, Latest and End times once
+		// closed. Nil keeps the time zone of the Results' timestamps.
+		Location *time.Location `json:"-"`
+		// Percentiles are the extra percentiles of the latencies computed
+		// once closed, e.g. 90 or 99.99, as well as those of the phases.
+		Percentiles []float64 `json:"-"`
+		// ApdexT is the target latency of the Apdex score. Zero disables it.
+		ApdexT time.Duration `json:"-"`
+		// Objectives are the service level objectives evaluated once closed.
+		Objectives []Objective `json:"-"`
 
 		// ErrorCount ...
 		ErrorCount map[string]uint
 
+		mu        sync.Mutex
 		errors    map[string]struct{}
 		success   uint64
-		latencies *quantile.Estimator
+		latencies *HDRHistogram
+		moments   moments
+		phases    struct{ dns, connect, tls, firstByte, body latencyStats }
+		codes     map[string]*latencyStats
+		classes   map[string]*latencyStats
 	}
 
 	// LatencyMetrics holds computed request latency metrics.
@@ -58,8 +96,40 @@ type (
 		P95 time.Duration `json:"95th"`
 		// P99 is the 99th percentile request latency.
 		P99 time.Duration `json:"99th"`
+		// P999 is the 99.9th percentile request latency.
+		P999 time.Duration `json:"99.9th"`
 		// Max is the maximum observed request latency.
 		Max time.Duration `json:"max"`
+		// StdDev is the standard deviation of the request latencies.
+		StdDev time.Duration `json:"stddev"`
+		// Variance is the variance of the request latencies, in squared
+		// nanoseconds.
+		Variance float64 `json:"variance"`
+		// Percentiles are the extra request latency percentiles asked for,
+		// keyed by their formatted percentile, e.g. "99.99".
+		Percentiles map[string]time.Duration `json:"percentiles,omitempty"`
+	}
+
+	// BackoffMetrics holds metrics of the hits paused as asked by the targets.
+	BackoffMetrics struct {
+		// Count is the number of paused hits.
+		Count uint64 `json:"count"`
+		// Total is the total time hits were paused for.
+		Total time.Duration `json:"total"`
+		// Max is the longest time a hit was paused for.
+		Max time.Duration `json:"max"`
+	}
+
+	// TLSHandshakeMetrics holds metrics of the TLS handshakes of the hits
+	// which established connections.
+	TLSHandshakeMetrics struct {
+		// Full is the number of full handshakes.
+		Full uint64 `json:"full"`
+		// Resumed is the number of abbreviated handshakes of resumed
+		// sessions.
+		Resumed uint64 `json:"resumed"`
+		// ResumptionRatio is the fraction of handshakes which were resumed.
+		ResumptionRatio float64 `json:"resumption_ratio"`
 	}
 
 	// ByteMetrics holds computed byte flow metrics.
@@ -72,17 +142,29 @@ type (
 )
 
 // Add implements the Add method of the Report interface by adding the given
-// Result to Metrics.
+// Result to Metrics. It's safe to call concurrently, e.g. from multiple
+// decoding goroutines.
 func (m *Metrics) Add(r *Result) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	m.init()
 
+	code := strconv.Itoa(int(r.Code))
+
 	m.Requests++
-	m.StatusCodes[strconv.Itoa(int(r.Code))]++
+	m.StatusCodes[code]++
+	addLatency(m.codes, m.CodeLatencies, code, r.Latency)
 	m.Latencies.Total += r.Latency
 	m.BytesOut.Total += r.BytesOut
 	m.BytesIn.Total += r.BytesIn
 
-	m.latencies.Add(float64(r.Latency))
+	m.latencies.Record(r.Latency)
+	m.moments.add(r.Latency)
+
+	if m.Apdex != nil {
+		m.Apdex.add(r)
+	}
 
 	if m.Earliest.IsZero() || m.Earliest.After(r.Timestamp) {
 		m.Earliest = r.Timestamp
@@ -100,11 +182,53 @@ func (m *Metrics) Add(r *Result) {
 		m.Latencies.Max = r.Latency
 	}
 
-	if r.Code >= 200 && r.Code < 400 {
+	if r.Backoff > 0 {
+		m.Backoffs.Count++
+		m.Backoffs.Total += r.Backoff
+		if r.Backoff > m.Backoffs.Max {
+			m.Backoffs.Max = r.Backoff
+		}
+	}
+
+	for _, p := range []struct {
+		stats *latencyStats
+		m     *LatencyMetrics
+		d     time.Duration
+	}{
+		{&m.phases.dns, &m.Phases.DNS, r.Phases.DNS},
+		{&m.phases.connect, &m.Phases.Connect, r.Phases.Connect},
+		{&m.phases.tls, &m.Phases.TLS, r.Phases.TLS},
+		{&m.phases.firstByte, &m.Phases.FirstByte, r.Phases.FirstByte},
+		{&m.phases.body, &m.Phases.Body, r.Phases.Body},
+	} {
+		// Hits which didn't go through a phase don't count in it.
+		if p.d > 0 {
+			p.stats.add(p.m, p.d)
+		}
+	}
+
+	switch r.TLSHandshake {
+	case FullHandshake:
+		m.TLSHandshakes.Full++
+	case ResumedHandshake:
+		m.TLSHandshakes.Resumed++
+	}
+
+	if isAssertion(r.Error) {
+		m.AssertionFailures++
+	} else if succeeded(r) {
 		m.success++
 	}
 
 	if r.Error != "" {
+		// Results of older attacks are classified here.
+		class := r.ErrorClass
+		if class == "" {
+			class = classifyError(r.Error, r.Code, false)
+		}
+		m.ErrorClasses[class]++
+		addLatency(m.classes, m.ErrorClassLatencies, class, r.Latency)
+
 		m.ErrorCount[r.Error]++
 		if _, ok := m.errors[r.Error]; !ok {
 			m.errors[r.Error] = struct{}{}
@@ -113,9 +237,29 @@ func (m *Metrics) Add(r *Result) {
 	}
 }
 
+// succeeded returns whether the given Result counts as a success. Results
+// accepted by a success predicate or status assertion succeed unless they
+// carry an error, as do results without a status code (e.g. TCP probes).
+// Other results succeed with a 2xx or 3xx status code.
+func succeeded(r *Result) bool {
+	switch {
+	case isAssertion(r.Error):
+		return false
+	case r.Accepted && r.Error == "":
+		return true
+	case strings.HasPrefix(r.Error, ErrUnsuccessful.Error()):
+		return false
+	default:
+		return r.Code >= 200 && r.Code < 400 || r.Code == 0 && r.Error == ""
+	}
+}
+
 // Close implements the Close method of the Report interface by computing
 // derived summary metrics which don't need to be run on every Add call.
 func (m *Metrics) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	m.init()
 	m.Rate = float64(m.Requests)
 	m.Duration = m.Latest.Sub(m.Earliest)
@@ -123,13 +267,36 @@ func (m *Metrics) Close() {
 		m.Rate /= secs
 	}
 	m.Wait = m.End.Sub(m.Latest)
+	if m.Location != nil {
+		m.Earliest, m.Latest, m.End = m.Earliest.In(m.Location), m.Latest.In(m.Location), m.End.In(m.Location)
+	}
 	m.BytesIn.Mean = float64(m.BytesIn.Total) / float64(m.Requests)
 	m.BytesOut.Mean = float64(m.BytesOut.Total) / float64(m.Requests)
 	m.Success = float64(m.success) / float64(m.Requests)
 	m.Latencies.Mean = time.Duration(float64(m.Latencies.Total) / float64(m.Requests))
-	m.Latencies.P50 = time.Duration(m.latencies.Get(0.50))
-	m.Latencies.P95 = time.Duration(m.latencies.Get(0.95))
-	m.Latencies.P99 = time.Duration(m.latencies.Get(0.99))
+	if n := m.TLSHandshakes.Full + m.TLSHandshakes.Resumed; n > 0 {
+		m.TLSHandshakes.ResumptionRatio = float64(m.TLSHandshakes.Resumed) / float64(n)
+	}
+	m.Latencies.quantiles(m.latencies, m.Percentiles)
+	m.Latencies.spread(m.moments)
+	m.phases.dns.close(&m.Phases.DNS, m.Percentiles)
+	m.phases.connect.close(&m.Phases.Connect, m.Percentiles)
+	m.phases.tls.close(&m.Phases.TLS, m.Percentiles)
+	m.phases.firstByte.close(&m.Phases.FirstByte, m.Percentiles)
+	m.phases.body.close(&m.Phases.Body, m.Percentiles)
+	for code, s := range m.codes {
+		s.close(m.CodeLatencies[code], m.Percentiles)
+	}
+	for class, s := range m.classes {
+		s.close(m.ErrorClassLatencies[class], m.Percentiles)
+	}
+	if m.Apdex != nil {
+		m.Apdex.close()
+	}
+	m.SLOs = m.SLOs[:0]
+	for _, o := range m.Objectives {
+		m.SLOs = append(m.SLOs, o.Evaluate(m))
+	}
 }
 
 func (m *Metrics) init() {
@@ -142,18 +309,150 @@ func (m *Metrics) init() {
 	}
 
 	if m.latencies == nil {
-		m.latencies = quantile.New(
-			quantile.Known(0.50, 0.01),
-			quantile.Known(0.95, 0.001),
-			quantile.Known(0.99, 0.0005),
-		)
+		m.latencies = NewHDRHistogram()
 	}
 
 	if m.Errors == nil {
 		m.Errors = make([]string, 0)
 	}
 
+	if m.ErrorClasses == nil {
+		m.ErrorClasses = map[string]uint64{}
+	}
+
+	if m.CodeLatencies == nil {
+		m.CodeLatencies = map[string]*LatencyMetrics{}
+	}
+
+	if m.ErrorClassLatencies == nil {
+		m.ErrorClassLatencies = map[string]*LatencyMetrics{}
+	}
+
+	if m.Apdex == nil && m.ApdexT > 0 {
+		m.Apdex = &ApdexMetrics{T: m.ApdexT}
+	}
+
+	if m.codes == nil {
+		m.codes = map[string]*latencyStats{}
+	}
+
+	if m.classes == nil {
+		m.classes = map[string]*latencyStats{}
+	}
+
 	if m.ErrorCount == nil {
 		m.ErrorCount = make(map[string]uint)
 	}
 }
+
+// latencyStats computes LatencyMetrics of a subset of the hits.
+type latencyStats struct {
+	count     uint64
+	latencies *HDRHistogram
+	moments   moments
+}
+
+// add adds the given latency of a hit to the metrics.
+func (s *latencyStats) add(m *LatencyMetrics, d time.Duration) {
+	if s.latencies == nil {
+		s.latencies = NewHDRHistogram()
+	}
+
+	s.count++
+	s.latencies.Record(d)
+	s.moments.add(d)
+	m.Total += d
+	if d > m.Max {
+		m.Max = d
+	}
+}
+
+// close computes the derived metrics, including the given extra percentiles.
+func (s *latencyStats) close(m *LatencyMetrics, percentiles []float64) {
+	if s.count == 0 {
+		return
+	}
+
+	m.Mean = m.Total / time.Duration(s.count)
+	m.quantiles(s.latencies, percentiles)
+	m.spread(s.moments)
+}
+
+// addLatency adds the given latency of a hit to the metrics of the given
+// key, e.g. its status code.
+func addLatency(stats map[string]*latencyStats, ms map[string]*LatencyMetrics, key string, d time.Duration) {
+	s, ok := stats[key]
+	if !ok {
+		s = &latencyStats{}
+		stats[key] = s
+		ms[key] = &LatencyMetrics{}
+	}
+	s.add(ms[key], d)
+}
+
+// A quantiler estimates quantiles of latencies, e.g. an HDRHistogram.
+type quantiler interface {
+	Quantile(q float64) time.Duration
+}
+
+// quantiles sets the fixed and the given extra percentiles of the
+// LatencyMetrics estimated by the given quantiler, capped to their Max.
+func (l *LatencyMetrics) quantiles(h quantiler, percentiles []float64) {
+	for _, p := range []struct {
+		q float64
+		d *time.Duration
+	}{
+		{0.50, &l.P50},
+		{0.95, &l.P95},
+		{0.99, &l.P99},
+		{0.999, &l.P999},
+	} {
+		*p.d = l.quantile(h, p.q)
+	}
+
+	if len(percentiles) == 0 {
+		return
+	}
+
+	l.Percentiles = make(map[string]time.Duration, len(percentiles))
+	for _, p := range percentiles {
+		l.Percentiles[FormatPercentile(p)] = l.quantile(h, p/100)
+	}
+}
+
+// quantile returns the given quantile estimated by the given quantiler,
+// capped to the Max of the LatencyMetrics.
+func (l *LatencyMetrics) quantile(h quantiler, q float64) time.Duration {
+	if d := h.Quantile(q); d < l.Max {
+		return d
+	}
+	return l.Max
+}
+
+// spread sets the standard deviation and variance of the LatencyMetrics.
+func (l *LatencyMetrics) spread(s moments) {
+	if s.n == 0 {
+		return
+	}
+	l.Variance = s.m2 / s.n
+	l.StdDev = time.Duration(math.Sqrt(l.Variance))
+}
+
+// FormatPercentile formats the given percentile as the key of its latency
+// in the Percentiles of LatencyMetrics, e.g. "99.9".
+func FormatPercentile(p float64) string {
+	return strconv.FormatFloat(p, 'f', -1, 64)
+}
+
+// moments computes the mean and variance of latencies in a single pass with
+// Welford's algorithm, which is numerically stable over long attacks.
+type moments struct{ n, mean, m2 float64 }
+
+// add adds the given latency.
+func (s *moments) add(d time.Duration) {
+	x := float64(d)
+	s.n++
+	delta := x - s.mean
+	s.mean += delta / s.n
+	s.m2 += delta * (x - s.mean)
+}
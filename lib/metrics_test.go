@@ -1,7 +1,9 @@
 package vegeta
 
 import (
+	"math"
 	"reflect"
+	"sync"
 	"testing"
 	"time"
 )
@@ -35,33 +37,45 @@ func TestMetrics_Add(t *testing.T) {
 
 	want := Metrics{
 		Latencies: LatencyMetrics{
-			Total: duration("50.005s"),
-			Mean:  duration("5.0005ms"),
-			P50:   duration("4.991ms"),
-			P95:   duration("9.509ms"),
-			P99:   duration("9.898ms"),
-			Max:   duration("10ms"),
+			Total:    duration("50.005s"),
+			Mean:     duration("5.0005ms"),
+			P50:      duration("5.013503ms"),
+			P95:      duration("9.502719ms"),
+			P99:      duration("9.961471ms"),
+			P999:     duration("10ms"),
+			Max:      duration("10ms"),
+			StdDev:   duration("2.886751ms"),
+			Variance: 8.33333325e+12,
 		},
-		BytesIn:     ByteMetrics{Total: 10240000, Mean: 1024},
-		BytesOut:    ByteMetrics{Total: 5120000, Mean: 512},
-		Earliest:    time.Unix(0, 0),
-		Latest:      time.Unix(9999, 0),
-		End:         time.Unix(9999, 0).Add(10000 * time.Microsecond),
-		Duration:    duration("2h46m39s"),
-		Wait:        duration("10ms"),
-		Requests:    10000,
-		Rate:        1.000100010001,
-		Success:     0.6667,
-		StatusCodes: map[string]int{"500": 3333, "200": 3334, "302": 3333},
-		Errors:      []string{"Internal server error"},
+		BytesIn:      ByteMetrics{Total: 10240000, Mean: 1024},
+		BytesOut:     ByteMetrics{Total: 5120000, Mean: 512},
+		Earliest:     time.Unix(0, 0),
+		Latest:       time.Unix(9999, 0),
+		End:          time.Unix(9999, 0).Add(10000 * time.Microsecond),
+		Duration:     duration("2h46m39s"),
+		Wait:         duration("10ms"),
+		Requests:     10000,
+		Rate:         1.000100010001,
+		Success:      0.6667,
+		StatusCodes:  map[string]int{"500": 3333, "200": 3334, "302": 3333},
+		Errors:       []string{"Internal server error"},
+		ErrorClasses: map[string]uint64{ErrorClassOther: 5000},
+		ErrorCount:   map[string]uint{"Internal server error": 5000},
+
+		// Checked by TestMetrics_CodeLatencies.
+		CodeLatencies:       got.CodeLatencies,
+		ErrorClassLatencies: got.ErrorClassLatencies,
 
 		errors:    got.errors,
 		success:   got.success,
 		latencies: got.latencies,
+		moments:   got.moments,
+		codes:     got.codes,
+		classes:   got.classes,
 	}
 
-	if !reflect.DeepEqual(got, want) {
-		t.Errorf("\ngot:  %+v\nwant: %+v", got, want)
+	if !reflect.DeepEqual(&got, &want) {
+		t.Errorf("\ngot:  %+v\nwant: %+v", &got, &want)
 	}
 }
 
@@ -90,3 +104,156 @@ func TestMetrics_NonNilErrorsOnClose(t *testing.T) {
 		t.Errorf("\ngot:  %+v\nwant: %+v", got, want)
 	}
 }
+
+func TestMetrics_SuccessWithoutStatusCode(t *testing.T) {
+	t.Parallel()
+
+	var m Metrics
+	m.Add(&Result{Code: 0})
+	m.Add(&Result{Code: 0, Error: "dial tcp: connection refused"})
+	m.Close()
+
+	if got, want := m.Success, 0.5; got != want {
+		t.Errorf("got success %f, want %f", got, want)
+	}
+}
+
+func TestMetrics_AssertionFailures(t *testing.T) {
+	t.Parallel()
+
+	var m Metrics
+	m.Add(&Result{Code: 200})
+	m.Add(&Result{Code: 200, Error: "assertion failed: missing header ETag"})
+	m.Add(&Result{Code: 500, Error: "500 Internal Server Error"})
+	m.Close()
+
+	if got, want := m.AssertionFailures, uint64(1); got != want {
+		t.Errorf("got %d assertion failures, want %d", got, want)
+	}
+
+	if got, want := m.Success, 1/3.0; got != want {
+		t.Errorf("got success %f, want %f", got, want)
+	}
+}
+
+func TestMetrics_Location(t *testing.T) {
+	t.Parallel()
+
+	tokyo := time.FixedZone("JST", 9*3600)
+	m := Metrics{Location: tokyo}
+	m.Add(&Result{Code: 200, Timestamp: time.Unix(0, 0), Latency: time.Second})
+	m.Close()
+
+	for _, ts := range []time.Time{m.Earliest, m.Latest, m.End} {
+		if ts.Location() != tokyo {
+			t.Errorf("%s: got location %s, want %s", ts, ts.Location(), tokyo)
+		}
+	}
+}
+
+func TestMetrics_Percentiles(t *testing.T) {
+	t.Parallel()
+
+	m := Metrics{Percentiles: []float64{90, 99.99, 100}}
+	for i := 1; i <= 10000; i++ {
+		m.Add(&Result{Code: 200, Timestamp: time.Unix(0, 0), Latency: time.Duration(i) * time.Millisecond})
+	}
+	m.Close()
+
+	for key, want := range map[string]time.Duration{
+		"90":    9000 * time.Millisecond,
+		"99.99": 9999 * time.Millisecond,
+		"100":   10000 * time.Millisecond,
+	} {
+		got, ok := m.Latencies.Percentiles[key]
+		if !ok || got < want || float64(got-want) > 0.01*float64(want) {
+			t.Errorf("percentile %s: got %s, want %s within 1%%", key, got, want)
+		}
+	}
+
+	if got := m.Latencies.Percentiles["100"]; got != m.Latencies.Max {
+		t.Errorf("got 100th percentile %s, want max %s", got, m.Latencies.Max)
+	}
+
+	// The variance of the uniform distribution of 1ms to 10s.
+	wantVar := (10000*10000 - 1) / 12.0 * float64(time.Millisecond*time.Millisecond)
+	if got := m.Latencies.Variance; math.Abs(got-wantVar) > 1e-9*wantVar {
+		t.Errorf("got variance %g, want %g", got, wantVar)
+	}
+
+	if got, want := m.Latencies.StdDev, time.Duration(math.Sqrt(wantVar)); got != want {
+		t.Errorf("got stddev %s, want %s", got, want)
+	}
+
+	if len(m.Phases.DNS.Percentiles) != 0 {
+		t.Errorf("got percentiles of a phase no hit went through: %v", m.Phases.DNS.Percentiles)
+	}
+}
+
+func TestMetrics_CodeLatencies(t *testing.T) {
+	t.Parallel()
+
+	var m Metrics
+	for _, r := range []Result{
+		{Code: 200, Latency: 100 * time.Millisecond},
+		{Code: 200, Latency: 300 * time.Millisecond},
+		{Code: 500, Latency: 2 * time.Millisecond, Error: "500 Internal Server Error"},
+		{Code: 0, Latency: time.Second, Error: "Get http://localhost: net/http: request canceled (Client.Timeout exceeded while awaiting headers)"},
+	} {
+		r := r
+		m.Add(&r)
+	}
+	m.Close()
+
+	for _, tc := range []struct {
+		name      string
+		latencies map[string]*LatencyMetrics
+		key       string
+		mean, max time.Duration
+	}{
+		{"code", m.CodeLatencies, "200", 200 * time.Millisecond, 300 * time.Millisecond},
+		{"code", m.CodeLatencies, "500", 2 * time.Millisecond, 2 * time.Millisecond},
+		{"code", m.CodeLatencies, "0", time.Second, time.Second},
+		{"class", m.ErrorClassLatencies, ErrorClassServer, 2 * time.Millisecond, 2 * time.Millisecond},
+		{"class", m.ErrorClassLatencies, ErrorClassTimeout, time.Second, time.Second},
+	} {
+		l, ok := tc.latencies[tc.key]
+		if !ok {
+			t.Errorf("%s %s: no latencies", tc.name, tc.key)
+			continue
+		}
+		if l.Mean != tc.mean || l.Max != tc.max {
+			t.Errorf("%s %s: got mean %s, max %s, want %s, %s", tc.name, tc.key, l.Mean, l.Max, tc.mean, tc.max)
+		}
+	}
+
+	if got, want := len(m.ErrorClassLatencies), 2; got != want {
+		t.Errorf("got latencies of %d error classes, want %d", got, want)
+	}
+}
+
+func TestMetrics_ConcurrentAdd(t *testing.T) {
+	t.Parallel()
+
+	var (
+		m  Metrics
+		wg sync.WaitGroup
+	)
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				m.Add(&Result{Code: 200, Timestamp: time.Unix(int64(j), 0), Latency: time.Millisecond})
+			}
+		}()
+	}
+	wg.Wait()
+	m.Close()
+
+	if m.Requests != 8000 || m.StatusCodes["200"] != 8000 || m.Success != 1 {
+		t.Errorf("got %d requests, %d 200s and %.2f success, want 8000, 8000 and 1",
+			m.Requests, m.StatusCodes["200"], m.Success)
+	}
+}
@@ -0,0 +1,92 @@
+package vegeta
+
+import (
+	"crypto"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// ClientCertificates returns a functional option which makes an Attacker
+// present the given TLS client certificates in turn, one per handshake, e.g.
+// to load test mTLS services which rate limit or route requests per client.
+// Targets can present their own with their Certificate.
+func ClientCertificates(certs ...tls.Certificate) func(*Attacker) {
+	return func(a *Attacker) {
+		if len(certs) == 0 {
+			return
+		}
+
+		var next uint64
+		a.tlsConfig(func(c *tls.Config) {
+			c.Certificates = nil
+			c.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+				return &certs[(atomic.AddUint64(&next, 1)-1)%uint64(len(certs))], nil
+			}
+		})
+	}
+}
+
+// certificates caches the client certificates of Targets by file names, so
+// that those shared by many Targets are loaded once.
+var certificates sync.Map
+
+// loadCertificate loads the PEM encoded certificate and private key in the
+// given files, or both in the first if the second is empty.
+func loadCertificate(certf, keyf string) (*tls.Certificate, error) {
+	if keyf == "" {
+		keyf = certf
+	}
+
+	key := [2]string{certf, keyf}
+	if cert, ok := certificates.Load(key); ok {
+		return cert.(*tls.Certificate), nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(certf, keyf)
+	if err != nil {
+		return nil, err
+	}
+
+	actual, _ := certificates.LoadOrStore(key, &cert)
+	return actual.(*tls.Certificate), nil
+}
+
+// SignerCertificate returns a TLS client certificate of the given PEM encoded
+// certificate chain whose private key is the given crypto.Signer, which signs
+// handshakes without exposing the key, e.g. one backed by a PKCS#11 module,
+// HSM or OS keychain which forbids exporting keys to disk. It's meant for
+// ClientCertificates or the Certificate of Targets.
+func SignerCertificate(chain []byte, key crypto.Signer) (tls.Certificate, error) {
+	var cert tls.Certificate
+	for {
+		var block *pem.Block
+		if block, chain = pem.Decode(chain); block == nil {
+			break
+		} else if block.Type == "CERTIFICATE" {
+			cert.Certificate = append(cert.Certificate, block.Bytes)
+		}
+	}
+
+	if len(cert.Certificate) == 0 {
+		return cert, errors.New("bad client certificate: no PEM encoded certificate")
+	}
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return cert, fmt.Errorf("bad client certificate: %s", err)
+	}
+
+	pub, ok := key.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !pub.Equal(leaf.PublicKey) {
+		return cert, errors.New("bad client certificate: public key doesn't match the signer's")
+	}
+
+	cert.PrivateKey, cert.Leaf = key, leaf
+
+	return cert, nil
+}
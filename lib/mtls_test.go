@@ -0,0 +1,191 @@
+package vegeta
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestClientCertificates(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu    sync.Mutex
+		names []string
+	)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if certs := r.TLS.PeerCertificates; len(certs) > 0 {
+			names = append(names, certs[0].Subject.CommonName)
+		} else {
+			names = append(names, "")
+		}
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	goku, vegeta, trunks := clientCert(t, "goku"), clientCert(t, "vegeta"), clientCert(t, "trunks")
+
+	atk := NewAttacker(KeepAlive(false), ClientCertificates(goku, vegeta))
+	for _, tgt := range []Target{
+		{Method: "GET", URL: server.URL},
+		{Method: "GET", URL: server.URL},
+		{Method: "GET", URL: server.URL, Certificate: &trunks},
+		{Method: "GET", URL: server.URL},
+	} {
+		if res := atk.hit(NewStaticTargeter(tgt), "", 0); res.Error != "" {
+			t.Fatal(res.Error)
+		}
+	}
+
+	if want := []string{"goku", "vegeta", "trunks", "goku"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("got client certificates %v, want %v", names, want)
+	}
+}
+
+func TestJSONTargetCertificate(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "vegeta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cert := clientCert(t, "goku")
+	certf := filepath.Join(dir, "goku.pem")
+	pemBytes := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]})
+	key, err := x509.MarshalECPrivateKey(cert.PrivateKey.(*ecdsa.PrivateKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pemBytes = append(pemBytes, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: key})...)
+	if err = ioutil.WriteFile(certf, pemBytes, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	src := `{"method": "GET", "url": "https://goku", "cert_file": "` + certf + `"}
+{"method": "GET", "url": "https://goku", "cert_file": "` + certf + `"}`
+	tr := NewLazyJSONTargeter(strings.NewReader(src), nil, nil)
+
+	var first, second Target
+	if err = tr(&first); err != nil {
+		t.Fatal(err)
+	} else if err = tr(&second); err != nil {
+		t.Fatal(err)
+	}
+
+	if first.Certificate == nil || !reflect.DeepEqual(first.Certificate.Certificate, cert.Certificate) {
+		t.Errorf("got certificate %v, want that of %s", first.Certificate, certf)
+	}
+
+	// Certificates are loaded once.
+	if first.Certificate != second.Certificate {
+		t.Error("got distinct certificates of the same file")
+	}
+
+	bad := `{"method": "GET", "url": "https://goku", "cert_file": "` + filepath.Join(dir, "missing.pem") + `"}`
+	if err = NewLazyJSONTargeter(strings.NewReader(bad), nil, nil)(&first); err == nil {
+		t.Error("got no error with a missing certificate")
+	}
+}
+
+// clientCert returns a new self-signed client certificate of the given
+// common name.
+func clientCert(t *testing.T, name string) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestSignerCertificate(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu    sync.Mutex
+		names []string
+	)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, cert := range r.TLS.PeerCertificates {
+			names = append(names, cert.Subject.CommonName)
+		}
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	goku := clientCert(t, "goku")
+	chain := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: goku.Certificate[0]})
+
+	// The key is only reachable through the crypto.Signer interface, as
+	// with hardware modules.
+	signer := opaqueSigner{goku.PrivateKey.(crypto.Signer)}
+	cert, err := SignerCertificate(chain, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	atk := NewAttacker(ClientCertificates(cert))
+	if res := atk.hit(NewStaticTargeter(Target{Method: "GET", URL: server.URL}), "", 0); res.Error != "" {
+		t.Fatal(res.Error)
+	}
+
+	if want := []string{"goku"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("got client certificates %v, want %v", names, want)
+	}
+
+	other := clientCert(t, "vegeta").PrivateKey.(crypto.Signer)
+	if _, err = SignerCertificate(chain, other); err == nil {
+		t.Error("got no error with a mismatched signer")
+	}
+
+	if _, err = SignerCertificate([]byte("kamehameha"), signer); err == nil {
+		t.Error("got no error without a certificate")
+	}
+}
+
+// opaqueSigner hides the type of its crypto.Signer.
+type opaqueSigner struct{ s crypto.Signer }
+
+func (o opaqueSigner) Public() crypto.PublicKey { return o.s.Public() }
+
+func (o opaqueSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return o.s.Sign(rand, digest, opts)
+}
@@ -0,0 +1,175 @@
+package vegeta
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultOAuth2Leeway is the default amount of time before their expiry at
+// which OAuth2 tokens are refreshed.
+const DefaultOAuth2Leeway = 30 * time.Second
+
+// OAuth2Config configures the OAuth2 client credentials grant of an OAuth2
+// token source.
+type OAuth2Config struct {
+	// TokenURL is the token endpoint of the authorization server.
+	TokenURL string
+	// ClientID and ClientSecret are the client's credentials, sent with
+	// HTTP basic authentication.
+	ClientID     string
+	ClientSecret string
+	// Scopes are the optional requested scopes.
+	Scopes []string
+	// Leeway is the amount of time before their expiry at which tokens are
+	// refreshed, DefaultOAuth2Leeway if zero. It's capped to half of their
+	// lifetime.
+	Leeway time.Duration
+	// Client is the http.Client tokens are requested with,
+	// http.DefaultClient if nil.
+	Client *http.Client
+}
+
+// OAuth2 is a source of OAuth2 access tokens obtained with the client
+// credentials grant, which it refreshes as they near their expiry. It's safe
+// for concurrent use.
+type OAuth2 struct {
+	cfg        OAuth2Config
+	mu         sync.Mutex
+	token      string
+	refresh    time.Time
+	expiry     time.Time
+	refreshing bool
+}
+
+// NewOAuth2 returns an OAuth2 token source with the given configuration,
+// after fetching its first token so that bad credentials fail early.
+func NewOAuth2(cfg OAuth2Config) (*OAuth2, error) {
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+
+	if cfg.Leeway <= 0 {
+		cfg.Leeway = DefaultOAuth2Leeway
+	}
+
+	o := &OAuth2{cfg: cfg}
+	tok, err := o.fetch()
+	if err != nil {
+		return nil, err
+	}
+	o.set(tok)
+
+	return o, nil
+}
+
+// OAuth2Credentials returns a functional option which makes an Attacker send
+// the tokens of the given OAuth2 token source as Bearer tokens in the
+// Authorization header of its requests. Tokens are refreshed before the
+// requests are timed, so refreshes don't count in their latencies.
+func OAuth2Credentials(o *OAuth2) func(*Attacker) {
+	return func(a *Attacker) { a.oauth2 = o }
+}
+
+// Token returns a valid access token. Tokens nearing their expiry are
+// refreshed in the background while they're still returned, and expired
+// ones are refreshed before returning.
+func (o *OAuth2) Token() (string, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	switch now := time.Now(); {
+	case now.Before(o.refresh):
+	case now.Before(o.expiry):
+		if !o.refreshing {
+			o.refreshing = true
+			go func() {
+				// Errors are retried by later calls.
+				tok, err := o.fetch()
+				o.mu.Lock()
+				defer o.mu.Unlock()
+				if o.refreshing = false; err == nil {
+					o.set(tok)
+				}
+			}()
+		}
+	default:
+		tok, err := o.fetch()
+		if err != nil {
+			return "", err
+		}
+		o.set(tok)
+	}
+
+	return o.token, nil
+}
+
+// oauth2Token is the token response of an authorization server.
+type oauth2Token struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// fetch requests a new token from the authorization server.
+func (o *OAuth2) fetch() (tok oauth2Token, err error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(o.cfg.Scopes) > 0 {
+		form.Set("scope", strings.Join(o.cfg.Scopes, " "))
+	}
+
+	req, err := http.NewRequest("POST", o.cfg.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return tok, fmt.Errorf("oauth2 token: %s", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(o.cfg.ClientID), url.QueryEscape(o.cfg.ClientSecret))
+
+	r, err := o.cfg.Client.Do(req)
+	if err != nil {
+		return tok, fmt.Errorf("oauth2 token: %s", err)
+	}
+	defer r.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		return tok, fmt.Errorf("oauth2 token: %s", err)
+	} else if r.StatusCode != http.StatusOK {
+		return tok, fmt.Errorf("oauth2 token: %s: %s", r.Status, body)
+	}
+
+	if err = json.Unmarshal(body, &tok); err != nil {
+		return tok, fmt.Errorf("oauth2 token: bad response: %s", err)
+	} else if tok.AccessToken == "" {
+		return tok, fmt.Errorf("oauth2 token: no access_token in response")
+	}
+
+	return tok, nil
+}
+
+// set sets the current token. It must be called with o.mu held, unless o
+// isn't shared yet.
+func (o *OAuth2) set(tok oauth2Token) {
+	now := time.Now()
+	o.token = tok.AccessToken
+	if tok.ExpiresIn <= 0 {
+		// Tokens without an expiry are used until the end of times.
+		o.refresh = now.AddDate(100, 0, 0)
+		o.expiry = o.refresh
+		return
+	}
+
+	lifetime := time.Duration(tok.ExpiresIn) * time.Second
+	leeway := o.cfg.Leeway
+	if leeway > lifetime/2 {
+		leeway = lifetime / 2
+	}
+
+	o.expiry = now.Add(lifetime)
+	o.refresh = o.expiry.Add(-leeway)
+}
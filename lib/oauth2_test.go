@@ -0,0 +1,65 @@
+package vegeta
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestOAuth2(t *testing.T) {
+	t.Parallel()
+
+	var tokens int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			id, secret, _ := r.BasicAuth()
+			if id != "goku" || secret != "kamehameha" || r.FormValue("grant_type") != "client_credentials" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			n := atomic.AddInt32(&tokens, 1)
+			fmt.Fprintf(w, `{"access_token": "t%d", "token_type": "Bearer", "expires_in": 2}`, n)
+		default:
+			w.Write([]byte(r.Header.Get("Authorization")))
+		}
+	}))
+	defer server.Close()
+
+	cfg := OAuth2Config{TokenURL: server.URL + "/token", ClientID: "goku", ClientSecret: "kamehameha"}
+	o, err := NewOAuth2(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	atk := NewAttacker(OAuth2Credentials(o))
+	tr := NewStaticTargeter(Target{Method: "GET", URL: server.URL})
+
+	if res := atk.hit(tr, "", 0); string(res.Body) != "Bearer t1" {
+		t.Errorf("got authorization %q, want %q", res.Body, "Bearer t1")
+	}
+
+	// Past half of its lifetime, the token is refreshed in the background
+	// while still being used.
+	time.Sleep(1100 * time.Millisecond)
+	if tok, err := o.Token(); err != nil || tok != "t1" {
+		t.Errorf("got token %q, error %v, want t1", tok, err)
+	}
+
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(10 * time.Millisecond) {
+		if res := atk.hit(tr, "", 0); string(res.Body) == "Bearer t2" {
+			break
+		} else if time.Now().After(deadline) {
+			t.Fatalf("got authorization %q, want %q", res.Body, "Bearer t2")
+		}
+	}
+
+	cfg.ClientSecret = "galick gun"
+	if _, err := NewOAuth2(cfg); err == nil || !strings.HasPrefix(err.Error(), "oauth2 token: 401") {
+		t.Errorf("got error %v, want unauthorized", err)
+	}
+}
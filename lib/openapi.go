@@ -0,0 +1,423 @@
+package vegeta
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// NewOpenAPITargets reads an OpenAPI 3 or Swagger 2 spec, in JSON or YAML,
+// out of the provided io.Reader and returns one Target per operation, sorted
+// by path and method.
+//
+// base is prepended to every path; when empty, the first server in the spec
+// is used. ops restricts the returned Targets to the given operations, which
+// are matched by operationId or as "METHOD /path". When empty, all operations
+// are returned.
+//
+// Path, query and header parameters and JSON request bodies are filled in
+// from examples, defaults and enums in the spec, falling back to fake data
+// generated from their schemas: strings by format or by parameter and
+// property name (e.g. email, first_name, city), numbers within their minimum
+// and maximum.
+func NewOpenAPITargets(spec io.Reader, base string, ops ...string) ([]Target, error) {
+	doc, err := openAPIDecode(spec)
+	if err != nil {
+		return nil, fmt.Errorf("bad spec: %s", err)
+	}
+
+	s := openAPISpec{doc: doc}
+	if base == "" {
+		base = s.server()
+	}
+	base = strings.TrimSuffix(base, "/")
+
+	paths, _ := doc["paths"].(map[string]interface{})
+	if len(paths) == 0 {
+		return nil, ErrNoTargets
+	}
+
+	selected := make(map[string]bool, len(ops))
+	for _, op := range ops {
+		selected[op] = true
+	}
+
+	var tgts []Target
+	for _, path := range sortedKeys(paths) {
+		item, _ := s.resolve(paths[path]).(map[string]interface{})
+		for _, method := range []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"} {
+			op, ok := s.resolve(item[method]).(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			name, _ := op["operationId"].(string)
+			id := strings.ToUpper(method) + " " + path
+			if len(selected) > 0 && !selected[name] && !selected[id] {
+				continue
+			}
+
+			tgt, err := s.target(base, path, method, item, op)
+			if err != nil {
+				return nil, fmt.Errorf("bad operation %s: %s", id, err)
+			}
+			tgts = append(tgts, tgt)
+		}
+	}
+
+	if len(tgts) == 0 {
+		return nil, ErrNoTargets
+	}
+
+	return tgts, nil
+}
+
+// openAPIDecode decodes a JSON or YAML document into the same generic
+// representation encoding/json produces.
+func openAPIDecode(r io.Reader) (map[string]interface{}, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc map[string]interface{}
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		err = json.Unmarshal(data, &doc)
+		return doc, err
+	}
+
+	var v interface{}
+	if err = yaml.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+
+	doc, ok := openAPIFromYAML(v).(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("not an object")
+	}
+
+	return doc, nil
+}
+
+// openAPIFromYAML converts the maps and numbers decoded by yaml.v2 to
+// string keyed maps and float64s.
+func openAPIFromYAML(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(val))
+		for k, v := range val {
+			m[fmt.Sprint(k)] = openAPIFromYAML(v)
+		}
+		return m
+	case []interface{}:
+		for i := range val {
+			val[i] = openAPIFromYAML(val[i])
+		}
+		return val
+	case int:
+		return float64(val)
+	case int64:
+		return float64(val)
+	case uint64:
+		return float64(val)
+	default:
+		return val
+	}
+}
+
+// openAPISpec wraps a generically decoded OpenAPI document.
+type openAPISpec struct {
+	doc map[string]interface{}
+}
+
+func (s openAPISpec) server() string {
+	if servers, ok := s.doc["servers"].([]interface{}); ok && len(servers) > 0 {
+		if server, ok := servers[0].(map[string]interface{}); ok {
+			u, _ := server["url"].(string)
+			return u
+		}
+	}
+
+	host, _ := s.doc["host"].(string)
+	if host == "" {
+		host = "localhost"
+	}
+
+	scheme := "http"
+	if schemes, ok := s.doc["schemes"].([]interface{}); ok && len(schemes) > 0 {
+		scheme, _ = schemes[0].(string)
+	}
+
+	basePath, _ := s.doc["basePath"].(string)
+	return scheme + "://" + host + basePath
+}
+
+func (s openAPISpec) target(base, path, method string, item, op map[string]interface{}) (Target, error) {
+	tgt := Target{Method: strings.ToUpper(method), Header: http.Header{}}
+	query := url.Values{}
+
+	params := append(s.list(item["parameters"]), s.list(op["parameters"])...)
+	for _, p := range params {
+		param, ok := s.resolve(p).(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		name, _ := param["name"].(string)
+		in, _ := param["in"].(string)
+		if in == "body" {
+			body, err := json.Marshal(s.value(param, name, 0))
+			if err != nil {
+				return tgt, err
+			}
+			tgt.Body = body
+			tgt.Header.Set("Content-Type", "application/json")
+			continue
+		}
+
+		required, _ := param["required"].(bool)
+		if in != "path" && !required {
+			continue
+		}
+
+		v := openAPIString(s.value(param, name, 0))
+		switch in {
+		case "path":
+			path = strings.Replace(path, "{"+name+"}", url.PathEscape(v), -1)
+		case "query":
+			query.Add(name, v)
+		case "header":
+			tgt.Header.Set(name, v)
+		}
+	}
+
+	if rb, ok := s.resolve(op["requestBody"]).(map[string]interface{}); ok {
+		content, _ := rb["content"].(map[string]interface{})
+		for _, ct := range sortedKeys(content) {
+			if !strings.Contains(ct, "json") {
+				continue
+			}
+			body, err := json.Marshal(s.value(content[ct], "", 0))
+			if err != nil {
+				return tgt, err
+			}
+			tgt.Body = body
+			tgt.Header.Set("Content-Type", ct)
+			break
+		}
+	}
+
+	tgt.URL = base + path
+	if len(query) > 0 {
+		tgt.URL += "?" + query.Encode()
+	}
+
+	return tgt, nil
+}
+
+// openAPIMaxDepth bounds the recursion of value generation for recursive
+// schemas.
+const openAPIMaxDepth = 8
+
+// value returns an example value for the given parameter, media type or
+// schema object. name is the parameter or property name the value is for,
+// used to pick a fitting fake string.
+func (s openAPISpec) value(v interface{}, name string, depth int) interface{} {
+	obj, ok := s.resolve(v).(map[string]interface{})
+	if !ok || depth > openAPIMaxDepth {
+		return nil
+	}
+
+	if ex, ok := obj["example"]; ok {
+		return ex
+	}
+
+	if exs, ok := obj["examples"].(map[string]interface{}); ok {
+		for _, k := range sortedKeys(exs) {
+			if ex, ok := s.resolve(exs[k]).(map[string]interface{}); ok {
+				if val, ok := ex["value"]; ok {
+					return val
+				}
+			}
+		}
+	}
+
+	if def, ok := obj["default"]; ok {
+		return def
+	}
+
+	if enum, ok := obj["enum"].([]interface{}); ok && len(enum) > 0 {
+		return enum[0]
+	}
+
+	if schema, ok := obj["schema"]; ok {
+		return s.value(schema, name, depth+1)
+	}
+
+	for _, key := range []string{"allOf", "oneOf", "anyOf"} {
+		if schemas := s.list(obj[key]); len(schemas) > 0 {
+			if key != "allOf" {
+				return s.value(schemas[0], name, depth+1)
+			}
+			merged := map[string]interface{}{}
+			for _, schema := range schemas {
+				if m, ok := s.value(schema, name, depth+1).(map[string]interface{}); ok {
+					for k, v := range m {
+						merged[k] = v
+					}
+				}
+			}
+			return merged
+		}
+	}
+
+	typ, _ := obj["type"].(string)
+	format, _ := obj["format"].(string)
+	min, hasMin := obj["minimum"].(float64)
+	max, hasMax := obj["maximum"].(float64)
+	if !hasMin {
+		min = 1
+	}
+	if !hasMax || max < min {
+		max = min + 99
+	}
+
+	switch {
+	case typ == "object" || obj["properties"] != nil:
+		props, _ := obj["properties"].(map[string]interface{})
+		out := make(map[string]interface{}, len(props))
+		for prop, schema := range props {
+			out[prop] = s.value(schema, prop, depth+1)
+		}
+		return out
+	case typ == "array":
+		return []interface{}{s.value(obj["items"], name, depth+1)}
+	case typ == "integer":
+		lo, hi := int64(math.Ceil(min)), int64(math.Floor(max))
+		if hi < lo {
+			return lo
+		}
+		return lo + rand.Int63n(hi-lo+1)
+	case typ == "number":
+		return math.Round((min+rand.Float64()*(max-min))*100) / 100
+	case typ == "boolean":
+		return true
+	case typ == "string":
+		if fake, ok := openAPIStringFormats[format]; ok {
+			return fake()
+		}
+		key := strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(name))
+		if fake, ok := openAPIStringNames[key]; ok {
+			return fake()
+		}
+		word, _ := lorem(1)
+		return word
+	}
+
+	return nil
+}
+
+// openAPIStringFormats maps well known string formats to fake value
+// generators.
+var openAPIStringFormats = map[string]func() string{
+	"date":      func() string { return time.Now().Format("2006-01-02") },
+	"date-time": func() string { return time.Now().UTC().Format(time.RFC3339) },
+	"email":     fakeEmail,
+	"hostname":  func() string { return fakePick(fakeDomains) },
+	"ipv4":      func() string { return fmt.Sprintf("10.%d.%d.%d", rand.Intn(256), rand.Intn(256), 1+rand.Intn(254)) },
+	"ipv6":      func() string { return fmt.Sprintf("fd00::%x", 1+rand.Intn(0xffff)) },
+	"uri":       func() string { return "http://" + fakePick(fakeDomains) + "/" },
+	"uuid":      func() string { id, _ := templateUUID(); return id },
+	"byte":      func() string { return "dmVnZXRh" },
+	"password":  func() string { word, _ := lorem(1); return word + strconv.Itoa(rand.Intn(1000)) },
+}
+
+// openAPIStringNames maps parameter and property names, lower cased and
+// stripped of underscores and dashes, to fake value generators.
+var openAPIStringNames = map[string]func() string{
+	"name":        fakeName,
+	"fullname":    fakeName,
+	"firstname":   fakeFirstName,
+	"givenname":   fakeFirstName,
+	"lastname":    fakeLastName,
+	"surname":     fakeLastName,
+	"familyname":  fakeLastName,
+	"email":       fakeEmail,
+	"phone":       fakePhone,
+	"phonenumber": fakePhone,
+	"street":      fakeStreet,
+	"address":     fakeAddress,
+	"city":        fakeCity,
+	"zip":         fakeZip,
+	"zipcode":     fakeZip,
+	"postcode":    fakeZip,
+	"postalcode":  fakeZip,
+	"company":     fakeCompany,
+	"description": loremSentence,
+}
+
+// resolve follows local JSON references (e.g. #/components/schemas/Pet).
+func (s openAPISpec) resolve(v interface{}) interface{} {
+	for i := 0; i < openAPIMaxDepth; i++ {
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return v
+		}
+
+		ref, ok := obj["$ref"].(string)
+		if !ok || !strings.HasPrefix(ref, "#/") {
+			return v
+		}
+
+		var cur interface{} = s.doc
+		for _, tok := range strings.Split(ref[2:], "/") {
+			tok = strings.Replace(strings.Replace(tok, "~1", "/", -1), "~0", "~", -1)
+			m, _ := cur.(map[string]interface{})
+			cur = m[tok]
+		}
+		v = cur
+	}
+	return v
+}
+
+func (s openAPISpec) list(v interface{}) []interface{} {
+	l, _ := s.resolve(v).([]interface{})
+	return l
+}
+
+func openAPIString(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case int, int64, bool:
+		return fmt.Sprint(val)
+	default:
+		bs, _ := json.Marshal(val)
+		return string(bs)
+	}
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,270 @@
+package vegeta
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const petstore = `{
+  "openapi": "3.0.0",
+  "servers": [{"url": "http://petstore.local/v1"}],
+  "paths": {
+    "/pets": {
+      "get": {
+        "operationId": "listPets",
+        "parameters": [
+          {"name": "limit", "in": "query", "required": true, "schema": {"type": "integer", "minimum": 10}},
+          {"name": "offset", "in": "query", "schema": {"type": "integer"}}
+        ]
+      },
+      "post": {
+        "operationId": "createPet",
+        "requestBody": {
+          "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Pet"}}}
+        }
+      }
+    },
+    "/pets/{petId}": {
+      "parameters": [{"name": "petId", "in": "path", "required": true, "example": "42"}],
+      "delete": {
+        "operationId": "deletePet",
+        "parameters": [{"name": "X-Token", "in": "header", "required": true, "schema": {"type": "string", "enum": ["t0k3n"]}}]
+      }
+    }
+  },
+  "components": {
+    "schemas": {
+      "Pet": {
+        "type": "object",
+        "properties": {
+          "name": {"type": "string", "example": "goku"},
+          "tags": {"type": "array", "items": {"type": "string"}}
+        }
+      }
+    }
+  }
+}`
+
+func TestNewOpenAPITargets(t *testing.T) {
+	t.Parallel()
+
+	got, err := NewOpenAPITargets(strings.NewReader(petstore), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 3 {
+		t.Fatalf("got %d targets, want 3", len(got))
+	}
+
+	// Values generated from schemas are random, so check their shape and
+	// blank them out before comparing.
+	u, err := url.Parse(got[0].URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if limit, err := strconv.Atoi(u.Query().Get("limit")); err != nil || limit < 10 || limit > 109 {
+		t.Errorf("limit %q not within [10, 109]", u.Query().Get("limit"))
+	}
+	got[0].URL = strings.Split(got[0].URL, "?")[0]
+
+	var pet struct {
+		Name string
+		Tags []string
+	}
+	if err := json.Unmarshal(got[1].Body, &pet); err != nil {
+		t.Fatal(err)
+	}
+	if pet.Name != "goku" || len(pet.Tags) != 1 || !isLoremWord(pet.Tags[0]) {
+		t.Errorf("bad pet body: %s", got[1].Body)
+	}
+	got[1].Body = nil
+
+	want := []Target{
+		{
+			Method: "GET",
+			URL:    "http://petstore.local/v1/pets",
+			Header: http.Header{},
+		},
+		{
+			Method: "POST",
+			URL:    "http://petstore.local/v1/pets",
+			Header: http.Header{"Content-Type": []string{"application/json"}},
+		},
+		{
+			Method: "DELETE",
+			URL:    "http://petstore.local/v1/pets/42",
+			Header: http.Header{"X-Token": []string{"t0k3n"}},
+		},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("\ngot:  %+v\nwant: %+v", got, want)
+	}
+}
+
+func TestNewOpenAPITargets_Selection(t *testing.T) {
+	t.Parallel()
+
+	got, err := NewOpenAPITargets(strings.NewReader(petstore), "http://staging/", "createPet", "DELETE /pets/{petId}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var urls []string
+	for _, tgt := range got {
+		urls = append(urls, tgt.Method+" "+tgt.URL)
+	}
+
+	want := []string{"POST http://staging/pets", "DELETE http://staging/pets/42"}
+	if !reflect.DeepEqual(urls, want) {
+		t.Errorf("got: %v, want: %v", urls, want)
+	}
+
+	if _, err = NewOpenAPITargets(strings.NewReader(petstore), "", "bogus"); err != ErrNoTargets {
+		t.Errorf("got: %v, want: %v", err, ErrNoTargets)
+	}
+}
+
+func TestNewOpenAPITargets_Swagger2(t *testing.T) {
+	t.Parallel()
+
+	spec := `{
+	  "swagger": "2.0",
+	  "host": "api.local",
+	  "basePath": "/v2",
+	  "schemes": ["https"],
+	  "paths": {
+	    "/users": {
+	      "post": {
+	        "parameters": [{"name": "user", "in": "body", "schema": {"$ref": "#/definitions/User"}}]
+	      }
+	    }
+	  },
+	  "definitions": {
+	    "User": {"properties": {"email": {"type": "string", "format": "email"}}}
+	  }
+	}`
+
+	got, err := NewOpenAPITargets(strings.NewReader(spec), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 1 {
+		t.Fatalf("got %d targets, want 1", len(got))
+	}
+
+	if !regexp.MustCompile(`^\{"email":"[a-z]+\.[a-z]+\d+@example\.(com|net|org)"\}$`).Match(got[0].Body) {
+		t.Errorf("bad user body: %s", got[0].Body)
+	}
+	got[0].Body = nil
+
+	want := []Target{{
+		Method: "POST",
+		URL:    "https://api.local/v2/users",
+		Header: http.Header{"Content-Type": []string{"application/json"}},
+	}}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("\ngot:  %+v\nwant: %+v", got, want)
+	}
+}
+
+func TestNewOpenAPITargets_YAML(t *testing.T) {
+	t.Parallel()
+
+	spec := `
+openapi: 3.0.0
+servers:
+  - url: http://petstore.local/v1
+paths:
+  /pets/{petId}:
+    get:
+      operationId: showPet
+      parameters:
+        - name: petId
+          in: path
+          required: true
+          schema: {type: integer, minimum: 7, maximum: 7}
+        - name: X-Token
+          in: header
+          required: true
+          example: t0k3n
+`
+
+	got, err := NewOpenAPITargets(strings.NewReader(spec), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Target{{
+		Method: "GET",
+		URL:    "http://petstore.local/v1/pets/7",
+		Header: http.Header{"X-Token": []string{"t0k3n"}},
+	}}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("\ngot:  %+v\nwant: %+v", got, want)
+	}
+
+	if _, err = NewOpenAPITargets(strings.NewReader("- not\n- a spec\n"), ""); err == nil {
+		t.Error("want error decoding a YAML list")
+	}
+}
+
+func TestOpenAPISpec_FakeValues(t *testing.T) {
+	t.Parallel()
+
+	var s openAPISpec
+	for _, tc := range []struct {
+		name   string
+		schema string
+		match  func(interface{}) bool
+	}{
+		{"first_name", `{"type": "string"}`, func(v interface{}) bool { return contains(fakeFirstNames, v) }},
+		{"city", `{"type": "string"}`, func(v interface{}) bool { return contains(fakeCities, v) }},
+		{"Last-Name", `{"type": "string"}`, func(v interface{}) bool { return contains(fakeLastNames, v) }},
+		{"id", `{"type": "string", "format": "uuid"}`, func(v interface{}) bool {
+			return regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(v.(string))
+		}},
+		{"nickname", `{"type": "string"}`, func(v interface{}) bool { return isLoremWord(v.(string)) }},
+		{"status", `{"type": "string", "enum": ["sold", "pending"]}`, func(v interface{}) bool { return v == "sold" }},
+		{"age", `{"type": "integer", "minimum": 18, "maximum": 21}`, func(v interface{}) bool {
+			n := v.(int64)
+			return n >= 18 && n <= 21
+		}},
+		{"price", `{"type": "number", "minimum": 0.5, "maximum": 2}`, func(v interface{}) bool {
+			n := v.(float64)
+			return n >= 0.5 && n <= 2
+		}},
+	} {
+		var schema interface{}
+		if err := json.Unmarshal([]byte(tc.schema), &schema); err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 20; i++ {
+			if v := s.value(schema, tc.name, 0); !tc.match(v) {
+				t.Errorf("%s %s: unexpected value %#v", tc.name, tc.schema, v)
+				break
+			}
+		}
+	}
+}
+
+func isLoremWord(w string) bool { return contains(fakeLoremWords, w) }
+
+func contains(words []string, v interface{}) bool {
+	for _, w := range words {
+		if w == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,30 @@
+// Package pacing defines the times at which attacks hit. It only depends on
+// the standard library, so that custom binaries can pace any work with it.
+// Its types are aliased by the vegeta package, which drives them.
+package pacing
+
+import "time"
+
+// A Pacer defines the times at which an attack hits its targets.
+type Pacer interface {
+	// Pace returns the time, relative to the beginning of the attack, at which
+	// the given zero based hit is due or true if the attack should stop
+	// before it.
+	Pace(hit uint64) (due time.Duration, stop bool)
+}
+
+// ConstantPacer is a Pacer which hits at a constant rate, in hits per second,
+// for the given duration. When the duration is zero it paces forever.
+type ConstantPacer struct {
+	Rate     uint64
+	Duration time.Duration
+}
+
+// Pace implements the Pacer interface.
+func (p ConstantPacer) Pace(hit uint64) (time.Duration, bool) {
+	if hits := p.Rate * uint64(p.Duration.Seconds()); hits > 0 && hit >= hits {
+		return 0, true
+	}
+	interval := 1e9 / p.Rate
+	return time.Duration(hit * interval), false
+}
@@ -0,0 +1,27 @@
+package pacing
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConstantPacer(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		pacer ConstantPacer
+		hit   uint64
+		due   time.Duration
+		stop  bool
+	}{
+		{ConstantPacer{Rate: 10, Duration: time.Second}, 0, 0, false},
+		{ConstantPacer{Rate: 10, Duration: time.Second}, 9, 900 * time.Millisecond, false},
+		{ConstantPacer{Rate: 10, Duration: time.Second}, 10, 0, true},
+		{ConstantPacer{Rate: 4}, 1000, 250 * time.Second, false},
+	} {
+		due, stop := tc.pacer.Pace(tc.hit)
+		if due != tc.due || stop != tc.stop {
+			t.Errorf("%+v.Pace(%d): got (%s, %t), want (%s, %t)", tc.pacer, tc.hit, due, stop, tc.due, tc.stop)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package vegeta
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http/httptrace"
+	"sync/atomic"
+	"time"
+)
+
+// Phases are the durations of the phases of a hit, which tell where its
+// latency was spent. Those of establishing a connection are zero when the
+// hit reused one.
+type Phases struct {
+	// DNS is the time spent resolving the target host.
+	DNS time.Duration `json:"dns"`
+	// Connect is the time spent establishing the TCP connection, from the
+	// first attempt on.
+	Connect time.Duration `json:"connect"`
+	// TLS is the time spent on the TLS handshake.
+	TLS time.Duration `json:"tls"`
+	// FirstByte is the time from writing the request to reading the first
+	// byte of the response, i.e. the time the target took to respond.
+	FirstByte time.Duration `json:"first_byte"`
+	// Body is the time spent reading the response body.
+	Body time.Duration `json:"body"`
+}
+
+// phaseTrace records the Phases of a request, the kind of its TLS handshake
+// and the connection it got, with an httptrace.ClientTrace. Its hooks can be
+// called concurrently, e.g. by racing dials.
+type phaseTrace struct {
+	// Nanoseconds since start, zero until set.
+	dnsStart, dns         int64
+	connectStart, connect int64
+	tlsStart, tls         int64
+	wrote, firstByte      int64
+	reused                int32
+	start                 time.Time
+	handshake             atomic.Value
+	remote                atomic.Value
+}
+
+// newPhaseTrace returns a new phaseTrace of a request about to be sent.
+func newPhaseTrace() *phaseTrace {
+	return &phaseTrace{start: time.Now()}
+}
+
+// clientTrace returns the httptrace.ClientTrace recording the phases.
+func (p *phaseTrace) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused {
+				atomic.StoreInt32(&p.reused, 1)
+			}
+			if addr := info.Conn.RemoteAddr(); addr != nil {
+				p.remote.Store(addr.String())
+			}
+		},
+		DNSStart: func(httptrace.DNSStartInfo) { p.begin(&p.dnsStart) },
+		DNSDone: func(info httptrace.DNSDoneInfo) {
+			if info.Err == nil {
+				p.end(&p.dnsStart, &p.dns)
+			}
+		},
+		ConnectStart: func(_, _ string) { p.begin(&p.connectStart) },
+		ConnectDone: func(_, _ string, err error) {
+			if err == nil {
+				p.end(&p.connectStart, &p.connect)
+			}
+		},
+		TLSHandshakeStart: func() { p.begin(&p.tlsStart) },
+		TLSHandshakeDone: func(cs tls.ConnectionState, err error) {
+			if err == nil {
+				p.end(&p.tlsStart, &p.tls)
+				p.handshake.Store(handshakeKind(cs))
+			}
+		},
+		WroteRequest: func(info httptrace.WroteRequestInfo) {
+			if info.Err == nil {
+				p.begin(&p.wrote)
+			}
+		},
+		GotFirstResponseByte: func() { p.end(&p.wrote, &p.firstByte) },
+	}
+}
+
+// begin records the start of a phase, unless it already started, so that
+// racing dials count from the first one on.
+func (p *phaseTrace) begin(start *int64) {
+	atomic.CompareAndSwapInt64(start, 0, p.now())
+}
+
+// end records the duration of a started phase.
+func (p *phaseTrace) end(start, d *int64) {
+	if s := atomic.LoadInt64(start); s != 0 {
+		atomic.StoreInt64(d, p.now()-s)
+	}
+}
+
+// now returns the nanoseconds since start, at least one.
+func (p *phaseTrace) now() int64 {
+	return int64(time.Since(p.start)) + 1
+}
+
+// phases returns the recorded Phases, but the Body one, and the kind of the
+// TLS handshake, if any. Those of establishing a connection are left out
+// when the request reused one, in case its dial was still running.
+func (p *phaseTrace) phases() (ph Phases, handshake string) {
+	ph.FirstByte = time.Duration(atomic.LoadInt64(&p.firstByte))
+	if atomic.LoadInt32(&p.reused) == 1 {
+		return ph, ""
+	}
+
+	ph.DNS = time.Duration(atomic.LoadInt64(&p.dns))
+	ph.Connect = time.Duration(atomic.LoadInt64(&p.connect))
+	ph.TLS = time.Duration(atomic.LoadInt64(&p.tls))
+	handshake, _ = p.handshake.Load().(string)
+
+	return ph, handshake
+}
+
+// conn returns the remote address of the connection the request got, if
+// any, and whether it was reused.
+func (p *phaseTrace) conn() (remote string, reused bool) {
+	remote, _ = p.remote.Load().(string)
+	return remote, atomic.LoadInt32(&p.reused) == 1
+}
+
+// lookup resolves the given host with the Attacker's Resolver, reporting it
+// to the httptrace.ClientTrace of the given context, if any, since it
+// doesn't go through the lookups of the net package.
+func (a *Attacker) lookup(ctx context.Context, host string) ([]string, error) {
+	trace := httptrace.ContextClientTrace(ctx)
+	if trace == nil || net.ParseIP(host) != nil {
+		return a.resolver.LookupHost(ctx, host)
+	}
+
+	if trace.DNSStart != nil {
+		trace.DNSStart(httptrace.DNSStartInfo{Host: host})
+	}
+
+	ips, err := a.resolver.LookupHost(ctx, host)
+
+	if trace.DNSDone != nil {
+		info := httptrace.DNSDoneInfo{Err: err}
+		for _, ip := range ips {
+			info.Addrs = append(info.Addrs, net.IPAddr{IP: net.ParseIP(ip)})
+		}
+		trace.DNSDone(info)
+	}
+
+	return ips, err
+}
+
+// PhaseMetrics holds latency metrics of the phases of the hits, each
+// computed over the hits which went through it.
+type PhaseMetrics struct {
+	DNS       LatencyMetrics `json:"dns"`
+	Connect   LatencyMetrics `json:"connect"`
+	TLS       LatencyMetrics `json:"tls"`
+	FirstByte LatencyMetrics `json:"first_byte"`
+	Body      LatencyMetrics `json:"body"`
+}
@@ -0,0 +1,60 @@
+package vegeta
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestPhases(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.(http.Flusher).Flush()
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte("kamehameha"))
+	}))
+	defer server.Close()
+
+	// The host name is resolved by the Attacker, unlike the server's IP.
+	url := "https://localhost:" + server.URL[len("https://127.0.0.1:"):]
+	atk := NewAttacker(HostMap(map[string][]string{"localhost": {"127.0.0.1"}}))
+	tr := NewStaticTargeter(Target{Method: "GET", URL: url})
+
+	var m Metrics
+	for i := 0; i < 2; i++ {
+		res := atk.hit(tr, "", uint64(i))
+		if res.Error != "" {
+			t.Fatal(res.Error)
+		}
+		m.Add(res)
+
+		ph := res.Phases
+		if ph.FirstByte < 20*time.Millisecond || ph.Body < 20*time.Millisecond {
+			t.Errorf("hit %d: got first byte %s and body %s phases, want at least 20ms", i, ph.FirstByte, ph.Body)
+		}
+
+		if dialed := ph.DNS > 0 && ph.Connect > 0 && ph.TLS > 0; dialed != (i == 0) {
+			t.Errorf("hit %d: got connection phases %+v", i, ph)
+		}
+
+		if res.RemoteAddr != server.Listener.Addr().String() || res.ConnReused != (i == 1) {
+			t.Errorf("hit %d: got connection to %s reused %t", i, res.RemoteAddr, res.ConnReused)
+		}
+
+		if sum := ph.DNS + ph.Connect + ph.TLS + ph.FirstByte + ph.Body; sum > res.Latency {
+			t.Errorf("hit %d: got phases summing to %s, more than the %s latency", i, sum, res.Latency)
+		}
+	}
+	m.Close()
+
+	if m.Phases.TLS.Mean != m.Phases.TLS.Max || m.Phases.TLS.Total != m.Phases.TLS.Max {
+		t.Errorf("got TLS phase metrics %+v of a single handshake", m.Phases.TLS)
+	}
+
+	if m.Phases.Body.Total < 40*time.Millisecond || m.Phases.Body.Mean < 20*time.Millisecond {
+		t.Errorf("got body phase metrics %+v", m.Phases.Body)
+	}
+}
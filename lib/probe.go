@@ -0,0 +1,258 @@
+package vegeta
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+// A ProbeTargeter decodes a probe address or returns an error in case of
+// failure. Implementations must be safe for concurrent use.
+type ProbeTargeter func(*string) error
+
+// NewProbeTargeter eagerly reads all addresses out of the provided io.Reader,
+// one per non empty line, and returns a ProbeTargeter which round-robins over
+// them. Addresses are host:port pairs for TCP probes and hosts for ICMP
+// probes.
+func NewProbeTargeter(src io.Reader) (ProbeTargeter, error) {
+	var addrs []string
+	sc := bufio.NewScanner(src)
+	for sc.Scan() {
+		if addr := strings.TrimSpace(sc.Text()); addr != "" {
+			addrs = append(addrs, addr)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(addrs) == 0 {
+		return nil, ErrNoTargets
+	}
+
+	i := int64(-1)
+	return func(addr *string) error {
+		if addr == nil {
+			return ErrNilTarget
+		}
+		*addr = addrs[atomic.AddInt64(&i, 1)%int64(len(addrs))]
+		return nil
+	}, nil
+}
+
+// Probe modes supported by a ProbeAttacker.
+const (
+	// ProbeTCP probes by establishing and closing a TCP connection.
+	ProbeTCP = "tcp"
+	// ProbeICMP probes by sending an ICMP echo request and waiting for its
+	// reply.
+	ProbeICMP = "icmp"
+)
+
+// ProbeAttacker is an attack executor which measures raw network path
+// latency with TCP connects or ICMP echoes, without any HTTP involved.
+type ProbeAttacker struct {
+	mode       string
+	timeout    time.Duration
+	workers    uint64
+	privileged bool
+	stopch     chan struct{}
+}
+
+// NewProbeAttacker returns a new ProbeAttacker with default options which are
+// overridden by the optionally provided opts.
+func NewProbeAttacker(opts ...func(*ProbeAttacker)) *ProbeAttacker {
+	a := &ProbeAttacker{
+		mode:    ProbeTCP,
+		timeout: DefaultTimeout,
+		workers: DefaultWorkers,
+		stopch:  make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	return a
+}
+
+// ProbeMode returns a functional option which sets the kind of probes sent
+// by a ProbeAttacker: one of ProbeTCP or ProbeICMP.
+func ProbeMode(mode string) func(*ProbeAttacker) {
+	return func(a *ProbeAttacker) { a.mode = mode }
+}
+
+// ProbeTimeout returns a functional option which sets the maximum amount of
+// time a ProbeAttacker waits for a probe to complete.
+func ProbeTimeout(d time.Duration) func(*ProbeAttacker) {
+	return func(a *ProbeAttacker) { a.timeout = d }
+}
+
+// ProbeWorkers returns a functional option which sets the initial number of
+// workers a ProbeAttacker uses to send its probes.
+func ProbeWorkers(n uint64) func(*ProbeAttacker) {
+	return func(a *ProbeAttacker) { a.workers = n }
+}
+
+// ProbePrivileged returns a functional option which makes a ProbeAttacker
+// send ICMP probes over raw sockets, which requires elevated privileges.
+// Otherwise, unprivileged ICMP datagram sockets are used, which must be
+// allowed by the system (e.g. net.ipv4.ping_group_range on Linux).
+func ProbePrivileged(privileged bool) func(*ProbeAttacker) {
+	return func(a *ProbeAttacker) { a.privileged = privileged }
+}
+
+// Attack reads its addresses from the passed ProbeTargeter and probes them at
+// the rate specified for the given duration. When the duration is zero the
+// attack runs until Stop is called. Results are sent to the returned channel
+// as soon as they arrive and will have their Attack field set to the given
+// name.
+func (a *ProbeAttacker) Attack(tr ProbeTargeter, rate uint64, du time.Duration, name string) <-chan *Result {
+	return Pace(ConstantPacer{Rate: rate, Duration: du}, a.workers, a.stopch, func(seq uint64) *Result {
+		return a.hit(tr, name, seq)
+	})
+}
+
+// Stop stops the current attack.
+func (a *ProbeAttacker) Stop() {
+	select {
+	case <-a.stopch:
+		return
+	default:
+		close(a.stopch)
+	}
+}
+
+func (a *ProbeAttacker) hit(tr ProbeTargeter, name string, seq uint64) *Result {
+	var (
+		res  = Result{Attack: name, Seq: seq}
+		addr string
+		err  error
+	)
+
+	defer func() {
+		if err != nil {
+			res.Error = err.Error()
+		}
+	}()
+
+	if err = tr(&addr); err != nil {
+		a.Stop()
+		return &res
+	}
+
+	res.Timestamp = time.Now()
+	switch a.mode {
+	case ProbeTCP:
+		var conn net.Conn
+		if conn, err = net.DialTimeout("tcp", addr, a.timeout); err == nil {
+			err = conn.Close()
+		}
+	case ProbeICMP:
+		res.BytesOut, res.BytesIn, err = a.ping(addr, seq)
+	default:
+		err = fmt.Errorf("unsupported probe mode: %s", a.mode)
+	}
+
+	if err == nil {
+		res.Latency = time.Since(res.Timestamp)
+	}
+
+	return &res
+}
+
+var errICMPTimeout = errors.New("icmp: timeout awaiting echo reply")
+
+func (a *ProbeAttacker) ping(host string, seq uint64) (out, in uint64, err error) {
+	dst, err := net.ResolveIPAddr("ip", host)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var (
+		network, laddr string
+		proto          int
+		typ            icmp.Type
+	)
+
+	if dst.IP.To4() != nil {
+		network, laddr, proto, typ = "udp4", "0.0.0.0", 1, ipv4.ICMPTypeEcho
+		if a.privileged {
+			network = "ip4:icmp"
+		}
+	} else {
+		network, laddr, proto, typ = "udp6", "::", 58, ipv6.ICMPTypeEchoRequest
+		if a.privileged {
+			network = "ip6:ipv6-icmp"
+		}
+	}
+
+	conn, err := icmp.ListenPacket(network, laddr)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer conn.Close()
+
+	msg := icmp.Message{
+		Type: typ,
+		Body: &icmp.Echo{
+			ID:   os.Getpid() & 0xffff,
+			Seq:  int(seq & 0xffff),
+			Data: []byte("vegeta"),
+		},
+	}
+
+	wb, err := msg.Marshal(nil)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var peer net.Addr = dst
+	if !a.privileged {
+		peer = &net.UDPAddr{IP: dst.IP, Zone: dst.Zone}
+	}
+
+	conn.SetDeadline(time.Now().Add(a.timeout))
+	if _, err = conn.WriteTo(wb, peer); err != nil {
+		return 0, 0, err
+	}
+	out = uint64(len(wb))
+
+	rb := make([]byte, 1500)
+	for {
+		n, _, err := conn.ReadFrom(rb)
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				err = errICMPTimeout
+			}
+			return out, 0, err
+		}
+
+		reply, err := icmp.ParseMessage(proto, rb[:n])
+		if err != nil {
+			return out, uint64(n), err
+		}
+
+		switch echo := reply.Body.(type) {
+		case *icmp.Echo:
+			if reply.Type == ipv4.ICMPTypeEchoReply || reply.Type == ipv6.ICMPTypeEchoReply {
+				if echo.Seq == int(seq&0xffff) {
+					return out, uint64(n), nil
+				}
+			}
+		case *icmp.DstUnreach:
+			return out, uint64(n), errors.New("icmp: destination unreachable")
+		case *icmp.TimeExceeded:
+			return out, uint64(n), errors.New("icmp: time exceeded")
+		}
+	}
+}
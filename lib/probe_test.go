@@ -0,0 +1,87 @@
+package vegeta
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewProbeTargeter(t *testing.T) {
+	t.Parallel()
+
+	tr, err := NewProbeTargeter(strings.NewReader("a:1\n\n b:2 \n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"a:1", "b:2", "a:1"} {
+		var got string
+		if err := tr(&got); err != nil {
+			t.Fatal(err)
+		} else if got != want {
+			t.Errorf("got: %v, want: %v", got, want)
+		}
+	}
+
+	if _, err := NewProbeTargeter(strings.NewReader("\n")); err != ErrNoTargets {
+		t.Errorf("got: %v, want: %v", err, ErrNoTargets)
+	}
+}
+
+func TestProbeAttackerTCP(t *testing.T) {
+	t.Parallel()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	// Grab a port nobody listens on.
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedAddr := closed.Addr().String()
+	closed.Close()
+
+	tr, err := NewProbeTargeter(strings.NewReader(ln.Addr().String() + "\n" + closedAddr))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	atk := NewProbeAttacker(ProbeTimeout(time.Second))
+	if res := atk.hit(tr, "", 0); res.Error != "" || res.Latency == 0 {
+		t.Errorf("got error %q and latency %s, want success", res.Error, res.Latency)
+	}
+
+	if res := atk.hit(tr, "", 1); !strings.Contains(res.Error, "refused") {
+		t.Errorf("got error %q, want connection refused", res.Error)
+	}
+}
+
+func TestProbeAttackerICMP(t *testing.T) {
+	t.Parallel()
+
+	tr := func(addr *string) error { *addr = "127.0.0.1"; return nil }
+	atk := NewProbeAttacker(ProbeMode(ProbeICMP), ProbeTimeout(time.Second))
+	res := atk.hit(tr, "", 0)
+	if strings.Contains(res.Error, "permitted") || strings.Contains(res.Error, "permission") {
+		t.Skip("unprivileged ICMP sockets not allowed:", res.Error)
+	}
+
+	if res.Error != "" || res.BytesIn == 0 {
+		t.Errorf("got error %q and %d bytes in, want success", res.Error, res.BytesIn)
+	}
+}
@@ -0,0 +1,414 @@
+package vegeta
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// NewProtobufEncoder returns an Encoder that dumps the given *Results as
+// size delimited Protocol Buffers messages, as defined in result.proto,
+// which are more compact than gob and decodable in any language.
+func NewProtobufEncoder(w io.Writer) Encoder {
+	var size [binary.MaxVarintLen64]byte
+	return func(r *Result) error {
+		msg := encodeProtobuf(r)
+		n := binary.PutUvarint(size[:], uint64(len(msg)))
+		if _, err := w.Write(size[:n]); err != nil {
+			return err
+		}
+		_, err := w.Write(msg)
+		return err
+	}
+}
+
+// maxProtobufSize is the size above which NewProtobufDecoder rejects
+// messages, which is most likely that of a corrupt stream or of one in
+// another encoding.
+const maxProtobufSize = 1 << 30
+
+// NewProtobufDecoder returns a Decoder that decodes Results encoded by
+// NewProtobufEncoder. It skips fields unknown to it, e.g. of later versions.
+func NewProtobufDecoder(rd io.Reader) Decoder {
+	br := bufio.NewReader(rd)
+	return func(r *Result) error {
+		size, err := binary.ReadUvarint(br)
+		if err != nil {
+			return err
+		} else if size > maxProtobufSize {
+			return fmt.Errorf("protobuf message of %d bytes exceeds the limit of %d bytes", size, maxProtobufSize)
+		}
+
+		// The buffer grows as the message is read rather than by its
+		// declared size, which can't be trusted.
+		var buf bytes.Buffer
+		if _, err = buf.ReadFrom(io.LimitReader(br, int64(size))); err != nil {
+			return err
+		} else if uint64(buf.Len()) < size {
+			return io.ErrUnexpectedEOF
+		}
+
+		*r = Result{}
+		return decodeProtobuf(buf.Bytes(), r)
+	}
+}
+
+// encodeProtobuf returns the Protocol Buffers message of the given Result.
+func encodeProtobuf(r *Result) []byte {
+	var m protoMessage
+	m.string(1, r.Attack)
+	m.varint(2, r.Seq)
+	m.varint(3, uint64(r.Code))
+	m.time(4, r.Timestamp)
+	m.varint(5, uint64(r.Latency))
+	m.varint(6, r.BytesOut)
+	m.varint(7, r.BytesIn)
+	m.string(8, r.Error)
+	m.bytes(9, r.Body)
+	m.string(10, r.Method)
+	m.string(11, r.URL)
+	m.header(12, r.Header)
+	m.varint(13, uint64(r.ClockOffset))
+	m.varint(14, uint64(r.Backoff))
+	m.string(15, r.BodyHash)
+	m.stringMap(16, r.Tags)
+	m.stringMap(17, r.Extract)
+	m.string(18, r.TraceID)
+	m.string(19, r.RequestID)
+	m.varint(20, uint64(r.Retries))
+	m.string(21, r.TLSHandshake)
+
+	var phases protoMessage
+	phases.varint(1, uint64(r.Phases.DNS))
+	phases.varint(2, uint64(r.Phases.Connect))
+	phases.varint(3, uint64(r.Phases.TLS))
+	phases.varint(4, uint64(r.Phases.FirstByte))
+	phases.varint(5, uint64(r.Phases.Body))
+	if len(phases) > 0 {
+		m.bytes(22, phases)
+	}
+
+	m.string(23, r.RemoteAddr)
+	m.bool(24, r.ConnReused)
+	m.header(25, r.ResponseHeader)
+	m.bool(26, r.Truncated)
+	m.bytes(27, r.RequestBody)
+
+	for _, rd := range r.Redirects {
+		var redirect protoMessage
+		redirect.string(1, rd.URL)
+		redirect.varint(2, uint64(rd.Code))
+		redirect.varint(3, uint64(rd.Latency))
+		m.message(28, redirect)
+	}
+
+	m.string(29, r.ErrorClass)
+	m.string(30, r.BodyFile)
+	m.time(31, r.Intended)
+	m.bool(32, r.Accepted)
+
+	return m
+}
+
+// decodeProtobuf decodes the given Protocol Buffers message into r.
+func decodeProtobuf(msg []byte, r *Result) error {
+	return protoFields(msg, func(field int, v uint64, b []byte) (err error) {
+		switch field {
+		case 1:
+			r.Attack = string(b)
+		case 2:
+			r.Seq = v
+		case 3:
+			r.Code = uint16(v)
+		case 4:
+			r.Timestamp = time.Unix(0, int64(v))
+		case 5:
+			r.Latency = time.Duration(v)
+		case 6:
+			r.BytesOut = v
+		case 7:
+			r.BytesIn = v
+		case 8:
+			r.Error = string(b)
+		case 9:
+			r.Body = b
+		case 10:
+			r.Method = string(b)
+		case 11:
+			r.URL = string(b)
+		case 12:
+			r.Header, err = decodeProtobufHeader(r.Header, b)
+		case 13:
+			r.ClockOffset = time.Duration(v)
+		case 14:
+			r.Backoff = time.Duration(v)
+		case 15:
+			r.BodyHash = string(b)
+		case 16:
+			r.Tags, err = decodeProtobufMap(r.Tags, b)
+		case 17:
+			r.Extract, err = decodeProtobufMap(r.Extract, b)
+		case 18:
+			r.TraceID = string(b)
+		case 19:
+			r.RequestID = string(b)
+		case 20:
+			r.Retries = uint16(v)
+		case 21:
+			r.TLSHandshake = string(b)
+		case 22:
+			err = protoFields(b, func(field int, v uint64, _ []byte) error {
+				switch field {
+				case 1:
+					r.Phases.DNS = time.Duration(v)
+				case 2:
+					r.Phases.Connect = time.Duration(v)
+				case 3:
+					r.Phases.TLS = time.Duration(v)
+				case 4:
+					r.Phases.FirstByte = time.Duration(v)
+				case 5:
+					r.Phases.Body = time.Duration(v)
+				}
+				return nil
+			})
+		case 23:
+			r.RemoteAddr = string(b)
+		case 24:
+			r.ConnReused = v != 0
+		case 25:
+			r.ResponseHeader, err = decodeProtobufHeader(r.ResponseHeader, b)
+		case 26:
+			r.Truncated = v != 0
+		case 27:
+			r.RequestBody = b
+		case 28:
+			var rd Redirect
+			err = protoFields(b, func(field int, v uint64, b []byte) error {
+				switch field {
+				case 1:
+					rd.URL = string(b)
+				case 2:
+					rd.Code = uint16(v)
+				case 3:
+					rd.Latency = time.Duration(v)
+				}
+				return nil
+			})
+			r.Redirects = append(r.Redirects, rd)
+		case 29:
+			r.ErrorClass = string(b)
+		case 30:
+			r.BodyFile = string(b)
+		case 31:
+			r.Intended = time.Unix(0, int64(v))
+		case 32:
+			r.Accepted = v != 0
+		}
+		return err
+	})
+}
+
+// decodeProtobufMap decodes a map entry into the given map.
+func decodeProtobufMap(m map[string]string, entry []byte) (map[string]string, error) {
+	var k, v string
+	err := protoFields(entry, func(field int, _ uint64, b []byte) error {
+		switch field {
+		case 1:
+			k = string(b)
+		case 2:
+			v = string(b)
+		}
+		return nil
+	})
+
+	if m == nil {
+		m = map[string]string{}
+	}
+	m[k] = v
+
+	return m, err
+}
+
+// decodeProtobufHeader decodes a header map entry into the given header.
+func decodeProtobufHeader(h http.Header, entry []byte) (http.Header, error) {
+	var (
+		k  string
+		vs []string
+	)
+
+	err := protoFields(entry, func(field int, _ uint64, b []byte) error {
+		switch field {
+		case 1:
+			k = string(b)
+		case 2:
+			return protoFields(b, func(field int, _ uint64, b []byte) error {
+				if field == 1 {
+					vs = append(vs, string(b))
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+
+	if h == nil {
+		h = http.Header{}
+	}
+	h[k] = vs
+
+	return h, err
+}
+
+// A protoMessage is a Protocol Buffers message being encoded, which omits
+// fields with zero values, as proto3 does.
+type protoMessage []byte
+
+// tag appends the tag of a field.
+func (m *protoMessage) tag(field int, wireType uint64) {
+	m.uvarint(uint64(field)<<3 | wireType)
+}
+
+// uvarint appends a varint.
+func (m *protoMessage) uvarint(v uint64) {
+	var buf [binary.MaxVarintLen64]byte
+	*m = append(*m, buf[:binary.PutUvarint(buf[:], v)]...)
+}
+
+// varint appends a varint field, which encodes negative integers in ten
+// bytes, as int64 fields do.
+func (m *protoMessage) varint(field int, v uint64) {
+	if v != 0 {
+		m.tag(field, 0)
+		m.uvarint(v)
+	}
+}
+
+// bool appends a bool field.
+func (m *protoMessage) bool(field int, v bool) {
+	if v {
+		m.varint(field, 1)
+	}
+}
+
+// time appends a time field as a UNIX timestamp in ns since epoch.
+func (m *protoMessage) time(field int, t time.Time) {
+	if !t.IsZero() {
+		m.varint(field, uint64(t.UnixNano()))
+	}
+}
+
+// bytes appends a bytes field.
+func (m *protoMessage) bytes(field int, b []byte) {
+	if len(b) > 0 {
+		m.message(field, b)
+	}
+}
+
+// string appends a string field.
+func (m *protoMessage) string(field int, s string) {
+	m.bytes(field, []byte(s))
+}
+
+// message appends a length delimited field, even if empty.
+func (m *protoMessage) message(field int, b []byte) {
+	m.tag(field, 2)
+	m.uvarint(uint64(len(b)))
+	*m = append(*m, b...)
+}
+
+// stringMap appends the entries of a map field, sorted by key.
+func (m *protoMessage) stringMap(field int, kvs map[string]string) {
+	keys := make([]string, 0, len(kvs))
+	for k := range kvs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		var entry protoMessage
+		entry.string(1, k)
+		entry.string(2, kvs[k])
+		m.message(field, entry)
+	}
+}
+
+// header appends the entries of a header map field, sorted by key.
+func (m *protoMessage) header(field int, h http.Header) {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		var values protoMessage
+		for _, v := range h[k] {
+			values.message(1, []byte(v))
+		}
+
+		var entry protoMessage
+		entry.string(1, k)
+		entry.message(2, values)
+		m.message(field, entry)
+	}
+}
+
+// errProtobufTruncated is returned when decoding truncated messages.
+var errProtobufTruncated = errors.New("truncated protobuf message")
+
+// protoFields calls f with the number of each field of the given message
+// and with its value if it's a varint, or its bytes if it's length
+// delimited. Fields of other wire types are skipped.
+func protoFields(msg []byte, f func(field int, v uint64, b []byte) error) error {
+	for len(msg) > 0 {
+		tag, n := binary.Uvarint(msg)
+		if n <= 0 {
+			return errProtobufTruncated
+		}
+		msg = msg[n:]
+
+		var (
+			v uint64
+			b []byte
+		)
+
+		switch wireType := tag & 7; wireType {
+		case 0:
+			if v, n = binary.Uvarint(msg); n <= 0 {
+				return errProtobufTruncated
+			}
+			msg = msg[n:]
+		case 1, 5:
+			size := 8
+			if wireType == 5 {
+				size = 4
+			}
+			if len(msg) < size {
+				return errProtobufTruncated
+			}
+			msg = msg[size:]
+			continue
+		case 2:
+			size, n := binary.Uvarint(msg)
+			if n <= 0 || uint64(len(msg)-n) < size {
+				return errProtobufTruncated
+			}
+			b, msg = msg[n:n+int(size)], msg[n+int(size):]
+		default:
+			return fmt.Errorf("unsupported protobuf wire type %d", wireType)
+		}
+
+		if err := f(int(tag>>3), v, b); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package vegeta
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestProtobufEncoder(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	r := Result{
+		Attack:  "a",
+		Code:    200,
+		Latency: time.Millisecond,
+		Tags:    map[string]string{"k": "v"},
+	}
+
+	if err := NewProtobufEncoder(&buf).Encode(&r); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []byte{
+		19,           // size
+		0x0a, 1, 'a', // attack
+		0x18, 0xc8, 0x01, // code
+		0x28, 0xc0, 0x84, 0x3d, // latency
+		0x82, 0x01, 6, 0x0a, 1, 'k', 0x12, 1, 'v', // tags
+	}
+
+	if got := buf.Bytes(); !bytes.Equal(got, want) {
+		t.Fatalf("got % x, want % x", got, want)
+	}
+
+	// Fields unknown to the decoder are skipped.
+	buf.Write([]byte{
+		16,           // size
+		0x0a, 1, 'b', // attack
+		0xf8, 0x07, 1, // varint field 127
+		0xfa, 0x07, 1, 'x', // bytes field 127
+		0xfd, 0x07, 1, 2, 3, 4, // fixed32 field 127
+	})
+
+	dec := NewProtobufDecoder(&buf)
+	for _, want := range []Result{r, {Attack: "b"}} {
+		var got Result
+		if err := dec.Decode(&got); err != nil {
+			t.Fatal(err)
+		} else if !got.Equal(want) {
+			t.Errorf("got %+v, want %+v", got, want)
+		}
+	}
+
+	var got Result
+	if err := dec.Decode(&got); err != io.EOF {
+		t.Errorf("got error %v, want EOF", err)
+	}
+
+	if err := NewProtobufDecoder(bytes.NewReader([]byte{5, 0x0a, 1})).Decode(&got); err != io.ErrUnexpectedEOF {
+		t.Errorf("got error %v, want unexpected EOF", err)
+	}
+
+	if err := NewProtobufDecoder(bytes.NewReader([]byte{2, 0x0a, 5})).Decode(&got); err != errProtobufTruncated {
+		t.Errorf("got error %v, want %v", err, errProtobufTruncated)
+	}
+}
+
+func TestProtobufDecoder_Garbage(t *testing.T) {
+	t.Parallel()
+
+	// A size of 2^63 bytes, as of a corrupt stream.
+	huge := []byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x01}
+	if err := NewProtobufDecoder(bytes.NewReader(huge)).Decode(&Result{}); err == nil {
+		t.Error("got no error with a huge message size")
+	}
+
+	var gob bytes.Buffer
+	if err := NewEncoder(&gob).Encode(&Result{Attack: "gob", Code: 200, Body: []byte("body")}); err != nil {
+		t.Fatal(err)
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	inputs := [][]byte{gob.Bytes()}
+	for i := 0; i < 1000; i++ {
+		garbage := make([]byte, rng.Intn(64))
+		rng.Read(garbage)
+		inputs = append(inputs, garbage)
+	}
+
+	for _, in := range inputs {
+		dec := NewProtobufDecoder(bytes.NewReader(in))
+		// Every message holds at least a byte, so decoding must stop with
+		// an error before the input is over.
+		for i := 0; i <= len(in); i++ {
+			if err := dec.Decode(&Result{}); err != nil {
+				break
+			} else if i == len(in) {
+				t.Fatalf("no error decoding % x", in)
+			}
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package vegeta
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrCorruptedRange is the error reported when a partial response doesn't
+// match the requested byte range of the full object.
+var ErrCorruptedRange = errors.New("corrupted range")
+
+// RangeVerification returns a functional option which makes an Attacker
+// verify the integrity of the partial responses to single byte range
+// requests for up to n sampled objects: the first n distinct URLs requested
+// with a Range header. The full object of each sampled URL is fetched once
+// and kept in memory as a reference against which the bytes of every partial
+// response are compared, after its latency is recorded.
+//
+// Partial responses with inconsistent Content-Range headers are reported for
+// all objects, sampled or not.
+//
+// Bodies are read whole to be verified even with HashBodies or DiscardBodies,
+// which only apply once they are. Those of a BodyHandler set with
+// ResponseBodies aren't verified, since it may not keep them, nor are those
+// truncated by MaxBody.
+//
+// Zero, the default, disables verification.
+func RangeVerification(n int) func(*Attacker) {
+	return func(a *Attacker) {
+		if a.ranges = nil; n > 0 {
+			a.ranges = &rangeVerifier{max: n, objects: map[string]*rangeObject{}}
+		}
+	}
+}
+
+// rangeVerifier verifies partial responses against the full objects they're
+// part of.
+type rangeVerifier struct {
+	mu      sync.Mutex
+	max     int
+	objects map[string]*rangeObject
+}
+
+// rangeObject is a lazily fetched reference object.
+type rangeObject struct {
+	once sync.Once
+	body []byte
+	err  error
+}
+
+// verify checks that the given partial response body is consistent with its
+// Content-Range header and the range requested, and that it matches the same
+// range of the full object if it's sampled.
+func (v *rangeVerifier) verify(c *http.Client, req *http.Request, r *http.Response, body []byte) error {
+	if r.StatusCode != http.StatusPartialContent {
+		return nil
+	}
+
+	want, ok := parseByteRange(req.Header.Get("Range"))
+	if !ok {
+		return nil // multiple or unsupported ranges
+	}
+
+	start, end, total, err := parseContentRange(r.Header.Get("Content-Range"))
+	if err != nil {
+		return fmt.Errorf("%s: %s", ErrCorruptedRange, err)
+	}
+
+	if want[0] >= 0 && start != want[0] || want[1] >= 0 && end > want[1] {
+		return fmt.Errorf("%s: got bytes %d-%d, want %s", ErrCorruptedRange, start, end, req.Header.Get("Range"))
+	}
+
+	if got := int64(len(body)); got != end-start+1 {
+		return fmt.Errorf("%s: got %d bytes, want %d", ErrCorruptedRange, got, end-start+1)
+	}
+
+	obj := v.sample(req.URL.String())
+	if obj == nil {
+		return nil
+	}
+
+	obj.once.Do(func() { obj.body, obj.err = fetchObject(c, req) })
+	if obj.err != nil {
+		return fmt.Errorf("%s: reference object: %s", ErrCorruptedRange, obj.err)
+	}
+
+	if total >= 0 && total != int64(len(obj.body)) {
+		return fmt.Errorf("%s: got size %d, want %d", ErrCorruptedRange, total, len(obj.body))
+	}
+
+	if end >= int64(len(obj.body)) || !bytes.Equal(body, obj.body[start:end+1]) {
+		return fmt.Errorf("%s: bytes %d-%d differ from the full object", ErrCorruptedRange, start, end)
+	}
+
+	return nil
+}
+
+// sample returns the reference object of the given URL or nil if it's not
+// sampled.
+func (v *rangeVerifier) sample(url string) *rangeObject {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	obj, ok := v.objects[url]
+	if !ok && len(v.objects) < v.max {
+		obj = &rangeObject{}
+		v.objects[url] = obj
+	}
+
+	return obj
+}
+
+// fetchObject fetches the full object requested by the given range request.
+func fetchObject(c *http.Client, req *http.Request) ([]byte, error) {
+	full, err := http.NewRequest("GET", req.URL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, vs := range req.Header {
+		if k != "Range" && k != "If-Range" {
+			full.Header[k] = vs
+		}
+	}
+	full.Host = req.Host
+
+	r, err := c.Do(full)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+
+	if r.StatusCode != http.StatusOK {
+		return nil, errors.New(r.Status)
+	}
+
+	return ioutil.ReadAll(r.Body)
+}
+
+// parseByteRange parses a single byte range Range header value into its
+// first and last byte positions, which are -1 when omitted.
+func parseByteRange(h string) (r [2]int64, ok bool) {
+	if !strings.HasPrefix(h, "bytes=") || strings.Contains(h, ",") {
+		return r, false
+	}
+
+	bounds := strings.SplitN(strings.TrimSpace(h[len("bytes="):]), "-", 2)
+	if len(bounds) != 2 {
+		return r, false
+	}
+
+	for i, b := range bounds {
+		if r[i] = -1; b == "" {
+			continue
+		}
+		n, err := strconv.ParseInt(b, 10, 64)
+		if err != nil {
+			return r, false
+		}
+		r[i] = n
+	}
+
+	// Suffix ranges (bytes=-n) can't be checked without knowing the size.
+	if r[0] == -1 {
+		r[1] = -1
+	}
+
+	return r, true
+}
+
+// parseContentRange parses a Content-Range header value. total is -1 when
+// the size of the full object is unknown.
+func parseContentRange(h string) (start, end, total int64, err error) {
+	if !strings.HasPrefix(h, "bytes ") {
+		return 0, 0, 0, fmt.Errorf("bad Content-Range: %q", h)
+	}
+
+	parts := strings.SplitN(h[len("bytes "):], "/", 2)
+	bounds := strings.SplitN(parts[0], "-", 2)
+	if len(parts) != 2 || len(bounds) != 2 {
+		return 0, 0, 0, fmt.Errorf("bad Content-Range: %q", h)
+	}
+
+	if start, err = strconv.ParseInt(bounds[0], 10, 64); err != nil {
+		return 0, 0, 0, fmt.Errorf("bad Content-Range: %q", h)
+	}
+
+	if end, err = strconv.ParseInt(bounds[1], 10, 64); err != nil || end < start {
+		return 0, 0, 0, fmt.Errorf("bad Content-Range: %q", h)
+	}
+
+	if total = -1; parts[1] != "*" {
+		if total, err = strconv.ParseInt(parts[1], 10, 64); err != nil || end >= total {
+			return 0, 0, 0, fmt.Errorf("bad Content-Range: %q", h)
+		}
+	}
+
+	return start, end, total, nil
+}
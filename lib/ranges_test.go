@@ -0,0 +1,114 @@
+package vegeta
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRangeVerification(t *testing.T) {
+	t.Parallel()
+
+	object := bytes.Repeat([]byte("0123456789"), 100)
+	var corrupt, fulls int32
+	server := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Range") == "" {
+				atomic.AddInt32(&fulls, 1)
+			} else if atomic.LoadInt32(&corrupt) == 1 {
+				// Serve the requested range shifted by one byte.
+				w.Header().Set("Content-Range", fmt.Sprintf("bytes 10-19/%d", len(object)))
+				w.WriteHeader(http.StatusPartialContent)
+				w.Write(object[11:21])
+				return
+			}
+			http.ServeContent(w, r, "object", time.Time{}, bytes.NewReader(object))
+		}),
+	)
+	defer server.Close()
+
+	atk := NewAttacker(RangeVerification(1))
+	for i, tc := range []struct {
+		rng     string
+		corrupt int32
+		err     string
+	}{
+		{"bytes=10-19", 0, ""},
+		{"bytes=990-", 0, ""},
+		{"bytes=0-9,20-29", 0, ""}, // multipart ranges aren't verified
+		{"bytes=10-19", 1, "bytes 10-19 differ from the full object"},
+	} {
+		atomic.StoreInt32(&corrupt, tc.corrupt)
+		tr := NewStaticTargeter(Target{
+			Method: "GET",
+			URL:    server.URL,
+			Header: http.Header{"Range": {tc.rng}},
+		})
+
+		res := atk.hit(tr, "", uint64(i))
+		if tc.err == "" && res.Error != "" || !strings.Contains(res.Error, tc.err) {
+			t.Errorf("%s: got error %q, want %q", tc.rng, res.Error, tc.err)
+		}
+	}
+
+	if got, want := atomic.LoadInt32(&fulls), int32(1); got != want {
+		t.Errorf("got %d full object fetches, want %d", got, want)
+	}
+	// Discarded bodies are read whole to be verified, and those of custom
+	// BodyHandlers or truncated ones aren't verified.
+	atomic.StoreInt32(&corrupt, 0)
+	tr := NewStaticTargeter(Target{
+		Method: "GET",
+		URL:    server.URL,
+		Header: http.Header{"Range": {"bytes=10-19"}},
+	})
+
+	for _, tc := range []struct {
+		name string
+		opt  func(*Attacker)
+		body string
+	}{
+		{"discarded", DiscardBodies(true), ""},
+		{"custom", ResponseBodies(DiscardedBodies), ""},
+		{"truncated", MaxBody(5), "01234"},
+	} {
+		atk := NewAttacker(RangeVerification(1), tc.opt)
+		if res := atk.hit(tr, "", 0); res.Error != "" || string(res.Body) != tc.body {
+			t.Errorf("%s: got error %q and body %q, want none and %q", tc.name, res.Error, res.Body, tc.body)
+		}
+	}
+
+	// Zero disables verification.
+	atomic.StoreInt32(&corrupt, 1)
+	atk = NewAttacker(RangeVerification(1), RangeVerification(0))
+	if res := atk.hit(tr, "", 0); res.Error != "" {
+		t.Errorf("disabled: got error %q, want none", res.Error)
+	}
+}
+
+func TestParseContentRange(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		in                string
+		start, end, total int64
+		ok                bool
+	}{
+		{"bytes 0-9/100", 0, 9, 100, true},
+		{"bytes 10-19/*", 10, 19, -1, true},
+		{"bytes 10-9/100", 0, 0, 0, false},
+		{"bytes 0-100/100", 0, 0, 0, false},
+		{"items 0-9/100", 0, 0, 0, false},
+		{"", 0, 0, 0, false},
+	} {
+		start, end, total, err := parseContentRange(tc.in)
+		if ok := err == nil; ok != tc.ok || ok && (start != tc.start || end != tc.end || total != tc.total) {
+			t.Errorf("%q: got (%d, %d, %d, %v)", tc.in, start, end, total, err)
+		}
+	}
+}
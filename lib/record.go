@@ -0,0 +1,133 @@
+package vegeta
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// NewRecorder returns an http.Handler which proxies every request it serves
+// and passes a Target built out of it to the given record function before
+// forwarding it. Calls to record are serialized.
+//
+// When upstream is nil, the Recorder acts as a forward proxy which must be
+// configured as the HTTP proxy of its clients. HTTPS requests are tunneled
+// to their destination with CONNECT but, being encrypted, can't be recorded.
+// Otherwise it acts as a reverse proxy which sends all requests to upstream.
+func NewRecorder(upstream *url.URL, record func(*Target) error) http.Handler {
+	return &recorder{
+		upstream: upstream,
+		record:   record,
+		proxy:    &httputil.ReverseProxy{Director: func(*http.Request) {}},
+	}
+}
+
+type recorder struct {
+	mu       sync.Mutex
+	upstream *url.URL
+	record   func(*Target) error
+	proxy    *httputil.ReverseProxy
+}
+
+// hopHeaders are the headers which only apply to a single connection and
+// are thus not recorded.
+var hopHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Proxy-Connection",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+func (rec *recorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodConnect {
+		rec.tunnel(w, r)
+		return
+	}
+
+	if rec.upstream != nil {
+		u := *rec.upstream
+		u.Path = strings.TrimSuffix(u.Path, "/") + r.URL.Path
+		u.RawPath = ""
+		u.RawQuery = r.URL.RawQuery
+		r.URL, r.Host = &u, u.Host
+	} else if !r.URL.IsAbs() {
+		http.Error(w, "not a proxy request: "+r.RequestURI, http.StatusBadRequest)
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	tgt := Target{Method: r.Method, URL: r.URL.String(), Header: r.Header.Clone()}
+	if len(body) > 0 {
+		tgt.Body = body
+	}
+
+	for _, h := range hopHeaders {
+		tgt.Header.Del(h)
+	}
+
+	rec.mu.Lock()
+	err = rec.record(&tgt)
+	rec.mu.Unlock()
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	rec.proxy.ServeHTTP(w, r)
+}
+
+// tunnel pipes a CONNECT request's connection to its destination.
+func (rec *recorder) tunnel(w http.ResponseWriter, r *http.Request) {
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "tunneling not supported", http.StatusInternalServerError)
+		return
+	}
+
+	dst, err := net.Dial("tcp", r.Host)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	src, _, err := hj.Hijack()
+	if err != nil {
+		dst.Close()
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if _, err = io.WriteString(src, "HTTP/1.1 200 Connection established\r\n\r\n"); err != nil {
+		src.Close()
+		dst.Close()
+		return
+	}
+
+	go func() {
+		defer dst.Close()
+		io.Copy(dst, src)
+	}()
+
+	go func() {
+		defer src.Close()
+		io.Copy(src, dst)
+	}()
+}
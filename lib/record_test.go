@@ -0,0 +1,86 @@
+package vegeta
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestRecorder(t *testing.T) {
+	t.Parallel()
+
+	upstream := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			w.Write(append([]byte(r.Method+" "+r.URL.String()+" "), body...))
+		}),
+	)
+	defer upstream.Close()
+
+	u, err := url.Parse(upstream.URL + "/api/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name     string
+		upstream *url.URL
+		client   func(proxy string) (*http.Client, string)
+		want     string
+	}{
+		{
+			name:     "reverse",
+			upstream: u,
+			client: func(proxy string) (*http.Client, string) {
+				return http.DefaultClient, proxy
+			},
+			want: upstream.URL + "/api/things?id=1",
+		},
+		{
+			name: "forward",
+			client: func(proxy string) (*http.Client, string) {
+				pu, _ := url.Parse(proxy)
+				return &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(pu)}}, upstream.URL
+			},
+			want: upstream.URL + "/things?id=1",
+		},
+	} {
+		var got []Target
+		proxy := httptest.NewServer(NewRecorder(tc.upstream, func(tgt *Target) error {
+			got = append(got, *tgt)
+			return nil
+		}))
+
+		c, base := tc.client(proxy.URL)
+		req, _ := http.NewRequest("POST", base+"/things?id=1", strings.NewReader("goku"))
+		req.Header.Set("X-Power", "9001")
+
+		res, err := c.Do(req)
+		if err != nil {
+			t.Fatalf("%s: %s", tc.name, err)
+		}
+		body, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		proxy.Close()
+
+		if want := "POST " + strings.TrimPrefix(tc.want, upstream.URL) + " goku"; string(body) != want {
+			t.Errorf("%s: got response %q, want %q", tc.name, body, want)
+		}
+
+		if len(got) != 1 {
+			t.Fatalf("%s: got %d recorded targets, want 1", tc.name, len(got))
+		}
+
+		tgt := got[0]
+		if tgt.Method != "POST" || tgt.URL != tc.want || string(tgt.Body) != "goku" || tgt.Header.Get("X-Power") != "9001" {
+			t.Errorf("%s: got %+v", tc.name, tgt)
+		}
+
+		if tgt.Header.Get("Proxy-Connection") != "" || tgt.Header.Get("Connection") != "" {
+			t.Errorf("%s: hop-by-hop headers recorded: %v", tc.name, tgt.Header)
+		}
+	}
+}
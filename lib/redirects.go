@@ -0,0 +1,105 @@
+package vegeta
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A Redirect is a redirect response followed by a hit.
+type Redirect struct {
+	// URL is the URL of the request which got the redirect response.
+	URL string `json:"url"`
+	// Code is the status code of the redirect response.
+	Code uint16 `json:"code"`
+	// Latency is the time from sending the request to receiving the
+	// redirect response.
+	Latency time.Duration `json:"latency"`
+}
+
+// resultKey is the context key of the Result of a hit's request.
+type resultKey struct{}
+
+// withResult returns a copy of the given request whose context holds the
+// given Result, in which its redirects are recorded.
+func withResult(req *http.Request, res *Result) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), resultKey{}, res))
+}
+
+// checkRedirect is the CheckRedirect function of the Attacker's clients,
+// which follows up to its number of redirects and records them in the Result
+// of the request, if any.
+func (a *Attacker) checkRedirect(req *http.Request, via []*http.Request) error {
+	switch {
+	case a.redirects == NoFollow:
+		return http.ErrUseLastResponse
+	case a.redirects < len(via):
+		return fmt.Errorf("stopped after %d redirects", a.redirects)
+	}
+
+	// Redirects are followed by the goroutine sending the request, which
+	// owns its Result.
+	if res, ok := req.Context().Value(resultKey{}).(*Result); ok && req.Response != nil {
+		hop := Redirect{
+			URL:     via[len(via)-1].URL.String(),
+			Code:    uint16(req.Response.StatusCode),
+			Latency: time.Since(res.Timestamp),
+		}
+		for _, prev := range res.Redirects {
+			hop.Latency -= prev.Latency
+		}
+		res.Redirects = append(res.Redirects, hop)
+	}
+
+	return nil
+}
+
+// encodeRedirects encodes the given redirects as space separated triples of
+// code, latency in ns and query escaped URL, separated by commas.
+func encodeRedirects(rs []Redirect) string {
+	hops := make([]string, len(rs))
+	for i, r := range rs {
+		hops[i] = strconv.FormatUint(uint64(r.Code), 10) + "," +
+			strconv.FormatInt(r.Latency.Nanoseconds(), 10) + "," + url.QueryEscape(r.URL)
+	}
+	return strings.Join(hops, " ")
+}
+
+// decodeRedirects decodes redirects encoded by encodeRedirects.
+func decodeRedirects(s string) ([]Redirect, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	hops := strings.Split(s, " ")
+	rs := make([]Redirect, len(hops))
+	for i, hop := range hops {
+		fields := strings.SplitN(hop, ",", 3)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("bad redirect: %q", hop)
+		}
+
+		code, err := strconv.ParseUint(fields[0], 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("bad redirect: %s", err)
+		}
+
+		latency, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("bad redirect: %s", err)
+		}
+
+		u, err := url.QueryUnescape(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("bad redirect: %s", err)
+		}
+
+		rs[i] = Redirect{URL: u, Code: uint16(code), Latency: time.Duration(latency)}
+	}
+
+	return rs, nil
+}
@@ -0,0 +1,59 @@
+package vegeta
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRedirectChain(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.Handle("/short", http.RedirectHandler("/login", http.StatusFound))
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+		http.Redirect(w, r, "/home", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/home", func(http.ResponseWriter, *http.Request) {})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tr := NewStaticTargeter(Target{Method: "GET", URL: server.URL + "/short"})
+
+	for _, tc := range []struct {
+		redirects int
+		code      uint16
+		want      []Redirect
+	}{
+		{DefaultRedirects, 200, []Redirect{{URL: server.URL + "/short", Code: 302}, {URL: server.URL + "/login", Code: 301}}},
+		{1, 0, []Redirect{{URL: server.URL + "/short", Code: 302}}},
+		{NoFollow, 302, nil},
+	} {
+		res := NewAttacker(Redirects(tc.redirects)).hit(tr, "", 0)
+		if res.Code != tc.code {
+			t.Errorf("redirects %d: got code %d, want %d", tc.redirects, res.Code, tc.code)
+		}
+
+		if len(res.Redirects) != len(tc.want) {
+			t.Fatalf("redirects %d: got %+v, want %+v", tc.redirects, res.Redirects, tc.want)
+		}
+
+		var total time.Duration
+		for i, hop := range res.Redirects {
+			if hop.URL != tc.want[i].URL || hop.Code != tc.want[i].Code || hop.Latency <= 0 {
+				t.Errorf("redirects %d: got hop %d %+v, want %+v", tc.redirects, i, hop, tc.want[i])
+			}
+			total += hop.Latency
+		}
+
+		if len(res.Redirects) > 1 && res.Redirects[1].Latency < 10*time.Millisecond {
+			t.Errorf("redirects %d: got second hop latency %s, want at least 10ms", tc.redirects, res.Redirects[1].Latency)
+		}
+
+		if total > res.Latency && res.Latency > 0 {
+			t.Errorf("redirects %d: got hops of %s, more than the %s latency", tc.redirects, total, res.Latency)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package vegeta
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// NewResultsTargeter eagerly reads all Results out of the provided Decoder
+// and returns a NewStaticTargeter with the requests that produced them, as
+// recorded in their Method, URL, Header and Tags fields. When errored is
+// true only the requests of Results with an error are included, which allows
+// a failing run to be replayed for debugging.
+//
+// body will be set as the Target's body, unless its Result recorded a non
+// empty one.
+// hdr will be merged with the each Target's headers.
+func NewResultsTargeter(dec Decoder, errored bool, body []byte, hdr http.Header) (Targeter, error) {
+	tgts, _, err := readResults(dec, errored, body, hdr)
+	if err != nil {
+		return nil, err
+	}
+	return NewStaticTargeter(tgts...), nil
+}
+
+// NewResultsReplay is like NewResultsTargeter but returns a Targeter which
+// yields the requests once, in decoding order, along with a Pacer which hits
+// them with their original inter-arrival times divided by the given speed
+// factor.
+func NewResultsReplay(dec Decoder, errored bool, speed float64, body []byte, hdr http.Header) (Targeter, Pacer, error) {
+	tgts, times, err := readResults(dec, errored, body, hdr)
+	if err != nil {
+		return nil, nil, err
+	}
+	return newReplay(tgts, times, speed)
+}
+
+func readResults(dec Decoder, errored bool, body []byte, hdr http.Header) ([]Target, []time.Time, error) {
+	var (
+		tgts  []Target
+		times []time.Time
+	)
+
+	for {
+		var r Result
+		if err := dec.Decode(&r); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, nil, fmt.Errorf("bad result: %s", err)
+		}
+
+		if r.Method == "" || r.URL == "" || errored && r.Error == "" {
+			continue
+		}
+
+		tgt := Target{Method: r.Method, URL: r.URL, Body: body, Header: http.Header{}, Tags: r.Tags}
+		for k, vs := range hdr {
+			tgt.Header[k] = vs
+		}
+		for k, vs := range r.Header {
+			tgt.Header[k] = append(tgt.Header[k], vs...)
+		}
+
+		if len(r.RequestBody) > 0 {
+			tgt.Body = r.RequestBody
+		}
+
+		tgts = append(tgts, tgt)
+		times = append(times, r.Timestamp)
+	}
+
+	if len(tgts) == 0 {
+		return nil, nil, ErrNoTargets
+	}
+
+	return tgts, times, nil
+}
+
+// newReplay returns a Targeter which yields the given Targets once, in order,
+// and a Pacer which hits them at the given times, relative to the first one,
+// divided by the given speed factor.
+func newReplay(tgts []Target, times []time.Time, speed float64) (Targeter, Pacer, error) {
+	if speed <= 0 {
+		return nil, nil, fmt.Errorf("bad replay speed: %g", speed)
+	}
+
+	i := int64(-1)
+	tr := func(tgt *Target) error {
+		if tgt == nil {
+			return ErrNilTarget
+		}
+		n := atomic.AddInt64(&i, 1)
+		if n >= int64(len(tgts)) {
+			return ErrNoTargets
+		}
+		*tgt = tgts[n]
+		return nil
+	}
+
+	return tr, &replayPacer{times: times, speed: speed}, nil
+}
+
+// replayPacer is a Pacer which replays the given times relative to the
+// earliest one.
+type replayPacer struct {
+	times []time.Time
+	speed float64
+}
+
+func (p *replayPacer) Pace(hit uint64) (time.Duration, bool) {
+	if hit >= uint64(len(p.times)) {
+		return 0, true
+	}
+	offset := p.times[hit].Sub(p.times[0])
+	if offset < 0 { // out of order entries are hit right away
+		offset = 0
+	}
+	return time.Duration(float64(offset) / p.speed), false
+}
@@ -0,0 +1,78 @@
+package vegeta
+
+import (
+	"bytes"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNewResultsReplay(t *testing.T) {
+	t.Parallel()
+
+	began := time.Unix(0, 0)
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	for _, r := range []Result{
+		{Timestamp: began, Method: "GET", URL: "http://goku/1", Header: http.Header{"X-Id": {"1"}}},
+		{Timestamp: began.Add(time.Second), Method: "POST", URL: "http://goku/2", Error: "500 Internal Server Error"},
+		{Timestamp: began.Add(2 * time.Second), Error: "no targets to attack"}, // no request recorded
+		{Timestamp: began.Add(4 * time.Second), Method: "GET", URL: "http://goku/3", Error: "timeout"},
+		{Timestamp: began.Add(5 * time.Second), Method: "PUT", URL: "http://goku/4", Error: "timeout", RequestBody: []byte("recorded")},
+	} {
+		r := r
+		if err := enc.Encode(&r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tr, p, err := NewResultsReplay(NewDecoder(&buf), true, 1, []byte("body"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []Target{
+		{Method: "POST", URL: "http://goku/2", Body: []byte("body"), Header: http.Header{}},
+		{Method: "GET", URL: "http://goku/3", Body: []byte("body"), Header: http.Header{}},
+		{Method: "PUT", URL: "http://goku/4", Body: []byte("recorded"), Header: http.Header{}},
+	} {
+		var got Target
+		if err := tr(&got); err != nil {
+			t.Fatal(err)
+		} else if !reflect.DeepEqual(got, want) {
+			t.Errorf("\ngot:  %+v\nwant: %+v", got, want)
+		}
+	}
+
+	if err := tr(&Target{}); err != ErrNoTargets {
+		t.Errorf("got: %v, want: %v", err, ErrNoTargets)
+	}
+
+	if got, _ := p.Pace(1); got != 3*time.Second {
+		t.Errorf("got: %s, want: %s", got, 3*time.Second)
+	}
+
+	if _, err := NewResultsTargeter(NewDecoder(&bytes.Buffer{}), false, nil, nil); err != ErrNoTargets {
+		t.Errorf("got: %v, want: %v", err, ErrNoTargets)
+	}
+}
+
+func TestHitRecordsRequest(t *testing.T) {
+	t.Parallel()
+
+	tgt := Target{Method: "PATCH", URL: "http://127.0.0.1:0/", Header: http.Header{"X-Id": {"1"}}, Body: []byte("goku")}
+	res := NewAttacker().hit(NewStaticTargeter(tgt), "", 0)
+	if res.Method != tgt.Method || res.URL != tgt.URL {
+		t.Errorf("got: %s %s, want: %s %s", res.Method, res.URL, tgt.Method, tgt.URL)
+	}
+
+	if res.Header != nil || res.RequestBody != nil {
+		t.Errorf("got request headers %v and body %q without recording them", res.Header, res.RequestBody)
+	}
+
+	res = NewAttacker(RequestHeaders(true), RequestBodies(true)).hit(NewStaticTargeter(tgt), "", 0)
+	if !reflect.DeepEqual(res.Header, tgt.Header) || !bytes.Equal(res.RequestBody, tgt.Body) {
+		t.Errorf("got request headers %v and body %q, want %v and %q", res.Header, res.RequestBody, tgt.Header, tgt.Body)
+	}
+}
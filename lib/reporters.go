@@ -1,12 +1,15 @@
 package vegeta
 
 import (
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"io"
+	"sort"
 	"strconv"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/lucasb-eyer/go-colorful"
 )
@@ -54,11 +57,13 @@ func NewHistogramReporter(h *Histogram) Reporter {
 }
 
 // NewTextReporter returns a Reporter that writes out Metrics as aligned,
-// formatted text.
+// formatted text. The latency percentiles reported are the Percentiles of
+// the Metrics, if any, instead of the fixed ones.
 func NewTextReporter(m *Metrics) Reporter {
 	const fmtstr = "Requests\t[total, rate]\t%d, %.2f\n" +
 		"Duration\t[total, attack, wait]\t%s, %s, %s\n" +
-		"Latencies\t[mean, 50, 95, 99, max]\t%s, %s, %s, %s, %s\n" +
+		"Latencies\t%s\n" +
+		"Spread\t[stddev, variance]\t%s, %gs²\n" +
 		"Bytes In\t[total, mean]\t%d, %.2f\n" +
 		"Bytes Out\t[total, mean]\t%d, %.2f\n" +
 		"Success\t[ratio]\t%.2f%%\n" +
@@ -69,7 +74,8 @@ func NewTextReporter(m *Metrics) Reporter {
 		if _, err = fmt.Fprintf(tw, fmtstr,
 			m.Requests, m.Rate,
 			m.Duration+m.Wait, m.Duration, m.Wait,
-			m.Latencies.Mean, m.Latencies.P50, m.Latencies.P95, m.Latencies.P99, m.Latencies.Max,
+			latencyColumns(m.Latencies, m.Percentiles),
+			m.Latencies.StdDev, m.Latencies.Variance/float64(time.Second*time.Second),
 			m.BytesIn.Total, m.BytesIn.Mean,
 			m.BytesOut.Total, m.BytesOut.Mean,
 			m.Success*100,
@@ -83,6 +89,111 @@ func NewTextReporter(m *Metrics) Reporter {
 			}
 		}
 
+		if m.AssertionFailures > 0 {
+			if _, err = fmt.Fprintf(tw, "\nAssertions\t[failed]\t%d", m.AssertionFailures); err != nil {
+				return err
+			}
+		}
+
+		if m.Backoffs.Count > 0 {
+			if _, err = fmt.Fprintf(tw, "\nBackoffs\t[count, total, max]\t%d, %s, %s",
+				m.Backoffs.Count, m.Backoffs.Total, m.Backoffs.Max,
+			); err != nil {
+				return err
+			}
+		}
+
+		if hs := m.TLSHandshakes; hs.Full+hs.Resumed > 0 {
+			if _, err = fmt.Fprintf(tw, "\nTLS handshakes\t[full, resumed, ratio]\t%d, %d, %.2f%%",
+				hs.Full, hs.Resumed, hs.ResumptionRatio*100,
+			); err != nil {
+				return err
+			}
+		}
+
+		for _, phase := range []struct {
+			name string
+			m    LatencyMetrics
+		}{
+			{"DNS", m.Phases.DNS},
+			{"Connect", m.Phases.Connect},
+			{"TLS", m.Phases.TLS},
+			{"First byte", m.Phases.FirstByte},
+			{"Body", m.Phases.Body},
+		} {
+			if phase.m.Max == 0 {
+				continue
+			}
+			if _, err = fmt.Fprintf(tw, "\n%s\t%s", phase.name,
+				latencyColumns(phase.m, m.Percentiles),
+			); err != nil {
+				return err
+			}
+		}
+
+		// Latencies are broken down by status code, unless all hits had the
+		// same, and by error class.
+		for _, breakdown := range []struct {
+			latencies map[string]*LatencyMetrics
+			min       int
+		}{
+			{m.CodeLatencies, 2},
+			{m.ErrorClassLatencies, 1},
+		} {
+			if len(breakdown.latencies) < breakdown.min {
+				continue
+			}
+
+			keys := make([]string, 0, len(breakdown.latencies))
+			for key := range breakdown.latencies {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+
+			for _, key := range keys {
+				if _, err = fmt.Fprintf(tw, "\nLatencies %s\t%s", key,
+					latencyColumns(*breakdown.latencies[key], m.Percentiles),
+				); err != nil {
+					return err
+				}
+			}
+		}
+
+		if a := m.Apdex; a != nil {
+			if _, err = fmt.Fprintf(tw, "\nApdex\t[score, t, satisfied, tolerating, frustrated]\t%.2f, %s, %d, %d, %d",
+				a.Score, a.T, a.Satisfied, a.Tolerating, a.Frustrated,
+			); err != nil {
+				return err
+			}
+		}
+
+		for _, slo := range m.SLOs {
+			verdict := "met"
+			if !slo.Met {
+				verdict = "MISSED"
+			}
+			if _, err = fmt.Fprintf(tw, "\nSLO %s\t[value, verdict]\t%s, %s", slo.Objective, slo.Value, verdict); err != nil {
+				return err
+			}
+		}
+
+		if len(m.ErrorClasses) > 0 {
+			classes := make([]string, 0, len(m.ErrorClasses))
+			for class := range m.ErrorClasses {
+				classes = append(classes, class)
+			}
+			sort.Strings(classes)
+
+			if _, err = fmt.Fprint(tw, "\nError Classes\t[class:count]\t"); err != nil {
+				return err
+			}
+			for _, class := range classes {
+				if _, err = fmt.Fprintf(tw, "%s:%d  ", class, m.ErrorClasses[class]); err != nil {
+					return err
+				}
+			}
+		}
+
 		if _, err = fmt.Fprintln(tw, "\nError Set:"); err != nil {
 			return err
 		}
@@ -97,6 +208,134 @@ func NewTextReporter(m *Metrics) Reporter {
 	}
 }
 
+// latencyColumns returns the header and values columns of the given
+// LatencyMetrics in text reports, with the given percentiles, if any,
+// instead of the fixed ones.
+func latencyColumns(l LatencyMetrics, percentiles []float64) string {
+	if len(percentiles) == 0 {
+		return fmt.Sprintf("[mean, 50, 95, 99, max]\t%s, %s, %s, %s, %s",
+			l.Mean, l.P50, l.P95, l.P99, l.Max)
+	}
+
+	header := []string{"mean"}
+	values := []string{l.Mean.String()}
+	for _, p := range percentiles {
+		key := FormatPercentile(p)
+		header = append(header, key)
+		values = append(values, l.Percentiles[key].String())
+	}
+	header = append(header, "max")
+	values = append(values, l.Max.String())
+
+	return "[" + strings.Join(header, ", ") + "]\t" + strings.Join(values, ", ")
+}
+
+// metricsHeader returns the header of the columns of tables of Metrics with
+// a row each, with the given latency percentiles, if any, instead of the
+// fixed ones.
+func metricsHeader(percentiles []float64) string {
+	header := []string{"Requests", "Rate", "Success", "Mean"}
+	if len(percentiles) == 0 {
+		header = append(header, "50", "95", "99")
+	}
+	for _, p := range percentiles {
+		header = append(header, FormatPercentile(p))
+	}
+	return strings.Join(append(header, "Max", "Errors"), "\t")
+}
+
+// metricsRow returns the columns of the given Metrics in tables headed by
+// metricsHeader.
+func metricsRow(m *Metrics, percentiles []float64) string {
+	var errs uint64
+	for _, n := range m.ErrorClasses {
+		errs += n
+	}
+
+	return statsRow(m.Requests, m.Rate, m.Success, &m.Latencies, errs, percentiles)
+}
+
+// statsRow returns the given columns in tables headed by metricsHeader.
+func statsRow(requests uint64, rate, success float64, l *LatencyMetrics, errs uint64, percentiles []float64) string {
+	row := []string{
+		strconv.FormatUint(requests, 10),
+		fmt.Sprintf("%.2f", rate),
+		fmt.Sprintf("%.2f%%", success*100),
+		l.Mean.String(),
+	}
+
+	if len(percentiles) == 0 {
+		row = append(row, l.P50.String(), l.P95.String(), l.P99.String())
+	}
+	for _, p := range percentiles {
+		row = append(row, l.Percentiles[FormatPercentile(p)].String())
+	}
+
+	return strings.Join(append(row, l.Max.String(), strconv.FormatUint(errs, 10)), "\t")
+}
+
+// NewCSVReporter returns a Reporter that writes out closed Metrics as a CSV
+// header and row, e.g. to load into data frames or spreadsheets, with their
+// latency Percentiles, if any, instead of the fixed ones. Durations are in
+// ns.
+func NewCSVReporter(m *Metrics) Reporter {
+	return func(w io.Writer) error {
+		enc := csv.NewWriter(w)
+		enc.Write(csvMetricsHeader(m.Percentiles))
+		enc.Write(csvMetricsRow(m, m.Percentiles))
+		enc.Flush()
+		return enc.Error()
+	}
+}
+
+// csvMetricsHeader returns the header of CSV records of Metrics with the
+// given latency percentiles, if any, instead of the fixed ones.
+func csvMetricsHeader(percentiles []float64) []string {
+	header := []string{"requests", "rate", "success", "duration", "wait", "latency_mean"}
+	if len(percentiles) == 0 {
+		header = append(header, "latency_50", "latency_95", "latency_99")
+	}
+	for _, p := range percentiles {
+		header = append(header, "latency_"+FormatPercentile(p))
+	}
+	return append(header, "latency_max", "latency_stddev", "bytes_in", "bytes_out", "errors")
+}
+
+// csvMetricsRow returns the CSV record of the given Metrics headed by
+// csvMetricsHeader.
+func csvMetricsRow(m *Metrics, percentiles []float64) []string {
+	ns := func(d time.Duration) string { return strconv.FormatInt(d.Nanoseconds(), 10) }
+
+	row := []string{
+		strconv.FormatUint(m.Requests, 10),
+		strconv.FormatFloat(m.Rate, 'f', -1, 64),
+		strconv.FormatFloat(m.Success, 'f', -1, 64),
+		ns(m.Duration),
+		ns(m.Wait),
+		ns(m.Latencies.Mean),
+	}
+
+	if len(percentiles) == 0 {
+		row = append(row, ns(m.Latencies.P50), ns(m.Latencies.P95), ns(m.Latencies.P99))
+	}
+	for _, p := range percentiles {
+		row = append(row, ns(m.Latencies.Percentiles[FormatPercentile(p)]))
+	}
+
+	var errs uint64
+	for _, n := range m.ErrorClasses {
+		errs += n
+	}
+
+	return append(row,
+		ns(m.Latencies.Max),
+		ns(m.Latencies.StdDev),
+		strconv.FormatUint(m.BytesIn.Total, 10),
+		strconv.FormatUint(m.BytesOut.Total, 10),
+		strconv.FormatUint(errs, 10),
+	)
+}
+
 // NewJSONReporter returns a Reporter that writes out Metrics as JSON.
 func NewJSONReporter(m *Metrics) Reporter {
 	return func(w io.Writer) error {
@@ -104,10 +343,91 @@ func NewJSONReporter(m *Metrics) Reporter {
 	}
 }
 
+// NewHGRMReporter returns a Reporter that writes out the percentile
+// distribution of the latencies of closed Metrics in milliseconds, in the
+// .hgrm format of HdrHistogram tooling, as HDRHistogram.WritePercentiles.
+func NewHGRMReporter(m *Metrics) Reporter {
+	return func(w io.Writer) error {
+		h := m.latencies
+		if h == nil {
+			h = NewHDRHistogram()
+		}
+		return h.WritePercentiles(w, time.Millisecond)
+	}
+}
+
+// NewUniqueBodiesReporter returns a Reporter that writes out the distinct
+// response bodies of each target as aligned, formatted text, with their
+// abbreviated digests and samples.
+func NewUniqueBodiesReporter(u *UniqueBodies) Reporter {
+	return func(w io.Writer) (err error) {
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.StripEscape)
+		for i, tb := range u.Targets {
+			if i > 0 {
+				if _, err = fmt.Fprintln(tw); err != nil {
+					return err
+				}
+			}
+
+			if _, err = fmt.Fprintf(tw, "%s %s [total, distinct] %d, %d\n",
+				tb.Method, tb.URL, tb.Total, len(tb.Bodies),
+			); err != nil {
+				return err
+			}
+
+			if _, err = fmt.Fprintf(tw, "Count\t%%\tSHA-256\tBytes\tStatus Codes\tSample\n"); err != nil {
+				return err
+			}
+
+			for _, bc := range tb.Bodies {
+				codes := make([]string, 0, len(bc.StatusCodes))
+				for code, count := range bc.StatusCodes {
+					codes = append(codes, code+":"+strconv.Itoa(count))
+				}
+				sort.Strings(codes)
+
+				if _, err = fmt.Fprintf(tw, "%d\t%.2f%%\t%.12s\t%d\t%s\t%q\n",
+					bc.Count, float64(bc.Count)/float64(tb.Total)*100,
+					bc.Sum, bc.Bytes, strings.Join(codes, " "), bc.Sample,
+				); err != nil {
+					return err
+				}
+			}
+		}
+
+		return tw.Flush()
+	}
+}
+
 // NewPlotReporter returns a Reporter that writes a self-contained
 // HTML page with an interactive plot of the latencies of Requests, built with
 // http://dygraphs.com/
 func NewPlotReporter(title string, rs *Results) Reporter {
+	return NewPlotReporterWithAxis(title, rs, TimeAxis{})
+}
+
+// A TimeAxis defines the time axis of reports of Results over time.
+type TimeAxis struct {
+	// WallClock makes the axis show wall-clock times instead of the time
+	// elapsed since the beginning of each attack, e.g. to line up results
+	// of attacks run at the same time from multiple regions.
+	WallClock bool
+	// Location is the time zone wall-clock times are shown in. Nil means
+	// UTC.
+	Location *time.Location
+}
+
+// location returns the time zone of the TimeAxis.
+func (ax TimeAxis) location() *time.Location {
+	if ax.Location == nil {
+		return time.UTC
+	}
+	return ax.Location
+}
+
+// NewPlotReporterWithAxis is like NewPlotReporter but plots the latencies
+// over the given TimeAxis.
+func NewPlotReporterWithAxis(title string, rs *Results, ax TimeAxis) Reporter {
 	return func(w io.Writer) (err error) {
 		_, err = fmt.Fprintf(w, plotsTemplateHead, title, asset(dygraphs), asset(html2canvas))
 		if err != nil {
@@ -140,8 +460,16 @@ func NewPlotReporter(title string, rs *Results) Reporter {
 					offset++
 				}
 
-				ts := r.Timestamp.Sub(results[0].Timestamp).Seconds()
-				data[0] = strconv.FormatFloat(ts, 'f', -1, 32)
+				if ax.WallClock {
+					// Dygraphs shows dates in UTC or the browser's time
+					// zone, so they're shifted to show them in the axis'.
+					_, offset := r.Timestamp.In(ax.location()).Zone()
+					ms := r.Timestamp.Add(time.Duration(offset)*time.Second).UnixNano() / 1e6
+					data[0] = "new Date(" + strconv.FormatInt(ms, 10) + ")"
+				} else {
+					ts := r.Timestamp.Sub(results[0].Timestamp).Seconds()
+					data[0] = strconv.FormatFloat(ts, 'f', -1, 32)
+				}
 
 				latency := r.Latency.Seconds() * 1000
 				data[offset] = strconv.FormatFloat(latency, 'f', -1, 32)
@@ -158,8 +486,12 @@ func NewPlotReporter(title string, rs *Results) Reporter {
 			}
 		}
 
-		labels := make([]string, len(data))
+		labels, xlabel := make([]string, len(data)), "Seconds elapsed"
 		labels[0] = strconv.Quote("Seconds")
+		if ax.WallClock {
+			labels[0] = strconv.Quote("Time")
+			xlabel = "Time (" + ax.location().String() + ")"
+		}
 
 		for attack, offset := range offsets {
 			labels[offset] = strconv.Quote(attack + " - ERR")
@@ -176,7 +508,8 @@ func NewPlotReporter(title string, rs *Results) Reporter {
 			colors = append(colors, strconv.Quote(color.Hex()))
 		}
 
-		_, err = fmt.Fprintf(w, plotsTemplateTail, title, strings.Join(labels, ","), strings.Join(colors, ","))
+		_, err = fmt.Fprintf(w, plotsTemplateTail, title, strings.Join(labels, ","),
+			strconv.Quote(xlabel), strings.Join(colors, ","), ax.WallClock)
 		return err
 	}
 }
@@ -202,8 +535,9 @@ const (
       title: '%s',
       labels: [%s],
       ylabel: 'Latency (ms)',
-      xlabel: 'Seconds elapsed',
+      xlabel: %s,
       colors: [%s],
+      labelsUTC: %t,
       showRoller: true,
       legend: 'always',
       logscale: true,
@@ -1,7 +1,9 @@
 package vegeta
 
 import (
+	"bytes"
 	"io/ioutil"
+	"strings"
 	"testing"
 	"time"
 )
@@ -28,3 +30,71 @@ func BenchmarkPlotReporter(b *testing.B) {
 		rep.Report(ioutil.Discard)
 	}
 }
+
+func TestPlotReporterWithAxis(t *testing.T) {
+	t.Parallel()
+
+	began := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	rs := Results{
+		{Attack: "a", Timestamp: began, Latency: time.Millisecond},
+		{Attack: "a", Timestamp: began.Add(time.Second), Latency: time.Millisecond},
+	}
+
+	tokyo := time.FixedZone("JST", 9*3600)
+	for _, tc := range []struct {
+		ax   TimeAxis
+		want []string
+	}{
+		{TimeAxis{}, []string{"[1,", `xlabel: "Seconds elapsed"`, "labelsUTC: false"}},
+		{TimeAxis{WallClock: true}, []string{"[new Date(1527854401000),", `xlabel: "Time (UTC)"`, "labelsUTC: true"}},
+		{TimeAxis{WallClock: true, Location: tokyo}, []string{"[new Date(1527886801000),", `xlabel: "Time (JST)"`}},
+	} {
+		var b bytes.Buffer
+		if err := NewPlotReporterWithAxis("plot", &rs, tc.ax).Report(&b); err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range tc.want {
+			if !strings.Contains(b.String(), want) {
+				t.Errorf("%+v: plot doesn't contain %q", tc.ax, want)
+			}
+		}
+	}
+}
+
+func TestCSVReporter(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		percentiles []float64
+		want        string
+	}{
+		{nil, "requests,rate,success,duration,wait,latency_mean,latency_50,latency_95,latency_99,latency_max,latency_stddev,bytes_in,bytes_out,errors\n" +
+			"4,1.3333333333333333,0.75,3000000000,4000000,2500000,2007039,4000000,4000000,4000000,1118033,40,0,1\n"},
+		{[]float64{99.9}, "requests,rate,success,duration,wait,latency_mean,latency_99.9,latency_max,latency_stddev,bytes_in,bytes_out,errors\n" +
+			"4,1.3333333333333333,0.75,3000000000,4000000,2500000,4000000,4000000,1118033,40,0,1\n"},
+	} {
+		m := Metrics{Percentiles: tc.percentiles}
+		for i := 1; i <= 4; i++ {
+			r := Result{
+				Code:      200,
+				Timestamp: time.Unix(int64(i), 0),
+				Latency:   time.Duration(i) * time.Millisecond,
+				BytesIn:   10,
+			}
+			if i == 4 {
+				r.Code, r.Error, r.ErrorClass = 500, "500 Internal Server Error", "http_5xx"
+			}
+			m.Add(&r)
+		}
+		m.Close()
+
+		var b bytes.Buffer
+		if err := NewCSVReporter(&m).Report(&b); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := b.String(); got != tc.want {
+			t.Errorf("percentiles %v: got:\n%s\nwant:\n%s", tc.percentiles, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package vegeta
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/dnscache"
+)
+
+// A Resolver resolves host names to IP addresses, e.g. a *net.Resolver.
+type Resolver interface {
+	LookupHost(ctx context.Context, host string) (addrs []string, err error)
+}
+
+// DNSCache returns a functional option which sets the time after which the
+// host names an Attacker cached the addresses of are resolved again, so that
+// long attacks notice DNS changes. Zero, the default, caches addresses for
+// the lifetime of the Attacker and negative durations disable caching.
+func DNSCache(ttl time.Duration) func(*Attacker) {
+	return func(a *Attacker) {
+		a.dns.ttl = ttl
+		a.resolver = a.dns.resolver()
+	}
+}
+
+// DNSResolver returns a functional option which sets the Resolver an Attacker
+// resolves host names with, whose answers are cached as set by DNSCache. It
+// defaults to net.DefaultResolver.
+func DNSResolver(r Resolver) func(*Attacker) {
+	return func(a *Attacker) {
+		a.dns.base = r
+		a.resolver = a.dns.resolver()
+	}
+}
+
+// HostMap returns a functional option which makes an Attacker resolve the
+// given host names to the given IP addresses instead of looking them up, like
+// /etc/hosts does, e.g. to point an attack at specific backends. Requests
+// keep their Host header and TLS server name.
+func HostMap(hosts map[string][]string) func(*Attacker) {
+	return func(a *Attacker) {
+		a.dns.hosts = make(map[string][]string, len(hosts))
+		for host, addrs := range hosts {
+			a.dns.hosts[strings.ToLower(host)] = addrs
+		}
+		a.resolver = a.dns.resolver()
+	}
+}
+
+// dnsConfig configures the name resolution of an Attacker.
+type dnsConfig struct {
+	base  Resolver
+	ttl   time.Duration
+	hosts map[string][]string
+}
+
+// resolver returns a new Resolver with the configuration, whose cache is
+// its own.
+func (c dnsConfig) resolver() Resolver {
+	base := c.base
+	if base == nil {
+		base = net.DefaultResolver
+	}
+
+	r := base
+	if c.ttl >= 0 {
+		cache := &dnsCache{ttl: c.ttl, refreshed: time.Now().UnixNano()}
+		cache.Resolver.Resolver = hostResolver{base}
+		r = cache
+	}
+
+	if len(c.hosts) > 0 {
+		r = hostMap{hosts: c.hosts, Resolver: r}
+	}
+
+	return r
+}
+
+// NewDNSServersResolver returns a Resolver which queries the given DNS servers,
+// as host:port addresses or IPs of port 53, in turn instead of those of the
+// system, e.g. for split horizon DNS.
+func NewDNSServersResolver(servers ...string) Resolver {
+	addrs := make([]string, len(servers))
+	for i, s := range servers {
+		if _, _, err := net.SplitHostPort(s); err != nil {
+			s = net.JoinHostPort(s, "53")
+		}
+		addrs[i] = s
+	}
+
+	var (
+		next   uint64
+		dialer net.Dialer
+	)
+
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			addr := addrs[(atomic.AddUint64(&next, 1)-1)%uint64(len(addrs))]
+			return dialer.DialContext(ctx, network, addr)
+		},
+	}
+}
+
+// NewDoHResolver returns a Resolver which looks up the A and AAAA records of
+// host names with the given DNS over HTTPS (RFC 8484) endpoint URL, e.g.
+// https://cloudflare-dns.com/dns-query, which it queries with the given
+// http.Client, http.DefaultClient if nil.
+func NewDoHResolver(url string, client *http.Client) Resolver {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &dohResolver{url: url, client: client}
+}
+
+// dohResolver is a DNS over HTTPS Resolver.
+type dohResolver struct {
+	url    string
+	client *http.Client
+}
+
+// LookupHost implements the Resolver interface.
+func (r *dohResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return []string{host}, nil
+	}
+
+	var addrs []string
+	for _, qtype := range []uint16{dnsTypes["A"], dnsTypes["AAAA"]} {
+		as, err := r.lookup(ctx, host, qtype)
+		if err != nil {
+			return nil, &net.DNSError{Err: err.Error(), Name: host, Server: r.url}
+		}
+		addrs = append(addrs, as...)
+	}
+
+	if len(addrs) == 0 {
+		return nil, &net.DNSError{Err: "no such host", Name: host, Server: r.url, IsNotFound: true}
+	}
+
+	return addrs, nil
+}
+
+// lookup looks up the addresses of the records of the given type.
+func (r *dohResolver) lookup(ctx context.Context, host string, qtype uint16) ([]string, error) {
+	// The ID is zero as per RFC 8484, section 4.1.
+	msg, err := dnsQuestion(0, host, qtype)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", r.url, bytes.NewReader(msg))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/dns-message")
+	req.Header.Set("Accept", "application/dns-message")
+
+	res, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	} else if res.StatusCode != http.StatusOK {
+		return nil, errors.New(res.Status)
+	}
+
+	switch rcode, err := dnsRcode(0, body); {
+	case err != nil:
+		return nil, err
+	case rcode == 3: // NXDOMAIN
+		return nil, nil
+	case rcode != 0:
+		return nil, errors.New(dnsRcodeText(rcode))
+	}
+
+	return dnsAddrs(body)
+}
+
+// hostMap is a Resolver of mapped host names, which looks up the others with
+// its Resolver.
+type hostMap struct {
+	Resolver
+	hosts map[string][]string
+}
+
+// LookupHost implements the Resolver interface.
+func (m hostMap) LookupHost(ctx context.Context, host string) ([]string, error) {
+	if addrs, ok := m.hosts[strings.ToLower(host)]; ok {
+		return addrs, nil
+	}
+	return m.Resolver.LookupHost(ctx, host)
+}
+
+// hostResolver adapts a Resolver to the resolvers of dnscache, which also
+// look up addresses.
+type hostResolver struct{ Resolver }
+
+func (r hostResolver) LookupAddr(ctx context.Context, addr string) ([]string, error) {
+	return net.DefaultResolver.LookupAddr(ctx, addr)
+}
+
+// dnsCache is a caching Resolver which refreshes its cached records in the
+// background once they're older than its ttl.
+type dnsCache struct {
+	dnscache.Resolver
+	ttl        time.Duration
+	refreshed  int64 // UNIX nanoseconds
+	refreshing int32
+}
+
+// LookupHost implements the Resolver interface.
+func (c *dnsCache) LookupHost(ctx context.Context, host string) ([]string, error) {
+	if c.ttl > 0 && time.Now().UnixNano()-atomic.LoadInt64(&c.refreshed) > int64(c.ttl) &&
+		atomic.CompareAndSwapInt32(&c.refreshing, 0, 1) {
+		go func() {
+			// Records of hosts which fail to resolve are kept, while those
+			// which weren't looked up since the last refresh are dropped.
+			c.RefreshWithOptions(dnscache.ResolverRefreshOptions{ClearUnused: true, PersistOnFailure: true})
+			atomic.StoreInt64(&c.refreshed, time.Now().UnixNano())
+			atomic.StoreInt32(&c.refreshing, 0)
+		}()
+	}
+	return c.Resolver.LookupHost(ctx, host)
+}
@@ -0,0 +1,203 @@
+package vegeta
+
+import (
+	"context"
+	"encoding/binary"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingResolver resolves every host to the loopback address and counts
+// its lookups.
+type countingResolver struct{ n int32 }
+
+func (r *countingResolver) LookupHost(context.Context, string) ([]string, error) {
+	atomic.AddInt32(&r.n, 1)
+	return []string{"127.0.0.1"}, nil
+}
+
+func TestDNSCache(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		ttl     time.Duration
+		lookups int32
+	}{
+		{0, 1},
+		{-1, 3},
+		{50 * time.Millisecond, 2},
+	} {
+		var r countingResolver
+		atk := NewAttacker(DNSResolver(&r), DNSCache(tc.ttl))
+
+		for i := 0; i < 3; i++ {
+			if tc.ttl > 0 && i == 2 {
+				time.Sleep(2 * tc.ttl)
+			}
+			if _, err := atk.resolver.LookupHost(context.Background(), "goku"); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// Refreshes happen in the background.
+		time.Sleep(20 * time.Millisecond)
+		if got := atomic.LoadInt32(&r.n); got != tc.lookups {
+			t.Errorf("ttl %s: got %d lookups, want %d", tc.ttl, got, tc.lookups)
+		}
+	}
+
+	// Attackers don't share their caches.
+	var r countingResolver
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+	tr := NewStaticTargeter(Target{Method: "GET", URL: "http://vegeta.test:" + u.Port()})
+	for i := 0; i < 2; i++ {
+		if res := NewAttacker(DNSResolver(&r)).hit(tr, "", 0); res.Error != "" {
+			t.Fatal(res.Error)
+		}
+	}
+
+	if got := atomic.LoadInt32(&r.n); got != 2 {
+		t.Errorf("got %d lookups by two attackers, want 2", got)
+	}
+}
+
+func TestHostMap(t *testing.T) {
+	t.Parallel()
+
+	var host string
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		host = r.Host
+	}))
+	defer server.Close()
+
+	var r countingResolver
+	atk := NewAttacker(DNSResolver(&r), HostMap(map[string][]string{"Goku.Test": {"127.0.0.1"}}))
+
+	u, _ := url.Parse(server.URL)
+	tr := NewStaticTargeter(Target{Method: "GET", URL: "http://goku.test:" + u.Port()})
+	if res := atk.hit(tr, "", 0); res.Error != "" {
+		t.Fatal(res.Error)
+	}
+
+	if want := "goku.test:" + u.Port(); host != want {
+		t.Errorf("got host %q, want %q", host, want)
+	}
+
+	if _, err := atk.resolver.LookupHost(context.Background(), "vegeta.test"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := atomic.LoadInt32(&r.n); got != 1 {
+		t.Errorf("got %d lookups, want only the unmapped host's", got)
+	}
+}
+
+// dnsRecords answers the given query with records of the queried type among
+// the given IP addresses, whose names are compressed.
+func dnsRecords(query []byte, ips ...string) []byte {
+	end := dnsSkipName(query, 12) + 4
+	qtype := binary.BigEndian.Uint16(query[end-4:])
+
+	reply := append([]byte(nil), query[:end]...)
+	binary.BigEndian.PutUint16(reply[2:], 0x8180)
+	binary.BigEndian.PutUint16(reply[10:], 0) // ARCOUNT
+
+	var an uint16
+	for _, s := range ips {
+		ip, typ := net.ParseIP(s).To4(), dnsTypes["A"]
+		if ip == nil {
+			ip, typ = net.ParseIP(s), dnsTypes["AAAA"]
+		}
+		if typ != qtype {
+			continue
+		}
+
+		an++
+		reply = append(reply, 0xc0, 12) // pointer to the question name
+		for _, v := range []uint16{typ, 1, 0, 60, uint16(len(ip))} {
+			reply = append(reply, byte(v>>8), byte(v))
+		}
+		reply = append(reply, ip...)
+	}
+	binary.BigEndian.PutUint16(reply[6:], an)
+
+	return reply
+}
+
+func TestNewDNSServersResolver(t *testing.T) {
+	t.Parallel()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			conn.WriteTo(dnsRecords(buf[:n], "10.0.0.1", "10.0.0.2"), addr)
+		}
+	}()
+
+	r := NewDNSServersResolver(conn.LocalAddr().String())
+	addrs, err := r.LookupHost(context.Background(), "goku.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Strings(addrs)
+	if want := []string{"10.0.0.1", "10.0.0.2"}; !reflect.DeepEqual(addrs, want) {
+		t.Errorf("got addresses %v, want %v", addrs, want)
+	}
+}
+
+func TestNewDoHResolver(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query, _ := ioutil.ReadAll(r.Body)
+		if r.Header.Get("Content-Type") != "application/dns-message" || binary.BigEndian.Uint16(query) != 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if strings.Contains(string(query), "missing") {
+			w.Write(dnsReply(query, 3))
+			return
+		}
+		w.Write(dnsRecords(query, "10.0.0.1", "::1"))
+	}))
+	defer server.Close()
+
+	r := NewDoHResolver(server.URL, nil)
+	addrs, err := r.LookupHost(context.Background(), "goku.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"10.0.0.1", "::1"}; !reflect.DeepEqual(addrs, want) {
+		t.Errorf("got addresses %v, want %v", addrs, want)
+	}
+
+	_, err = r.LookupHost(context.Background(), "missing.goku.test")
+	if dnsErr, ok := err.(*net.DNSError); !ok || !dnsErr.IsNotFound {
+		t.Errorf("got error %v, want not found", err)
+	}
+}
@@ -2,11 +2,17 @@ package vegeta
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"encoding/base64"
 	"encoding/csv"
 	"encoding/gob"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"io"
+	"net/http"
+	"net/url"
+	"reflect"
 	"sort"
 	"strconv"
 	"time"
@@ -27,11 +33,121 @@ type Result struct {
 	BytesIn   uint64        `json:"bytes_in"`
 	Error     string        `json:"error"`
 	Body      []byte        `json:"body"`
+	// Method is the HTTP method of the hit's request.
+	Method string `json:"method"`
+	// URL is the URL of the hit's request.
+	URL string `json:"url"`
+	// Header holds the headers of the hit's request, as recorded with
+	// RequestHeaders.
+	Header http.Header `json:"headers"`
+	// ClockOffset is the offset of the attacker's clock to a reference
+	// clock, as measured by ClockOffset, to be added to Timestamp.
+	ClockOffset time.Duration `json:"clock_offset"`
+	// Backoff is the time the hit was paused for before being sent, as
+	// asked by previous responses of the same host.
+	Backoff time.Duration `json:"backoff"`
+	// BodyHash is the hex encoded SHA-256 digest of the response body, set
+	// instead of Body when the Attacker only keeps body digests.
+	BodyHash string `json:"body_hash"`
+	// Tags are the labels of the hit's Target.
+	Tags map[string]string `json:"tags"`
+	// Extract holds the values extracted from the response by the
+	// Attacker's Extractors, keyed by their names.
+	Extract map[string]string `json:"extract"`
+	// TraceID is the hex encoded ID of the trace started by the hit, when
+	// the Attacker sends trace headers.
+	TraceID string `json:"trace_id"`
+	// RequestID is the unique ID the hit's request was sent with, when the
+	// Attacker sends request IDs.
+	RequestID string `json:"request_id"`
+	// Retries is the number of times the hit was retried as per the
+	// RetryPolicy of its Target. Its Timestamp and Latency span all of them.
+	Retries uint16 `json:"retries"`
+	// TLSHandshake is the kind of the TLS handshake of the connection the
+	// hit established, FullHandshake or ResumedHandshake, if any.
+	TLSHandshake string `json:"tls_handshake"`
+	// Phases are the durations of the phases of the hit.
+	Phases Phases `json:"phases"`
+	// RemoteAddr is the IP:port address of the connection the hit was sent
+	// on, e.g. that of the backend of a DNS balanced host, or of the proxy.
+	RemoteAddr string `json:"remote_addr"`
+	// ConnReused is true if the hit was sent on a connection established by
+	// a previous one, and false if it established its own.
+	ConnReused bool `json:"conn_reused"`
+	// ResponseHeader holds the response headers recorded as set by
+	// ResponseHeaders.
+	ResponseHeader http.Header `json:"response_headers"`
+	// Truncated is true if the response body was longer than the limit set
+	// by MaxBody, past which it wasn't read.
+	Truncated bool `json:"truncated"`
+	// RequestBody is the body of the hit's request, when the Attacker
+	// records them.
+	RequestBody []byte `json:"request_body"`
+	// Redirects are the redirect responses the hit followed before the
+	// final response, in order.
+	Redirects []Redirect `json:"redirects"`
+	// ErrorClass is the class of the Error, one of the ErrorClass
+	// constants, if any.
+	ErrorClass string `json:"error_class"`
+	// BodyFile is the path of the file the response body was written to by
+	// a BodyFiles handler.
+	BodyFile string `json:"body_file"`
+	// Intended is the time the hit was due at as per the Pacer of its
+	// attack. It precedes Timestamp when the attack fell behind schedule,
+	// e.g. with a saturated target, whose latency then omits the wait.
+	Intended time.Time `json:"intended"`
+	// Accepted is true if the response was judged successful by a success
+	// predicate or by the expected status codes of its Target's assertions
+	// rather than by its status code.
+	Accepted bool `json:"accepted"`
+}
+
+// BodySum returns the hex encoded SHA-256 digest of the response body,
+// either as recorded in BodyHash or computed from Body, which allows
+// comparing bodies regardless of whether they were kept in full.
+func (r *Result) BodySum() string {
+	if r.BodyHash != "" {
+		return r.BodyHash
+	}
+	sum := sha256.Sum256(r.Body)
+	return hex.EncodeToString(sum[:])
 }
 
 // End returns the time at which a Result ended.
 func (r *Result) End() time.Time { return r.Timestamp.Add(r.Latency) }
 
+// CorrectedLatency returns the latency of the Result from its Intended time
+// on, which corrects the coordinated omission of the time the hit waited
+// to be sent when the attack fell behind schedule.
+func (r *Result) CorrectedLatency() time.Duration {
+	if r.Intended.IsZero() || !r.Intended.Before(r.Timestamp) {
+		return r.Latency
+	}
+	return r.End().Sub(r.Intended)
+}
+
+// A CorrectedReport wraps a Report, adding Results to it as if they had
+// been sent at their Intended times, with their CorrectedLatency, so that
+// reports of attacks which fell behind schedule aren't overly optimistic.
+type CorrectedReport struct{ Report }
+
+// Add implements the Add method of the Report interface.
+func (c CorrectedReport) Add(r *Result) {
+	corrected := *r
+	if !r.Intended.IsZero() && r.Intended.Before(r.Timestamp) {
+		corrected.Timestamp, corrected.Latency = r.Intended, r.CorrectedLatency()
+	}
+	c.Report.Add(&corrected)
+}
+
+// Close implements the Close method of the Report interface by closing the
+// wrapped Report, if it's a Closer.
+func (c CorrectedReport) Close() {
+	if cl, ok := c.Report.(Closer); ok {
+		cl.Close()
+	}
+}
+
 // Equal returns true if the given Result is equal to the receiver.
 func (r Result) Equal(other Result) bool {
 	return r.Attack == other.Attack &&
@@ -42,7 +158,48 @@ func (r Result) Equal(other Result) bool {
 		r.BytesIn == other.BytesIn &&
 		r.BytesOut == other.BytesOut &&
 		r.Error == other.Error &&
-		bytes.Equal(r.Body, other.Body)
+		bytes.Equal(r.Body, other.Body) &&
+		r.BodyHash == other.BodyHash &&
+		mapEqual(r.Tags, other.Tags) &&
+		mapEqual(r.Extract, other.Extract) &&
+		r.Method == other.Method &&
+		r.URL == other.URL &&
+		headerEqual(r.Header, other.Header) &&
+		r.ClockOffset == other.ClockOffset &&
+		r.Backoff == other.Backoff &&
+		r.TraceID == other.TraceID &&
+		r.RequestID == other.RequestID &&
+		r.Retries == other.Retries &&
+		r.TLSHandshake == other.TLSHandshake &&
+		r.Phases == other.Phases &&
+		r.RemoteAddr == other.RemoteAddr &&
+		r.ConnReused == other.ConnReused &&
+		headerEqual(r.ResponseHeader, other.ResponseHeader) &&
+		r.Truncated == other.Truncated &&
+		bytes.Equal(r.RequestBody, other.RequestBody) &&
+		(len(r.Redirects) == 0 && len(other.Redirects) == 0 || reflect.DeepEqual(r.Redirects, other.Redirects)) &&
+		r.ErrorClass == other.ErrorClass &&
+		r.BodyFile == other.BodyFile &&
+		r.Intended.Equal(other.Intended) &&
+		r.Accepted == other.Accepted
+}
+
+// headerEqual returns true if both headers hold the same values, treating
+// nil and empty headers as equal.
+func headerEqual(a, b http.Header) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// mapEqual returns true if both maps hold the same values, treating nil and
+// empty maps as equal.
+func mapEqual(a, b map[string]string) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
 }
 
 // Results is a slice of Result type elements.
@@ -107,10 +264,50 @@ func (enc Encoder) Encode(r *Result) error { return enc(r) }
 // NewCSVEncoder returns an Encoder that dumps the given *Result as a CSV
 // record. The columns are: UNIX timestamp in ns since epoch,
 // HTTP status code, request latency in ns, bytes out, bytes in,
-// response body, and lastly the error.
+// error, base64 encoded response body, attack name, sequence number,
+// response body digest, URL query encoded tags, URL query encoded extracted
+// values, trace ID, request ID, retries, TLS handshake kind, the durations
+// in ns of the DNS, connect, TLS, first byte and body phases, remote address,
+// whether the connection was reused, the URL query encoded recorded response
+// headers, whether the response body was truncated, request method, URL,
+// URL query encoded request headers, base64 encoded request body, the
+// redirects followed, as space separated code, latency in ns and query
+// escaped URL triples separated by commas, error class, the path of the
+// response body file, the intended UNIX timestamp in ns since epoch,
+// whether the response was accepted by a success predicate or assertion, the
+// clock offset in ns and lastly the backoff in ns.
 func NewCSVEncoder(w io.Writer) Encoder {
+	return newCSVEncoder(w, false)
+}
+
+// CSVHeader holds the names of the columns of CSV encoded Results, as those
+// of their JSON encoding. Durations are in ns.
+var CSVHeader = []string{
+	"timestamp", "code", "latency", "bytes_out", "bytes_in", "error", "body",
+	"attack", "seq", "body_hash", "tags", "extract", "trace_id", "request_id",
+	"retries", "tls_handshake", "dns", "connect", "tls", "first_byte",
+	"body_read", "remote_addr", "conn_reused", "response_headers", "truncated",
+	"method", "url", "headers", "request_body", "redirects", "error_class",
+	"body_file", "intended", "accepted", "clock_offset", "backoff",
+}
+
+// NewCSVHeaderEncoder is like NewCSVEncoder but writes the CSVHeader
+// before the first Result, so that the CSV can be loaded as is into data
+// frames or spreadsheets. NewCSVDecoder skips it.
+func NewCSVHeaderEncoder(w io.Writer) Encoder {
+	return newCSVEncoder(w, true)
+}
+
+func newCSVEncoder(w io.Writer, header bool) Encoder {
 	enc := csv.NewWriter(w)
 	return func(r *Result) error {
+		if header {
+			if err := enc.Write(CSVHeader); err != nil {
+				return err
+			}
+			header = false
+		}
+
 		err := enc.Write([]string{
 			strconv.FormatInt(r.Timestamp.UnixNano(), 10),
 			strconv.FormatUint(uint64(r.Code), 10),
@@ -121,6 +318,33 @@ func NewCSVEncoder(w io.Writer) Encoder {
 			base64.StdEncoding.EncodeToString(r.Body),
 			r.Attack,
 			strconv.FormatUint(r.Seq, 10),
+			r.BodyHash,
+			encodeMap(r.Tags),
+			encodeMap(r.Extract),
+			r.TraceID,
+			r.RequestID,
+			strconv.FormatUint(uint64(r.Retries), 10),
+			r.TLSHandshake,
+			strconv.FormatInt(r.Phases.DNS.Nanoseconds(), 10),
+			strconv.FormatInt(r.Phases.Connect.Nanoseconds(), 10),
+			strconv.FormatInt(r.Phases.TLS.Nanoseconds(), 10),
+			strconv.FormatInt(r.Phases.FirstByte.Nanoseconds(), 10),
+			strconv.FormatInt(r.Phases.Body.Nanoseconds(), 10),
+			r.RemoteAddr,
+			strconv.FormatBool(r.ConnReused),
+			url.Values(r.ResponseHeader).Encode(),
+			strconv.FormatBool(r.Truncated),
+			r.Method,
+			r.URL,
+			url.Values(r.Header).Encode(),
+			base64.StdEncoding.EncodeToString(r.RequestBody),
+			encodeRedirects(r.Redirects),
+			r.ErrorClass,
+			r.BodyFile,
+			encodeTime(r.Intended),
+			strconv.FormatBool(r.Accepted),
+			strconv.FormatInt(r.ClockOffset.Nanoseconds(), 10),
+			strconv.FormatInt(r.Backoff.Nanoseconds(), 10),
 		})
 
 		if err != nil {
@@ -133,11 +357,18 @@ func NewCSVEncoder(w io.Writer) Encoder {
 	}
 }
 
-// NewCSVDecoder returns a Decoder that decodes CSV encoded Results.
+// NewCSVDecoder returns a Decoder that decodes CSV encoded Results, with or
+// without the CSVHeader.
 func NewCSVDecoder(rd io.Reader) Decoder {
 	dec := csv.NewReader(rd)
+	first := true
 	return func(r *Result) error {
 		rec, err := dec.Read()
+		if err == nil && first && rec[0] == CSVHeader[0] {
+			rec, err = dec.Read()
+		}
+		first = false
+
 		if err != nil {
 			return err
 		}
@@ -176,10 +407,176 @@ func NewCSVDecoder(rd io.Reader) Decoder {
 			return err
 		}
 
+		if len(rec) > 9 {
+			r.BodyHash = rec[9]
+		}
+
+		if len(rec) > 10 {
+			if r.Tags, err = decodeMap(rec[10]); err != nil {
+				return fmt.Errorf("bad tags: %s", err)
+			}
+		}
+
+		if len(rec) > 11 {
+			if r.Extract, err = decodeMap(rec[11]); err != nil {
+				return fmt.Errorf("bad extracted values: %s", err)
+			}
+		}
+
+		if len(rec) > 12 {
+			r.TraceID = rec[12]
+		}
+
+		if len(rec) > 13 {
+			r.RequestID = rec[13]
+		}
+
+		if len(rec) > 14 {
+			retries, err := strconv.ParseUint(rec[14], 10, 16)
+			if err != nil {
+				return err
+			}
+			r.Retries = uint16(retries)
+		}
+
+		if len(rec) > 15 {
+			r.TLSHandshake = rec[15]
+		}
+
+		if len(rec) > 20 {
+			phases := []*time.Duration{
+				&r.Phases.DNS, &r.Phases.Connect, &r.Phases.TLS, &r.Phases.FirstByte, &r.Phases.Body,
+			}
+			for i, d := range phases {
+				ns, err := strconv.ParseInt(rec[16+i], 10, 64)
+				if err != nil {
+					return fmt.Errorf("bad phases: %s", err)
+				}
+				*d = time.Duration(ns)
+			}
+		}
+
+		if len(rec) > 22 {
+			r.RemoteAddr = rec[21]
+			if r.ConnReused, err = strconv.ParseBool(rec[22]); err != nil {
+				return fmt.Errorf("bad connection reuse: %s", err)
+			}
+		}
+
+		if len(rec) > 23 && rec[23] != "" {
+			vs, err := url.ParseQuery(rec[23])
+			if err != nil {
+				return fmt.Errorf("bad response headers: %s", err)
+			}
+			r.ResponseHeader = http.Header(vs)
+		}
+
+		if len(rec) > 24 {
+			if r.Truncated, err = strconv.ParseBool(rec[24]); err != nil {
+				return fmt.Errorf("bad truncation: %s", err)
+			}
+		}
+
+		if len(rec) > 28 {
+			r.Method, r.URL = rec[25], rec[26]
+			if rec[27] != "" {
+				vs, err := url.ParseQuery(rec[27])
+				if err != nil {
+					return fmt.Errorf("bad request headers: %s", err)
+				}
+				r.Header = http.Header(vs)
+			}
+			if rec[28] != "" {
+				if r.RequestBody, err = base64.StdEncoding.DecodeString(rec[28]); err != nil {
+					return fmt.Errorf("bad request body: %s", err)
+				}
+			}
+		}
+
+		if len(rec) > 29 {
+			if r.Redirects, err = decodeRedirects(rec[29]); err != nil {
+				return err
+			}
+		}
+
+		if len(rec) > 30 {
+			r.ErrorClass = rec[30]
+		}
+
+		if len(rec) > 31 {
+			r.BodyFile = rec[31]
+		}
+
+		if len(rec) > 32 && rec[32] != "" {
+			ts, err := strconv.ParseInt(rec[32], 10, 64)
+			if err != nil {
+				return err
+			}
+			r.Intended = time.Unix(0, ts)
+		}
+
+		if len(rec) > 33 {
+			if r.Accepted, err = strconv.ParseBool(rec[33]); err != nil {
+				return fmt.Errorf("bad acceptance: %s", err)
+			}
+		}
+
+		if len(rec) > 34 {
+			offset, err := strconv.ParseInt(rec[34], 10, 64)
+			if err != nil {
+				return fmt.Errorf("bad clock offset: %s", err)
+			}
+			r.ClockOffset = time.Duration(offset)
+		}
+
+		if len(rec) > 35 {
+			backoff, err := strconv.ParseInt(rec[35], 10, 64)
+			if err != nil {
+				return fmt.Errorf("bad backoff: %s", err)
+			}
+			r.Backoff = time.Duration(backoff)
+		}
+
 		return err
 	}
 }
 
+// encodeTime encodes the given time in ns since epoch, or as empty if zero.
+func encodeTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// encodeMap encodes the given map in URL query format, sorted by key.
+func encodeMap(m map[string]string) string {
+	vs := make(url.Values, len(m))
+	for k, v := range m {
+		vs.Set(k, v)
+	}
+	return vs.Encode()
+}
+
+// decodeMap decodes a map encoded by encodeMap.
+func decodeMap(s string) (map[string]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	vs, err := url.ParseQuery(s)
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(map[string]string, len(vs))
+	for k := range vs {
+		m[k] = vs.Get(k)
+	}
+
+	return m, nil
+}
+
 // NewJSONEncoder returns an Encoder that dumps the given *Results as a JSON
 // object.
 func NewJSONEncoder(w io.Writer) Encoder {
@@ -192,3 +589,38 @@ func NewJSONDecoder(r io.Reader) Decoder {
 	dec := json.NewDecoder(r)
 	return func(r *Result) error { return dec.Decode(r) }
 }
+
+// ResultSchema is the version of the schema of NDJSON encoded Results. It's
+// bumped by changes old readers can't ignore, e.g. renamed fields, but not
+// by added fields, which they skip.
+const ResultSchema = 1
+
+// ndjsonResult is an NDJSON encoded Result.
+type ndjsonResult struct {
+	Schema int `json:"schema"`
+	*Result
+}
+
+// NewNDJSONEncoder returns an Encoder that dumps the given *Results as
+// newline delimited JSON objects, e.g. for jq, Logstash or BigQuery, with
+// the fields of the JSON encoding and a schema field of ResultSchema.
+func NewNDJSONEncoder(w io.Writer) Encoder {
+	enc := json.NewEncoder(w)
+	return func(r *Result) error { return enc.Encode(ndjsonResult{ResultSchema, r}) }
+}
+
+// NewNDJSONDecoder returns a Decoder that decodes NDJSON encoded Results. It
+// fails on Results of a newer schema than ResultSchema and decodes those
+// without one as JSON encoded Results.
+func NewNDJSONDecoder(rd io.Reader) Decoder {
+	dec := json.NewDecoder(rd)
+	return func(r *Result) error {
+		res := ndjsonResult{Result: r}
+		if err := dec.Decode(&res); err != nil {
+			return err
+		} else if res.Schema > ResultSchema {
+			return fmt.Errorf("unsupported result schema %d, want up to %d", res.Schema, ResultSchema)
+		}
+		return nil
+	}
+}
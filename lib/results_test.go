@@ -2,8 +2,11 @@ package vegeta
 
 import (
 	"bytes"
+	"fmt"
 	"io"
+	"net/http"
 	"reflect"
+	"strings"
 	"testing"
 	"testing/quick"
 	"time"
@@ -57,23 +60,59 @@ func TestEncoding(t *testing.T) {
 	}{
 		{"gob", NewEncoder, NewDecoder},
 		{"csv", NewCSVEncoder, NewCSVDecoder},
+		{"csv with header", NewCSVHeaderEncoder, NewCSVDecoder},
 		{"json", NewJSONEncoder, NewJSONDecoder},
+		{"ndjson", NewNDJSONEncoder, NewNDJSONDecoder},
+		{"protobuf", NewProtobufEncoder, NewProtobufDecoder},
 	} {
 		t.Run(tc.encoding, func(t *testing.T) {
 			var buf bytes.Buffer
 			enc := tc.enc(&buf)
 			dec := tc.dec(&buf)
-			err := quick.Check(func(code uint16, ts uint32, latency time.Duration, seq, bsIn, bsOut uint64, body []byte, attack, e string) bool {
+			err := quick.Check(func(code uint16, ts uint32, latency time.Duration, seq, bsIn, bsOut uint64, body []byte, attack, e, hash, trace, id string, retries uint16, handshake string, phases Phases, remote string, reused bool, respHeaders map[string]string, truncated bool, method, url string, reqHeaders map[string]string, reqBody []byte, redirects []Redirect, class, bodyFile string, intended uint32, accepted bool, offset, backoff time.Duration, tags, extract map[string]string) bool {
+				respHeader := make(http.Header, len(respHeaders))
+				for k, v := range respHeaders {
+					respHeader[k] = []string{v}
+				}
+
+				reqHeader := make(http.Header, len(reqHeaders))
+				for k, v := range reqHeaders {
+					reqHeader[k] = []string{v}
+				}
+
 				want := Result{
-					Attack:    attack,
-					Seq:       seq,
-					Code:      code,
-					Timestamp: time.Unix(int64(ts), 0),
-					Latency:   latency,
-					BytesIn:   bsIn,
-					BytesOut:  bsOut,
-					Error:     e,
-					Body:      body,
+					Attack:         attack,
+					Seq:            seq,
+					Code:           code,
+					Timestamp:      time.Unix(int64(ts), 0),
+					Latency:        latency,
+					BytesIn:        bsIn,
+					BytesOut:       bsOut,
+					Error:          e,
+					Body:           body,
+					BodyHash:       hash,
+					Tags:           tags,
+					Extract:        extract,
+					TraceID:        trace,
+					RequestID:      id,
+					Retries:        retries,
+					TLSHandshake:   handshake,
+					Phases:         phases,
+					RemoteAddr:     remote,
+					ConnReused:     reused,
+					ResponseHeader: respHeader,
+					Truncated:      truncated,
+					Method:         method,
+					URL:            url,
+					Header:         reqHeader,
+					RequestBody:    reqBody,
+					Redirects:      redirects,
+					ErrorClass:     class,
+					BodyFile:       bodyFile,
+					Intended:       time.Unix(int64(intended), 0),
+					Accepted:       accepted,
+					ClockOffset:    offset,
+					Backoff:        backoff,
 				}
 
 				if err := enc(&want); err != nil {
@@ -100,3 +139,86 @@ func TestEncoding(t *testing.T) {
 	}
 
 }
+
+func TestCorrectedReport(t *testing.T) {
+	t.Parallel()
+
+	at := time.Unix(100, 0)
+	for _, tc := range []struct {
+		name    string
+		in      Result
+		at      time.Time
+		latency time.Duration
+	}{
+		{
+			name:    "on schedule",
+			in:      Result{Timestamp: at, Intended: at, Latency: time.Second},
+			at:      at,
+			latency: time.Second,
+		},
+		{
+			name:    "behind schedule",
+			in:      Result{Timestamp: at, Intended: at.Add(-3 * time.Second), Latency: time.Second},
+			at:      at.Add(-3 * time.Second),
+			latency: 4 * time.Second,
+		},
+		{
+			name:    "no intended time",
+			in:      Result{Timestamp: at, Latency: time.Second},
+			at:      at,
+			latency: time.Second,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tc.in.CorrectedLatency(); got != tc.latency {
+				t.Errorf("got corrected latency %s, want %s", got, tc.latency)
+			}
+
+			var rs Results
+			c := CorrectedReport{Report: &rs}
+			c.Add(&tc.in)
+			c.Close()
+
+			if len(rs) != 1 || !rs[0].Timestamp.Equal(tc.at) || rs[0].Latency != tc.latency {
+				t.Errorf("got %+v, want timestamp %s and latency %s", rs, tc.at, tc.latency)
+			}
+		})
+	}
+}
+
+func TestNDJSONDecoder_Schema(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		in   string
+		code uint16
+		err  string
+	}{
+		{`{"schema":1,"code":200}`, 200, ""},
+		{`{"code":200}`, 200, ""},
+		{`{"schema":1,"code":200,"added_later":true}`, 200, ""},
+		{`{"schema":2,"code":200}`, 0, "unsupported result schema 2, want up to 1"},
+	} {
+		var r Result
+		err := NewNDJSONDecoder(strings.NewReader(tc.in)).Decode(&r)
+		if got := fmt.Sprint(err); tc.err != "" && got != tc.err {
+			t.Errorf("%s: got error %v, want %q", tc.in, err, tc.err)
+		} else if tc.err == "" && err != nil {
+			t.Errorf("%s: got error %v", tc.in, err)
+		} else if tc.err == "" && r.Code != tc.code {
+			t.Errorf("%s: got code %d, want %d", tc.in, r.Code, tc.code)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := NewNDJSONEncoder(&buf).Encode(&Result{Code: 200}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := buf.String(); !strings.HasPrefix(got, `{"schema":1,`) || !strings.HasSuffix(got, "}\n") {
+		t.Errorf("got %q, want an object with the schema on a line", got)
+	}
+}
@@ -0,0 +1,56 @@
+package vegeta
+
+import (
+	"crypto/tls"
+	"sync/atomic"
+)
+
+// Kinds of TLS handshakes recorded in Results.
+const (
+	FullHandshake    = "full"
+	ResumedHandshake = "resumed"
+)
+
+// TLSSessionCache returns a functional option which makes an Attacker cache
+// the TLS sessions of up to size hosts, so that its new connections resume
+// them with abbreviated handshakes instead of full ones, as most real clients
+// do. Every fullEvery-th handshake is a full one regardless, so that targets
+// are tested under a mix of both. Zero fullEvery never forces full handshakes
+// and sizes below one disable the cache, the default, which makes every
+// handshake a full one.
+func TLSSessionCache(size, fullEvery int) func(*Attacker) {
+	return func(a *Attacker) {
+		a.tlsConfig(func(c *tls.Config) {
+			c.ClientSessionCache = nil
+			if size > 0 {
+				c.ClientSessionCache = &sessionCache{
+					ClientSessionCache: tls.NewLRUClientSessionCache(size),
+					every:              uint64(fullEvery),
+				}
+			}
+		})
+	}
+}
+
+// sessionCache is a tls.ClientSessionCache which misses every n-th lookup.
+type sessionCache struct {
+	tls.ClientSessionCache
+	every uint64
+	n     uint64
+}
+
+// Get implements the tls.ClientSessionCache interface.
+func (c *sessionCache) Get(key string) (*tls.ClientSessionState, bool) {
+	if c.every > 0 && atomic.AddUint64(&c.n, 1)%c.every == 0 {
+		return nil, false
+	}
+	return c.ClientSessionCache.Get(key)
+}
+
+// handshakeKind returns the kind of the TLS handshake with the given state.
+func handshakeKind(cs tls.ConnectionState) string {
+	if cs.DidResume {
+		return ResumedHandshake
+	}
+	return FullHandshake
+}
@@ -0,0 +1,61 @@
+package vegeta
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestTLSSessionCache(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer server.Close()
+
+	for _, tc := range []struct {
+		opt  func(*Attacker)
+		want []string
+	}{
+		{TLSSessionCache(0, 0), []string{"full", "full", "full", "full", "full", "full"}},
+		{TLSSessionCache(10, 0), []string{"full", "resumed", "resumed", "resumed", "resumed", "resumed"}},
+		{TLSSessionCache(10, 3), []string{"full", "resumed", "full", "resumed", "resumed", "full"}},
+	} {
+		atk := NewAttacker(KeepAlive(false), tc.opt)
+		tr := NewStaticTargeter(Target{Method: "GET", URL: server.URL})
+
+		var (
+			got []string
+			m   Metrics
+		)
+		for i := 0; i < len(tc.want); i++ {
+			res := atk.hit(tr, "", 0)
+			if res.Error != "" {
+				t.Fatal(res.Error)
+			}
+			got = append(got, res.TLSHandshake)
+			m.Add(res)
+		}
+		m.Close()
+
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("got handshakes %v, want %v", got, tc.want)
+		}
+
+		var resumed uint64
+		for _, kind := range tc.want {
+			if kind == ResumedHandshake {
+				resumed++
+			}
+		}
+
+		want := TLSHandshakeMetrics{
+			Full:            uint64(len(tc.want)) - resumed,
+			Resumed:         resumed,
+			ResumptionRatio: float64(resumed) / float64(len(tc.want)),
+		}
+		if m.TLSHandshakes != want {
+			t.Errorf("got metrics %+v, want %+v", m.TLSHandshakes, want)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package vegeta
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A RetryPolicy retries the failed hits of a Target. Retries are reported in
+// the Result of the hit rather than as hits of their own.
+type RetryPolicy struct {
+	// Attempts is the maximum number of retries of a hit.
+	Attempts int
+	// Backoff is the time waited for before the first retry, doubled before
+	// each of the next ones.
+	Backoff time.Duration
+	// On lists the failures which are retried: error for requests which got
+	// no response, timeout for requests which timed out, 4xx or 5xx for
+	// responses of a status class and status codes, e.g. 429. It defaults
+	// to error and 5xx.
+	On []string
+}
+
+// defaultRetryOn are the failures retried by default.
+var defaultRetryOn = []string{"error", "5xx"}
+
+// UnmarshalJSON implements the json.Unmarshaler interface, decoding
+// {"attempts": 3, "backoff": "100ms", "on": ["error", "429"]}.
+func (p *RetryPolicy) UnmarshalJSON(data []byte) error {
+	var jp struct {
+		Attempts int      `json:"attempts"`
+		Backoff  string   `json:"backoff"`
+		On       []string `json:"on"`
+	}
+
+	if err := json.Unmarshal(data, &jp); err != nil {
+		return err
+	}
+
+	rp := RetryPolicy{Attempts: jp.Attempts, On: jp.On}
+	if jp.Backoff != "" {
+		var err error
+		if rp.Backoff, err = time.ParseDuration(jp.Backoff); err != nil {
+			return fmt.Errorf("bad retry backoff: %s", err)
+		}
+	}
+
+	for _, on := range rp.On {
+		switch on {
+		case "error", "timeout", "1xx", "2xx", "3xx", "4xx", "5xx":
+		default:
+			if code, err := strconv.Atoi(on); err != nil || code < 100 || code > 999 {
+				return fmt.Errorf("bad retry condition: %q", on)
+			}
+		}
+	}
+
+	*p = rp
+	return nil
+}
+
+// retries returns true if the hit with the given Result is to be retried.
+func (p *RetryPolicy) retries(res *Result) bool {
+	if p == nil || int(res.Retries) >= p.Attempts || res.Error == "" {
+		return false
+	}
+
+	on := p.On
+	if len(on) == 0 {
+		on = defaultRetryOn
+	}
+
+	for _, c := range on {
+		switch {
+		case c == "error":
+			if res.Code == 0 {
+				return true
+			}
+		case c == "timeout":
+			for _, e := range []error{ErrDialTimeout, ErrTLSHandshakeTimeout, ErrResponseHeaderTimeout, ErrBodyTimeout, ErrRequestTimeout} {
+				if strings.HasPrefix(res.Error, e.Error()) {
+					return true
+				}
+			}
+		case strings.HasSuffix(c, "xx"):
+			if res.Code/100 == uint16(c[0]-'0') {
+				return true
+			}
+		default:
+			if strconv.Itoa(int(res.Code)) == c {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// wait waits for the backoff before the given retry, and returns false if the
+// given channel is closed in the meantime.
+func (p *RetryPolicy) wait(retry int, stop <-chan struct{}) bool {
+	d := p.Backoff << uint(retry)
+	if d <= 0 {
+		return true
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return true
+	case <-stop:
+		return false
+	}
+}
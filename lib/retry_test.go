@@ -0,0 +1,95 @@
+package vegeta
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	t.Parallel()
+
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Every third hit succeeds.
+		if atomic.AddInt32(&hits, 1)%3 != 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	atk := NewAttacker()
+	for _, tc := range []struct {
+		retry   *RetryPolicy
+		code    uint16
+		retries uint16
+	}{
+		{nil, 503, 0},
+		{&RetryPolicy{Attempts: 5, Backoff: time.Millisecond}, 200, 2},
+		{&RetryPolicy{Attempts: 1}, 503, 1},
+		{&RetryPolicy{Attempts: 5, On: []string{"429", "error"}}, 503, 0},
+		{&RetryPolicy{Attempts: 2, On: []string{"503"}}, 200, 2},
+	} {
+		atomic.StoreInt32(&hits, 0)
+		tr := NewStaticTargeter(Target{Method: "GET", URL: server.URL, Retry: tc.retry})
+		res := atk.hit(tr, "", 0)
+		if res.Code != tc.code || res.Retries != tc.retries {
+			t.Errorf("%+v: got code %d after %d retries, want %d after %d", tc.retry, res.Code, res.Retries, tc.code, tc.retries)
+		}
+		if n := atomic.LoadInt32(&hits); int(n) != int(tc.retries)+1 {
+			t.Errorf("%+v: got %d hits, want %d", tc.retry, n, tc.retries+1)
+		}
+	}
+}
+
+func TestTargetTimeout(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	atk := NewAttacker(TotalTimeout(time.Second))
+
+	tr := NewStaticTargeter(Target{Method: "GET", URL: server.URL, Timeout: 50 * time.Millisecond})
+	if res := atk.hit(tr, "", 0); !strings.HasPrefix(res.Error, ErrRequestTimeout.Error()+": ") {
+		t.Errorf("got error %q, want prefix %q", res.Error, ErrRequestTimeout)
+	}
+
+	tr = NewStaticTargeter(Target{Method: "GET", URL: server.URL})
+	if res := atk.hit(tr, "", 0); res.Error != "" {
+		t.Errorf("got error %q, want none", res.Error)
+	}
+}
+
+func TestJSONTargetRetry(t *testing.T) {
+	t.Parallel()
+
+	src := strings.NewReader(`{"method": "GET", "url": "http://goku", "timeout": "2s", "retry": {"attempts": 3, "backoff": "100ms", "on": ["timeout", "5xx", "429"]}}`)
+	tr := NewLazyJSONTargeter(src, nil, nil)
+
+	var tgt Target
+	if err := tr(&tgt); err != nil {
+		t.Fatal(err)
+	}
+
+	want := &RetryPolicy{Attempts: 3, Backoff: 100 * time.Millisecond, On: []string{"timeout", "5xx", "429"}}
+	if tgt.Timeout != 2*time.Second || !reflect.DeepEqual(tgt.Retry, want) {
+		t.Errorf("got timeout %s and retry %+v, want 2s and %+v", tgt.Timeout, tgt.Retry, want)
+	}
+
+	for _, bad := range []string{
+		`{"method": "GET", "url": "http://goku", "timeout": "soon"}`,
+		`{"method": "GET", "url": "http://goku", "retry": {"on": ["never"]}}`,
+		`{"method": "GET", "url": "http://goku", "retry": {"backoff": "1 second"}}`,
+	} {
+		if err := NewLazyJSONTargeter(strings.NewReader(bad), nil, nil)(&tgt); err == nil {
+			t.Errorf("%s: got no error", bad)
+		}
+	}
+}
@@ -0,0 +1,300 @@
+package vegeta
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/cookiejar"
+	"sort"
+	"time"
+)
+
+// A Scenario is an ordered sequence of Steps, e.g. login, browse and
+// checkout, which every virtual user of a scenario attack goes through.
+// Scenarios are built by NewScenario.
+type Scenario struct {
+	Steps []Step
+	// Vars are the values every virtual user starts with, e.g. those
+	// returned by RunScenario for a setup Scenario.
+	Vars  map[string]interface{}
+	tmpls templateCache
+}
+
+// A Step is a request of a Scenario. The URL, header values and body of its
+// Target are executed as text/template templates with the values extracted
+// from the responses of the previous Steps of the same virtual user, e.g.
+// http://goku/orders/{{.order_id}}. See NewTemplateTargeter for the built-in
+// template functions.
+type Step struct {
+	Name    string
+	Target  Target
+	Extract []*Extractor
+}
+
+// NewScenario decodes a Scenario from the given io.Reader, whose Steps are
+// JSON objects, one per line by convention, in the format of
+// NewLazyJSONTargeter with the following additional fields:
+//
+//	{
+//	  "name": "login",
+//	  "method": "POST",
+//	  "url": "http://goku/login",
+//	  "body": "{\"user\": \"kakarot\"}",
+//	  "extract": {"token": "json:auth.token", "cart": "header:Location"}
+//	}
+//
+// See NewExtractor for the format of extractors.
+//
+// body will be set as the Steps' body if no body is provided.
+// hdr will be merged with the each Step's headers.
+func NewScenario(src io.Reader, body []byte, hdr http.Header) (*Scenario, error) {
+	sc := &Scenario{tmpls: templateCache{funcs: templateFuncs}}
+	dec := json.NewDecoder(src)
+	for {
+		var js jsonStep
+		if err := dec.Decode(&js); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("bad step: %s", err)
+		}
+
+		step := Step{Name: js.Name}
+		if step.Name == "" {
+			step.Name = fmt.Sprintf("%d", len(sc.Steps)+1)
+		}
+
+		if err := js.target(&step.Target, body, hdr); err != nil {
+			return nil, fmt.Errorf("bad step %s: %s", step.Name, err)
+		}
+
+		names := make([]string, 0, len(js.Extract))
+		for name := range js.Extract {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			e, err := NewExtractor(name, js.Extract[name])
+			if err != nil {
+				return nil, fmt.Errorf("bad step %s: %s", step.Name, err)
+			}
+			step.Extract = append(step.Extract, e)
+		}
+
+		sc.Steps = append(sc.Steps, step)
+	}
+
+	if len(sc.Steps) == 0 {
+		return nil, ErrNoTargets
+	}
+
+	return sc, nil
+}
+
+// jsonStep is the JSON representation of a Step.
+type jsonStep struct {
+	jsonTarget
+	Name    string            `json:"name"`
+	Extract map[string]string `json:"extract"`
+}
+
+// Cookies returns a functional option which makes every virtual user of an
+// Attacker's scenario attacks keep its own cookie jar, e.g. to hold its
+// session, instead of ignoring cookies.
+func Cookies(enabled bool) func(*Attacker) {
+	return func(a *Attacker) { a.cookies = enabled }
+}
+
+// StickyConnections returns a functional option which makes every virtual
+// user of an Attacker's scenario attacks send its requests over its own
+// connections, closed once it's done, instead of the shared ones. They're
+// opened by a clone of the Attacker's *http.Transport, bypassing the
+// RoundTrippers set with RoundTripper which wrap it, and the hits of virtual
+// users fail if there's none.
+func StickyConnections(enabled bool) func(*Attacker) {
+	return func(a *Attacker) { a.sticky = enabled }
+}
+
+// ThinkTime returns a functional option which makes every virtual user of an
+// Attacker's scenario attacks pause between its Steps, for a random time
+// picked uniformly between min and max, as real users do. Fixed pauses have
+// equal min and max.
+func ThinkTime(min, max time.Duration) func(*Attacker) {
+	return func(a *Attacker) { a.think = [2]time.Duration{min, max} }
+}
+
+// AttackScenario starts virtual users at the times defined by the given
+// Pacer, each of which hits the Steps of the given Scenario in order, one
+// after the other, until one of them fails. The Results of each virtual
+// user have its sequence number and the name of their Step in the "step"
+// tag. Values can't be extracted from bodies when they are only hashed.
+func (a *Attacker) AttackScenario(sc *Scenario, p Pacer, name string) <-chan *Result {
+	results := make(chan *Result)
+	emit := func(r *Result) { results <- r }
+	users := Pace(p, a.workers, a.stopch, func(seq uint64) *Result {
+		return a.run(sc, name, seq, sc.vars(), emit)
+	})
+
+	go func() {
+		defer close(results)
+		defer a.closeTLSTransports(nil)
+		for res := range users {
+			results <- res
+		}
+	}()
+
+	return results
+}
+
+// RunScenario runs a single virtual user through the given Scenario, e.g. to
+// set up fixtures before an attack or tear them down after it, and returns
+// the Scenario's Vars along with the values it extracted. Its Results aren't
+// returned, so they're excluded from the Metrics of the attack. It returns
+// an error at the first failed Step.
+func (a *Attacker) RunScenario(sc *Scenario) (map[string]interface{}, error) {
+	vars, done := sc.vars(), 0
+	if res := a.run(sc, "", 0, vars, func(*Result) { done++ }); res.Error != "" {
+		return nil, fmt.Errorf("step %s: %s", sc.Steps[done].Name, res.Error)
+	}
+	return vars, nil
+}
+
+// vars returns a copy of the Scenario's Vars.
+func (sc *Scenario) vars() map[string]interface{} {
+	vars := make(map[string]interface{}, len(sc.Vars))
+	for k, v := range sc.Vars {
+		vars[k] = v
+	}
+	return vars
+}
+
+// run runs a virtual user with the given values through the given Scenario,
+// emitting the Results of all its Steps but the last one, which it returns.
+func (a *Attacker) run(sc *Scenario, name string, seq uint64, vars map[string]interface{}, emit func(*Result)) *Result {
+	vu := virtualUser{script: a.script, tmpls: &sc.tmpls, vars: vars}
+
+	client := &a.client
+	if a.cookies || a.sticky {
+		c := a.client
+		client = &c
+	}
+
+	if a.cookies {
+		// Without a public suffix list, cookies can't be set for domains
+		// above the host, which virtual users don't need.
+		client.Jar, _ = cookiejar.New(nil)
+	}
+
+	if a.sticky {
+		tr, err := a.ownTransport()
+		if err != nil {
+			return &Result{Attack: name, Seq: seq, ClockOffset: a.offset, Error: "sticky connections: " + err.Error()}
+		}
+		defer tr.CloseIdleConnections()
+		defer a.closeTLSTransports(tr)
+		client.Transport = tr
+	}
+
+	var res *Result
+	for i := range sc.Steps {
+		if res != nil {
+			if !a.pause() {
+				break
+			}
+			emit(res)
+		}
+
+		vu.step = &sc.Steps[i]
+		res = a.do(vu.next, name, seq, client, &vu)
+		if res.Error != "" {
+			break
+		}
+
+		select {
+		case <-a.stopch:
+			return res
+		default:
+		}
+	}
+
+	return res
+}
+
+// pause pauses a virtual user for its think time, returning false if the
+// attack was stopped in the meantime.
+func (a *Attacker) pause() bool {
+	think := a.think[0]
+	if spread := a.think[1] - a.think[0]; spread > 0 {
+		think += time.Duration(rand.Int63n(int64(spread) + 1))
+	}
+
+	if think <= 0 {
+		return true
+	}
+
+	timer := time.NewTimer(think)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-a.stopch:
+		return false
+	}
+}
+
+// virtualUser is the Script of a virtual user's hits, which executes its
+// Steps' templates with the values extracted so far.
+type virtualUser struct {
+	script Script
+	tmpls  *templateCache
+	step   *Step
+	vars   map[string]interface{}
+}
+
+// next is the Targeter of the virtual user's current Step.
+func (vu *virtualUser) next(tgt *Target) error {
+	*tgt = vu.step.Target
+	tgt.Tags = make(map[string]string, len(vu.step.Target.Tags)+1)
+	for k, v := range vu.step.Target.Tags {
+		tgt.Tags[k] = v
+	}
+	tgt.Tags["step"] = vu.step.Name
+	return nil
+}
+
+// Before implements the Script interface.
+func (vu *virtualUser) Before(tgt *Target) error {
+	if err := vu.tmpls.executeTarget(tgt, vu.vars); err != nil {
+		return err
+	}
+	if vu.script != nil {
+		return vu.script.Before(tgt)
+	}
+	return nil
+}
+
+// After implements the Script interface.
+func (vu *virtualUser) After(res *Result, r *http.Response) error {
+	if vu.script != nil {
+		if err := vu.script.After(res, r); err != nil {
+			return err
+		}
+	}
+
+	if res.Error != "" {
+		return nil
+	}
+
+	for _, e := range vu.step.Extract {
+		v, err := e.Extract(r, res.Body)
+		if err != nil {
+			return fmt.Errorf("extracting %s: %s", e.Name, err)
+		}
+		vu.vars[e.Name] = v
+	}
+
+	return nil
+}
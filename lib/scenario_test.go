@@ -0,0 +1,202 @@
+package vegeta
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestScenario(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			w.Write([]byte(`{"auth": {"token": "kakarot"}}`))
+		case "/cart":
+			if r.Header.Get("Authorization") != "Bearer kakarot" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("Location", "/carts/42")
+			w.WriteHeader(http.StatusCreated)
+		case "/carts/42/checkout":
+			w.Write([]byte("order=1337"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	steps := strings.Join([]string{
+		`{"name": "login", "method": "POST", "url": "` + server.URL + `/login", "extract": {"token": "json:auth.token"}}`,
+		`{"name": "cart", "method": "POST", "url": "` + server.URL + `/cart", "headers": {"Authorization": "Bearer {{.token}}"}, "extract": {"cart": "header:Location"}}`,
+		`{"name": "checkout", "method": "POST", "url": "` + server.URL + `{{.cart}}/checkout", "extract": {"order": "regex:order=(\\d+)"}}`,
+		`{"name": "order", "method": "GET", "url": "` + server.URL + `/orders/{{.order}}"}`,
+	}, "\n")
+
+	sc, err := NewScenario(strings.NewReader(steps), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	atk := NewAttacker()
+	var got []string
+	for res := range atk.AttackScenario(sc, ConstantPacer{Rate: 2, Duration: time.Second}, "") {
+		got = append(got, res.Tags["step"]+" "+res.URL+" "+res.Error)
+	}
+
+	// Each virtual user stops at the order step, which isn't found.
+	want := []string{
+		"login " + server.URL + "/login ",
+		"cart " + server.URL + "/cart ",
+		"checkout " + server.URL + "/carts/42/checkout ",
+		"order " + server.URL + "/orders/1337 404 Not Found",
+	}
+	if !reflect.DeepEqual(got, append(want, want...)) {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+}
+
+func TestScenarioSessions(t *testing.T) {
+	t.Parallel()
+
+	var conns int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			if _, err := r.Cookie("session"); err == nil {
+				w.WriteHeader(http.StatusConflict)
+				return
+			}
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "kakarot"})
+		case "/me":
+			if c, err := r.Cookie("session"); err != nil || c.Value != "kakarot" {
+				w.WriteHeader(http.StatusUnauthorized)
+			}
+		}
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	steps := `{"method": "POST", "url": "` + server.URL + `/login"}` + "\n" +
+		`{"method": "GET", "url": "` + server.URL + `/me"}`
+
+	for _, tc := range []struct {
+		sticky bool
+		conns  int32
+	}{
+		{false, 1},
+		{true, 2},
+	} {
+		sc, err := NewScenario(strings.NewReader(steps), nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		atomic.StoreInt32(&conns, 0)
+		atk := NewAttacker(Cookies(true), StickyConnections(tc.sticky), Workers(1))
+		for res := range atk.AttackScenario(sc, ConstantPacer{Rate: 2, Duration: time.Second}, "") {
+			if res.Error != "" {
+				t.Errorf("sticky %v: %s: got error %q", tc.sticky, res.URL, res.Error)
+			}
+		}
+
+		if got := atomic.LoadInt32(&conns); got != tc.conns {
+			t.Errorf("sticky %v: got %d connections, want %d", tc.sticky, got, tc.conns)
+		}
+	}
+
+	// Sticky connections need an *http.Transport to clone.
+	sc, err := NewScenario(strings.NewReader(steps), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	atk := NewAttacker(RoundTripper(struct{ http.RoundTripper }{http.DefaultTransport}), StickyConnections(true))
+	if _, err = atk.RunScenario(sc); err == nil || !strings.Contains(err.Error(), errNoTransport.Error()) {
+		t.Errorf("got error %v, want %v", err, errNoTransport)
+	}
+}
+
+func TestScenarioThinkTime(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer server.Close()
+
+	step := `{"method": "GET", "url": "` + server.URL + `"}` + "\n"
+	sc, err := NewScenario(strings.NewReader(step+step+step), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	atk := NewAttacker(ThinkTime(100*time.Millisecond, 200*time.Millisecond))
+	var rs Results
+	for res := range atk.AttackScenario(sc, ConstantPacer{Rate: 1, Duration: time.Second}, "") {
+		rs = append(rs, *res)
+	}
+
+	if len(rs) != 3 {
+		t.Fatalf("got %d results, want 3", len(rs))
+	}
+
+	for i := 1; i < len(rs); i++ {
+		if think := rs[i].Timestamp.Sub(rs[i-1].End()); think < 100*time.Millisecond || think > 300*time.Millisecond {
+			t.Errorf("step %d: got think time %s, want between 100ms and 200ms", i+1, think)
+		}
+	}
+}
+
+func TestRunScenario(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/fixtures":
+			w.Write([]byte(`{"id": "f1"}`))
+		case "/fixtures/f1":
+			if r.Header.Get("X-Run") != "r1" {
+				w.WriteHeader(http.StatusBadRequest)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	atk := NewAttacker()
+
+	steps := `{"name": "create", "method": "POST", "url": "` + server.URL + `/fixtures", "extract": {"id": "json:id"}}` + "\n" +
+		`{"name": "check", "method": "GET", "url": "` + server.URL + `/fixtures/{{.id}}", "headers": {"X-Run": "{{.run}}"}}`
+
+	sc, err := NewScenario(strings.NewReader(steps), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sc.Vars = map[string]interface{}{"run": "r1"}
+
+	vars, err := atk.RunScenario(sc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := map[string]interface{}{"run": "r1", "id": "f1"}; !reflect.DeepEqual(vars, want) {
+		t.Errorf("got vars %v, want %v", vars, want)
+	}
+
+	sc.Vars = map[string]interface{}{"run": "r2"}
+	if _, err = atk.RunScenario(sc); err == nil || !strings.HasPrefix(err.Error(), "step check: 400") {
+		t.Errorf("got error %v, want step check failure", err)
+	}
+}
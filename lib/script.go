@@ -0,0 +1,23 @@
+package vegeta
+
+import "net/http"
+
+// A Script runs custom logic around every hit of an Attacker, such as
+// computing auth headers, choosing bodies conditionally or deciding whether
+// a response passes. Scripts are called concurrently by an Attacker's
+// workers.
+type Script interface {
+	// Before is called with every Target before it's hit and may modify it.
+	// An error fails the hit without sending its request.
+	Before(*Target) error
+	// After is called with the Result of every answered hit and its
+	// response, whose body was already read. An error is recorded as the
+	// Result's error, whatever the response's status code.
+	After(*Result, *http.Response) error
+}
+
+// Scripted returns a functional option which makes an Attacker run the
+// given Script around each of its hits.
+func Scripted(s Script) func(*Attacker) {
+	return func(a *Attacker) { a.script = s }
+}
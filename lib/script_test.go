@@ -0,0 +1,60 @@
+package vegeta
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type testScript struct {
+	before func(*Target) error
+	after  func(*Result, *http.Response) error
+}
+
+func (s testScript) Before(t *Target) error                   { return s.before(t) }
+func (s testScript) After(r *Result, rs *http.Response) error { return s.after(r, rs) }
+
+func TestScripted(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Shard", r.Header.Get("Authorization"))
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	atk := NewAttacker(RequestHeaders(true), Scripted(testScript{
+		before: func(tgt *Target) error {
+			if tgt.URL == "" {
+				return errors.New("no url")
+			}
+			tgt.Header = http.Header{"Authorization": {"Bearer goku"}}
+			return nil
+		},
+		after: func(r *Result, rs *http.Response) error {
+			if !bytes.Equal(r.Body, []byte("ok")) || rs.Header.Get("X-Shard") != "Bearer goku" {
+				return errors.New("unexpected response")
+			}
+			if r.Seq == 1 {
+				return errors.New("failed by script")
+			}
+			return nil
+		},
+	}))
+
+	tr := NewStaticTargeter(Target{Method: "GET", URL: server.URL})
+	if res := atk.hit(tr, "", 0); res.Error != "" || res.Header.Get("Authorization") != "Bearer goku" {
+		t.Errorf("got error %q and headers %v, want no error and script headers", res.Error, res.Header)
+	}
+
+	if res := atk.hit(tr, "", 1); res.Error != "failed by script" {
+		t.Errorf("got error %q, want script failure", res.Error)
+	}
+
+	tr = NewStaticTargeter(Target{Method: "GET"})
+	if res := atk.hit(tr, "", 2); res.Error != "no url" || res.Code != 0 {
+		t.Errorf("got error %q and code %d, want failure before hit", res.Error, res.Code)
+	}
+}
@@ -0,0 +1,275 @@
+package vegeta
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ApdexMetrics holds the Apdex score of the hits, as defined at
+// https://www.apdex.org, which rates their latencies from 0, all frustrated,
+// to 1, all satisfied, against a target latency T.
+type ApdexMetrics struct {
+	// T is the target latency: hits up to T are satisfied, up to 4T
+	// tolerating and the others frustrated, as are all errored hits.
+	T time.Duration `json:"t"`
+	// Satisfied is the number of satisfied hits.
+	Satisfied uint64 `json:"satisfied"`
+	// Tolerating is the number of tolerating hits.
+	Tolerating uint64 `json:"tolerating"`
+	// Frustrated is the number of frustrated hits.
+	Frustrated uint64 `json:"frustrated"`
+	// Score is the Apdex score, the satisfied hits plus half the
+	// tolerating ones over all of them.
+	Score float64 `json:"score"`
+}
+
+// add rates the given hit.
+func (a *ApdexMetrics) add(r *Result) {
+	switch {
+	case r.Error != "" || r.Latency > 4*a.T:
+		a.Frustrated++
+	case r.Latency > a.T:
+		a.Tolerating++
+	default:
+		a.Satisfied++
+	}
+}
+
+// close computes the Score.
+func (a *ApdexMetrics) close() {
+	if n := a.Satisfied + a.Tolerating + a.Frustrated; n > 0 {
+		a.Score = (float64(a.Satisfied) + float64(a.Tolerating)/2) / float64(n)
+	}
+}
+
+// An Objective is a service level objective of the Metrics of an attack,
+// such as "p99<500ms" or "success>99.9%", as parsed by ParseObjective.
+type Objective struct {
+	// Metric is the name of the metric: mean, max or stddev of the
+	// latencies, a latency percentile prefixed by p, e.g. p99.9, success,
+	// rate, requests, errors or apdex.
+	Metric string `json:"metric"`
+	// Op is the comparison the metric must satisfy: <, <=, > or >=.
+	Op string `json:"op"`
+	// Threshold is the value the metric is compared with, in nanoseconds
+	// for latencies and as a ratio for success.
+	Threshold float64 `json:"threshold"`
+}
+
+// objectiveRe matches an Objective's metric, comparison and threshold.
+var objectiveRe = regexp.MustCompile(`^\s*(p\d+(?:\.\d+)?|[a-z]+)\s*(<=|>=|<|>)\s*(\S+)\s*$`)
+
+// ParseObjective parses an Objective of the form <metric><op><threshold>,
+// e.g. "p99<500ms", "success>=99.9%", "rate>100" or "apdex>0.9".
+func ParseObjective(s string) (Objective, error) {
+	m := objectiveRe.FindStringSubmatch(s)
+	if m == nil {
+		return Objective{}, fmt.Errorf("bad objective: %q", s)
+	}
+
+	o := Objective{Metric: m[1], Op: m[2]}
+
+	var err error
+	switch {
+	case o.latency():
+		var d time.Duration
+		d, err = time.ParseDuration(m[3])
+		o.Threshold = float64(d)
+	case o.Metric == "success":
+		if strings.HasSuffix(m[3], "%") {
+			o.Threshold, err = strconv.ParseFloat(strings.TrimSuffix(m[3], "%"), 64)
+			o.Threshold /= 100
+		} else {
+			o.Threshold, err = strconv.ParseFloat(m[3], 64)
+		}
+	case o.Metric == "rate", o.Metric == "requests", o.Metric == "errors", o.Metric == "apdex":
+		o.Threshold, err = strconv.ParseFloat(m[3], 64)
+	default:
+		return Objective{}, fmt.Errorf("bad objective metric: %q", o.Metric)
+	}
+
+	if err != nil {
+		return Objective{}, fmt.Errorf("bad objective threshold: %q", s)
+	}
+
+	return o, nil
+}
+
+// ParseObjectives parses comma separated Objectives.
+func ParseObjectives(s string) ([]Objective, error) {
+	var objectives []Objective
+	for _, f := range strings.Split(s, ",") {
+		o, err := ParseObjective(f)
+		if err != nil {
+			return nil, err
+		}
+		objectives = append(objectives, o)
+	}
+	return objectives, nil
+}
+
+// String returns the Objective in the form parsed by ParseObjective.
+func (o Objective) String() string {
+	return o.Metric + o.Op + o.format(o.Threshold)
+}
+
+// latency returns true if the Objective's metric is a latency.
+func (o Objective) latency() bool {
+	switch o.Metric {
+	case "mean", "max", "stddev":
+		return true
+	}
+	_, err := o.percentile()
+	return err == nil
+}
+
+// percentile returns the latency percentile of the Objective's metric.
+func (o Objective) percentile() (float64, error) {
+	if !strings.HasPrefix(o.Metric, "p") {
+		return 0, fmt.Errorf("not a percentile: %q", o.Metric)
+	}
+
+	p, err := strconv.ParseFloat(o.Metric[1:], 64)
+	if err == nil && (p <= 0 || p > 100) {
+		err = fmt.Errorf("bad percentile: %q", o.Metric)
+	}
+
+	return p, err
+}
+
+// format formats the given value of the Objective's metric.
+func (o Objective) format(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "n/a"
+	case o.latency():
+		return time.Duration(v).String()
+	case o.Metric == "success":
+		return FormatPercentile(v*100) + "%"
+	case o.Metric == "rate":
+		return strconv.FormatFloat(v, 'f', 2, 64)
+	default:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+}
+
+// value returns the value of the Objective's metric in the given closed
+// Metrics, or NaN when they don't have it.
+func (o Objective) value(m *Metrics) float64 {
+	switch o.Metric {
+	case "mean":
+		return float64(m.Latencies.Mean)
+	case "max":
+		return float64(m.Latencies.Max)
+	case "stddev":
+		return float64(m.Latencies.StdDev)
+	case "success":
+		return m.Success
+	case "rate":
+		return m.Rate
+	case "requests":
+		return float64(m.Requests)
+	case "errors":
+		var n uint64
+		for _, count := range m.ErrorClasses {
+			n += count
+		}
+		return float64(n)
+	case "apdex":
+		if m.Apdex == nil {
+			return math.NaN()
+		}
+		return m.Apdex.Score
+	}
+
+	p, err := o.percentile()
+	switch {
+	case err != nil:
+		return math.NaN()
+	case m.latencies != nil:
+		return float64(m.Latencies.quantile(m.latencies, p/100))
+	}
+
+	if d, ok := m.Latencies.Percentiles[FormatPercentile(p)]; ok {
+		return float64(d)
+	}
+
+	return math.NaN()
+}
+
+// Evaluate evaluates the Objective against the given closed Metrics. It's
+// never met by metrics the Metrics don't have, e.g. apdex without ApdexT.
+func (o Objective) Evaluate(m *Metrics) ObjectiveResult {
+	v := o.value(m)
+
+	var met bool
+	switch o.Op {
+	case "<":
+		met = v < o.Threshold
+	case "<=":
+		met = v <= o.Threshold
+	case ">":
+		met = v > o.Threshold
+	case ">=":
+		met = v >= o.Threshold
+	}
+
+	return ObjectiveResult{Objective: o.String(), Value: o.format(v), Met: met}
+}
+
+// An ObjectiveResult is the result of the evaluation of an Objective.
+type ObjectiveResult struct {
+	// Objective is the evaluated Objective.
+	Objective string `json:"objective"`
+	// Value is the formatted value of its metric.
+	Value string `json:"value"`
+	// Met is true if the Objective was met.
+	Met bool `json:"met"`
+}
+
+// violated evaluates the Objective as a failure condition against the given
+// closed Metrics, returning true if it holds or can't be checked.
+func (o Objective) violated(m *Metrics) (ObjectiveResult, bool) {
+	res := o.Evaluate(m)
+	return res, res.Met || math.IsNaN(o.value(m))
+}
+
+// A ThresholdError is returned by Check when failure conditions hold for
+// Metrics.
+type ThresholdError struct {
+	// Violations are the failure conditions which held, with the values
+	// of their metrics.
+	Violations []ObjectiveResult
+}
+
+// Error implements the error interface.
+func (e *ThresholdError) Error() string {
+	vs := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		vs[i] = v.Objective + " (" + v.Value + ")"
+	}
+	return "thresholds violated: " + strings.Join(vs, ", ")
+}
+
+// Check returns a *ThresholdError if any of the given failure conditions,
+// e.g. p99>500ms or success<99.9%, holds for the given closed Metrics, so
+// that load tests can gate deployments. Conditions on metrics the Metrics
+// don't have can't be checked and count as violated.
+func Check(m *Metrics, conditions ...Objective) error {
+	var violations []ObjectiveResult
+	for _, c := range conditions {
+		if res, violated := c.violated(m); violated {
+			violations = append(violations, res)
+		}
+	}
+
+	if len(violations) > 0 {
+		return &ThresholdError{Violations: violations}
+	}
+
+	return nil
+}
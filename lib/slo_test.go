@@ -0,0 +1,138 @@
+package vegeta
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseObjective(t *testing.T) {
+	t.Parallel()
+
+	for in, want := range map[string]Objective{
+		"p99<500ms":      {"p99", "<", float64(500 * time.Millisecond)},
+		" p99.9 <= 1s ":  {"p99.9", "<=", float64(time.Second)},
+		"mean<100ms":     {"mean", "<", float64(100 * time.Millisecond)},
+		"success>99.9%":  {"success", ">", 0.999},
+		"success>=0.95":  {"success", ">=", 0.95},
+		"rate>100":       {"rate", ">", 100},
+		"apdex>0.9":      {"apdex", ">", 0.9},
+		"errors<=10":     {"errors", "<=", 10},
+		"requests>=1000": {"requests", ">=", 1000},
+		"p99=500ms":      {},
+		"p0<1s":          {},
+		"p101<1s":        {},
+		"latency<1s":     {},
+		"p99<500":        {},
+		"success>lots":   {},
+		"":               {},
+	} {
+		got, err := ParseObjective(in)
+		if want == (Objective{}) {
+			if err == nil {
+				t.Errorf("%q: got %+v, want error", in, got)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%q: %v", in, err)
+		} else if got.Metric != want.Metric || got.Op != want.Op || got.Threshold-want.Threshold > 1e-12 || want.Threshold-got.Threshold > 1e-12 {
+			t.Errorf("%q: got %+v, want %+v", in, got, want)
+		}
+	}
+}
+
+func TestMetrics_Objectives(t *testing.T) {
+	t.Parallel()
+
+	objectives, err := ParseObjectives("p99<500ms,p50<=100ms,success>99%,apdex>0.5,errors<2,mean<1ms")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := Metrics{ApdexT: 100 * time.Millisecond, Objectives: objectives}
+	for i := 0; i < 100; i++ {
+		r := Result{Code: 200, Timestamp: time.Unix(int64(i), 0), Latency: 50 * time.Millisecond}
+		switch {
+		case i < 10:
+			r.Latency = 300 * time.Millisecond
+		case i == 99:
+			r.Code, r.Error, r.Latency = 500, "500 Internal Server Error", time.Second
+		}
+		m.Add(&r)
+	}
+	m.Close()
+
+	want := ApdexMetrics{T: 100 * time.Millisecond, Satisfied: 89, Tolerating: 10, Frustrated: 1, Score: 0.94}
+	if *m.Apdex != want {
+		t.Errorf("got apdex %+v, want %+v", *m.Apdex, want)
+	}
+
+	for i, want := range []ObjectiveResult{
+		{"p99<500ms", "301.989887ms", true}, // Estimated within 1%.
+		{"p50<=100ms", "50.069503ms", true},
+		{"success>99%", "99%", false},
+		{"apdex>0.5", "0.94", true},
+		{"errors<2", "1", true},
+		{"mean<1ms", "84.5ms", false},
+	} {
+		if i >= len(m.SLOs) {
+			t.Fatalf("got %d SLOs, want %d", len(m.SLOs), i+1)
+		}
+		if got := m.SLOs[i]; got != want {
+			t.Errorf("got %+v, want %+v", got, want)
+		}
+	}
+
+	// Objectives on metrics which weren't computed are missed.
+	o, err := ParseObjective("apdex>0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := o.Evaluate(&Metrics{}); got.Met || got.Value != "n/a" {
+		t.Errorf("got %+v of apdex without ApdexT", got)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	var m Metrics
+	for i := 0; i < 10; i++ {
+		m.Add(&Result{Code: 200, Timestamp: time.Unix(int64(i), 0), Latency: time.Duration(i+1) * 100 * time.Millisecond})
+	}
+	m.Close()
+
+	pass, err := ParseObjectives("max>2s,success<100%,errors>0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Check(&m, pass...); err != nil {
+		t.Errorf("got error %v, want none", err)
+	}
+
+	fail, err := ParseObjectives("mean>=500ms,max>2s,apdex<0.9")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = Check(&m, fail...)
+	terr, ok := err.(*ThresholdError)
+	if !ok {
+		t.Fatalf("got error %v, want a *ThresholdError", err)
+	}
+
+	want := []ObjectiveResult{
+		{"mean>=500ms", "550ms", true},
+		// Apdex isn't computed without ApdexT.
+		{"apdex<0.9", "n/a", false},
+	}
+	if len(terr.Violations) != len(want) || terr.Violations[0] != want[0] || terr.Violations[1] != want[1] {
+		t.Errorf("got violations %+v, want %+v", terr.Violations, want)
+	}
+
+	if got, want := err.Error(), "thresholds violated: mean>=500ms (550ms), apdex<0.9 (n/a)"; got != want {
+		t.Errorf("got error %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,78 @@
+package vegeta
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+)
+
+// errStopped is returned by writes interrupted by stopping an attack.
+var errStopped = errors.New("attack stopped")
+
+// SlowClient returns a functional option which makes an Attacker write the
+// headers and bodies of its requests at the given rate in bytes per second
+// on each connection, like slowloris attacks do, to test the timeouts and
+// resource exhaustion protections of targets. Every slow request holds its
+// connection for long, so they're spread across many connections.
+func SlowClient(rate int) func(*Attacker) {
+	return func(a *Attacker) {
+		tr := a.transport()
+		if rate <= 0 || tr == nil {
+			return
+		}
+
+		// Write in chunks ten times per second, or byte by byte at slower
+		// rates.
+		chunk := rate / 10
+		if chunk < 1 {
+			chunk = 1
+		}
+		delay := time.Duration(chunk) * time.Second / time.Duration(rate)
+
+		dial := tr.DialContext
+		tr.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			conn, err := dial(ctx, network, addr)
+			if err != nil {
+				return nil, err
+			}
+			return &slowConn{Conn: conn, chunk: chunk, delay: delay, stop: a.stopch}, nil
+		}
+	}
+}
+
+// slowConn is a net.Conn which writes chunks of bytes with a delay before
+// each of them.
+type slowConn struct {
+	net.Conn
+	chunk int
+	delay time.Duration
+	stop  <-chan struct{}
+}
+
+func (c *slowConn) Write(b []byte) (n int, err error) {
+	timer := time.NewTimer(c.delay)
+	defer timer.Stop()
+
+	for n < len(b) {
+		select {
+		case <-timer.C:
+		case <-c.stop:
+			return n, errStopped
+		}
+
+		end := n + c.chunk
+		if end > len(b) {
+			end = len(b)
+		}
+
+		written, err := c.Conn.Write(b[n:end])
+		if n += written; err != nil {
+			return n, err
+		}
+
+		timer.Reset(c.delay)
+	}
+
+	return n, nil
+}
@@ -0,0 +1,36 @@
+package vegeta
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSlowClient(t *testing.T) {
+	t.Parallel()
+
+	body := bytes.Repeat([]byte("x"), 400)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got, _ := ioutil.ReadAll(r.Body); !bytes.Equal(got, body) {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	// The request is written in chunks of 100 bytes every 100ms, which
+	// takes at least 500ms for its body and headers.
+	atk := NewAttacker(SlowClient(1000))
+	tr := NewStaticTargeter(Target{Method: "POST", URL: server.URL, Body: body})
+
+	began := time.Now()
+	if res := atk.hit(tr, "", 0); res.Error != "" {
+		t.Fatalf("got error %q", res.Error)
+	}
+
+	if took := time.Since(began); took < 500*time.Millisecond || took > 2*time.Second {
+		t.Errorf("slow request took %s, want between 500ms and 2s", took)
+	}
+}
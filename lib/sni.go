@@ -0,0 +1,76 @@
+package vegeta
+
+import (
+	"crypto/tls"
+	"net/http"
+)
+
+// ServerName returns a functional option which sets the TLS server name an
+// Attacker sends (SNI) and verifies certificates against, instead of the host
+// of the URLs of its targets, e.g. to test SNI routed edges and certificate
+// selection while dialing a fixed IP. It's set on the current TLS config, so
+// it must come after TLSConfig, and Targets can override it with their
+// ServerName.
+func ServerName(name string) func(*Attacker) {
+	return func(a *Attacker) {
+		a.tlsConfig(func(c *tls.Config) { c.ServerName = name })
+	}
+}
+
+// tlsKey keys the transports of the TLS settings of Targets by the transport
+// they're cloned from.
+type tlsKey struct {
+	base *http.Transport
+	name string
+	cert *tls.Certificate
+}
+
+// tlsClient returns a copy of the given client whose TLS connections are
+// established with the server name and client certificate of the given
+// Target, over a clone of its *http.Transport whose connections are thus its
+// own. Like ConnectionPerWorker, it bypasses the RoundTrippers wrapping the
+// client's transport, and fails if there's none.
+func (a *Attacker) tlsClient(base *http.Client, tgt *Target) (*http.Client, error) {
+	tr := unwrapTransport(base.Transport)
+	if tr == nil {
+		return nil, errNoTransport
+	}
+
+	key := tlsKey{tr, tgt.ServerName, tgt.Certificate}
+	cached, ok := a.tlsTransports.Load(key)
+	if !ok {
+		tr = tr.Clone()
+		if tr.TLSClientConfig == nil {
+			tr.TLSClientConfig = &tls.Config{}
+		}
+
+		if key.name != "" {
+			tr.TLSClientConfig.ServerName = key.name
+		}
+
+		if key.cert != nil {
+			tr.TLSClientConfig.Certificates = []tls.Certificate{*key.cert}
+			tr.TLSClientConfig.GetClientCertificate = nil
+		}
+
+		cached, _ = a.tlsTransports.LoadOrStore(key, tr)
+	}
+
+	c := *base
+	c.Transport = cached.(*http.Transport)
+
+	return &c, nil
+}
+
+// closeTLSTransports closes the idle connections of the transports cloned by
+// tlsClient from the given transport, or from any if it's nil, and forgets
+// them.
+func (a *Attacker) closeTLSTransports(base *http.Transport) {
+	a.tlsTransports.Range(func(k, v interface{}) bool {
+		if base == nil || k.(tlsKey).base == base {
+			a.tlsTransports.Delete(k)
+			v.(*http.Transport).CloseIdleConnections()
+		}
+		return true
+	})
+}
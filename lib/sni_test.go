@@ -0,0 +1,112 @@
+package vegeta
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestServerName(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu    sync.Mutex
+		names []string
+	)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	server.TLS = &tls.Config{GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+		mu.Lock()
+		names = append(names, hello.ServerName)
+		mu.Unlock()
+		return nil, nil
+	}}
+	server.StartTLS()
+	defer server.Close()
+
+	atk := NewAttacker(ServerName("goku.example.com"))
+	for _, tc := range []struct {
+		tgt  Target
+		want []string
+	}{
+		{Target{Method: "GET", URL: server.URL}, []string{"goku.example.com"}},
+		{Target{Method: "GET", URL: server.URL, ServerName: "vegeta.example.com"}, []string{"vegeta.example.com"}},
+		// Connections are reused by the Targets of the same name only.
+		{Target{Method: "GET", URL: server.URL}, nil},
+		{Target{Method: "GET", URL: server.URL, ServerName: "vegeta.example.com"}, nil},
+	} {
+		mu.Lock()
+		names = nil
+		mu.Unlock()
+
+		if res := atk.hit(NewStaticTargeter(tc.tgt), "", 0); res.Error != "" {
+			t.Fatal(res.Error)
+		}
+
+		mu.Lock()
+		if !reflect.DeepEqual(names, tc.want) {
+			t.Errorf("%+v: got server names %q, want %q", tc.tgt, names, tc.want)
+		}
+		mu.Unlock()
+	}
+}
+
+func TestServerName_Transports(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer server.Close()
+
+	tgt := Target{Method: "GET", URL: server.URL, ServerName: "vegeta.example.com"}
+	transports := func(a *Attacker) (n int) {
+		a.tlsTransports.Range(func(k, v interface{}) bool { n++; return true })
+		return n
+	}
+
+	// Transports are cloned from the one wrapped by RoundTrippers.
+	atk := NewAttacker(TLSConfig(&tls.Config{InsecureSkipVerify: true}))
+	atk = NewAttacker(RoundTripper(&countingTransport{rt: atk.client.Transport}))
+	for i := 0; i < 2; i++ {
+		if res := atk.hit(NewStaticTargeter(tgt), "", 0); res.Error != "" {
+			t.Fatal(res.Error)
+		}
+	}
+
+	if n := transports(atk); n != 1 {
+		t.Errorf("got %d transports, want 1", n)
+	}
+
+	// Those of virtual users with sticky connections are closed with them, and
+	// all are once the attack is over.
+	sc := &Scenario{Steps: []Step{{Name: "1", Target: tgt}}}
+	atk = NewAttacker(TLSConfig(&tls.Config{InsecureSkipVerify: true}), StickyConnections(true), Workers(1))
+	if _, err := atk.RunScenario(sc); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := transports(atk); n != 0 {
+		t.Errorf("got %d transports after a virtual user, want 0", n)
+	}
+
+	atk = NewAttacker(TLSConfig(&tls.Config{InsecureSkipVerify: true}))
+	for res := range atk.Attack(NewStaticTargeter(tgt), 5, time.Second, "") {
+		if res.Error != "" {
+			t.Fatal(res.Error)
+		}
+	}
+
+	if n := transports(atk); n != 0 {
+		t.Errorf("got %d transports after the attack, want 0", n)
+	}
+
+	// They need an *http.Transport to clone.
+	atk = NewAttacker(RoundTripper(struct{ http.RoundTripper }{http.DefaultTransport}))
+	res := atk.hit(NewStaticTargeter(tgt), "", 0)
+	if want := "target TLS settings: " + errNoTransport.Error(); !strings.HasPrefix(res.Error, want) {
+		t.Errorf("got error %q, want %q", res.Error, want)
+	}
+}
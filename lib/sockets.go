@@ -0,0 +1,76 @@
+package vegeta
+
+import (
+	"fmt"
+	"net"
+	"syscall"
+	"time"
+)
+
+// DialControl returns a functional option which adds the given function to
+// those called with the raw connection of each socket an Attacker dials,
+// after it's created and before it's connected, e.g. to set socket options
+// not otherwise exposed. Functions are called in the order they were added in
+// and their errors fail the dial.
+func DialControl(f func(network, address string, c syscall.RawConn) error) func(*Attacker) {
+	return func(a *Attacker) { a.control(f) }
+}
+
+// NoDelay returns a functional option which sets whether the TCP connections
+// of an Attacker disable Nagle's algorithm (TCP_NODELAY). It's true by default,
+// which favours the latency of small requests over their packet count.
+func NoDelay(on bool) func(*Attacker) {
+	return func(a *Attacker) {
+		a.sockopts = append(a.sockopts, func(c *net.TCPConn) error { return c.SetNoDelay(on) })
+	}
+}
+
+// SocketBuffers returns a functional option which sets the sizes in bytes of
+// the operating system's send (SO_SNDBUF) and receive (SO_RCVBUF) buffers of
+// the TCP connections of an Attacker. Zero sizes keep the system defaults.
+func SocketBuffers(send, receive int) func(*Attacker) {
+	return func(a *Attacker) {
+		a.sockopts = append(a.sockopts, func(c *net.TCPConn) error {
+			if send > 0 {
+				if err := c.SetWriteBuffer(send); err != nil {
+					return err
+				}
+			}
+			if receive > 0 {
+				return c.SetReadBuffer(receive)
+			}
+			return nil
+		})
+	}
+}
+
+// Linger returns a functional option which sets how long closing the TCP
+// connections of an Attacker waits for their unsent data to be sent
+// (SO_LINGER), with a resolution of seconds. Zero discards unsent data and
+// resets connections, which avoids piling up sockets in TIME_WAIT, and
+// negative durations close them in the background, the default.
+func Linger(d time.Duration) func(*Attacker) {
+	return func(a *Attacker) {
+		sec := -1
+		if d >= 0 {
+			sec = int(d / time.Second)
+		}
+		a.sockopts = append(a.sockopts, func(c *net.TCPConn) error { return c.SetLinger(sec) })
+	}
+}
+
+// tune sets the socket options of the Attacker on the given connection.
+func (a *Attacker) tune(conn net.Conn) error {
+	tc, ok := conn.(*net.TCPConn)
+	if !ok {
+		return nil
+	}
+
+	for _, opt := range a.sockopts {
+		if err := opt(tc); err != nil {
+			return fmt.Errorf("socket options: %s", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package vegeta
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestSocketOptions(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer server.Close()
+
+	var controls []string
+	atk := NewAttacker(
+		KeepAlive(false),
+		NoDelay(false),
+		SocketBuffers(1<<16, 1<<17),
+		Linger(0),
+		DialControl(func(network, address string, _ syscall.RawConn) error {
+			controls = append(controls, network+" "+address)
+			return nil
+		}),
+	)
+
+	tr := NewStaticTargeter(Target{Method: "GET", URL: server.URL})
+	if res := atk.hit(tr, "", 0); res.Error != "" || res.Code != 200 {
+		t.Fatalf("got code %d and error %q", res.Code, res.Error)
+	}
+
+	if want := "tcp4 " + server.Listener.Addr().String(); len(controls) != 1 || controls[0] != want {
+		t.Errorf("got controls %q, want [%q]", controls, want)
+	}
+
+	atk = NewAttacker(
+		KeepAlive(false),
+		Linger(time.Second),
+		DialControl(func(string, string, syscall.RawConn) error { return errors.New("denied") }),
+	)
+
+	if res := atk.hit(tr, "", 0); !strings.Contains(res.Error, "denied") {
+		t.Errorf("got error %q, want the control error", res.Error)
+	}
+}
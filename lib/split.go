@@ -0,0 +1,78 @@
+package vegeta
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+	"sync/atomic"
+)
+
+// TrafficSplit sends a percentage of the Targets of a Targeter to another
+// base URL, e.g. to validate canary deployments with realistic load splits.
+// The percentage can be adjusted while attacking.
+type TrafficSplit struct {
+	tr       Targeter
+	base     *url.URL
+	permille uint64
+	hits     uint64
+}
+
+// NewTrafficSplit returns a new TrafficSplit which sends the given percentage
+// of the Targets read from tr to the given base URL, by replacing their URL's
+// scheme and host with those of base and prefixing their path with its path.
+func NewTrafficSplit(tr Targeter, base string, percent float64) (*TrafficSplit, error) {
+	u, err := url.Parse(base)
+	if err != nil {
+		return nil, fmt.Errorf("bad split URL: %s", err)
+	} else if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("bad split URL: %s", base)
+	}
+
+	s := &TrafficSplit{tr: tr, base: u}
+	if err = s.SetPercent(percent); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// SetPercent atomically sets the percentage of Targets sent to the split base
+// URL, with a precision of a tenth of a percent.
+func (s *TrafficSplit) SetPercent(percent float64) error {
+	if percent < 0 || percent > 100 || math.IsNaN(percent) {
+		return fmt.Errorf("bad split percentage: %g", percent)
+	}
+	atomic.StoreUint64(&s.permille, uint64(math.Round(percent*10)))
+	return nil
+}
+
+// Percent returns the percentage of Targets sent to the split base URL.
+func (s *TrafficSplit) Percent() float64 {
+	return float64(atomic.LoadUint64(&s.permille)) / 10
+}
+
+// Target implements the Targeter function type, evenly interleaving the
+// Targets sent to the split base URL with the others.
+func (s *TrafficSplit) Target(tgt *Target) error {
+	if err := s.tr(tgt); err != nil {
+		return err
+	}
+
+	n, pm := atomic.AddUint64(&s.hits, 1), atomic.LoadUint64(&s.permille)
+	if n*pm/1000 == (n-1)*pm/1000 {
+		return nil
+	}
+
+	u, err := url.Parse(tgt.URL)
+	if err != nil {
+		return err
+	}
+
+	u.Scheme, u.Host = s.base.Scheme, s.base.Host
+	u.Path = strings.TrimSuffix(s.base.Path, "/") + u.Path
+	u.RawPath = ""
+	tgt.URL = u.String()
+
+	return nil
+}
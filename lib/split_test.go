@@ -0,0 +1,52 @@
+package vegeta
+
+import (
+	"testing"
+)
+
+func TestTrafficSplit(t *testing.T) {
+	t.Parallel()
+
+	tr := NewStaticTargeter(Target{Method: "GET", URL: "http://old:8080/things?page=1"})
+	split, err := NewTrafficSplit(tr, "https://new/v2/", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	count := func(n int) map[string]int {
+		urls := map[string]int{}
+		for i := 0; i < n; i++ {
+			var tgt Target
+			if err := split.Target(&tgt); err != nil {
+				t.Fatal(err)
+			}
+			urls[tgt.URL]++
+		}
+		return urls
+	}
+
+	got := count(100)
+	if got["http://old:8080/things?page=1"] != 90 || got["https://new/v2/things?page=1"] != 10 {
+		t.Errorf("got %v, want a 90/10 split", got)
+	}
+
+	if err := split.SetPercent(75); err != nil {
+		t.Fatal(err)
+	} else if got := split.Percent(); got != 75 {
+		t.Errorf("got percent %g, want 75", got)
+	}
+
+	if got = count(100); got["https://new/v2/things?page=1"] != 75 {
+		t.Errorf("got %v, want a 25/75 split", got)
+	}
+
+	for _, pct := range []float64{-1, 100.1} {
+		if err := split.SetPercent(pct); err == nil {
+			t.Errorf("%g: want error, got none", pct)
+		}
+	}
+
+	if _, err := NewTrafficSplit(tr, "/relative", 10); err == nil {
+		t.Error("want error for relative split URL, got none")
+	}
+}
@@ -3,16 +3,21 @@ package vegeta
 import (
 	"bufio"
 	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"math/rand"
 	"net/http"
 	"net/url"
 	"regexp"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 // Target is an HTTP request blueprint.
@@ -21,6 +26,29 @@ type Target struct {
 	URL    string
 	Body   []byte
 	Header http.Header
+	// SHA256 is the optional hex encoded SHA-256 digest the response body is
+	// expected to have. Mismatches are reported as corrupted body errors.
+	SHA256 string
+	// Tags are arbitrary labels copied into the Results of the Target's
+	// hits, e.g. to group them by endpoint, tenant or scenario step.
+	Tags map[string]string
+	// Assert holds the optional checks of the Target's responses, compiled
+	// with their Compile method. Failures are reported as assertion errors.
+	Assert *Assertions
+	// Timeout optionally overrides the total time the Attacker spends on
+	// each hit of the Target, as set by TotalTimeout.
+	Timeout time.Duration
+	// Retry is the optional policy by which failed hits of the Target are
+	// retried.
+	Retry *RetryPolicy
+	// ServerName optionally overrides the TLS server name of the Target's
+	// requests, as set by ServerName.
+	ServerName string
+	// Certificate is the optional TLS client certificate of the Target's
+	// requests, sent instead of those of the Attacker. Targets with either
+	// are sent over connections of their own, opened by a clone of the
+	// Attacker's *http.Transport which bypasses the RoundTrippers wrapping it.
+	Certificate *tls.Certificate
 }
 
 // Request creates an *http.Request out of Target and returns it along with an
@@ -64,20 +92,80 @@ func NewStaticTargeter(tgts ...Target) Targeter {
 	}
 }
 
+// NewWeightedTargeter returns a Targeter which picks one of the given
+// Targeters on every invocation in proportion to its weight, e.g. with
+// weights 80, 15 and 5, out of every 100 Targets, 80 come from the first
+// Targeter, 15 from the second and 5 from the third. Picks are smoothly
+// interleaved and deterministic.
+func NewWeightedTargeter(trs []Targeter, weights []uint64) (Targeter, error) {
+	if len(trs) == 0 {
+		return nil, ErrNoTargets
+	} else if len(trs) != len(weights) {
+		return nil, fmt.Errorf("got %d weights for %d targeters", len(weights), len(trs))
+	}
+
+	var total int64
+	for _, w := range weights {
+		total += int64(w)
+	}
+
+	if total == 0 {
+		return nil, errors.New("bad weights: all zero")
+	}
+
+	var (
+		mu      sync.Mutex
+		current = make([]int64, len(trs))
+	)
+
+	return func(tgt *Target) error {
+		if tgt == nil {
+			return ErrNilTarget
+		}
+
+		// Smooth weighted round-robin, as done by nginx.
+		mu.Lock()
+		pick := 0
+		for i, w := range weights {
+			if current[i] += int64(w); current[i] > current[pick] {
+				pick = i
+			}
+		}
+		current[pick] -= total
+		mu.Unlock()
+
+		return trs[pick](tgt)
+	}, nil
+}
+
 // NewEagerTargeter eagerly reads all Targets out of the provided io.Reader and
 // returns a NewStaticTargeter with them.
 //
 // body will be set as the Target's body if no body is provided.
 // hdr will be merged with the each Target's headers.
 func NewEagerTargeter(src io.Reader, body []byte, header http.Header) (Targeter, error) {
+	return readAllTargets(NewLazyTargeter(src, body, header))
+}
+
+// readAllTargets reads all Targets out of the given lazy Targeter and
+// returns a NewStaticTargeter with them.
+func readAllTargets(tr Targeter) (Targeter, error) {
+	tgts, err := readTargets(tr)
+	if err != nil {
+		return nil, err
+	}
+	return NewStaticTargeter(tgts...), nil
+}
+
+// readTargets reads all Targets out of the given lazy Targeter.
+func readTargets(tr Targeter) ([]Target, error) {
 	var (
-		sc   = NewLazyTargeter(src, body, header)
 		tgts []Target
 		tgt  Target
 		err  error
 	)
 	for {
-		if err = sc(&tgt); err == ErrNoTargets {
+		if err = tr(&tgt); err == ErrNoTargets {
 			break
 		} else if err != nil {
 			return nil, err
@@ -87,7 +175,64 @@ func NewEagerTargeter(src io.Reader, body []byte, header http.Header) (Targeter,
 	if len(tgts) == 0 {
 		return nil, ErrNoTargets
 	}
-	return NewStaticTargeter(tgts...), nil
+	return tgts, nil
+}
+
+// NewRandomTargeter eagerly reads all Targets out of the given lazy Targeter,
+// such as one returned by NewLazyTargeter, and returns a Targeter which picks
+// one of them at random on every invocation. The same seed always yields the
+// same sequence of Targets.
+func NewRandomTargeter(tr Targeter, seed int64) (Targeter, error) {
+	tgts, err := readTargets(tr)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		mu  sync.Mutex
+		rng = rand.New(rand.NewSource(seed))
+	)
+
+	return func(tgt *Target) error {
+		if tgt == nil {
+			return ErrNilTarget
+		}
+		mu.Lock()
+		*tgt = tgts[rng.Intn(len(tgts))]
+		mu.Unlock()
+		return nil
+	}, nil
+}
+
+// NewShuffledTargeter eagerly reads all Targets out of the given lazy
+// Targeter, such as one returned by NewLazyTargeter, and returns a Targeter
+// which yields all of them in a random order before reshuffling them for the
+// next pass. The same seed always yields the same sequence of Targets.
+func NewShuffledTargeter(tr Targeter, seed int64) (Targeter, error) {
+	tgts, err := readTargets(tr)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		mu   sync.Mutex
+		rng  = rand.New(rand.NewSource(seed))
+		perm []int
+	)
+
+	return func(tgt *Target) error {
+		if tgt == nil {
+			return ErrNilTarget
+		}
+		mu.Lock()
+		if len(perm) == 0 {
+			perm = rng.Perm(len(tgts))
+		}
+		*tgt = tgts[perm[0]]
+		perm = perm[1:]
+		mu.Unlock()
+		return nil
+	}, nil
 }
 
 // NewLazyTargeter returns a new Targeter that lazily scans Targets from the
@@ -169,6 +314,187 @@ func NewLazyTargeter(src io.Reader, body []byte, hdr http.Header) Targeter {
 	}
 }
 
+// NewLoopingTargeter returns a Targeter which reads Targets out of src with
+// the Targeter returned by lazy, such as NewLazyTargeter, and seeks back to
+// the start of src to read them all over again once exhausted. This allows
+// attacking Targets from files of any size for any duration with bounded
+// memory, like an eager Targeter would with all of them in memory.
+func NewLoopingTargeter(src io.ReadSeeker, lazy func(io.Reader) Targeter) Targeter {
+	var (
+		mu sync.Mutex
+		tr = lazy(src)
+	)
+
+	return func(tgt *Target) error {
+		if tgt == nil {
+			return ErrNilTarget
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		err := tr(tgt)
+		if err != ErrNoTargets {
+			return err
+		}
+
+		if _, err = src.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+
+		tr = lazy(src)
+		return tr(tgt)
+	}
+}
+
+// NewEagerJSONTargeter eagerly reads all Targets out of the provided
+// io.Reader in the JSON format and returns a NewStaticTargeter with them.
+// See NewLazyJSONTargeter for the format.
+//
+// body will be set as the Target's body if no body is provided.
+// hdr will be merged with the each Target's headers.
+func NewEagerJSONTargeter(src io.Reader, body []byte, hdr http.Header) (Targeter, error) {
+	return readAllTargets(NewLazyJSONTargeter(src, body, hdr))
+}
+
+// NewLazyJSONTargeter returns a new Targeter that lazily decodes Targets from
+// the provided io.Reader on every invocation. Targets are JSON objects, one
+// per line by convention, with the following fields:
+//
+//	{
+//	  "method": "POST",
+//	  "url": "http://goku/things",
+//	  "headers": {"Content-Type": "application/json", "X-Ki": ["1", "2"]},
+//	  "body": "{\"name\": \"kakarot\"}"
+//	}
+//
+// Instead of "body", binary bodies can be given base64 encoded in
+// "body_base64" and bodies stored in files referenced by "body_file".
+// Header values are either a string or an array of strings.
+//
+// body will be set as the Target's body if no body is provided.
+// hdr will be merged with the each Target's headers.
+func NewLazyJSONTargeter(src io.Reader, body []byte, hdr http.Header) Targeter {
+	var mu sync.Mutex
+	dec := json.NewDecoder(src)
+	return func(tgt *Target) (err error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if tgt == nil {
+			return ErrNilTarget
+		}
+
+		var jt jsonTarget
+		if err = dec.Decode(&jt); err == io.EOF {
+			return ErrNoTargets
+		} else if err != nil {
+			return fmt.Errorf("bad target: %s", err)
+		}
+
+		return jt.target(tgt, body, hdr)
+	}
+}
+
+// jsonTarget is the JSON representation of a Target.
+type jsonTarget struct {
+	Method     string            `json:"method"`
+	URL        string            `json:"url"`
+	Headers    jsonHeaders       `json:"headers"`
+	Body       *string           `json:"body"`
+	BodyBase64 string            `json:"body_base64"`
+	BodyFile   string            `json:"body_file"`
+	Tags       map[string]string `json:"tags"`
+	Assert     *Assertions       `json:"assert"`
+	Timeout    string            `json:"timeout"`
+	Retry      *RetryPolicy      `json:"retry"`
+	ServerName string            `json:"server_name"`
+	CertFile   string            `json:"cert_file"`
+	KeyFile    string            `json:"key_file"`
+}
+
+// target sets the fields of tgt from the jsonTarget, defaulting to the given
+// body and merging the given headers.
+func (jt *jsonTarget) target(tgt *Target, body []byte, hdr http.Header) (err error) {
+	if !httpMethodChecker.MatchString(jt.Method + " ") {
+		return fmt.Errorf("bad method: %s", jt.Method)
+	}
+	// Templated URLs are only valid once executed.
+	if _, err = url.ParseRequestURI(jt.URL); err != nil && !strings.Contains(jt.URL, "{{") {
+		return fmt.Errorf("bad URL: %s", jt.URL)
+	}
+
+	tgt.Method, tgt.URL, tgt.Tags, tgt.Assert, tgt.Retry = jt.Method, jt.URL, jt.Tags, jt.Assert, jt.Retry
+	tgt.ServerName = jt.ServerName
+	if jt.CertFile != "" {
+		if tgt.Certificate, err = loadCertificate(jt.CertFile, jt.KeyFile); err != nil {
+			return fmt.Errorf("bad certificate: %s", err)
+		}
+	}
+	if jt.Timeout != "" {
+		if tgt.Timeout, err = time.ParseDuration(jt.Timeout); err != nil {
+			return fmt.Errorf("bad timeout: %s", err)
+		}
+	}
+	if tgt.Assert != nil {
+		if err = tgt.Assert.Compile(); err != nil {
+			return err
+		}
+	}
+	tgt.Header = http.Header{}
+	for k, vs := range hdr {
+		tgt.Header[k] = vs
+	}
+	for k, vs := range jt.Headers {
+		// Case-sensitive keys, as in the http format.
+		tgt.Header[k] = append(tgt.Header[k], vs...)
+	}
+
+	switch {
+	case jt.Body != nil:
+		tgt.Body = []byte(*jt.Body)
+	case jt.BodyBase64 != "":
+		if tgt.Body, err = base64.StdEncoding.DecodeString(jt.BodyBase64); err != nil {
+			return fmt.Errorf("bad body: %s", err)
+		}
+	case jt.BodyFile != "":
+		if tgt.Body, err = ioutil.ReadFile(jt.BodyFile); err != nil {
+			return fmt.Errorf("bad body: %s", err)
+		}
+	default:
+		tgt.Body = body
+	}
+
+	return nil
+}
+
+// jsonHeaders are headers whose values are either a string or an array of
+// strings.
+type jsonHeaders map[string][]string
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (h *jsonHeaders) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*h = make(jsonHeaders, len(raw))
+	for k, v := range raw {
+		var vs []string
+		if err := json.Unmarshal(v, &vs); err != nil {
+			var s string
+			if err = json.Unmarshal(v, &s); err != nil {
+				return fmt.Errorf("bad header %s: %s", k, v)
+			}
+			vs = []string{s}
+		}
+		(*h)[k] = vs
+	}
+
+	return nil
+}
+
 var httpMethodChecker = regexp.MustCompile("^[A-Z]+\\s")
 
 // A line starts with an http method when the first word is uppercase ascii
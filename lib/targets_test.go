@@ -212,6 +212,185 @@ func TestNewLazyTargeter(t *testing.T) {
 	}
 }
 
+func TestNewLazyJSONTargeter(t *testing.T) {
+	t.Parallel()
+
+	f, err := ioutil.TempFile("", "vegeta-json-body")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString("from file")
+	f.Close()
+
+	src := `{"method": "GET", "url": "http://goku/1"}
+{"method": "POST", "url": "http://goku/2", "headers": {"X-One": "1", "x-many": ["a", "b"]}, "body": "{\"a\": 1}"}
+{"method": "PUT", "url": "http://goku/3", "body_base64": "AP8="}
+
+{"method": "PATCH", "url": "http://goku/4", "body_file": "` + f.Name() + `"}
+{"method": "PATCH", "url": "http://goku/5", "body": ""}
+{"method": "GET", "url": "http://goku/6", "tags": {"endpoint": "goku", "tenant": "a"}}
+`
+
+	read := NewLazyJSONTargeter(strings.NewReader(src), []byte("default"), http.Header{"X-One": {"0"}})
+	for _, want := range []Target{
+		{Method: "GET", URL: "http://goku/1", Body: []byte("default"), Header: http.Header{"X-One": {"0"}}},
+		{
+			Method: "POST",
+			URL:    "http://goku/2",
+			Body:   []byte(`{"a": 1}`),
+			Header: http.Header{"X-One": {"0", "1"}, "x-many": {"a", "b"}},
+		},
+		{Method: "PUT", URL: "http://goku/3", Body: []byte{0, 255}, Header: http.Header{"X-One": {"0"}}},
+		{Method: "PATCH", URL: "http://goku/4", Body: []byte("from file"), Header: http.Header{"X-One": {"0"}}},
+		{Method: "PATCH", URL: "http://goku/5", Body: []byte{}, Header: http.Header{"X-One": {"0"}}},
+		{
+			Method: "GET",
+			URL:    "http://goku/6",
+			Body:   []byte("default"),
+			Header: http.Header{"X-One": {"0"}},
+			Tags:   map[string]string{"endpoint": "goku", "tenant": "a"},
+		},
+	} {
+		var got Target
+		if err := read(&got); err != nil {
+			t.Fatal(err)
+		} else if !reflect.DeepEqual(want, got) {
+			t.Fatalf("want: %#v, got: %#v", want, got)
+		}
+	}
+
+	if err := read(&Target{}); err != ErrNoTargets {
+		t.Fatalf("got: %v, want: %v", err, ErrNoTargets)
+	}
+
+	for _, src := range []string{
+		`{"method": "get", "url": "http://goku"}`,
+		`{"method": "GET", "url": "goku"}`,
+		`{"method": "GET", "url": "http://goku", "headers": {"X": 1}}`,
+		`{"method": "GET", "url": "http://goku", "body_base64": "!"}`,
+		`{"method": "GET", "url": "http://goku", "body_file": "/does/not/exist"}`,
+		`GET http://goku`,
+	} {
+		if err := NewLazyJSONTargeter(strings.NewReader(src), nil, nil)(&Target{}); err == nil || err == ErrNoTargets {
+			t.Errorf("%s: got: %v, want error", src, err)
+		}
+	}
+}
+
+func TestNewWeightedTargeter(t *testing.T) {
+	t.Parallel()
+
+	var trs []Targeter
+	for _, u := range []string{"http://goku/a", "http://goku/b", "http://goku/c"} {
+		trs = append(trs, NewStaticTargeter(Target{Method: "GET", URL: u}))
+	}
+
+	tr, err := NewWeightedTargeter(trs, []uint64{5, 3, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[string]int{}
+	for i := 0; i < 100; i++ {
+		var tgt Target
+		if err := tr(&tgt); err != nil {
+			t.Fatal(err)
+		}
+		got[tgt.URL]++
+	}
+
+	want := map[string]int{"http://goku/a": 50, "http://goku/b": 30, "http://goku/c": 20}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+
+	for _, weights := range [][]uint64{{1, 2}, {0, 0, 0}} {
+		if _, err := NewWeightedTargeter(trs, weights); err == nil {
+			t.Errorf("%v: want error, got none", weights)
+		}
+	}
+}
+
+func TestNewRandomAndShuffledTargeters(t *testing.T) {
+	t.Parallel()
+
+	const src = "GET http://goku/a\nGET http://goku/b\nGET http://goku/c\nGET http://goku/d\n"
+
+	for name, ctor := range map[string]func(Targeter, int64) (Targeter, error){
+		"random":  NewRandomTargeter,
+		"shuffle": NewShuffledTargeter,
+	} {
+		read := func(seed int64, n int) []string {
+			tr, err := ctor(NewLazyTargeter(strings.NewReader(src), nil, nil), seed)
+			if err != nil {
+				t.Fatalf("%s: %s", name, err)
+			}
+			urls := make([]string, n)
+			for i := range urls {
+				var tgt Target
+				if err := tr(&tgt); err != nil {
+					t.Fatalf("%s: %s", name, err)
+				}
+				urls[i] = tgt.URL
+			}
+			return urls
+		}
+
+		a, b := read(42, 40), read(42, 40)
+		if !reflect.DeepEqual(a, b) {
+			t.Errorf("%s: same seed yielded different sequences:\n%v\n%v", name, a, b)
+		}
+
+		if c := read(7, 40); reflect.DeepEqual(a, c) {
+			t.Errorf("%s: different seeds yielded the same sequence: %v", name, a)
+		}
+
+		if name != "shuffle" {
+			continue
+		}
+
+		for pass := 0; pass < len(a); pass += 4 {
+			seen := map[string]bool{}
+			for _, u := range a[pass : pass+4] {
+				seen[u] = true
+			}
+			if len(seen) != 4 {
+				t.Errorf("shuffle: pass %v doesn't have all targets", a[pass:pass+4])
+			}
+		}
+	}
+
+	if _, err := NewShuffledTargeter(NewLazyTargeter(strings.NewReader(""), nil, nil), 0); err != ErrNoTargets {
+		t.Errorf("got: %v, want: %v", err, ErrNoTargets)
+	}
+}
+
+func TestNewLoopingTargeter(t *testing.T) {
+	t.Parallel()
+
+	src := strings.NewReader("GET http://goku/a\n\nPOST http://goku/b\n")
+	tr := NewLoopingTargeter(src, func(r io.Reader) Targeter {
+		return NewLazyTargeter(r, nil, nil)
+	})
+
+	for i, want := range []string{"GET", "POST", "GET", "POST", "GET"} {
+		var got Target
+		if err := tr(&got); err != nil {
+			t.Fatalf("hit %d: %s", i, err)
+		} else if got.Method != want {
+			t.Errorf("hit %d: got: %s, want: %s", i, got.Method, want)
+		}
+	}
+
+	empty := NewLoopingTargeter(strings.NewReader(""), func(r io.Reader) Targeter {
+		return NewLazyTargeter(r, nil, nil)
+	})
+	if err := empty(&Target{}); err != ErrNoTargets {
+		t.Errorf("got: %v, want: %v", err, ErrNoTargets)
+	}
+}
+
 func TestErrNilTarget(t *testing.T) {
 	t.Parallel()
 
@@ -219,9 +398,15 @@ func TestErrNilTarget(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	weighted, err := NewWeightedTargeter([]Targeter{eager}, []uint64{1})
+	if err != nil {
+		t.Fatal(err)
+	}
 	for i, tr := range []Targeter{
 		NewStaticTargeter(Target{Method: "GET", URL: "http://foo.bar"}),
 		NewLazyTargeter(strings.NewReader("GET http://foo.bar"), nil, nil),
+		weighted,
+		NewLazyJSONTargeter(strings.NewReader(`{"method": "GET", "url": "http://foo.bar"}`), nil, nil),
 		eager,
 	} {
 		if got, want := tr(nil), ErrNilTarget; got != want {
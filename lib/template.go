@@ -0,0 +1,174 @@
+package vegeta
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	mrand "math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// NewTemplateTargeter returns a Targeter which executes the URL, header
+// values and body of every Target read from tr as text/template templates,
+// evaluated on every hit. The following functions are built-in:
+//
+//	uuid              a random (version 4) UUID
+//	randInt a b       a random integer in [a, b]
+//	randString n      a random alphanumeric string of length n
+//	now layout        the current time formatted with the given time layout,
+//	                  or as seconds ("unix") or milliseconds ("unixms")
+//	                  since the UNIX epoch
+//
+// Realistic fake data, e.g. for write-path bodies, is generated by:
+//
+//	fakeName, fakeFirstName, fakeLastName, fakeEmail, fakePhone, fakeCompany,
+//	fakeAddress, fakeStreet, fakeCity, fakeZip
+//	lorem n           n random lorem ipsum words
+//	loremSentence     a random lorem ipsum sentence
+//	loremParagraph    a random lorem ipsum paragraph
+//
+// e.g. http://goku/things/{{uuid}}?cb={{randString 8}}.
+func NewTemplateTargeter(tr Targeter) Targeter {
+	tmpls := templateCache{funcs: templateFuncs}
+	return func(tgt *Target) error {
+		if err := tr(tgt); err != nil {
+			return err
+		}
+		return tmpls.executeTarget(tgt, nil)
+	}
+}
+
+// templateFuncs are the built-in functions of targets templates.
+var templateFuncs = template.FuncMap{
+	"uuid":       templateUUID,
+	"randInt":    templateRandInt,
+	"randString": templateRandString,
+	"now":        templateNow,
+
+	"fakeName":       fakeName,
+	"fakeFirstName":  fakeFirstName,
+	"fakeLastName":   fakeLastName,
+	"fakeEmail":      fakeEmail,
+	"fakePhone":      fakePhone,
+	"fakeCompany":    fakeCompany,
+	"fakeAddress":    fakeAddress,
+	"fakeStreet":     fakeStreet,
+	"fakeCity":       fakeCity,
+	"fakeZip":        fakeZip,
+	"lorem":          lorem,
+	"loremSentence":  loremSentence,
+	"loremParagraph": loremParagraph,
+}
+
+func templateUUID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40 // version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
+
+func templateRandInt(a, b int64) (int64, error) {
+	if b < a {
+		return 0, fmt.Errorf("randInt: %d is less than %d", b, a)
+	}
+	n, err := rand.Int(rand.Reader, big.NewInt(0).Add(big.NewInt(b-a), big.NewInt(1)))
+	if err != nil {
+		return 0, err
+	}
+	return a + n.Int64(), nil
+}
+
+const alphanumeric = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+func templateRandString(n int) (string, error) {
+	if n < 0 {
+		return "", fmt.Errorf("randString: negative length %d", n)
+	}
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = alphanumeric[mrand.Intn(len(alphanumeric))]
+	}
+	return string(b), nil
+}
+
+func templateNow(layout string) string {
+	now := time.Now()
+	switch layout {
+	case "unix":
+		return strconv.FormatInt(now.Unix(), 10)
+	case "unixms":
+		return strconv.FormatInt(now.UnixNano()/1e6, 10)
+	default:
+		return now.Format(layout)
+	}
+}
+
+// templateCache caches parsed templates by their text.
+type templateCache struct {
+	sync.Map
+	funcs template.FuncMap
+}
+
+// executeTarget replaces the URL, header values and body of the given
+// Target with the result of executing them as templates with data.
+func (c *templateCache) executeTarget(tgt *Target, data interface{}) (err error) {
+	if tgt.URL, err = c.execute(tgt.URL, data); err != nil {
+		return err
+	}
+
+	if tgt.Header != nil {
+		hdr := make(http.Header, len(tgt.Header))
+		for k, vs := range tgt.Header {
+			hdr[k] = make([]string, len(vs))
+			for i, v := range vs {
+				if hdr[k][i], err = c.execute(v, data); err != nil {
+					return err
+				}
+			}
+		}
+		tgt.Header = hdr
+	}
+
+	if bytes.Contains(tgt.Body, []byte("{{")) {
+		body, err := c.execute(string(tgt.Body), data)
+		if err != nil {
+			return err
+		}
+		tgt.Body = []byte(body)
+	}
+
+	return nil
+}
+
+// execute executes the given text as a template with data, returning the
+// text unchanged if it has no actions.
+func (c *templateCache) execute(text string, data interface{}) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+
+	t, ok := c.Load(text)
+	if !ok {
+		parsed, err := template.New("").Funcs(c.funcs).Option("missingkey=error").Parse(text)
+		if err != nil {
+			return "", fmt.Errorf("bad template: %s", err)
+		}
+		t, _ = c.LoadOrStore(text, parsed)
+	}
+
+	var b strings.Builder
+	if err := t.(*template.Template).Execute(&b, data); err != nil {
+		return "", fmt.Errorf("bad template: %s", err)
+	}
+
+	return b.String(), nil
+}
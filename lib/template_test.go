@@ -0,0 +1,58 @@
+package vegeta
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewTemplateTargeter(t *testing.T) {
+	t.Parallel()
+
+	tr := NewTemplateTargeter(NewStaticTargeter(Target{
+		Method: "POST",
+		URL:    "http://goku/things/{{uuid}}?cb={{randString 8}}",
+		Header: http.Header{"X-Year": {`{{now "2006"}}`}, "X-Now": {`{{now "unix"}}`}},
+		Body:   []byte(`{"power": {{randInt 9000 9001}}}`),
+	}))
+
+	url := regexp.MustCompile(`^http://goku/things/[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}\?cb=[a-zA-Z0-9]{8}$`)
+	body := regexp.MustCompile(`^{"power": 900[01]}$`)
+
+	var prev string
+	for i := 0; i < 10; i++ {
+		var tgt Target
+		if err := tr(&tgt); err != nil {
+			t.Fatal(err)
+		}
+
+		if !url.MatchString(tgt.URL) {
+			t.Errorf("bad URL: %s", tgt.URL)
+		} else if tgt.URL == prev {
+			t.Errorf("URL not evaluated per hit: %s", tgt.URL)
+		}
+		prev = tgt.URL
+
+		if !body.Match(tgt.Body) {
+			t.Errorf("bad body: %s", tgt.Body)
+		}
+
+		if got, want := tgt.Header.Get("X-Year"), strconv.Itoa(time.Now().Year()); got != want {
+			t.Errorf("got year %s, want %s", got, want)
+		}
+
+		if secs, err := strconv.ParseInt(tgt.Header.Get("X-Now"), 10, 64); err != nil || time.Since(time.Unix(secs, 0)) > time.Minute {
+			t.Errorf("bad unix time: %s", tgt.Header.Get("X-Now"))
+		}
+	}
+
+	for _, u := range []string{"http://goku/{{randInt 2 1}}", "http://goku/{{randString -1}}", "http://goku/{{nope}}"} {
+		tr := NewTemplateTargeter(NewStaticTargeter(Target{Method: "GET", URL: u}))
+		if err := tr(&Target{}); err == nil || !strings.Contains(err.Error(), "bad template") {
+			t.Errorf("%s: got: %v, want bad template error", u, err)
+		}
+	}
+}
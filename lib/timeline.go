@@ -0,0 +1,164 @@
+package vegeta
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// A Timeline is a Report of metrics of the hits started in each interval of
+// an attack, which shows how they evolved over its course, e.g. the latencies
+// degrading as the target runs out of memory.
+type Timeline struct {
+	// Interval is the duration of the buckets, aligned on multiples of it
+	// since the zero time. Zero means one second.
+	Interval time.Duration
+	// Percentiles are the extra latency percentiles computed for each
+	// bucket, as those of Metrics.
+	Percentiles []float64
+	// Buckets are the buckets with hits in time order, once closed.
+	Buckets []TimeBucket
+
+	buckets map[time.Time]*TimeBucket
+}
+
+// A TimeBucket holds metrics of the hits started in an interval of a
+// Timeline. Unlike Metrics, it only keeps the counts and latencies of the
+// hits, in a sparse histogram, so that the buckets of long attacks fit in
+// memory.
+type TimeBucket struct {
+	// Start is the start of the interval.
+	Start time.Time `json:"start"`
+	// Offset is the time from the start of the first bucket to Start.
+	Offset time.Duration `json:"offset"`
+	// Requests is the number of hits of the interval.
+	Requests uint64 `json:"requests"`
+	// Rate is the number of hits per second of the whole interval.
+	Rate float64 `json:"rate"`
+	// Success is the fraction of successful hits, as in Metrics.
+	Success float64 `json:"success"`
+	// Errors is the number of hits which failed with an error.
+	Errors uint64 `json:"errors"`
+	// Latencies holds the latency metrics of the hits, with the Percentiles
+	// of the Timeline.
+	Latencies LatencyMetrics `json:"latencies"`
+
+	success   uint64
+	latencies sparseHistogram
+	moments   moments
+}
+
+// Add implements the Add method of the Report interface by adding the given
+// Result to the bucket of its interval.
+func (tl *Timeline) Add(r *Result) {
+	if tl.buckets == nil {
+		tl.buckets = map[time.Time]*TimeBucket{}
+	}
+
+	start := r.Timestamp.Truncate(tl.interval())
+	b, ok := tl.buckets[start]
+	if !ok {
+		b = &TimeBucket{Start: start}
+		tl.buckets[start] = b
+	}
+
+	b.Requests++
+	b.Latencies.Total += r.Latency
+	if r.Latency > b.Latencies.Max {
+		b.Latencies.Max = r.Latency
+	}
+	b.latencies.Record(r.Latency)
+	b.moments.add(r.Latency)
+
+	if succeeded(r) {
+		b.success++
+	}
+
+	if r.Error != "" {
+		b.Errors++
+	}
+}
+
+// Close implements the Close method of the Report interface by computing the
+// derived metrics of each interval and sorting them into Buckets.
+func (tl *Timeline) Close() {
+	tl.Buckets = make([]TimeBucket, 0, len(tl.buckets))
+	for _, b := range tl.buckets {
+		b.Rate = float64(b.Requests) / tl.interval().Seconds()
+		b.Success = float64(b.success) / float64(b.Requests)
+		b.Latencies.Mean = b.Latencies.Total / time.Duration(b.Requests)
+		b.Latencies.quantiles(&b.latencies, tl.Percentiles)
+		b.Latencies.spread(b.moments)
+		tl.Buckets = append(tl.Buckets, *b)
+	}
+
+	sort.Slice(tl.Buckets, func(i, j int) bool {
+		return tl.Buckets[i].Start.Before(tl.Buckets[j].Start)
+	})
+
+	for i := range tl.Buckets {
+		tl.Buckets[i].Offset = tl.Buckets[i].Start.Sub(tl.Buckets[0].Start)
+	}
+}
+
+// quantile returns the given quantile of the latencies of the bucket, capped
+// to their Max.
+func (b *TimeBucket) quantile(q float64) time.Duration {
+	return b.Latencies.quantile(&b.latencies, q)
+}
+
+// interval returns the Interval of the Timeline.
+func (tl *Timeline) interval() time.Duration {
+	if tl.Interval <= 0 {
+		return time.Second
+	}
+	return tl.Interval
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface by
+// parsing an Interval in brackets, e.g. [10s].
+func (tl *Timeline) UnmarshalText(value []byte) error {
+	if len(value) < 2 || value[0] != '[' || value[len(value)-1] != ']' {
+		return fmt.Errorf("bad interval: %s", value)
+	}
+
+	d, err := time.ParseDuration(strings.TrimSpace(string(value[1 : len(value)-1])))
+	if err != nil {
+		return err
+	} else if d <= 0 {
+		return fmt.Errorf("bad interval: %s", value)
+	}
+
+	tl.Interval = d
+	return nil
+}
+
+// NewTimelineReporter returns a Reporter that writes out the buckets of a
+// Timeline as aligned, formatted text, one per line, starting at their
+// times on the given TimeAxis.
+func NewTimelineReporter(tl *Timeline, ax TimeAxis) Reporter {
+	return func(w io.Writer) (err error) {
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.StripEscape)
+
+		if _, err = fmt.Fprintf(tw, "Time\t%s\n", metricsHeader(tl.Percentiles)); err != nil {
+			return err
+		}
+
+		for _, b := range tl.Buckets {
+			at := b.Offset.String()
+			if ax.WallClock {
+				at = b.Start.In(ax.location()).Format(time.RFC3339)
+			}
+
+			row := statsRow(b.Requests, b.Rate, b.Success, &b.Latencies, b.Errors, tl.Percentiles)
+			if _, err = fmt.Fprintf(tw, "%s\t%s\n", at, row); err != nil {
+				return err
+			}
+		}
+
+		return tw.Flush()
+	}
+}
@@ -0,0 +1,95 @@
+package vegeta
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeline(t *testing.T) {
+	t.Parallel()
+
+	tl := Timeline{Interval: 10 * time.Second}
+	begin := time.Unix(1000, 0)
+	for i := 0; i < 30; i++ {
+		r := Result{
+			Code:      200,
+			Timestamp: begin.Add(time.Duration(i) * time.Second),
+			Latency:   time.Duration(i+1) * time.Millisecond,
+		}
+		if i >= 20 {
+			r.Code, r.Error = 500, "500 Internal Server Error"
+		}
+		tl.Add(&r)
+	}
+	tl.Close()
+
+	if got, want := len(tl.Buckets), 3; got != want {
+		t.Fatalf("got %d buckets, want %d", got, want)
+	}
+
+	for i, b := range tl.Buckets {
+		if want := begin.Add(time.Duration(i) * 10 * time.Second); !b.Start.Equal(want) {
+			t.Errorf("bucket %d: got start %s, want %s", i, b.Start, want)
+		}
+		if want := time.Duration(i) * 10 * time.Second; b.Offset != want {
+			t.Errorf("bucket %d: got offset %s, want %s", i, b.Offset, want)
+		}
+		if got, want := b.Requests, uint64(10); got != want {
+			t.Errorf("bucket %d: got %d requests, want %d", i, got, want)
+		}
+		if got, want := b.Rate, 1.0; got != want {
+			t.Errorf("bucket %d: got rate %g, want %g", i, got, want)
+		}
+		if want := time.Duration(10*i+10) * time.Millisecond; b.Latencies.Max != want {
+			t.Errorf("bucket %d: got max latency %s, want %s", i, b.Latencies.Max, want)
+		}
+	}
+
+	if got := tl.Buckets[2].Success; got != 0 {
+		t.Errorf("got success %g of the last bucket, want 0", got)
+	}
+
+	var buf bytes.Buffer
+	if err := NewTimelineReporter(&tl, TimeAxis{}).Report(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if got, want := len(lines), 4; got != want {
+		t.Fatalf("got %d lines, want %d:\n%s", got, want, buf.String())
+	}
+
+	for i, prefix := range []string{"Time ", "0s ", "10s ", "20s "} {
+		if !strings.HasPrefix(lines[i], prefix) {
+			t.Errorf("line %d: got %q, want prefix %q", i, lines[i], prefix)
+		}
+	}
+
+	if !strings.HasSuffix(lines[3], " 10") {
+		t.Errorf("got last line %q, want 10 errors", lines[3])
+	}
+}
+
+func TestTimeline_UnmarshalText(t *testing.T) {
+	t.Parallel()
+
+	for in, want := range map[string]time.Duration{
+		"[10s]":   10 * time.Second,
+		"[ 1m ]":  time.Minute,
+		"[500ms]": 500 * time.Millisecond,
+		"10s":     0,
+		"[]":      0,
+		"[-1s]":   0,
+		"[foo]":   0,
+	} {
+		var tl Timeline
+		err := tl.UnmarshalText([]byte(in))
+		if want == 0 && err == nil {
+			t.Errorf("%q: got no error", in)
+		} else if want != 0 && (err != nil || tl.Interval != want) {
+			t.Errorf("%q: got interval %s, error %v, want %s", in, tl.Interval, err, want)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package vegeta
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// Errors reported in Results, as prefixes of their Error field, when the
+// respective phases of requests time out.
+var (
+	ErrDialTimeout           = errors.New("dial timeout")
+	ErrTLSHandshakeTimeout   = errors.New("tls handshake timeout")
+	ErrResponseHeaderTimeout = errors.New("response header timeout")
+	ErrBodyTimeout           = errors.New("body read timeout")
+	ErrRequestTimeout        = errors.New("request timeout")
+	ErrSLAMiss               = errors.New("sla miss")
+)
+
+// DialTimeout returns a functional option which sets the maximum amount of
+// time an Attacker waits for a connection to be established.
+func DialTimeout(d time.Duration) func(*Attacker) {
+	return func(a *Attacker) { a.dialer.Timeout = d }
+}
+
+// TLSHandshakeTimeout returns a functional option which sets the maximum
+// amount of time an Attacker waits for a TLS handshake to complete.
+func TLSHandshakeTimeout(d time.Duration) func(*Attacker) {
+	return func(a *Attacker) {
+		if tr := a.transport(); tr != nil {
+			tr.TLSHandshakeTimeout = d
+		}
+	}
+}
+
+// ResponseHeaderTimeout returns a functional option which sets the maximum
+// amount of time an Attacker waits for the headers of a response after
+// writing its request.
+func ResponseHeaderTimeout(d time.Duration) func(*Attacker) {
+	return func(a *Attacker) {
+		if tr := a.transport(); tr != nil {
+			tr.ResponseHeaderTimeout = d
+		}
+	}
+}
+
+// BodyTimeout returns a functional option which sets the maximum amount of
+// time an Attacker spends reading the body of a response after its headers
+// were received. Zero, the default, means no limit.
+func BodyTimeout(d time.Duration) func(*Attacker) {
+	return func(a *Attacker) { a.body = d }
+}
+
+// TotalTimeout returns a functional option which sets the maximum amount of
+// time an Attacker spends on each request, from dialing to reading the last
+// byte of its response body, so that slow streaming responses can't hold up
+// workers. Zero, the default, means no limit.
+func TotalTimeout(d time.Duration) func(*Attacker) {
+	return func(a *Attacker) { a.total = d }
+}
+
+// Deadline returns a functional option which makes an Attacker give up on
+// requests which weren't fully responded to within the given deadline, as
+// real clients with a latency SLA do. Their Results are reported with an
+// ErrSLAMiss error, distinct from the errors of servers. Zero, the default,
+// means no deadline.
+func Deadline(d time.Duration) func(*Attacker) {
+	return func(a *Attacker) { a.deadline = d }
+}
+
+// timeoutError returns err prefixed with the error of the request phase which
+// timed out, if any.
+func timeoutError(err error) error {
+	var kind error
+
+	var op *net.OpError
+	switch msg := err.Error(); {
+	case errors.As(err, &op) && op.Op == "dial" && op.Timeout():
+		kind = ErrDialTimeout
+	case strings.Contains(msg, "TLS handshake timeout"):
+		kind = ErrTLSHandshakeTimeout
+	case strings.Contains(msg, "timeout awaiting response headers"):
+		kind = ErrResponseHeaderTimeout
+	default:
+		return err
+	}
+
+	return fmt.Errorf("%s: %s", kind, err)
+}
@@ -0,0 +1,127 @@
+package vegeta
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// timeoutErr is a net.Error which timed out.
+type timeoutErr struct{}
+
+func (timeoutErr) Error() string   { return "i/o timeout" }
+func (timeoutErr) Timeout() bool   { return true }
+func (timeoutErr) Temporary() bool { return true }
+
+func TestTimeouts(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/header" {
+			time.Sleep(time.Second)
+		}
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		if r.URL.Path == "/body" {
+			time.Sleep(time.Second)
+		}
+	}))
+	defer server.Close()
+
+	// A listener which never completes TLS handshakes.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	atk := NewAttacker(
+		TLSHandshakeTimeout(100*time.Millisecond),
+		ResponseHeaderTimeout(100*time.Millisecond),
+		BodyTimeout(100*time.Millisecond),
+	)
+
+	for _, tc := range []struct {
+		url  string
+		want error
+	}{
+		{"https://" + ln.Addr().String(), ErrTLSHandshakeTimeout},
+		{server.URL + "/header", ErrResponseHeaderTimeout},
+		{server.URL + "/body", ErrBodyTimeout},
+	} {
+		tr := NewStaticTargeter(Target{Method: "GET", URL: tc.url})
+		if res := atk.hit(tr, "", 0); !strings.HasPrefix(res.Error, tc.want.Error()+": ") {
+			t.Errorf("%s: got error %q, want prefix %q", tc.url, res.Error, tc.want)
+		}
+	}
+
+	tr := NewStaticTargeter(Target{Method: "GET", URL: server.URL})
+	if res := atk.hit(tr, "", 0); res.Error != "" {
+		t.Errorf("got error %q, want none", res.Error)
+	}
+
+	err = timeoutError(&net.OpError{Op: "dial", Net: "tcp", Err: timeoutErr{}})
+	if !strings.HasPrefix(err.Error(), ErrDialTimeout.Error()+": ") {
+		t.Errorf("got error %q, want prefix %q", err, ErrDialTimeout)
+	}
+}
+
+func TestDeadline(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(time.Second)
+		}
+	}))
+	defer server.Close()
+
+	atk := NewAttacker(Deadline(100 * time.Millisecond))
+
+	tr := NewStaticTargeter(Target{Method: "GET", URL: server.URL + "/slow"})
+	if res := atk.hit(tr, "", 0); !strings.HasPrefix(res.Error, ErrSLAMiss.Error()+": ") {
+		t.Errorf("got error %q, want prefix %q", res.Error, ErrSLAMiss)
+	}
+
+	tr = NewStaticTargeter(Target{Method: "GET", URL: server.URL})
+	if res := atk.hit(tr, "", 0); res.Error != "" {
+		t.Errorf("got error %q, want none", res.Error)
+	}
+}
+
+func TestTotalTimeout(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		if r.URL.Path == "/stream" {
+			time.Sleep(time.Second)
+		}
+	}))
+	defer server.Close()
+
+	atk := NewAttacker(TotalTimeout(100 * time.Millisecond))
+
+	tr := NewStaticTargeter(Target{Method: "GET", URL: server.URL + "/stream"})
+	if res := atk.hit(tr, "", 0); !strings.HasPrefix(res.Error, ErrRequestTimeout.Error()+": ") {
+		t.Errorf("got error %q, want prefix %q", res.Error, ErrRequestTimeout)
+	}
+
+	tr = NewStaticTargeter(Target{Method: "GET", URL: server.URL})
+	if res := atk.hit(tr, "", 0); res.Error != "" {
+		t.Errorf("got error %q, want none", res.Error)
+	}
+}
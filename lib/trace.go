@@ -0,0 +1,82 @@
+package vegeta
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// TraceContext returns a functional option which makes an Attacker send a
+// W3C Trace Context traceparent header with a fresh, sampled trace in each
+// of its requests, and record the trace ID in the TraceID field of Results,
+// so that slow hits can be looked up in a distributed tracing backend.
+func TraceContext(enabled bool) func(*Attacker) {
+	return func(a *Attacker) { a.trace.w3c = enabled }
+}
+
+// B3Propagation returns a functional option which makes an Attacker send
+// Zipkin B3 headers with a fresh, sampled trace in each of its requests, and
+// record the trace ID in the TraceID field of Results. Along with
+// TraceContext, both formats carry the same trace.
+func B3Propagation(enabled bool) func(*Attacker) {
+	return func(a *Attacker) { a.trace.b3 = enabled }
+}
+
+// tracing configures the trace headers of an Attacker's requests.
+type tracing struct {
+	w3c bool
+	b3  bool
+}
+
+// inject sets the trace headers of a new trace in the given header,
+// returning the trace ID, or an empty string if tracing is disabled.
+func (t tracing) inject(hdr http.Header) (string, error) {
+	if !t.w3c && !t.b3 {
+		return "", nil
+	}
+
+	var ids [24]byte
+	if _, err := rand.Read(ids[:]); err != nil {
+		return "", err
+	}
+	trace, span := hex.EncodeToString(ids[:16]), hex.EncodeToString(ids[16:])
+
+	if t.w3c {
+		hdr.Set("Traceparent", "00-"+trace+"-"+span+"-01")
+	}
+
+	if t.b3 {
+		hdr.Set("X-B3-TraceId", trace)
+		hdr.Set("X-B3-SpanId", span)
+		hdr.Set("X-B3-Sampled", "1")
+	}
+
+	return trace, nil
+}
+
+// RequestIDs returns a functional option which makes an Attacker send a fresh
+// random UUID in the given headers of each of its requests, e.g.
+// X-Request-ID or Idempotency-Key, and record it in the RequestID field of
+// Results, to correlate them exactly with server side logs.
+func RequestIDs(headers ...string) func(*Attacker) {
+	return func(a *Attacker) { a.ids = headers }
+}
+
+// injectID sets a new request ID in the given headers of hdr, returning it,
+// or an empty string if there are no such headers.
+func injectID(hdr http.Header, headers []string) (string, error) {
+	if len(headers) == 0 {
+		return "", nil
+	}
+
+	id, err := templateUUID()
+	if err != nil {
+		return "", err
+	}
+
+	for _, h := range headers {
+		hdr.Set(h, id)
+	}
+
+	return id, nil
+}
@@ -0,0 +1,89 @@
+package vegeta
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+func TestTraceHeaders(t *testing.T) {
+	t.Parallel()
+
+	var hdr http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		hdr = r.Header
+	}))
+	defer server.Close()
+
+	traceparent := regexp.MustCompile(`^00-([0-9a-f]{32})-[0-9a-f]{16}-01$`)
+	tr := NewStaticTargeter(Target{Method: "GET", URL: server.URL})
+
+	for _, tc := range []struct {
+		w3c, b3 bool
+	}{
+		{false, false},
+		{true, false},
+		{false, true},
+		{true, true},
+	} {
+		atk := NewAttacker(TraceContext(tc.w3c), B3Propagation(tc.b3))
+		res := atk.hit(tr, "", 0)
+		if res.Error != "" {
+			t.Fatal(res.Error)
+		}
+
+		if !tc.w3c && !tc.b3 {
+			if res.TraceID != "" || hdr.Get("Traceparent") != "" || hdr.Get("X-B3-TraceId") != "" {
+				t.Errorf("got trace %q with tracing disabled", res.TraceID)
+			}
+			continue
+		}
+
+		if len(res.TraceID) != 32 {
+			t.Errorf("%+v: got trace ID %q", tc, res.TraceID)
+		}
+
+		if m := traceparent.FindStringSubmatch(hdr.Get("Traceparent")); tc.w3c && (m == nil || m[1] != res.TraceID) {
+			t.Errorf("%+v: got traceparent %q, want trace %s", tc, hdr.Get("Traceparent"), res.TraceID)
+		}
+
+		if got := hdr.Get("X-B3-TraceId"); tc.b3 && (got != res.TraceID || len(hdr.Get("X-B3-SpanId")) != 16 || hdr.Get("X-B3-Sampled") != "1") {
+			t.Errorf("%+v: got B3 headers %v, want trace %s", tc, hdr, res.TraceID)
+		}
+	}
+}
+
+func TestRequestIDs(t *testing.T) {
+	t.Parallel()
+
+	var hdr http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		hdr = r.Header
+	}))
+	defer server.Close()
+
+	tr := NewStaticTargeter(Target{Method: "POST", URL: server.URL})
+	atk := NewAttacker(RequestIDs("X-Request-ID", "Idempotency-Key"), Workers(1))
+
+	seen := map[string]bool{}
+	for i := 0; i < 3; i++ {
+		res := atk.hit(tr, "", 0)
+		if res.Error != "" {
+			t.Fatal(res.Error)
+		}
+
+		if len(res.RequestID) != 36 || seen[res.RequestID] {
+			t.Errorf("got request ID %q, want a new UUID", res.RequestID)
+		}
+		seen[res.RequestID] = true
+
+		if hdr.Get("X-Request-ID") != res.RequestID || hdr.Get("Idempotency-Key") != res.RequestID {
+			t.Errorf("got headers %v, want request ID %s", hdr, res.RequestID)
+		}
+	}
+
+	if res := NewAttacker().hit(tr, "", 0); res.RequestID != "" || hdr.Get("X-Request-ID") != "" {
+		t.Errorf("got request ID %q without request IDs", res.RequestID)
+	}
+}
@@ -0,0 +1,44 @@
+package vegeta
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+)
+
+// InsecureSkipVerify returns a functional option which sets whether an
+// Attacker skips the verification of the TLS certificates of targets. It
+// does by default, as DefaultTLSConfig does, so verifying them must be
+// opted into.
+func InsecureSkipVerify(skip bool) func(*Attacker) {
+	return func(a *Attacker) {
+		a.tlsConfig(func(c *tls.Config) { c.InsecureSkipVerify = skip })
+	}
+}
+
+// RootCAs returns a functional option which sets the root certificate
+// authorities an Attacker verifies the TLS certificates of targets with,
+// instead of those of the system, and enables their verification.
+func RootCAs(pool *x509.CertPool) func(*Attacker) {
+	return func(a *Attacker) {
+		a.tlsConfig(func(c *tls.Config) {
+			c.RootCAs = pool
+			c.InsecureSkipVerify = false
+		})
+	}
+}
+
+// tlsConfig sets the Attacker's TLS config to a copy modified by the given
+// function, so that shared configs such as DefaultTLSConfig are left as is.
+func (a *Attacker) tlsConfig(modify func(*tls.Config)) {
+	tr := a.transport()
+	if tr == nil {
+		return
+	}
+
+	c := tr.TLSClientConfig.Clone()
+	if c == nil {
+		c = &tls.Config{}
+	}
+	modify(c)
+	tr.TLSClientConfig = c
+}
@@ -0,0 +1,40 @@
+package vegeta
+
+import (
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestVerification(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer server.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+
+	tr := NewStaticTargeter(Target{Method: "GET", URL: server.URL})
+	for _, tc := range []struct {
+		opts []func(*Attacker)
+		err  string
+	}{
+		{nil, ""},
+		{[]func(*Attacker){InsecureSkipVerify(false)}, "certificate"},
+		{[]func(*Attacker){RootCAs(pool)}, ""},
+		{[]func(*Attacker){RootCAs(x509.NewCertPool())}, "certificate"},
+		{[]func(*Attacker){RootCAs(x509.NewCertPool()), InsecureSkipVerify(true)}, ""},
+	} {
+		res := NewAttacker(tc.opts...).hit(tr, "", 0)
+		if tc.err == "" && res.Error != "" || !strings.Contains(res.Error, tc.err) {
+			t.Errorf("got error %q, want one containing %q", res.Error, tc.err)
+		}
+	}
+
+	if DefaultTLSConfig.RootCAs != nil || !DefaultTLSConfig.InsecureSkipVerify {
+		t.Error("DefaultTLSConfig was modified")
+	}
+}
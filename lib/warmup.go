@@ -0,0 +1,72 @@
+package vegeta
+
+import (
+	"io"
+	"io/ioutil"
+	"sync"
+	"sync/atomic"
+)
+
+// Warmup returns a functional option which makes an Attacker establish the
+// given number of connections to the host of the first Target of its attacks
+// before their first hit, by sending it as many concurrent requests with the
+// given method, HEAD if empty, whose results are discarded. This keeps the
+// setup of connections, TLS handshakes included, out of the latencies of the
+// first seconds of attacks, unless cold starts are what's measured. Only as
+// many connections as set by Connections are kept idle, and HTTP/2 requests
+// share a single connection.
+func Warmup(conns int, method string) func(*Attacker) {
+	if method == "" {
+		method = "HEAD"
+	}
+	return func(a *Attacker) { a.warmup = warmup{conns: conns, method: method} }
+}
+
+// warmup configures the connections established before attacks.
+type warmup struct {
+	conns  int
+	method string
+}
+
+// warm establishes the warmup connections to the host of the first Target of
+// tr and returns a Targeter of all the Targets of tr.
+func (a *Attacker) warm(tr Targeter) Targeter {
+	if a.warmup.conns <= 0 {
+		return tr
+	}
+
+	var first Target
+	if err := tr(&first); err != nil {
+		// The error is returned again by the first hit.
+		return tr
+	}
+
+	tgt := first
+	tgt.Method, tgt.Body = a.warmup.method, nil
+
+	var wg sync.WaitGroup
+	for i := 0; i < a.warmup.conns; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, err := tgt.Request()
+			if err != nil {
+				return
+			}
+			if r, err := a.client.Do(req); err == nil {
+				io.Copy(ioutil.Discard, r.Body)
+				r.Body.Close()
+			}
+		}()
+	}
+	wg.Wait()
+
+	var taken int32
+	return func(t *Target) error {
+		if atomic.CompareAndSwapInt32(&taken, 0, 1) {
+			*t = first
+			return nil
+		}
+		return tr(t)
+	}
+}
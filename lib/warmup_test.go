@@ -0,0 +1,53 @@
+package vegeta
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWarmup(t *testing.T) {
+	t.Parallel()
+
+	var conns, heads, gets int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		if r.Method == "HEAD" {
+			atomic.AddInt32(&heads, 1)
+			// Keeps the warmup requests concurrent.
+			time.Sleep(50 * time.Millisecond)
+		} else {
+			atomic.AddInt32(&gets, 1)
+		}
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	atk := NewAttacker(Warmup(5, ""))
+	tr := NewStaticTargeter(Target{Method: "GET", URL: server.URL})
+	for res := range atk.Attack(tr, 5, time.Second, "") {
+		if res.Error != "" {
+			t.Fatal(res.Error)
+		}
+	}
+
+	if n := atomic.LoadInt32(&heads); n != 5 {
+		t.Errorf("got %d warmup requests, want 5", n)
+	}
+
+	if n := atomic.LoadInt32(&gets); n != 5 {
+		t.Errorf("got %d hits, want 5", n)
+	}
+
+	// Hits reuse the warm connections.
+	if n := atomic.LoadInt32(&conns); n != 5 {
+		t.Errorf("got %d connections, want 5", n)
+	}
+}
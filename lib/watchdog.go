@@ -0,0 +1,44 @@
+package vegeta
+
+import "time"
+
+// Watchdog flags when the throughput of an attack drops below a fraction of
+// its expected rate for a number of consecutive windows, which surfaces
+// attacker or network bottlenecks early. It isn't safe for concurrent use.
+type Watchdog struct {
+	min     float64
+	windows int
+	hits    uint64
+	low     int
+}
+
+// NewWatchdog returns a new Watchdog which expects at least the given
+// fraction of the given rate, in hits per second, and trips after the given
+// number of consecutive windows below it.
+func NewWatchdog(rate, fraction float64, windows int) *Watchdog {
+	if windows < 1 {
+		windows = 1
+	}
+	return &Watchdog{min: rate * fraction, windows: windows}
+}
+
+// Add implements the Add method of the Report interface by counting the hit
+// of the given Result in the current window.
+func (w *Watchdog) Add(*Result) { w.hits++ }
+
+// Check ends the current window, which lasted for the given duration, and
+// returns its achieved rate in hits per second along with whether it was the
+// last of enough consecutive windows below the expected rate to trip the
+// Watchdog. It stays tripped until a window achieves the expected rate.
+func (w *Watchdog) Check(window time.Duration) (rate float64, tripped bool) {
+	rate = float64(w.hits) / window.Seconds()
+	w.hits = 0
+
+	if rate < w.min {
+		w.low++
+	} else {
+		w.low = 0
+	}
+
+	return rate, w.low >= w.windows
+}
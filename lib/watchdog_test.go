@@ -0,0 +1,42 @@
+package vegeta
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWatchdog(t *testing.T) {
+	t.Parallel()
+
+	w := NewWatchdog(100, 0.9, 2)
+	for i, tc := range []struct {
+		hits    int
+		rate    float64
+		tripped bool
+	}{
+		{100, 100, false},
+		{80, 80, false},
+		{95, 95, false},
+		{80, 80, false},
+		{0, 0, true},
+		{10, 10, true},
+		{90, 90, false},
+	} {
+		for j := 0; j < tc.hits; j++ {
+			w.Add(&Result{})
+		}
+
+		if rate, tripped := w.Check(time.Second); rate != tc.rate || tripped != tc.tripped {
+			t.Errorf("window %d: got rate %v, tripped %v, want %v, %v", i, rate, tripped, tc.rate, tc.tripped)
+		}
+	}
+
+	w = NewWatchdog(100, 0.5, 1)
+	for j := 0; j < 40; j++ {
+		w.Add(&Result{})
+	}
+
+	if rate, tripped := w.Check(500 * time.Millisecond); rate != 80 || tripped {
+		t.Errorf("got rate %v, tripped %v, want 80, false", rate, tripped)
+	}
+}
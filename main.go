@@ -8,13 +8,19 @@ import (
 	"runtime"
 	"runtime/pprof"
 	"strings"
+
+	vegeta "github.com/FractalBlockchain/vegeta/lib"
 )
 
 func main() {
 	commands := map[string]command{
-		"attack": attackCmd(),
-		"report": reportCmd(),
-		"dump":   dumpCmd(),
+		"attack":  attackCmd(),
+		"report":  reportCmd(),
+		"dump":    dumpCmd(),
+		"dns":     dnsCmd(),
+		"openapi": openapiCmd(),
+		"probe":   probeCmd(),
+		"record":  recordCmd(),
 	}
 
 	fs := flag.NewFlagSet("vegeta", flag.ExitOnError)
@@ -78,6 +84,11 @@ func main() {
 	if cmd, ok := commands[args[0]]; !ok {
 		log.Fatalf("Unknown command: %s", args[0])
 	} else if err := cmd.fn(args[1:]); err != nil {
+		// Violated thresholds exit apart from failures to run commands.
+		if _, ok := err.(*vegeta.ThresholdError); ok {
+			log.Print(err)
+			os.Exit(2)
+		}
 		log.Fatal(err)
 	}
 }
@@ -92,6 +103,10 @@ examples:
   vegeta report -inputs=results.bin -reporter=json > metrics.json
   cat results.bin | vegeta report -reporter=plot > plot.html
   cat results.bin | vegeta report -reporter="hist[0,100ms,200ms,300ms]"
+  vegeta openapi -spec=openapi.json -base=http://localhost:8080 > targets.txt
+  echo "example.com AAAA" | vegeta dns -server=8.8.8.8:53 -duration=5s | vegeta report
+  echo "localhost:80" | vegeta probe -mode=tcp -rate=100 -duration=5s | vegeta report
+  vegeta record -upstream=http://localhost:8080 -listen=:9090 > targets.txt
 `
 
 type command struct {
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"path/filepath"
+
+	vegeta "github.com/FractalBlockchain/vegeta/lib"
+)
+
+func openapiCmd() command {
+	fs := flag.NewFlagSet("vegeta openapi", flag.ExitOnError)
+	opts := &openapiOpts{}
+
+	fs.StringVar(&opts.specf, "spec", "stdin", "OpenAPI or Swagger JSON or YAML spec file")
+	fs.StringVar(&opts.base, "base", "", "Base URL prepended to every path [default: first server in spec]")
+	fs.Var(&opts.ops, "operations", "Operation IDs or \"METHOD /path\" to generate targets for (comma separated list) [default: all]")
+	fs.StringVar(&opts.outputf, "output", "stdout", "Output targets file")
+	fs.StringVar(&opts.bodies, "bodies", ".", "Directory to write request body files to")
+
+	return command{fs, func(args []string) error {
+		fs.Parse(args)
+		return openapi(opts)
+	}}
+}
+
+// openapiOpts aggregates the openapi function command options
+type openapiOpts struct {
+	specf   string
+	base    string
+	ops     csl
+	outputf string
+	bodies  string
+}
+
+// openapi generates a targets file out of an OpenAPI spec.
+func openapi(opts *openapiOpts) error {
+	spec, err := file(opts.specf, false)
+	if err != nil {
+		return fmt.Errorf("error opening %s: %s", opts.specf, err)
+	}
+	defer spec.Close()
+
+	tgts, err := vegeta.NewOpenAPITargets(spec, opts.base, opts.ops...)
+	if err != nil {
+		return err
+	}
+
+	out, err := file(opts.outputf, true)
+	if err != nil {
+		return fmt.Errorf("error opening %s: %s", opts.outputf, err)
+	}
+	defer out.Close()
+
+	w := bufio.NewWriter(out)
+	for i := range tgts {
+		name := filepath.Join(opts.bodies, fmt.Sprintf("body-%d.json", i))
+		if err = writeTarget(w, &tgts[i], name); err != nil {
+			return err
+		}
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	vegeta "github.com/FractalBlockchain/vegeta/lib"
+)
+
+func probeCmd() command {
+	fs := flag.NewFlagSet("vegeta probe", flag.ExitOnError)
+	opts := &probeOpts{}
+
+	fs.StringVar(&opts.name, "name", "", "Attack name")
+	fs.StringVar(&opts.targetsf, "targets", "stdin", "Addresses file with one host[:port] per line")
+	fs.StringVar(&opts.outputf, "output", "stdout", "Output file")
+	fs.StringVar(&opts.mode, "mode", vegeta.ProbeTCP, "Probe mode [tcp, icmp]")
+	fs.BoolVar(&opts.privileged, "privileged", false, "Use raw sockets for ICMP probes")
+	fs.DurationVar(&opts.duration, "duration", 0, "Duration of the test [0 = forever]")
+	fs.DurationVar(&opts.timeout, "timeout", vegeta.DefaultTimeout, "Probes timeout")
+	fs.Uint64Var(&opts.rate, "rate", 50, "Probes per second")
+	fs.Uint64Var(&opts.workers, "workers", vegeta.DefaultWorkers, "Initial number of workers")
+
+	return command{fs, func(args []string) error {
+		fs.Parse(args)
+		return probe(opts)
+	}}
+}
+
+// probeOpts aggregates the probe function command options
+type probeOpts struct {
+	name       string
+	targetsf   string
+	outputf    string
+	mode       string
+	privileged bool
+	duration   time.Duration
+	timeout    time.Duration
+	rate       uint64
+	workers    uint64
+}
+
+// probe validates the probe arguments, sets up the required resources,
+// launches the probes and writes the results
+func probe(opts *probeOpts) error {
+	if opts.rate == 0 {
+		return errZeroRate
+	}
+
+	switch opts.mode {
+	case vegeta.ProbeTCP, vegeta.ProbeICMP:
+	default:
+		return fmt.Errorf("unsupported mode: %s", opts.mode)
+	}
+
+	src, err := file(opts.targetsf, false)
+	if err != nil {
+		return fmt.Errorf("error opening %s: %s", opts.targetsf, err)
+	}
+	defer src.Close()
+
+	tr, err := vegeta.NewProbeTargeter(src)
+	if err != nil {
+		return err
+	}
+
+	out, err := file(opts.outputf, true)
+	if err != nil {
+		return fmt.Errorf("error opening %s: %s", opts.outputf, err)
+	}
+	defer out.Close()
+
+	atk := vegeta.NewProbeAttacker(
+		vegeta.ProbeMode(opts.mode),
+		vegeta.ProbeTimeout(opts.timeout),
+		vegeta.ProbeWorkers(opts.workers),
+		vegeta.ProbePrivileged(opts.privileged),
+	)
+
+	res := atk.Attack(tr, opts.rate, opts.duration, opts.name)
+	enc := vegeta.NewEncoder(out)
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt)
+
+	for {
+		select {
+		case <-sig:
+			atk.Stop()
+			return nil
+		case r, ok := <-res:
+			if !ok {
+				return nil
+			}
+			if err = enc.Encode(r); err != nil {
+				return err
+			}
+		}
+	}
+}
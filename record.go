@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+
+	vegeta "github.com/FractalBlockchain/vegeta/lib"
+)
+
+func recordCmd() command {
+	fs := flag.NewFlagSet("vegeta record", flag.ExitOnError)
+	opts := &recordOpts{}
+
+	fs.StringVar(&opts.addr, "listen", "localhost:8080", "Proxy listen address")
+	fs.StringVar(&opts.upstream, "upstream", "", "Upstream URL to reverse proxy requests to [default: forward proxy]")
+	fs.StringVar(&opts.outputf, "output", "stdout", "Output targets file")
+	fs.StringVar(&opts.bodies, "bodies", ".", "Directory to write request body files to")
+
+	return command{fs, func(args []string) error {
+		fs.Parse(args)
+		return record(opts)
+	}}
+}
+
+// recordOpts aggregates the record function command options
+type recordOpts struct {
+	addr     string
+	upstream string
+	outputf  string
+	bodies   string
+}
+
+// record runs a proxy which writes every request it sees to a targets file
+// until interrupted.
+func record(opts *recordOpts) error {
+	var upstream *url.URL
+	if opts.upstream != "" {
+		u, err := url.Parse(opts.upstream)
+		if err != nil {
+			return fmt.Errorf("bad upstream URL: %s", err)
+		} else if !u.IsAbs() || u.Host == "" {
+			return fmt.Errorf("bad upstream URL: %s", opts.upstream)
+		}
+		upstream = u
+	}
+
+	out, err := file(opts.outputf, true)
+	if err != nil {
+		return fmt.Errorf("error opening %s: %s", opts.outputf, err)
+	}
+	defer out.Close()
+
+	w := bufio.NewWriter(out)
+	defer w.Flush()
+
+	n := 0
+	rec := vegeta.NewRecorder(upstream, func(tgt *vegeta.Target) error {
+		n++
+		name := filepath.Join(opts.bodies, fmt.Sprintf("body-%d.bin", n))
+		if err := writeTarget(w, tgt, name); err != nil {
+			return err
+		}
+		return w.Flush()
+	})
+
+	srv := &http.Server{Addr: opts.addr, Handler: rec}
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt)
+
+	go func() {
+		<-sig
+		srv.Close()
+	}()
+
+	log.Printf("Recording requests proxied on %s", opts.addr)
+	if err = srv.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+
+	return nil
+}
@@ -6,62 +6,186 @@ import (
 	"io"
 	"os"
 	"os/signal"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	vegeta "github.com/FractalBlockchain/vegeta/lib"
 )
 
 func reportCmd() command {
 	fs := flag.NewFlagSet("vegeta report", flag.ExitOnError)
-	reporter := fs.String("reporter", "text", "Reporter [text, json, plot, hist[buckets]]")
-	inputs := fs.String("inputs", "stdin", "Input files (comma separated)")
-	output := fs.String("output", "stdout", "Output file")
+	opts := &reportOpts{}
+
+	fs.StringVar(&opts.reporter, "reporter", "text", "Reporter [text, json, junit, plot, uniq, hist[buckets], time[interval], hgrm, html[interval], markdown[interval], csv]")
+	fs.StringVar(&opts.inputs, "inputs", "stdin", "Input files (comma separated)")
+	fs.StringVar(&opts.output, "output", "stdout", "Output file")
+	fs.StringVar(&opts.timezone, "timezone", "UTC", "Time zone of reported times, e.g. Local or Europe/Berlin")
+	fs.StringVar(&opts.origin, "time-origin", "attack", "Origin of plotted times [attack, wall]")
+	fs.StringVar(&opts.percentiles, "percentiles", "", "Latency percentiles reported instead of the fixed ones (comma separated), e.g. 50,90,99.9,99.99")
+	fs.DurationVar(&opts.apdexT, "apdex-t", 0, "Target latency of the reported Apdex score (0 = disabled)")
+	fs.StringVar(&opts.slos, "slo", "", "Service level objectives to report as met or missed (comma separated), e.g. p99<500ms,success>99.9%")
+	fs.StringVar(&opts.failIf, "fail-if", "", "Fail with exit code 2 if any of the conditions holds (comma separated), e.g. p99>500ms,success<99.9%")
+	fs.StringVar(&opts.groupBy, "group-by", "", "Report the metrics of each group of results by [name, tag:<key>, method, path]")
+	fs.StringVar(&opts.compare, "compare", "", "Baseline input files (comma separated) to compare the inputs with")
+	fs.BoolVar(&opts.corrected, "correct-omission", false, "Report latencies from the intended send times of hits, correcting coordinated omission")
+
 	return command{fs, func(args []string) error {
 		fs.Parse(args)
-		return report(*reporter, *inputs, *output)
+		// Input files can be given as arguments too, e.g. after -compare.
+		if fs.NArg() > 0 {
+			opts.inputs = strings.Join(fs.Args(), ",")
+		}
+		return report(opts)
 	}}
 }
 
+// reportOpts aggregates the report function command options
+type reportOpts struct {
+	reporter    string
+	inputs      string
+	output      string
+	timezone    string
+	origin      string
+	percentiles string
+	apdexT      time.Duration
+	slos        string
+	failIf      string
+	groupBy     string
+	compare     string
+	corrected   bool
+}
+
 // report validates the report arguments, sets up the required resources
 // and writes the report
-func report(reporter, inputs, output string) error {
-	if len(reporter) < 4 {
-		return fmt.Errorf("bad reporter: %s", reporter)
+func report(opts *reportOpts) error {
+	reporter := opts.reporter
+	// Reporters are told apart by their first four letters, e.g. hist[...].
+	kind := reporter
+	if len(kind) > 4 {
+		kind = kind[:4]
 	}
 
-	files := strings.Split(inputs, ",")
-	srcs := make([]vegeta.Decoder, len(files))
-	for i, f := range files {
-		in, err := file(f, false)
-		if err != nil {
+	var by vegeta.GroupBy
+	if opts.groupBy != "" {
+		if err := by.UnmarshalText([]byte(opts.groupBy)); err != nil {
 			return err
 		}
-		defer in.Close()
-		srcs[i] = vegeta.NewDecoder(in)
+		if reporter != "text" && reporter != "json" && reporter != "junit" && reporter != "csv" {
+			return fmt.Errorf("bad reporter with -group-by: %s", reporter)
+		}
+	}
+
+	if opts.compare != "" && (by != "" || reporter != "text" && reporter != "json") {
+		return fmt.Errorf("bad reporter with -compare: %s", reporter)
+	}
+
+	ps, err := parsePercentiles(opts.percentiles)
+	if err != nil {
+		return err
+	}
+
+	var objectives []vegeta.Objective
+	if opts.slos != "" {
+		if objectives, err = vegeta.ParseObjectives(opts.slos); err != nil {
+			return err
+		}
+	}
+
+	var conditions []vegeta.Objective
+	if opts.failIf != "" {
+		if conditions, err = vegeta.ParseObjectives(opts.failIf); err != nil {
+			return err
+		}
+		if reporter != "text" && reporter != "json" && reporter != "junit" {
+			return fmt.Errorf("bad reporter with -fail-if: %s", reporter)
+		}
+	}
+
+	loc, err := time.LoadLocation(opts.timezone)
+	if err != nil {
+		return fmt.Errorf("bad timezone: %s", err)
 	}
-	dec := vegeta.NewRoundRobinDecoder(srcs...)
 
-	out, err := file(output, true)
+	ax := vegeta.TimeAxis{Location: loc}
+	switch opts.origin {
+	case "attack":
+	case "wall":
+		ax.WallClock = true
+	default:
+		return fmt.Errorf("bad time origin: %s", opts.origin)
+	}
+
+	sigch := make(chan os.Signal, 1)
+	signal.Notify(sigch, os.Interrupt)
+
+	var baseline *vegeta.Metrics
+	if opts.compare != "" {
+		baseline = &vegeta.Metrics{Location: loc, Percentiles: ps, ApdexT: opts.apdexT}
+		if err = decode(opts.compare, corrected(baseline, opts.corrected), sigch); err != nil {
+			return err
+		}
+		baseline.Close()
+	}
+
+	out, err := file(opts.output, true)
 	if err != nil {
 		return err
 	}
 	defer out.Close()
 
+	// Every reporter's times are in the same time zone.
+	metrics := func() *vegeta.Metrics {
+		return &vegeta.Metrics{Location: loc, Percentiles: ps, ApdexT: opts.apdexT, Objectives: objectives}
+	}
+	groups := func() *vegeta.GroupedMetrics {
+		return &vegeta.GroupedMetrics{By: by, Percentiles: ps, Location: loc, ApdexT: opts.apdexT, Objectives: objectives}
+	}
+
 	var (
 		rep    vegeta.Reporter
 		report vegeta.Report
+		check  func() error
 	)
 
-	switch reporter[:4] {
+	switch kind {
 	case "text":
-		var m vegeta.Metrics
-		rep, report = vegeta.NewTextReporter(&m), &m
+		if by != "" {
+			g := groups()
+			rep, report, check = vegeta.NewGroupedTextReporter(g), g, checkGroups(g, conditions)
+			break
+		}
+		m := metrics()
+		rep, report, check = vegeta.NewTextReporter(m), m, checkMetrics(m, conditions)
+		if baseline != nil {
+			rep = vegeta.NewComparisonReporter(&vegeta.Comparison{Baseline: baseline, Current: m})
+		}
 	case "json":
-		var m vegeta.Metrics
-		rep, report = vegeta.NewJSONReporter(&m), &m
+		if by != "" {
+			g := groups()
+			rep, report, check = vegeta.NewGroupedJSONReporter(g), g, checkGroups(g, conditions)
+			break
+		}
+		m := metrics()
+		rep, report, check = vegeta.NewJSONReporter(m), m, checkMetrics(m, conditions)
+		if baseline != nil {
+			rep = vegeta.NewComparisonJSONReporter(&vegeta.Comparison{Baseline: baseline, Current: m})
+		}
+	case "juni":
+		if by != "" {
+			g := groups()
+			rep, report, check = vegeta.NewGroupedJUnitReporter(g, conditions), g, checkGroups(g, conditions)
+			break
+		}
+		m := metrics()
+		rep, report, check = vegeta.NewJUnitReporter(m, conditions), m, checkMetrics(m, conditions)
 	case "plot":
 		var rs vegeta.Results
-		rep, report = vegeta.NewPlotReporter("Vegeta Plot", &rs), &rs
+		rep, report = vegeta.NewPlotReporterWithAxis("Vegeta Plot", &rs, ax), &rs
+	case "uniq":
+		var u vegeta.UniqueBodies
+		rep, report = vegeta.NewUniqueBodiesReporter(&u), &u
 	case "hist":
 		if len(reporter) < 6 {
 			return fmt.Errorf("bad buckets: '%s'", reporter[4:])
@@ -71,33 +195,186 @@ func report(reporter, inputs, output string) error {
 			return err
 		}
 		rep, report = vegeta.NewHistogramReporter(&hist), &hist
+	case "time":
+		tl := vegeta.Timeline{Percentiles: ps}
+		if len(reporter) > 4 {
+			if err := tl.UnmarshalText([]byte(reporter[4:])); err != nil {
+				return err
+			}
+		}
+		rep, report = vegeta.NewTimelineReporter(&tl, ax), &tl
+	case "csv":
+		if by != "" {
+			g := groups()
+			rep, report = vegeta.NewGroupedCSVReporter(g), g
+			break
+		}
+		m := metrics()
+		rep, report = vegeta.NewCSVReporter(m), m
+	case "hgrm":
+		var m vegeta.Metrics
+		rep, report = vegeta.NewHGRMReporter(&m), &m
+	case "html":
+		h := vegeta.HTMLReport{
+			Metrics:  vegeta.Metrics{Location: loc, Percentiles: ps, ApdexT: opts.apdexT, Objectives: objectives},
+			Timeline: vegeta.Timeline{Percentiles: ps},
+		}
+		if len(reporter) > 4 {
+			if err := h.Timeline.UnmarshalText([]byte(reporter[4:])); err != nil {
+				return err
+			}
+		}
+		rep, report = vegeta.NewHTMLReporter("Vegeta Report", &h, ax), &h
+	case "mark":
+		if !strings.HasPrefix(reporter, "markdown") {
+			return fmt.Errorf("unknown reporter: %q", reporter)
+		}
+		m := metrics()
+		rep, report = vegeta.NewMarkdownReporter(m, nil), m
+		// Sparklines of the latencies over time are opt-in by interval.
+		if len(reporter) > len("markdown") {
+			tl := vegeta.Timeline{Percentiles: ps}
+			if err := tl.UnmarshalText([]byte(reporter[len("markdown"):])); err != nil {
+				return err
+			}
+			rep, report = vegeta.NewMarkdownReporter(m, &tl), reports{m, &tl}
+		}
 	default:
 		return fmt.Errorf("unknown reporter: %q", reporter)
 	}
 
-	sigch := make(chan os.Signal, 1)
-	signal.Notify(sigch, os.Interrupt)
+	if err = decode(opts.inputs, corrected(report, opts.corrected), sigch); err != nil {
+		return err
+	}
+
+	if c, ok := report.(vegeta.Closer); ok {
+		c.Close()
+	}
+
+	if err = rep.Report(out); err != nil || check == nil {
+		return err
+	}
+
+	return check()
+}
+
+// reports is a Report which adds Results to each of its Reports.
+type reports []vegeta.Report
+
+// Add implements the vegeta.Report interface.
+func (rs reports) Add(r *vegeta.Result) {
+	for _, report := range rs {
+		report.Add(r)
+	}
+}
+
+// Close implements the vegeta.Closer interface.
+func (rs reports) Close() {
+	for _, report := range rs {
+		if c, ok := report.(vegeta.Closer); ok {
+			c.Close()
+		}
+	}
+}
+
+// corrected returns the given Report wrapped in a vegeta.CorrectedReport if
+// coordinated omission is to be corrected.
+func corrected(report vegeta.Report, correct bool) vegeta.Report {
+	if correct {
+		return vegeta.CorrectedReport{Report: report}
+	}
+	return report
+}
+
+// decode decodes the Results of the given comma separated input files into
+// the given Report, clock aligned, until they're over or an interrupt is
+// received on the given channel.
+func decode(inputs string, report vegeta.Report, sigch <-chan os.Signal) error {
+	files := strings.Split(inputs, ",")
+	srcs := make([]vegeta.Decoder, len(files))
+	for i, f := range files {
+		in, err := file(f, false)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+
+		rd, err := vegeta.NewDecompressor(in)
+		if err != nil {
+			return err
+		}
+		defer rd.Close()
+		srcs[i] = vegeta.NewDecoder(rd)
+	}
+	dec := vegeta.NewClockAlignedDecoder(vegeta.NewRoundRobinDecoder(srcs...))
 
-decode:
 	for {
 		select {
 		case <-sigch:
-			break decode
+			return nil
 		default:
 			var r vegeta.Result
-			if err = dec.Decode(&r); err != nil {
+			if err := dec.Decode(&r); err != nil {
 				if err == io.EOF {
-					break decode
+					return nil
 				}
 				return err
 			}
 			report.Add(&r)
 		}
 	}
+}
 
-	if c, ok := report.(vegeta.Closer); ok {
-		c.Close()
+// checkMetrics returns a function checking the given failure conditions
+// against the given Metrics once closed.
+func checkMetrics(m *vegeta.Metrics, conditions []vegeta.Objective) func() error {
+	return func() error { return vegeta.Check(m, conditions...) }
+}
+
+// checkGroups returns a function checking the given failure conditions
+// against the Metrics of each group of the given GroupedMetrics once closed.
+func checkGroups(g *vegeta.GroupedMetrics, conditions []vegeta.Objective) func() error {
+	return func() error {
+		keys := make([]string, 0, len(g.Groups))
+		for key := range g.Groups {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		var violated vegeta.ThresholdError
+		for _, key := range keys {
+			err, ok := vegeta.Check(g.Groups[key], conditions...).(*vegeta.ThresholdError)
+			if !ok {
+				continue
+			}
+			for _, v := range err.Violations {
+				v.Objective = key + ": " + v.Objective
+				violated.Violations = append(violated.Violations, v)
+			}
+		}
+
+		if len(violated.Violations) > 0 {
+			return &violated
+		}
+
+		return nil
+	}
+}
+
+// parsePercentiles parses the given comma separated latency percentiles.
+func parsePercentiles(s string) ([]float64, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	var ps []float64
+	for _, f := range strings.Split(s, ",") {
+		p, err := strconv.ParseFloat(strings.TrimSpace(f), 64)
+		if err != nil || p <= 0 || p > 100 {
+			return nil, fmt.Errorf("bad percentile: %q", f)
+		}
+		ps = append(ps, p)
 	}
 
-	return rep.Report(out)
+	return ps, nil
 }
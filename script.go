@@ -0,0 +1,204 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	vegeta "github.com/FractalBlockchain/vegeta/lib"
+	"github.com/dop251/goja"
+)
+
+// newScript returns a vegeta.Script running the given file, which is a
+// WebAssembly module if its extension is .wasm and JavaScript otherwise.
+func newScript(filename string) (vegeta.Script, error) {
+	if strings.EqualFold(filepath.Ext(filename), ".wasm") {
+		return newWASMScript(filename)
+	}
+	return newJSScript(filename)
+}
+
+// jsScript is a vegeta.Script which calls the before(target) and
+// after(result, response) functions defined by a JavaScript file, either of
+// which is optional. Since JavaScript runtimes can't be used concurrently,
+// each one is taken from a pool for the duration of a call so that global
+// state is kept per runtime rather than per attack.
+type jsScript struct {
+	prog *goja.Program
+	pool sync.Pool
+}
+
+// jsRuntime is a JavaScript runtime which ran a script's program.
+type jsRuntime struct {
+	vm            *goja.Runtime
+	before, after goja.Callable
+}
+
+// newJSScript returns a new jsScript running the given JavaScript file.
+func newJSScript(filename string) (*jsScript, error) {
+	src, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	prog, err := goja.Compile(filename, string(src), false)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &jsScript{prog: prog}
+	rt, err := s.runtime()
+	if err != nil {
+		return nil, err
+	}
+	s.pool.Put(rt)
+
+	return s, nil
+}
+
+func (s *jsScript) runtime() (*jsRuntime, error) {
+	if rt, ok := s.pool.Get().(*jsRuntime); ok {
+		return rt, nil
+	}
+
+	vm := goja.New()
+	if _, err := vm.RunProgram(s.prog); err != nil {
+		return nil, err
+	}
+
+	rt := &jsRuntime{vm: vm}
+	rt.before, _ = goja.AssertFunction(vm.Get("before"))
+	rt.after, _ = goja.AssertFunction(vm.Get("after"))
+
+	return rt, nil
+}
+
+// Before implements the vegeta.Script interface by passing the target as an
+// object with method, url, headers and body fields, which before may modify.
+// The body is a Uint8Array so that binary bodies are passed through intact,
+// and may be replaced by another Uint8Array, an ArrayBuffer or a string.
+func (s *jsScript) Before(tgt *vegeta.Target) error {
+	rt, err := s.runtime()
+	if err != nil {
+		return fmt.Errorf("script: %s", err)
+	}
+	defer s.pool.Put(rt)
+
+	if rt.before == nil {
+		return nil
+	}
+
+	obj := rt.vm.NewObject()
+	obj.Set("method", tgt.Method)
+	obj.Set("url", tgt.URL)
+	obj.Set("headers", jsHeaders(rt.vm, tgt.Header))
+
+	body, err := rt.vm.New(rt.vm.Get("Uint8Array"), rt.vm.ToValue(rt.vm.NewArrayBuffer(append([]byte(nil), tgt.Body...))))
+	if err != nil {
+		return fmt.Errorf("script: %s", err)
+	}
+	obj.Set("body", body)
+
+	if _, err = rt.before(goja.Undefined(), obj); err != nil {
+		return fmt.Errorf("script: %s", err)
+	}
+
+	tgt.Method = obj.Get("method").String()
+	tgt.URL = obj.Get("url").String()
+	if tgt.Body, err = jsBytes(obj.Get("body")); err != nil {
+		return err
+	}
+	tgt.Header = http.Header{}
+
+	hdr, ok := obj.Get("headers").Export().(map[string]interface{})
+	if !ok {
+		return errors.New("script: target headers aren't an object")
+	}
+
+	for k, v := range hdr {
+		switch vs := v.(type) {
+		case []interface{}:
+			for _, v := range vs {
+				tgt.Header.Add(k, fmt.Sprint(v))
+			}
+		default:
+			tgt.Header.Add(k, fmt.Sprint(vs))
+		}
+	}
+
+	return nil
+}
+
+// After implements the vegeta.Script interface by passing the result, with
+// its body as a string and latency in milliseconds, and the response's
+// status and headers. The hit fails if after returns false.
+func (s *jsScript) After(r *vegeta.Result, resp *http.Response) error {
+	rt, err := s.runtime()
+	if err != nil {
+		return fmt.Errorf("script: %s", err)
+	}
+	defer s.pool.Put(rt)
+
+	if rt.after == nil {
+		return nil
+	}
+
+	result := rt.vm.NewObject()
+	result.Set("seq", r.Seq)
+	result.Set("code", r.Code)
+	result.Set("latency", r.Latency.Seconds()*1000)
+	result.Set("bytes_in", r.BytesIn)
+	result.Set("bytes_out", r.BytesOut)
+	result.Set("error", r.Error)
+	result.Set("body", string(r.Body))
+	result.Set("method", r.Method)
+	result.Set("url", r.URL)
+
+	response := rt.vm.NewObject()
+	response.Set("status", resp.StatusCode)
+	response.Set("headers", jsHeaders(rt.vm, resp.Header))
+
+	ok, err := rt.after(goja.Undefined(), result, response)
+	if err != nil {
+		return fmt.Errorf("script: %s", err)
+	} else if ok.Equals(rt.vm.ToValue(false)) {
+		return errors.New("script: failed")
+	}
+
+	return nil
+}
+
+// jsBytes returns a copy of the bytes of the given Uint8Array, ArrayBuffer or
+// string, whose buffer a script may still modify.
+func jsBytes(v goja.Value) ([]byte, error) {
+	switch b := v.Export().(type) {
+	case []byte:
+		return append([]byte(nil), b...), nil
+	case goja.ArrayBuffer:
+		return append([]byte(nil), b.Bytes()...), nil
+	case string:
+		return []byte(b), nil
+	case nil:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("script: target body of type %T isn't a Uint8Array, an ArrayBuffer or a string", b)
+	}
+}
+
+// jsHeaders returns the given headers as a JavaScript object of arrays of
+// values.
+func jsHeaders(vm *goja.Runtime, hdr http.Header) *goja.Object {
+	obj := vm.NewObject()
+	for k, vs := range hdr {
+		values := make([]interface{}, len(vs))
+		for i, v := range vs {
+			values[i] = v
+		}
+		obj.Set(k, vm.NewArray(values...))
+	}
+	return obj
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	vegeta "github.com/FractalBlockchain/vegeta/lib"
+)
+
+// writeScript writes the given JavaScript source to a temporary file and
+// returns a jsScript running it.
+func writeScript(t *testing.T, src string) (*jsScript, error) {
+	t.Helper()
+
+	filename := filepath.Join(t.TempDir(), "script.js")
+	if err := ioutil.WriteFile(filename, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return newJSScript(filename)
+}
+
+func TestJSScript_Before(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name string
+		src  string
+		in   vegeta.Target
+		want vegeta.Target
+	}{
+		{
+			name: "no before",
+			src:  `function after() {}`,
+			in:   vegeta.Target{Method: "GET", URL: "http://a", Body: []byte("body")},
+			want: vegeta.Target{Method: "GET", URL: "http://a", Body: []byte("body")},
+		},
+		{
+			name: "method and url",
+			src:  `function before(t) { t.method = "POST"; t.url = t.url + "/path"; }`,
+			in:   vegeta.Target{Method: "GET", URL: "http://a"},
+			want: vegeta.Target{Method: "POST", URL: "http://a/path", Header: http.Header{}},
+		},
+		{
+			name: "headers",
+			src: `function before(t) {
+				t.headers["Authorization"] = ["Bearer token"];
+				t.headers["X-Count"] = 1;
+				delete t.headers["X-Drop"];
+			}`,
+			in: vegeta.Target{Method: "GET", URL: "http://a", Header: http.Header{
+				"X-Keep": {"a", "b"},
+				"X-Drop": {"c"},
+			}},
+			want: vegeta.Target{Method: "GET", URL: "http://a", Header: http.Header{
+				"X-Keep":        {"a", "b"},
+				"Authorization": {"Bearer token"},
+				"X-Count":       {"1"},
+			}},
+		},
+		{
+			name: "binary body kept",
+			src:  `function before(t) {}`,
+			in:   vegeta.Target{Method: "POST", URL: "http://a", Body: []byte{0xff, 0x00, 0xc3, 0x28}},
+			want: vegeta.Target{Method: "POST", URL: "http://a", Body: []byte{0xff, 0x00, 0xc3, 0x28}, Header: http.Header{}},
+		},
+		{
+			name: "binary body modified",
+			src:  `function before(t) { t.body[0] = 0xfe; }`,
+			in:   vegeta.Target{Method: "POST", URL: "http://a", Body: []byte{0xff, 0x00}},
+			want: vegeta.Target{Method: "POST", URL: "http://a", Body: []byte{0xfe, 0x00}, Header: http.Header{}},
+		},
+		{
+			name: "array buffer body",
+			src:  `function before(t) { t.body = new Uint8Array([0x80, 0x81]).buffer; }`,
+			in:   vegeta.Target{Method: "POST", URL: "http://a"},
+			want: vegeta.Target{Method: "POST", URL: "http://a", Body: []byte{0x80, 0x81}, Header: http.Header{}},
+		},
+		{
+			name: "string body",
+			src:  `function before(t) { t.body = "héllo"; }`,
+			in:   vegeta.Target{Method: "POST", URL: "http://a"},
+			want: vegeta.Target{Method: "POST", URL: "http://a", Body: []byte("héllo"), Header: http.Header{}},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			s, err := writeScript(t, tc.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := tc.in
+			if err = s.Before(&got); err != nil {
+				t.Fatal(err)
+			}
+
+			if got.Method != tc.want.Method || got.URL != tc.want.URL ||
+				!bytes.Equal(got.Body, tc.want.Body) || !reflect.DeepEqual(got.Header, tc.want.Header) {
+				t.Errorf("got %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestJSScript_After(t *testing.T) {
+	t.Parallel()
+
+	s, err := writeScript(t, `function after(result, response) {
+		return result.body.indexOf("ok") !== -1 && response.headers["X-Ok"][0] === "yes";
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp := &http.Response{StatusCode: 200, Header: http.Header{"X-Ok": {"yes"}}}
+	if err = s.After(&vegeta.Result{Code: 200, Body: []byte("ok")}, resp); err != nil {
+		t.Errorf("got error %v with a passing result", err)
+	}
+
+	if err = s.After(&vegeta.Result{Code: 200, Body: []byte("ko")}, resp); err == nil {
+		t.Error("got no error with a failing result")
+	}
+}
+
+func TestJSScript_Errors(t *testing.T) {
+	t.Parallel()
+
+	if _, err := writeScript(t, `function before(t) {`); err == nil {
+		t.Error("got no error with a syntax error")
+	}
+
+	if _, err := writeScript(t, `throw new Error("boom");`); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("got error %v, want one thrown at load time", err)
+	}
+
+	for _, tc := range []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"before throws", `function before(t) { throw new Error("before boom"); }`, "script: Error: before boom"},
+		{"bad headers", `function before(t) { t.headers = 1; }`, "script: target headers aren't an object"},
+		{"bad body", `function before(t) { t.body = {}; }`, "script: target body of type"},
+		{"after throws", `function after(r) { throw new Error("after boom"); }`, "script: Error: after boom"},
+	} {
+		s, err := writeScript(t, tc.src)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tgt := vegeta.Target{Method: "GET", URL: "http://a"}
+		err = s.Before(&tgt)
+		if err == nil {
+			err = s.After(&vegeta.Result{}, &http.Response{})
+		}
+
+		if err == nil || !strings.HasPrefix(err.Error(), tc.want) {
+			t.Errorf("%s: got error %v, want %q", tc.name, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"reflect"
+	"sync"
+
+	vegeta "github.com/FractalBlockchain/vegeta/lib"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+)
+
+// wasmScript is a vegeta.Script which calls the generate and validate
+// functions exported by a WebAssembly module, either of which is optional.
+//
+// Both are passed a JSON document written to the module's memory at a
+// pointer returned by its exported alloc(size i32) i32 function, and return
+// a pointer to their JSON output in the high 32 bits of an i64 and its
+// length in the low 32 bits:
+//
+//	generate(ptr, len i32) i64 // target in, target to hit out
+//	validate(ptr, len i32) i64 // result in, failure message out
+//
+// Modules run sandboxed, with WASI but no access to the file system or the
+// network. Since module instances can't be used concurrently, each one is
+// taken from a pool for the duration of a call.
+type wasmScript struct {
+	rt   wazero.Runtime
+	mod  wazero.CompiledModule
+	pool sync.Pool
+}
+
+// wasmTarget is the JSON representation of a Target passed to and returned
+// from generate, which follows the JSON targets format.
+type wasmTarget struct {
+	Method     string              `json:"method"`
+	URL        string              `json:"url"`
+	Headers    map[string][]string `json:"headers"`
+	Body       *string             `json:"body,omitempty"`
+	BodyBase64 []byte              `json:"body_base64"`
+	Error      string              `json:"error,omitempty"`
+}
+
+// wasmResult is the JSON representation of a Result and its response passed
+// to validate.
+type wasmResult struct {
+	Seq        uint64              `json:"seq"`
+	Code       uint16              `json:"code"`
+	Latency    float64             `json:"latency"`
+	BytesIn    uint64              `json:"bytes_in"`
+	BytesOut   uint64              `json:"bytes_out"`
+	Error      string              `json:"error"`
+	BodyBase64 []byte              `json:"body_base64"`
+	Method     string              `json:"method"`
+	URL        string              `json:"url"`
+	Status     int                 `json:"status"`
+	Headers    map[string][]string `json:"headers"`
+}
+
+// newWASMScript returns a new wasmScript running the given WebAssembly
+// module file.
+func newWASMScript(filename string) (*wasmScript, error) {
+	bin, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	rt := wazero.NewRuntime(ctx)
+	if _, err = wasi_snapshot_preview1.Instantiate(ctx, rt); err != nil {
+		rt.Close(ctx)
+		return nil, err
+	}
+
+	mod, err := rt.CompileModule(ctx, bin)
+	if err == nil {
+		err = wasmCheckABI(mod)
+	}
+	if err != nil {
+		rt.Close(ctx)
+		return nil, err
+	}
+
+	s := &wasmScript{rt: rt, mod: mod}
+	inst, err := s.instance()
+	if err != nil {
+		rt.Close(ctx)
+		return nil, err
+	}
+	s.pool.Put(inst)
+
+	return s, nil
+}
+
+// wasmCheckABI returns an error if the given module doesn't export memory,
+// alloc and generate or validate with their ABI signatures.
+func wasmCheckABI(mod wazero.CompiledModule) error {
+	if _, ok := mod.ExportedMemories()["memory"]; !ok {
+		return errors.New("module doesn't export memory")
+	}
+
+	i32, i64 := api.ValueTypeI32, api.ValueTypeI64
+	sigs := []struct {
+		name            string
+		params, results []api.ValueType
+	}{
+		{"alloc", []api.ValueType{i32}, []api.ValueType{i32}},
+		{"generate", []api.ValueType{i32, i32}, []api.ValueType{i64}},
+		{"validate", []api.ValueType{i32, i32}, []api.ValueType{i64}},
+	}
+
+	fns := mod.ExportedFunctions()
+	for _, sig := range sigs {
+		fn, ok := fns[sig.name]
+		if !ok {
+			continue
+		}
+		if !reflect.DeepEqual(fn.ParamTypes(), sig.params) || !reflect.DeepEqual(fn.ResultTypes(), sig.results) {
+			return fmt.Errorf("module exports %s with the wrong signature", sig.name)
+		}
+	}
+
+	if fns["alloc"] == nil {
+		return errors.New("module doesn't export alloc")
+	} else if fns["generate"] == nil && fns["validate"] == nil {
+		return errors.New("module exports neither generate nor validate")
+	}
+
+	return nil
+}
+
+func (s *wasmScript) instance() (api.Module, error) {
+	if mod, ok := s.pool.Get().(api.Module); ok {
+		return mod, nil
+	}
+
+	// Modules are instantiated as WASI reactors, without a name so that
+	// several instances can coexist.
+	cfg := wazero.NewModuleConfig().
+		WithName("").
+		WithStartFunctions("_initialize").
+		WithStderr(os.Stderr).
+		WithSysWalltime().
+		WithSysNanotime().
+		WithRandSource(rand.Reader)
+
+	return s.rt.InstantiateModule(context.Background(), s.mod, cfg)
+}
+
+// call passes in to the exported function with the given name and returns
+// a copy of its output, which is nil if the module doesn't export it.
+// Instances which failed are closed rather than put back into the pool,
+// since their state can't be trusted anymore.
+func (s *wasmScript) call(name string, in []byte) ([]byte, error) {
+	if s.mod.ExportedFunctions()[name] == nil {
+		return nil, nil
+	}
+
+	mod, err := s.instance()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := wasmCall(mod, name, in)
+	if err != nil {
+		mod.Close(context.Background())
+		return nil, err
+	}
+	s.pool.Put(mod)
+
+	return out, nil
+}
+
+func wasmCall(mod api.Module, name string, in []byte) ([]byte, error) {
+	ctx := context.Background()
+
+	res, err := mod.ExportedFunction("alloc").Call(ctx, uint64(len(in)))
+	if err != nil {
+		return nil, err
+	}
+
+	ptr := uint32(res[0])
+	if !mod.Memory().Write(ptr, in) {
+		return nil, fmt.Errorf("alloc returned out of range pointer %d", ptr)
+	}
+
+	if res, err = mod.ExportedFunction(name).Call(ctx, uint64(ptr), uint64(len(in))); err != nil {
+		return nil, err
+	}
+
+	ptr, size := uint32(res[0]>>32), uint32(res[0])
+	if size == 0 {
+		return nil, nil
+	}
+
+	out, ok := mod.Memory().Read(ptr, size)
+	if !ok {
+		return nil, fmt.Errorf("%s output out of range at %d with length %d", name, ptr, size)
+	}
+
+	return append([]byte(nil), out...), nil
+}
+
+// Before implements the vegeta.Script interface by passing the target to
+// generate, whose output, when not empty, replaces it. A non empty error
+// field in the output fails the hit.
+func (s *wasmScript) Before(tgt *vegeta.Target) error {
+	in, err := json.Marshal(wasmTarget{
+		Method:     tgt.Method,
+		URL:        tgt.URL,
+		Headers:    tgt.Header,
+		BodyBase64: tgt.Body,
+	})
+	if err != nil {
+		return fmt.Errorf("script: %s", err)
+	}
+
+	out, err := s.call("generate", in)
+	if err != nil {
+		return fmt.Errorf("script: %s", err)
+	} else if out == nil {
+		return nil
+	}
+
+	var wt wasmTarget
+	if err = json.Unmarshal(out, &wt); err != nil {
+		return fmt.Errorf("script: bad generated target: %s", err)
+	} else if wt.Error != "" {
+		return fmt.Errorf("script: %s", wt.Error)
+	}
+
+	tgt.Method, tgt.URL = wt.Method, wt.URL
+	tgt.Header = http.Header{}
+	for k, vs := range wt.Headers {
+		for _, v := range vs {
+			tgt.Header.Add(k, v)
+		}
+	}
+
+	if tgt.Body = wt.BodyBase64; wt.Body != nil {
+		tgt.Body = []byte(*wt.Body)
+	}
+
+	return nil
+}
+
+// After implements the vegeta.Script interface by passing the result, with
+// its latency in milliseconds, and the response's status and headers to
+// validate. The hit fails with validate's output when it isn't empty.
+func (s *wasmScript) After(r *vegeta.Result, resp *http.Response) error {
+	in, err := json.Marshal(wasmResult{
+		Seq:        r.Seq,
+		Code:       r.Code,
+		Latency:    r.Latency.Seconds() * 1000,
+		BytesIn:    r.BytesIn,
+		BytesOut:   r.BytesOut,
+		Error:      r.Error,
+		BodyBase64: r.Body,
+		Method:     r.Method,
+		URL:        r.URL,
+		Status:     resp.StatusCode,
+		Headers:    resp.Header,
+	})
+	if err != nil {
+		return fmt.Errorf("script: %s", err)
+	}
+
+	out, err := s.call("validate", in)
+	if err != nil {
+		return fmt.Errorf("script: %s", err)
+	} else if len(out) > 0 {
+		return fmt.Errorf("script: %s", out)
+	}
+
+	return nil
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	vegeta "github.com/FractalBlockchain/vegeta/lib"
+)
+
+// WebAssembly function bodies of the test modules. alloc always returns
+// address 0, so inputs must fit in the module's single memory page.
+var (
+	wasmAllocZero = []byte{0x00, 0x41, 0x00, 0x0b} // i32.const 0
+	wasmEcho      = []byte{                        // ptr << 32 | len
+		0x00,
+		0x20, 0x00, 0xad, 0x42, 0x20, 0x86, // local.get 0; i64.extend_i32_u; i64.const 32; i64.shl
+		0x20, 0x01, 0xad, 0x84, // local.get 1; i64.extend_i32_u; i64.or
+		0x0b,
+	}
+	wasmEmpty = []byte{0x00, 0x42, 0x00, 0x0b} // i64.const 0
+)
+
+// wasmData returns a function body returning the output of the data
+// segment of the test module, of the given length.
+func wasmData(n int) []byte {
+	return append(append([]byte{0x00, 0x42}, wasmSLEB(1024<<32|int64(n))...), 0x0b)
+}
+
+// wasmModule returns a WebAssembly module with an exported memory and alloc,
+// and the given generate and validate function bodies, which are exported
+// when not nil. data is placed in memory at address 1024.
+func wasmModule(generate, validate []byte, data string) []byte {
+	vec := func(items ...[]byte) []byte {
+		out := wasmULEB(uint64(len(items)))
+		for _, item := range items {
+			out = append(out, item...)
+		}
+		return out
+	}
+	section := func(id byte, body []byte) []byte {
+		return append(append([]byte{id}, wasmULEB(uint64(len(body)))...), body...)
+	}
+	name := func(s string) []byte { return append(wasmULEB(uint64(len(s))), s...) }
+
+	funcs := [][]byte{{0x00}}
+	bodies := [][]byte{wasmAllocZero}
+	exports := [][]byte{
+		append(name("memory"), 0x02, 0x00),
+		append(name("alloc"), 0x00, 0x00),
+	}
+	for _, fn := range []struct {
+		name string
+		body []byte
+	}{{"generate", generate}, {"validate", validate}} {
+		if fn.body != nil {
+			exports = append(exports, append(name(fn.name), 0x00, byte(len(funcs))))
+			funcs = append(funcs, []byte{0x01})
+			bodies = append(bodies, fn.body)
+		}
+	}
+
+	code := make([][]byte, len(bodies))
+	for i, b := range bodies {
+		code[i] = append(wasmULEB(uint64(len(b))), b...)
+	}
+
+	mod := []byte("\x00asm\x01\x00\x00\x00")
+	mod = append(mod, section(1, vec(
+		[]byte{0x60, 0x01, 0x7f, 0x01, 0x7f},       // (i32) -> i32
+		[]byte{0x60, 0x02, 0x7f, 0x7f, 0x01, 0x7e}, // (i32, i32) -> i64
+	))...)
+	mod = append(mod, section(3, vec(funcs...))...)
+	mod = append(mod, section(5, vec([]byte{0x00, 0x01}))...)
+	mod = append(mod, section(7, vec(exports...))...)
+	mod = append(mod, section(10, vec(code...))...)
+	if data != "" {
+		seg := append([]byte{0x00, 0x41, 0x80, 0x08, 0x0b}, name(data)...) // i32.const 1024
+		mod = append(mod, section(11, vec(seg))...)
+	}
+
+	return mod
+}
+
+func wasmULEB(v uint64) (out []byte) {
+	for {
+		b := byte(v & 0x7f)
+		if v >>= 7; v != 0 {
+			b |= 0x80
+		}
+		if out = append(out, b); v == 0 {
+			return out
+		}
+	}
+}
+
+func wasmSLEB(v int64) (out []byte) {
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if (v == 0 && b&0x40 == 0) || (v == -1 && b&0x40 != 0) {
+			return append(out, b)
+		}
+		out = append(out, b|0x80)
+	}
+}
+
+// writeWASMScript writes the given WebAssembly module to a temporary file
+// and returns a script running it.
+func writeWASMScript(t *testing.T, mod []byte) (vegeta.Script, error) {
+	t.Helper()
+
+	filename := filepath.Join(t.TempDir(), "script.wasm")
+	if err := ioutil.WriteFile(filename, mod, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return newScript(filename)
+}
+
+func TestWASMScript_Before(t *testing.T) {
+	t.Parallel()
+
+	in := vegeta.Target{
+		Method: "POST",
+		URL:    "http://a",
+		Body:   []byte{0xff, 0x00, 0xc3, 0x28},
+		Header: http.Header{"X-Keep": {"a", "b"}},
+	}
+
+	generated := `{"method":"PUT","url":"http://b","headers":{"Authorization":["Bearer token"]},"body":"hi"}`
+	for _, tc := range []struct {
+		name string
+		mod  []byte
+		want vegeta.Target
+		err  string
+	}{
+		{"no generate", wasmModule(nil, wasmEmpty, ""), in, ""},
+		{"empty output", wasmModule(wasmEmpty, nil, ""), in, ""},
+		{"echo", wasmModule(wasmEcho, nil, ""), in, ""},
+		{
+			name: "generated",
+			mod:  wasmModule(wasmData(len(generated)), nil, generated),
+			want: vegeta.Target{
+				Method: "PUT",
+				URL:    "http://b",
+				Body:   []byte("hi"),
+				Header: http.Header{"Authorization": {"Bearer token"}},
+			},
+		},
+		{"error", wasmModule(wasmData(16), nil, `{"error":"boom"}`), in, "script: boom"},
+		{"bad output", wasmModule(wasmData(3), nil, "bad"), in, "script: bad generated target"},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			s, err := writeWASMScript(t, tc.mod)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			tgt := in
+			tgt.Header = in.Header.Clone()
+			err = s.Before(&tgt)
+			if tc.err != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), tc.err) {
+					t.Fatalf("got error %v, want %q", err, tc.err)
+				}
+				return
+			} else if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(tgt, tc.want) {
+				t.Errorf("\ngot:  %#v\nwant: %#v", tgt, tc.want)
+			}
+		})
+	}
+}
+
+func TestWASMScript_After(t *testing.T) {
+	t.Parallel()
+
+	r := &vegeta.Result{
+		Seq:     3,
+		Code:    500,
+		Latency: 1500 * time.Microsecond,
+		Body:    []byte("oops"),
+		Method:  "GET",
+		URL:     "http://a",
+	}
+	resp := &http.Response{StatusCode: 500, Header: http.Header{"X-Id": {"1"}}}
+
+	s, err := writeWASMScript(t, wasmModule(nil, wasmEmpty, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = s.After(r, resp); err != nil {
+		t.Errorf("empty validate output: got error %v", err)
+	}
+
+	// The echoing module fails every hit with its input.
+	if s, err = writeWASMScript(t, wasmModule(nil, wasmEcho, "")); err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.After(r, resp)
+	if err == nil {
+		t.Fatal("echoed validate output: got no error")
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(err.Error(), "script: ")), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]interface{}{
+		"seq":         3.0,
+		"code":        500.0,
+		"latency":     1.5,
+		"bytes_in":    0.0,
+		"bytes_out":   0.0,
+		"error":       "",
+		"body_base64": "b29wcw==",
+		"method":      "GET",
+		"url":         "http://a",
+		"status":      500.0,
+		"headers":     map[string]interface{}{"X-Id": []interface{}{"1"}},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("\ngot:  %v\nwant: %v", got, want)
+	}
+}
+
+func TestNewWASMScript_ABI(t *testing.T) {
+	t.Parallel()
+
+	noExports := wasmModule(nil, nil, "")
+	badSig := bytes.Replace(wasmModule(wasmAllocZero, nil, ""), []byte{0x01, 0x7e}, []byte{0x01, 0x7f}, 1)
+
+	for _, tc := range []struct {
+		name string
+		mod  []byte
+		err  string
+	}{
+		{"not wasm", []byte("function before() {}"), "invalid magic number"},
+		{"no functions", noExports, "module exports neither generate nor validate"},
+		{"bad signature", badSig, "module exports generate with the wrong signature"},
+	} {
+		if _, err := writeWASMScript(t, tc.mod); err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%s: got error %v, want %q", tc.name, err, tc.err)
+		}
+	}
+}